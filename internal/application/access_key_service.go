@@ -0,0 +1,321 @@
+package application
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAccessKeyNotFound = errors.New("access key not found")
+	ErrAccessKeyInactive = errors.New("access key is inactive")
+)
+
+// lastUsedResolution limits how often a key's last_used_at is rewritten
+const lastUsedResolution = time.Minute
+
+// sealedSecretPrefix marks secrets encrypted by sealSecret. Rows written
+// before secrets were sealed hold the plain secret until
+// SealLegacySecrets rewrites them.
+const sealedSecretPrefix = "gcm1:"
+
+type AccessKeyService struct {
+	repo domain.RepositoryPort
+	aead cipher.AEAD
+}
+
+// NewAccessKeyService creates the credentials service. encryptionKey seals
+// secrets at rest; changing it makes existing secrets unreadable.
+func NewAccessKeyService(repo domain.RepositoryPort, encryptionKey string) (*AccessKeyService, error) {
+	if encryptionKey == "" {
+		return nil, fmt.Errorf("access key encryption key is required")
+	}
+	key := sha256.Sum256([]byte(encryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create access key cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create access key cipher: %w", err)
+	}
+	return &AccessKeyService{repo: repo, aead: aead}, nil
+}
+
+// SealLegacySecrets encrypts the secrets of keys created before secrets
+// were sealed, overwriting the plain value, and returns how many it sealed
+func (s *AccessKeyService) SealLegacySecrets(ctx context.Context) (int, error) {
+	keys, err := s.repo.ListUnsealedAccessKeys(ctx, sealedSecretPrefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list unsealed access keys: %w", err)
+	}
+
+	for i := range keys {
+		key := &keys[i]
+		sealed, err := s.sealSecret(key.SecretKey)
+		if err != nil {
+			return i, err
+		}
+		key.SecretKey = sealed
+		if err := s.repo.SaveAccessKey(ctx, key); err != nil {
+			return i, fmt.Errorf("failed to save access key: %w", err)
+		}
+	}
+
+	return len(keys), nil
+}
+
+// CreateAccessKey issues a new credential pair for userID
+func (s *AccessKeyService) CreateAccessKey(ctx context.Context, userID string) (*dto.AccessKeySecretOutput, error) {
+	key := &domain.AccessKey{
+		AccessKeyID: generateAccessKeyID(),
+		UserID:      userID,
+		Status:      domain.AccessKeyStatusActive,
+		CreatedAt:   time.Now(),
+	}
+
+	secret, err := s.setSecret(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveAccessKey(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to save access key: %w", err)
+	}
+
+	return secretOutput(key, secret), nil
+}
+
+// ListAccessKeys lists userID's keys without their secrets
+func (s *AccessKeyService) ListAccessKeys(ctx context.Context, userID string) (*dto.ListAccessKeysOutput, error) {
+	keys, err := s.repo.ListAccessKeysByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access keys: %w", err)
+	}
+
+	output := &dto.ListAccessKeysOutput{AccessKeys: []dto.AccessKeyInfo{}, Total: len(keys)}
+	for _, key := range keys {
+		output.AccessKeys = append(output.AccessKeys, dto.AccessKeyInfo{
+			AccessKeyID: key.AccessKeyID,
+			Status:      key.Status,
+			CreatedAt:   key.CreatedAt,
+			LastUsedAt:  key.LastUsedAt,
+		})
+	}
+
+	return output, nil
+}
+
+// SetAccessKeyStatus enables or disables one of userID's keys
+func (s *AccessKeyService) SetAccessKeyStatus(ctx context.Context, userID, accessKeyID string, active bool) error {
+	key, err := s.ownedKey(ctx, userID, accessKeyID)
+	if err != nil {
+		return err
+	}
+
+	status := domain.AccessKeyStatusInactive
+	if active {
+		status = domain.AccessKeyStatusActive
+	}
+
+	if err := s.repo.UpdateAccessKeyStatus(ctx, key.AccessKeyID, status); err != nil {
+		return fmt.Errorf("failed to update access key: %w", err)
+	}
+
+	return nil
+}
+
+// RotateAccessKey replaces the secret of one of userID's keys, keeping its id
+func (s *AccessKeyService) RotateAccessKey(ctx context.Context, userID, accessKeyID string) (*dto.AccessKeySecretOutput, error) {
+	key, err := s.ownedKey(ctx, userID, accessKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.setSecret(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveAccessKey(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to save access key: %w", err)
+	}
+
+	return secretOutput(key, secret), nil
+}
+
+// DeleteAccessKey removes one of userID's keys
+func (s *AccessKeyService) DeleteAccessKey(ctx context.Context, userID, accessKeyID string) error {
+	key, err := s.ownedKey(ctx, userID, accessKeyID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteAccessKey(ctx, key.AccessKeyID); err != nil {
+		return fmt.Errorf("failed to delete access key: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateAPIKey implements middleware.APIKeyValidator: the x-api-key header
+// carries a secret access key, matched by its hash.
+func (s *AccessKeyService) ValidateAPIKey(apiKey string) (string, error) {
+	ctx := context.Background()
+
+	key, err := s.repo.GetAccessKeyBySecretHash(ctx, hashSecret(apiKey))
+	if err != nil {
+		return "", ErrAccessKeyNotFound
+	}
+
+	if !key.IsActive() {
+		return "", fmt.Errorf("%w: %s", ErrAccessKeyInactive, key.AccessKeyID)
+	}
+
+	s.touch(ctx, key)
+	return key.UserID, nil
+}
+
+// LookupAccessKey resolves an active access key for request authentication
+// and returns its secret and owning user.
+func (s *AccessKeyService) LookupAccessKey(ctx context.Context, accessKeyID string) (string, string, error) {
+	key, err := s.repo.GetAccessKeyByID(ctx, accessKeyID)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %s", ErrAccessKeyNotFound, accessKeyID)
+	}
+
+	if !key.IsActive() {
+		return "", "", fmt.Errorf("%w: %s", ErrAccessKeyInactive, accessKeyID)
+	}
+
+	secret, err := s.openSecret(key.SecretKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open secret for %s: %w", accessKeyID, err)
+	}
+
+	s.touch(ctx, key)
+	return secret, key.UserID, nil
+}
+
+// EnsureAccessKey stores a fixed credential pair, such as bootstrap
+// credentials taken from the environment.
+func (s *AccessKeyService) EnsureAccessKey(ctx context.Context, accessKeyID, secretKey, userID string) error {
+	if accessKeyID == "" || secretKey == "" {
+		return fmt.Errorf("access key id and secret are required")
+	}
+
+	sealed, err := s.sealSecret(secretKey)
+	if err != nil {
+		return err
+	}
+
+	key := &domain.AccessKey{
+		AccessKeyID: accessKeyID,
+		SecretKey:   sealed,
+		SecretHash:  hashSecret(secretKey),
+		UserID:      userID,
+		Status:      domain.AccessKeyStatusActive,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := s.repo.SaveAccessKey(ctx, key); err != nil {
+		return fmt.Errorf("failed to save access key: %w", err)
+	}
+
+	return nil
+}
+
+// ownedKey loads accessKeyID, hiding keys that belong to other users
+func (s *AccessKeyService) ownedKey(ctx context.Context, userID, accessKeyID string) (*domain.AccessKey, error) {
+	key, err := s.repo.GetAccessKeyByID(ctx, accessKeyID)
+	if err != nil || key.UserID != userID {
+		return nil, fmt.Errorf("%w: %s", ErrAccessKeyNotFound, accessKeyID)
+	}
+	return key, nil
+}
+
+func (s *AccessKeyService) touch(ctx context.Context, key *domain.AccessKey) {
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedResolution {
+		s.repo.TouchAccessKey(ctx, key.AccessKeyID, now)
+	}
+}
+
+// setSecret generates a new secret for key and returns it in the clear
+func (s *AccessKeyService) setSecret(key *domain.AccessKey) (string, error) {
+	buf := make([]byte, 30)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(buf)
+
+	sealed, err := s.sealSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	key.SecretKey = sealed
+	key.SecretHash = hashSecret(secret)
+	return secret, nil
+}
+
+func (s *AccessKeyService) sealSecret(secret string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(secret), nil)
+	return sealedSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *AccessKeyService) openSecret(stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, sealedSecretPrefix)
+	if !ok {
+		return "", fmt.Errorf("secret is not sealed")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return "", fmt.Errorf("malformed sealed secret")
+	}
+
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plain, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	return string(plain), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateAccessKeyID returns a 20 character id in the AWS "AK..." style
+func generateAccessKeyID() string {
+	buf := make([]byte, 15)
+	rand.Read(buf)
+	return "AK" + base32.StdEncoding.EncodeToString(buf)[:18]
+}
+
+func secretOutput(key *domain.AccessKey, secret string) *dto.AccessKeySecretOutput {
+	return &dto.AccessKeySecretOutput{
+		AccessKeyID:     key.AccessKeyID,
+		SecretAccessKey: secret,
+		Status:          key.Status,
+		CreatedAt:       key.CreatedAt,
+	}
+}
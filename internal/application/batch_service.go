@@ -3,23 +3,31 @@ package application
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type BatchService struct {
-	repo    domain.RepositoryPort
-	storage domain.StoragePort
+	repo        domain.RepositoryPort
+	storage     domain.StoragePort
+	quotas      *QuotaService
+	replication *ReplicationService
+	events      *EventBus
 }
 
-func NewBatchService(repo domain.RepositoryPort, storage domain.StoragePort) *BatchService {
+func NewBatchService(repo domain.RepositoryPort, storage domain.StoragePort, quotas *QuotaService, replication *ReplicationService, events *EventBus) *BatchService {
 	return &BatchService{
-		repo:    repo,
-		storage: storage,
+		repo:        repo,
+		storage:     storage,
+		quotas:      quotas,
+		replication: replication,
+		events:      events,
 	}
 }
 
@@ -68,24 +76,61 @@ func (s *BatchService) processBatchUpload(ctx context.Context, operationID strin
 	}
 
 	for i, file := range input.Files {
-		data, err := base64.StdEncoding.DecodeString(file.Data)
+		if err := checkOverwritable(ctx, s.repo, bucket.ID, file.Key); err != nil {
+			operation.FailedItems++
+			operation.Errors = append(operation.Errors, dto.BatchOperationError{
+				Index: i,
+				Item:  file.Key,
+				Error: err.Error(),
+			})
+			continue
+		}
+
+		// The decoded size is only known once written; room for it is checked then
+		reservation, err := s.quotas.reserve(ctx, &bucket, file.Key, -1)
 		if err != nil {
 			operation.FailedItems++
 			operation.Errors = append(operation.Errors, dto.BatchOperationError{
 				Index: i,
 				Item:  file.Key,
-				Error: fmt.Sprintf("invalid base64: %v", err),
+				Error: err.Error(),
 			})
 			continue
 		}
 
+		// Decode while streaming to storage instead of materialising a second copy
+		body := base64.NewDecoder(base64.StdEncoding, strings.NewReader(file.Data))
+
 		// Save to MinIO
-		if err := s.storage.SaveObject(ctx, bucket.Name, file.Key, data, file.Metadata); err != nil {
+		info, err := s.storage.SaveObjectStream(ctx, bucket.Name, file.Key, body, -1, file.ContentType, file.Metadata, nil)
+		if err != nil {
+			reason := "storage save failed"
+			var corrupt base64.CorruptInputError
+			if errors.As(err, &corrupt) {
+				reason = "invalid base64"
+			}
+			reservation.release(ctx)
 			operation.FailedItems++
 			operation.Errors = append(operation.Errors, dto.BatchOperationError{
 				Index: i,
 				Item:  file.Key,
-				Error: fmt.Sprintf("storage save failed: %v", err),
+				Error: fmt.Sprintf("%s: %v", reason, err),
+			})
+			continue
+		}
+
+		if err := reservation.resize(ctx, info.Size); err != nil {
+			reservation.release(ctx)
+			if info.VersionID != "" {
+				_, _ = s.storage.DeleteObjectVersion(ctx, bucket.Name, file.Key, info.VersionID)
+			} else {
+				_ = s.storage.DeleteObject(ctx, bucket.Name, file.Key)
+			}
+			operation.FailedItems++
+			operation.Errors = append(operation.Errors, dto.BatchOperationError{
+				Index: i,
+				Item:  file.Key,
+				Error: err.Error(),
 			})
 			continue
 		}
@@ -95,7 +140,7 @@ func (s *BatchService) processBatchUpload(ctx context.Context, operationID strin
 			ID:          uuid.New().String(),
 			BucketID:    input.BucketID,
 			Key:         file.Key,
-			Size:        int64(len(data)),
+			Size:        info.Size,
 			ContentType: file.ContentType,
 			Metadata:    file.Metadata,
 			CreatedAt:   time.Now(),
@@ -103,6 +148,7 @@ func (s *BatchService) processBatchUpload(ctx context.Context, operationID strin
 		}
 
 		if err := s.repo.SaveFile(ctx, fileRecord); err != nil {
+			reservation.release(ctx)
 			operation.FailedItems++
 			operation.Errors = append(operation.Errors, dto.BatchOperationError{
 				Index: i,
@@ -110,6 +156,9 @@ func (s *BatchService) processBatchUpload(ctx context.Context, operationID strin
 				Error: fmt.Sprintf("metadata save failed: %v", err),
 			})
 		} else {
+			reservation.commit(ctx, info.Size)
+			s.replication.ObjectCreated(ctx, bucket.ID, file.Key)
+			s.events.ObjectCreated(&fileRecord)
 			operation.ProcessedItems++
 		}
 
@@ -169,29 +218,39 @@ func (s *BatchService) processBatchDelete(ctx context.Context, operationID strin
 	}
 
 	for i, key := range input.Keys {
-		// Delete from MinIO
-		if err := s.storage.DeleteObject(ctx, bucket.Name, key); err != nil {
+		// Look the file up first so locked objects are never touched
+		file, err := s.repo.GetFileByKey(ctx, input.BucketID, key)
+		if err != nil {
 			operation.FailedItems++
 			operation.Errors = append(operation.Errors, dto.BatchOperationError{
 				Index: i,
 				Item:  key,
-				Error: fmt.Sprintf("storage delete failed: %v", err),
+				Error: fmt.Sprintf("metadata not found: %v", err),
+			})
+			continue
+		}
+		if err := checkRemovable(file, false); err != nil {
+			operation.FailedItems++
+			operation.Errors = append(operation.Errors, dto.BatchOperationError{
+				Index: i,
+				Item:  key,
+				Error: err.Error(),
 			})
 			continue
 		}
 
-		// Delete metadata from repository
-		file, err := s.repo.GetFileByKey(ctx, input.BucketID, key)
-		if err != nil {
+		// Delete from MinIO
+		if err := s.storage.DeleteObject(ctx, bucket.Name, key); err != nil {
 			operation.FailedItems++
 			operation.Errors = append(operation.Errors, dto.BatchOperationError{
 				Index: i,
 				Item:  key,
-				Error: fmt.Sprintf("metadata not found: %v", err),
+				Error: fmt.Sprintf("storage delete failed: %v", err),
 			})
 			continue
 		}
 
+		// Delete metadata from repository
 		if err := s.repo.DeleteFile(ctx, file.ID); err != nil {
 			operation.FailedItems++
 			operation.Errors = append(operation.Errors, dto.BatchOperationError{
@@ -200,6 +259,8 @@ func (s *BatchService) processBatchDelete(ctx context.Context, operationID strin
 				Error: fmt.Sprintf("metadata delete failed: %v", err),
 			})
 		} else {
+			s.replication.ObjectDeleted(ctx, bucket.ID, key)
+			s.events.ObjectDeleted(bucket.ID, key)
 			operation.ProcessedItems++
 		}
 
@@ -267,9 +328,18 @@ func (s *BatchService) processBatchCopy(ctx context.Context, operationID string,
 			})
 			continue
 		}
+		if err := checkOverwritable(ctx, s.repo, dstBucket.ID, item.DestKey); err != nil {
+			operation.FailedItems++
+			operation.Errors = append(operation.Errors, dto.BatchOperationError{
+				Index: i,
+				Item:  fmt.Sprintf("%s/%s", item.DestBucket, item.DestKey),
+				Error: err.Error(),
+			})
+			continue
+		}
 
 		// Copy in MinIO
-		if err := s.storage.CopyObject(ctx, srcBucket.Name, item.SourceKey, dstBucket.Name, item.DestKey); err != nil {
+		if err := s.storage.CopyObject(ctx, srcBucket.Name, item.SourceKey, dstBucket.Name, item.DestKey, nil, nil); err != nil {
 			operation.FailedItems++
 			operation.Errors = append(operation.Errors, dto.BatchOperationError{
 				Index: i,
@@ -297,7 +367,8 @@ func (s *BatchService) processBatchCopy(ctx context.Context, operationID string,
 			BucketID:    item.DestBucket,
 			Key:         item.DestKey,
 			Size:        srcFile.Size,
-			ContentType: srcFile.ContentType,
+			MimeType:    srcFile.MediaType(),
+			ContentType: srcFile.MediaType(),
 			Metadata:    srcFile.Metadata,
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
@@ -311,6 +382,8 @@ func (s *BatchService) processBatchCopy(ctx context.Context, operationID string,
 				Error: fmt.Sprintf("dest metadata save failed: %v", err),
 			})
 		} else {
+			s.replication.ObjectCreated(ctx, dstBucket.ID, item.DestKey)
+			s.events.ObjectCopied(srcBucket.ID, item.SourceKey, &dstFile)
 			operation.ProcessedItems++
 		}
 
@@ -378,9 +451,30 @@ func (s *BatchService) processBatchMove(ctx context.Context, operationID string,
 			})
 			continue
 		}
+		// A move deletes the source, which its lock forbids
+		if srcFile, err := s.repo.GetFileByKey(ctx, item.SourceBucket, item.SourceKey); err == nil {
+			if err := checkRemovable(srcFile, false); err != nil {
+				operation.FailedItems++
+				operation.Errors = append(operation.Errors, dto.BatchOperationError{
+					Index: i,
+					Item:  fmt.Sprintf("%s/%s", item.SourceBucket, item.SourceKey),
+					Error: err.Error(),
+				})
+				continue
+			}
+		}
+		if err := checkOverwritable(ctx, s.repo, dstBucket.ID, item.DestKey); err != nil {
+			operation.FailedItems++
+			operation.Errors = append(operation.Errors, dto.BatchOperationError{
+				Index: i,
+				Item:  fmt.Sprintf("%s/%s", item.DestBucket, item.DestKey),
+				Error: err.Error(),
+			})
+			continue
+		}
 
 		// Copy in MinIO
-		if err := s.storage.CopyObject(ctx, srcBucket.Name, item.SourceKey, dstBucket.Name, item.DestKey); err != nil {
+		if err := s.storage.CopyObject(ctx, srcBucket.Name, item.SourceKey, dstBucket.Name, item.DestKey, nil, nil); err != nil {
 			operation.FailedItems++
 			operation.Errors = append(operation.Errors, dto.BatchOperationError{
 				Index: i,
@@ -408,7 +502,8 @@ func (s *BatchService) processBatchMove(ctx context.Context, operationID string,
 			BucketID:    item.DestBucket,
 			Key:         item.DestKey,
 			Size:        srcFile.Size,
-			ContentType: srcFile.ContentType,
+			MimeType:    srcFile.MediaType(),
+			ContentType: srcFile.MediaType(),
 			Metadata:    srcFile.Metadata,
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
@@ -423,6 +518,7 @@ func (s *BatchService) processBatchMove(ctx context.Context, operationID string,
 			})
 			continue
 		}
+		s.replication.ObjectCreated(ctx, dstBucket.ID, item.DestKey)
 
 		// Delete source from MinIO
 		if err := s.storage.DeleteObject(ctx, srcBucket.Name, item.SourceKey); err != nil {
@@ -432,6 +528,7 @@ func (s *BatchService) processBatchMove(ctx context.Context, operationID string,
 				Item:  item.SourceKey,
 				Error: fmt.Sprintf("copied but delete failed: %v", err),
 			})
+			s.events.ObjectCopied(srcBucket.ID, item.SourceKey, &dstFile)
 			continue
 		}
 
@@ -443,7 +540,10 @@ func (s *BatchService) processBatchMove(ctx context.Context, operationID string,
 				Item:  item.SourceKey,
 				Error: fmt.Sprintf("copied but metadata delete failed: %v", err),
 			})
+			s.events.ObjectCopied(srcBucket.ID, item.SourceKey, &dstFile)
 		} else {
+			s.replication.ObjectDeleted(ctx, srcBucket.ID, item.SourceKey)
+			s.events.ObjectMoved(srcBucket.ID, item.SourceKey, &dstFile)
 			operation.ProcessedItems++
 		}
 
@@ -512,6 +612,8 @@ func (s *BatchService) processBatchUpdateMetadata(ctx context.Context, operation
 				Error: fmt.Sprintf("update failed: %v", err),
 			})
 		} else {
+			s.replication.MetadataUpdated(ctx, file.BucketID, file.Key)
+			s.events.ObjectMetadataUpdated(file)
 			operation.ProcessedItems++
 		}
 
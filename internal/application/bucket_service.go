@@ -11,12 +11,21 @@ import (
 	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
 	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPolicyForbidden       = errors.New("forbidden: only bucket owner or admin can update policy")
+	ErrPolicyVersionNotFound = errors.New("policy version not found")
+	ErrInvalidLifecycleRule  = errors.New("invalid lifecycle rule")
 )
 
 // BucketService provides business logic for managing buckets.
 type BucketService struct {
 	repo    domain.RepositoryPort
 	storage domain.StoragePort
+	events  *EventBus
 }
 
 type BucketAlreadyExists struct {
@@ -24,10 +33,11 @@ type BucketAlreadyExists struct {
 }
 
 // NewBucketService creates a new instance of BucketService.
-func NewBucketService(repo domain.RepositoryPort, storage domain.StoragePort) *BucketService {
+func NewBucketService(repo domain.RepositoryPort, storage domain.StoragePort, events *EventBus) *BucketService {
 	return &BucketService{
 		repo:    repo,
 		storage: storage,
+		events:  events,
 	}
 }
 func (e *BucketAlreadyExists) Error() string {
@@ -62,6 +72,7 @@ func (s *BucketService) CreateBucket(ctx context.Context, input dto.CreateBucket
 	if err != nil {
 		return nil, fmt.Errorf("failed to save bucket metadata: %w", err)
 	}
+	s.events.BucketCreated(&bucketObject)
 
 	// Return success
 	return &dto.CreateBucketOutput{
@@ -85,6 +96,20 @@ func (s *BucketService) GetBucket(ctx context.Context, bucketID string) (*dto.Ge
 	}, nil
 }
 
+// GetBucketByName looks a bucket up by its name rather than its ID.
+func (s *BucketService) GetBucketByName(ctx context.Context, name string) (*dto.GetBucketOutput, error) {
+	bucket, err := s.repo.GetBucketByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, name)
+	}
+
+	return &dto.GetBucketOutput{
+		BucketID:  bucket.ID,
+		Name:      bucket.Name,
+		CreatedAt: bucket.CreatedAt,
+	}, nil
+}
+
 func (s *BucketService) ListBuckets(ctx context.Context) ([]domain.Bucket, error) {
 	return s.repo.ListBuckets(ctx)
 }
@@ -108,6 +133,7 @@ if error != nil {
 	if err != nil {
 		return nil, fmt.Errorf("failed to update bucket: %w", err)
 	}
+	s.events.BucketUpdated(updated.ID, "name", updated.Name)
 
 	return &dto.GetBucketOutput{
 		BucketID:  updated.ID,
@@ -144,6 +170,7 @@ func (s *BucketService) DeleteBucket(ctx context.Context, bucketID string) error
 
 		return fmt.Errorf("failed to delete bucket: %w", err)
 	}
+	s.events.BucketDeleted(bucket.ID)
 	return nil
 }
 
@@ -182,58 +209,208 @@ func (s *BucketService) GetBucketPolicy(ctx context.Context, bucketID string) (*
 	}, nil
 }
 
-func (s *BucketService) UpdateBucketPolicy(ctx context.Context, bucketID string, input dto.UpdatePolicyInput, actor string) error {
+// UpdateBucketPolicy replaces the bucket policy with the single statement
+// described by input. PutBucketPolicy takes a full document.
+func (s *BucketService) UpdateBucketPolicy(ctx context.Context, bucketID string, input dto.UpdatePolicyInput, actor string) (*domain.PolicyVersion, error) {
+	// Convert DTO -> domain.Policy
+	policy := domain.Policy{
+		Version: input.Version,
+		Statement: []domain.Statement{
+			{
+				Effect:    domain.Effect(input.Effect),
+				Action:    make([]domain.Action, 0, len(input.Actions)),
+				Resource:  input.Resources,
+				Principal: make([]domain.Principal, 0, len(input.Principals)),
+				Condition: input.Conditions,
+			},
+		},
+	}
+
+	// convert string slices to typed slices
+	for _, a := range input.Actions {
+		policy.Statement[0].Action = append(policy.Statement[0].Action, domain.Action(a))
+	}
+
+	for _, a := range input.NotActions {
+		policy.Statement[0].NotAction = append(policy.Statement[0].NotAction, domain.Action(a))
+	}
+	policy.Statement[0].NotResource = input.NotResources
+
+	for _, p := range input.Principals {
+		policy.Statement[0].Principal = append(policy.Statement[0].Principal, domain.Principal(p))
+	}
+
+	return s.PutBucketPolicy(ctx, bucketID, &policy, actor)
+}
+
+// PutBucketPolicy validates and stores a complete policy document, recording
+// it as a new version attributed to actor.
+func (s *BucketService) PutBucketPolicy(ctx context.Context, bucketID string, policy *domain.Policy, actor string) (*domain.PolicyVersion, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	return s.savePolicy(ctx, bucketID, policy, actor, nil)
+}
+
+// ListPolicyVersions returns the policy history of a bucket, newest first
+func (s *BucketService) ListPolicyVersions(ctx context.Context, bucketID string) (*dto.PolicyVersionsOutput, error) {
+	if _, err := s.repo.GetBucketByID(ctx, bucketID); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+
+	versions, err := s.repo.ListPolicyVersions(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policy versions: %w", err)
+	}
+
+	output := &dto.PolicyVersionsOutput{
+		BucketID: bucketID,
+		Versions: make([]dto.PolicyVersionInfo, 0, len(versions)),
+	}
+	for i := range versions {
+		output.Versions = append(output.Versions, PolicyVersionInfo(&versions[i]))
+	}
+	output.Count = len(output.Versions)
+
+	return output, nil
+}
+
+func (s *BucketService) GetPolicyVersion(ctx context.Context, bucketID string, version int) (*dto.PolicyVersionInfo, error) {
+	entry, err := s.policyVersion(ctx, bucketID, version)
+	if err != nil {
+		return nil, err
+	}
+	info := PolicyVersionInfo(entry)
+	return &info, nil
+}
+
+func (s *BucketService) policyVersion(ctx context.Context, bucketID string, version int) (*domain.PolicyVersion, error) {
+	entry, err := s.repo.GetPolicyVersion(ctx, bucketID, version)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %d", ErrPolicyVersionNotFound, version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy version: %w", err)
+	}
+	return entry, nil
+}
+
+// DiffPolicyVersions compares two versions of a bucket policy statement by
+// statement. Statements are compared by content, so reordering them is not
+// reported as a change.
+func (s *BucketService) DiffPolicyVersions(ctx context.Context, bucketID string, from, to int) (*dto.PolicyDiffOutput, error) {
+	fromVersion, err := s.policyVersion(ctx, bucketID, from)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := s.policyVersion(ctx, bucketID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &dto.PolicyDiffOutput{
+		BucketID: bucketID,
+		From:     from,
+		To:       to,
+		Added:    []json.RawMessage{},
+		Removed:  []json.RawMessage{},
+	}
+	if fromVersion.Policy != nil && toVersion.Policy != nil && fromVersion.Policy.Version != toVersion.Policy.Version {
+		diff.DocumentVersion = &dto.PolicyDocumentVersionChange{From: fromVersion.Policy.Version, To: toVersion.Policy.Version}
+	}
+
+	remaining := statementCounts(fromVersion.Policy)
+	for _, stmt := range policyStatements(toVersion.Policy) {
+		key := statementKey(stmt)
+		if remaining[key] > 0 {
+			remaining[key]--
+			diff.Unchanged++
+			continue
+		}
+		diff.Added = append(diff.Added, json.RawMessage(key))
+	}
+	for _, stmt := range policyStatements(fromVersion.Policy) {
+		key := statementKey(stmt)
+		if remaining[key] > 0 {
+			remaining[key]--
+			diff.Removed = append(diff.Removed, json.RawMessage(key))
+		}
+	}
+
+	return diff, nil
+}
+
+// RollbackBucketPolicy makes an earlier version the current policy again. The
+// rollback is itself recorded as a new version, so history is never rewritten.
+func (s *BucketService) RollbackBucketPolicy(ctx context.Context, bucketID string, version int, actor string) (*domain.PolicyVersion, error) {
+	target, err := s.policyVersion(ctx, bucketID, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.savePolicy(ctx, bucketID, target.Policy, actor, &version)
+}
+
+func (s *BucketService) savePolicy(ctx context.Context, bucketID string, policy *domain.Policy, actor string, restoredFrom *int) (*domain.PolicyVersion, error) {
 	// actor is "user:<id>" or "role:admin"
 	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
 	if err != nil {
-		return fmt.Errorf("bucket not found: %w", err)
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
 	}
 
 	// Permission: only owner or admin can update
 	if actor != fmt.Sprintf("user:%s", bucket.OwnerID) && !IsAdmin(actor) {
-		return errors.New("forbidden: only bucket owner or admin can update policy")
-	}
-
-// Convert DTO -> domain.Policy
-policy := domain.Policy{
-	Version: input.Version,
-	Statement: []domain.Statement{
-		{
-			Effect:    domain.Effect(input.Effect),
-			Action:    make([]domain.Action, 0, len(input.Actions)),
-			Resource:  input.Resources,
-			Principal: make([]domain.Principal, 0, len(input.Principals)),
-			Condition: input.Conditions,
-		},
-	},
-}
+		return nil, ErrPolicyForbidden
+	}
 
-// convert string slices to typed slices
-for _, a := range input.Actions {
-	policy.Statement[0].Action = append(policy.Statement[0].Action, domain.Action(a))
-}
+	now := time.Now()
+	bucket.Policy = policy
+	bucket.UpdatedAt = now
+	entry := &domain.PolicyVersion{
+		ID:           uuid.New().String(),
+		UpdatedBy:    actor,
+		RestoredFrom: restoredFrom,
+		CreatedAt:    now,
+	}
+	if err := s.repo.SaveBucketPolicy(ctx, &bucket, entry); err != nil {
+		return nil, fmt.Errorf("failed to save policy: %w", err)
+	}
+	s.events.PolicyUpdated(bucket.ID, entry)
 
-for _, p := range input.Principals {
-	policy.Statement[0].Principal = append(policy.Statement[0].Principal, domain.Principal(p))
+	return entry, nil
 }
 
-// validate
-if err := policy.Validate(); err != nil {
-	return fmt.Errorf("invalid policy: %w", err)
+// PolicyVersionInfo converts a policy version for API output
+func PolicyVersionInfo(entry *domain.PolicyVersion) dto.PolicyVersionInfo {
+	document, _ := json.Marshal(entry.Policy)
+	return dto.PolicyVersionInfo{
+		Version:      entry.Version,
+		Policy:       document,
+		UpdatedBy:    entry.UpdatedBy,
+		RestoredFrom: entry.RestoredFrom,
+		CreatedAt:    entry.CreatedAt,
+	}
 }
 
-	// set and save; increment version
-	bucket.Policy = &policy
-	bucket.UpdatedAt = time.Now()
-	if err := s.repo.IncrementPolicyVersionAndUpdateBucket(ctx, &bucket); err != nil {
-		return fmt.Errorf("failed to save policy: %w", err)
+func policyStatements(policy *domain.Policy) []domain.Statement {
+	if policy == nil {
+		return nil
 	}
+	return policy.Statement
+}
 
+func statementCounts(policy *domain.Policy) map[string]int {
+	counts := make(map[string]int)
+	for _, stmt := range policyStatements(policy) {
+		counts[statementKey(stmt)]++
+	}
+	return counts
+}
 
-	// audit / history - optional
-	_ = s.repo.AppendPolicyHistory(ctx, bucketID, &policy, actor)
-
-	return nil
+// statementKey is the canonical JSON of a statement; map keys in conditions
+// are sorted by encoding/json, so equal statements give equal keys.
+func statementKey(stmt domain.Statement) string {
+	b, _ := json.Marshal(stmt)
+	return string(b)
 }
 
 func (s *BucketService) SetBucketVersioning(ctx context.Context, bucketID string, enabled bool) error {
@@ -244,6 +421,16 @@ func (s *BucketService) SetBucketVersioning(ctx context.Context, bucketID string
 	if err := s.storage.SetBucketVersioning(ctx, bucket.Name, enabled); err != nil {
 		return fmt.Errorf("failed to set versioning: %w", err)
 	}
+
+	// Writes consult the stored status to decide whether to record versions
+	status := domain.VersioningSuspended
+	if enabled {
+		status = domain.VersioningEnabled
+	}
+	if err := s.repo.SetBucketVersioning(ctx, bucket.ID, status); err != nil {
+		return fmt.Errorf("failed to save versioning status: %w", err)
+	}
+	s.events.BucketUpdated(bucket.ID, "versioning", status)
 	
 	return nil
 }
@@ -252,15 +439,12 @@ func (s *BucketService) SetBucketVersioning(ctx context.Context, bucketID string
 
 // isAdmin checks whether the actor (like "user:abc123") is an admin.
 // In MVP mode, we load admin IDs from an env var: ADMIN_USERS=user:abc123,user:def456
+// Admins bypass bucket policies, so when it is unset there are none.
 func IsAdmin(actor string) bool {
-	admins := os.Getenv("ADMIN_USERS")
-
-	// fallback for tests only — change or remove for production
-	if strings.TrimSpace(admins) == "" {
-		admins = "user:550e8400-e29b-41d4-a716-446655440000" // <-- dummy admin for testing; remove/override in prod
+	if actor == "" {
+		return false
 	}
-
-	for _, a := range strings.Split(admins, ",") {
+	for _, a := range strings.Split(os.Getenv("ADMIN_USERS"), ",") {
 		if strings.TrimSpace(a) == actor {
 			return true
 		}
@@ -287,28 +471,93 @@ func (s *BucketService) GetBucketVersioning(ctx context.Context, bucketID string
 
 
 
+// SetBucketEncryption turns default server-side encryption on or off for new
+// objects in the bucket. Existing objects keep the mode they were written with.
+func (s *BucketService) SetBucketEncryption(ctx context.Context, bucketID string, enabled bool) (*dto.BucketEncryptionOutput, error) {
+	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+	if err := s.repo.SetBucketEncryption(ctx, bucket.ID, enabled); err != nil {
+		return nil, fmt.Errorf("failed to save encryption configuration: %w", err)
+	}
+	s.events.BucketUpdated(bucket.ID, "encryption", enabled)
+	return bucketEncryptionOutput(bucket.ID, enabled), nil
+}
+
+func (s *BucketService) GetBucketEncryption(ctx context.Context, bucketID string) (*dto.BucketEncryptionOutput, error) {
+	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+	enabled, err := s.repo.GetBucketEncryption(ctx, bucket.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encryption configuration: %w", err)
+	}
+	return bucketEncryptionOutput(bucket.ID, enabled), nil
+}
+
+func bucketEncryptionOutput(bucketID string, enabled bool) *dto.BucketEncryptionOutput {
+	output := &dto.BucketEncryptionOutput{BucketID: bucketID, Enabled: enabled}
+	if enabled {
+		output.Algorithm = domain.EncryptionSSES3
+	}
+	return output
+}
+
+// SetBucketLifecycle replaces the bucket's lifecycle rules; the lifecycle
+// worker applies them on its next run.
 func (s *BucketService) SetBucketLifecycle(ctx context.Context, bucketID string, input dto.SetLifecycleInput) error {
-	for _, ruleInput := range input.Rules {
+	if _, err := s.repo.GetBucketByID(ctx, bucketID); err != nil {
+		return fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+
+	rules := make([]domain.LifecycleRule, 0, len(input.Rules))
+	seen := make(map[string]bool)
+	for i, ruleInput := range input.Rules {
 		// Map DTO to domain
 		rule := domain.LifecycleRule{
-			ID:                     ruleInput.ID,
-			Prefix:                 ruleInput.Prefix,
-			Status:                 ruleInput.Status,
-			ExpirationDays:         ruleInput.ExpirationDays,
-			TransitionDays:         ruleInput.TransitionDays,
-			TransitionStorageClass: ruleInput.TransitionStorageClass,
+			ID:                                 ruleInput.ID,
+			Prefix:                             ruleInput.Prefix,
+			Status:                             ruleInput.Status,
+			ExpirationDays:                     ruleInput.ExpirationDays,
+			TransitionDays:                     ruleInput.TransitionDays,
+			TransitionStorageClass:             ruleInput.TransitionStorageClass,
+			NoncurrentVersionExpirationDays:    ruleInput.NoncurrentVersionExpirationDays,
+			AbortIncompleteMultipartUploadDays: ruleInput.AbortIncompleteMultipartUploadDays,
 		}
-
-		// Marshal rule to JSON for storage
-		ruleJSON, err := json.Marshal(rule)
-		if err != nil {
-			return fmt.Errorf("failed to marshal rule: %w", err)
+		if rule.ID == "" {
+			rule.ID = fmt.Sprintf("rule-%d", i+1)
+		}
+		if seen[rule.ID] {
+			return fmt.Errorf("%w: duplicate rule id %q", ErrInvalidLifecycleRule, rule.ID)
 		}
+		seen[rule.ID] = true
 
-if err := 		s.repo.UpsertLifecycleRule(ctx, bucketID, ruleJSON); err != nil {
-			return fmt.Errorf("failed to save lifecycle rule: %w", err)
+		if rule.ExpirationDays < 0 || rule.TransitionDays < 0 || rule.NoncurrentVersionExpirationDays < 0 ||
+			rule.AbortIncompleteMultipartUploadDays < 0 {
+			return fmt.Errorf("%w: rule %q: day counts must not be negative", ErrInvalidLifecycleRule, rule.ID)
 		}
+		if (rule.TransitionDays > 0) != (rule.TransitionStorageClass != "") {
+			return fmt.Errorf("%w: rule %q: transition_days and transition_storage_class must be set together", ErrInvalidLifecycleRule, rule.ID)
+		}
+		if rule.TransitionStorageClass != "" && !domain.IsColdStorageClass(rule.TransitionStorageClass) {
+			return fmt.Errorf("%w: rule %q: unsupported transition storage class %q", ErrInvalidLifecycleRule, rule.ID, rule.TransitionStorageClass)
+		}
+		if rule.TransitionDays > 0 && rule.ExpirationDays > 0 && rule.ExpirationDays <= rule.TransitionDays {
+			return fmt.Errorf("%w: rule %q: expiration_days must be later than transition_days", ErrInvalidLifecycleRule, rule.ID)
+		}
+		if rule.ExpirationDays == 0 && rule.TransitionDays == 0 && rule.NoncurrentVersionExpirationDays == 0 &&
+			rule.AbortIncompleteMultipartUploadDays == 0 {
+			return fmt.Errorf("%w: rule %q has no action", ErrInvalidLifecycleRule, rule.ID)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := s.repo.ReplaceLifecycleRules(ctx, bucketID, rules); err != nil {
+		return fmt.Errorf("failed to save lifecycle rules: %w", err)
 	}
+	s.events.BucketUpdated(bucketID, "lifecycle", len(rules))
 	return nil
 }
 
@@ -0,0 +1,144 @@
+package application
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+)
+
+var (
+	ErrInvalidChecksum  = errors.New("malformed checksum")
+	ErrChecksumMismatch = errors.New("content does not match the supplied checksum")
+)
+
+// expectedChecksums decodes the client's checksums into the hex form they
+// are stored in
+func expectedChecksums(input dto.ChecksumInput) (domain.Checksums, error) {
+	var sums domain.Checksums
+	var err error
+	if input.ContentMD5 != "" {
+		if sums.MD5, err = decodeDigest(input.ContentMD5, 16, false); err != nil {
+			return sums, fmt.Errorf("%w: Content-MD5", ErrInvalidChecksum)
+		}
+	}
+	if input.SHA256 != "" {
+		if sums.SHA256, err = decodeDigest(input.SHA256, 32, true); err != nil {
+			return sums, fmt.Errorf("%w: SHA-256", ErrInvalidChecksum)
+		}
+	}
+	if input.CRC32C != "" {
+		if sums.CRC32C, err = decodeDigest(input.CRC32C, 4, true); err != nil {
+			return sums, fmt.Errorf("%w: CRC32C", ErrInvalidChecksum)
+		}
+	}
+	return sums, nil
+}
+
+// decodeDigest returns value, a base64 (or, if allowHex, hex) digest of
+// size bytes, hex encoded
+func decodeDigest(value string, size int, allowHex bool) (string, error) {
+	if allowHex && len(value) == hex.EncodedLen(size) {
+		if sum, err := hex.DecodeString(value); err == nil {
+			return hex.EncodeToString(sum), nil
+		}
+	}
+	sum, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(sum) != size {
+		return "", errors.New("bad digest")
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+// checksumReader hashes an upload as the storage backend reads it. Once the
+// declared size or EOF is reached it verifies the expected checksums and
+// fails the read on a mismatch, so the backend aborts the write rather than
+// committing bad data.
+type checksumReader struct {
+	r        io.Reader
+	hasher   *domain.ChecksumHasher
+	expected domain.Checksums
+	size     int64 // -1 when unknown
+	read     int64
+
+	verified bool
+	err      error
+}
+
+func newChecksumReader(r io.Reader, size int64, input dto.ChecksumInput) (*checksumReader, error) {
+	expected, err := expectedChecksums(input)
+	if err != nil {
+		return nil, err
+	}
+	withCRC32C := expected.CRC32C != "" || strings.EqualFold(input.Algorithm, "CRC32C")
+	return &checksumReader{r: r, hasher: domain.NewChecksumHasher(withCRC32C), expected: expected, size: size}, nil
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	n, err := c.r.Read(p)
+	c.hasher.Write(p[:n])
+	c.read += int64(n)
+
+	if err == io.EOF || (c.size >= 0 && c.read >= c.size) {
+		if verr := c.verify(); verr != nil {
+			return n, verr
+		}
+	}
+	return n, err
+}
+
+// verify checks what was read against the expected checksums; it only
+// compares once, later calls return the same result
+func (c *checksumReader) verify() error {
+	if !c.verified {
+		c.verified = true
+		if algorithm := c.hasher.Sum().Mismatch(c.expected); algorithm != "" {
+			c.err = fmt.Errorf("%w: %s", ErrChecksumMismatch, algorithm)
+		}
+	}
+	return c.err
+}
+
+// finish reads what the storage backend left of the body and verifies the
+// checksums. A backend that knows the size stops reading at it, so errors the
+// underlying reader only reports at EOF, like a bad final chunk signature,
+// surface here instead.
+func (c *checksumReader) finish() error {
+	if err := drainBody(c); err != nil {
+		return err
+	}
+	return c.verify()
+}
+
+// drainBody reads r to EOF, failing if it still has data
+func drainBody(r io.Reader) error {
+	n, err := io.Copy(io.Discard, r)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("%w: body is longer than its declared size", ErrChecksumMismatch)
+	}
+	return nil
+}
+
+// Sum returns the checksums of the content read so far
+func (c *checksumReader) Sum() domain.Checksums {
+	return c.hasher.Sum()
+}
+
+// setFileChecksums records sums on the files row being written
+func setFileChecksums(file *domain.File, sums domain.Checksums) {
+	file.ETag = sums.MD5
+	file.ChecksumSHA256 = sums.SHA256
+	file.ChecksumCRC32C = sums.CRC32C
+}
@@ -0,0 +1,119 @@
+package application
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+
+	"s3/internal/infrastructure/dto"
+)
+
+func TestDecodeDigest(t *testing.T) {
+	sum := md5.Sum([]byte("hello"))
+	hexSum := hex.EncodeToString(sum[:])
+	b64Sum := base64.StdEncoding.EncodeToString(sum[:])
+
+	tests := []struct {
+		name     string
+		value    string
+		allowHex bool
+		want     string
+		wantErr  bool
+	}{
+		{"base64", b64Sum, false, hexSum, false},
+		{"base64 with hex allowed", b64Sum, true, hexSum, false},
+		{"hex", hexSum, true, hexSum, false},
+		{"upper-case hex is normalised", strings.ToUpper(hexSum), true, hexSum, false},
+		{"hex not allowed", hexSum, false, "", true},
+		{"wrong size", base64.StdEncoding.EncodeToString(sum[:8]), true, "", true},
+		{"not base64", "not a digest!", true, "", true},
+		{"empty", "", true, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeDigest(tt.value, md5.Size, tt.allowHex)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("decodeDigest(%q) = %q, %v, want %q, error %v", tt.value, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChecksumReader(t *testing.T) {
+	content := "the quick brown fox jumps over the lazy dog"
+	md5Sum := md5.Sum([]byte(content))
+	sha256Sum := sha256.Sum256([]byte(content))
+	crc := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	crc.Write([]byte(content))
+
+	contentMD5 := base64.StdEncoding.EncodeToString(md5Sum[:])
+	sha256Hex := hex.EncodeToString(sha256Sum[:])
+	crc32cB64 := base64.StdEncoding.EncodeToString(crc.Sum(nil))
+	otherMD5 := md5.Sum([]byte("something else"))
+
+	tests := []struct {
+		name    string
+		body    string
+		size    int64
+		input   dto.ChecksumInput
+		wantErr error
+	}{
+		{"no checksums", content, int64(len(content)), dto.ChecksumInput{}, nil},
+		{"matching checksums", content, int64(len(content)), dto.ChecksumInput{ContentMD5: contentMD5, SHA256: sha256Hex, CRC32C: crc32cB64}, nil},
+		{"unknown size", content, -1, dto.ChecksumInput{ContentMD5: contentMD5, CRC32C: crc32cB64}, nil},
+		{"MD5 mismatch", content, int64(len(content)), dto.ChecksumInput{ContentMD5: base64.StdEncoding.EncodeToString(otherMD5[:])}, ErrChecksumMismatch},
+		{"SHA-256 mismatch", content + "!", -1, dto.ChecksumInput{SHA256: sha256Hex}, ErrChecksumMismatch},
+		{"CRC32C mismatch", "x" + content[1:], int64(len(content)), dto.ChecksumInput{CRC32C: crc32cB64}, ErrChecksumMismatch},
+		{"body longer than its size", content + "trailing", int64(len(content)), dto.ChecksumInput{}, ErrChecksumMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newChecksumReader(strings.NewReader(tt.body), tt.size, tt.input)
+			if err != nil {
+				t.Fatalf("newChecksumReader: %v", err)
+			}
+
+			// Read like a backend that stops at the declared size
+			var src io.Reader = r
+			if tt.size >= 0 {
+				src = io.LimitReader(r, tt.size)
+			}
+			_, err = io.Copy(io.Discard, src)
+			if err == nil {
+				err = r.finish()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("read and finish = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				if sums := r.Sum(); sums.MD5 != hex.EncodeToString(md5Sum[:]) || sums.SHA256 != sha256Hex {
+					t.Errorf("Sum() = %+v, want the content's MD5 and SHA-256", sums)
+				}
+			}
+		})
+	}
+}
+
+func TestNewChecksumReaderRejectsMalformedChecksums(t *testing.T) {
+	tests := []struct {
+		name  string
+		input dto.ChecksumInput
+	}{
+		{"Content-MD5", dto.ChecksumInput{ContentMD5: "abc"}},
+		{"SHA-256", dto.ChecksumInput{SHA256: "zz"}},
+		{"CRC32C", dto.ChecksumInput{CRC32C: base64.StdEncoding.EncodeToString([]byte{1, 2, 3, 4, 5})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newChecksumReader(strings.NewReader(""), 0, tt.input); !errors.Is(err, ErrInvalidChecksum) {
+				t.Errorf("newChecksumReader() error = %v, want %v", err, ErrInvalidChecksum)
+			}
+		})
+	}
+}
@@ -7,14 +7,20 @@ import (
 )
 
 type DeleteService struct {
-	storage    domain.StoragePort
-	repository domain.RepositoryPort
+	storage     domain.StoragePort
+	repository  domain.RepositoryPort
+	tiering     *TieringService
+	replication *ReplicationService
+	events      *EventBus
 }
 
-func NewDeleteService(storage domain.StoragePort, repository domain.RepositoryPort) *DeleteService {
+func NewDeleteService(storage domain.StoragePort, repository domain.RepositoryPort, tiering *TieringService, replication *ReplicationService, events *EventBus) *DeleteService {
 	return &DeleteService{
-		storage:    storage,
-		repository: repository,
+		storage:     storage,
+		repository:  repository,
+		tiering:     tiering,
+		replication: replication,
+		events:      events,
 	}
 }
 
@@ -22,19 +28,28 @@ type DeleteFileInput struct {
 	FileID   string
 	BucketID string
 	Key      string
+	// Lets a governance retention be overridden; the caller must have
+	// checked the s3:BypassGovernanceRetention permission
+	BypassGovernance bool
 }
 
 func (s *DeleteService) DeleteFile(ctx context.Context, input DeleteFileInput) error {
 	file, errors :=s.repository.GetFileByID(ctx, input.FileID)
 	if errors != nil{
-		return fmt.Errorf("Object with id %w does not exist", input.FileID)
+		return fmt.Errorf("Object with id %s does not exist: %w", input.FileID, errors)
 
 	}
+	if err := checkRemovable(file, input.BypassGovernance); err != nil {
+		return err
+	}
 	
 	// 1. Delete from storage (MinIO)
-	err := s.storage.DeleteObject(ctx, input.BucketID, file.Key)
+	err := deleteFromStorage(ctx, s.storage, s.repository, file.BucketID, input.BucketID, file.Key)
 	if err != nil {
-		return fmt.Errorf("failed to delete object from storage: %w", err)
+		return err
+	}
+	if err := s.tiering.deleteArchivedCopy(ctx, input.BucketID, file); err != nil {
+		return err
 	}
 
 	// 2. Delete metadata from database
@@ -42,6 +57,58 @@ func (s *DeleteService) DeleteFile(ctx context.Context, input DeleteFileInput) e
 	if err != nil {
 		return fmt.Errorf("failed to delete file metadata: %w", err)
 	}
+	s.replication.ObjectDeleted(ctx, file.BucketID, file.Key)
+	s.events.ObjectDeleted(file.BucketID, file.Key)
 
 	return nil
-}
\ No newline at end of file
+}
+
+// DeleteObject deletes an object addressed by bucket name and key. Deleting a
+// key that does not exist returns ErrObjectNotFound.
+func (s *DeleteService) DeleteObject(ctx context.Context, bucketName, key string, bypassGovernance bool) error {
+	bucket, err := s.repository.GetBucketByName(ctx, bucketName)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBucketNotFound, bucketName)
+	}
+
+	file, err := s.repository.GetFileByKey(ctx, bucket.ID, key)
+	if err != nil {
+		return fmt.Errorf("%w: %s/%s", ErrObjectNotFound, bucketName, key)
+	}
+	if err := checkRemovable(file, bypassGovernance); err != nil {
+		return err
+	}
+
+	if err := deleteFromStorage(ctx, s.storage, s.repository, bucket.ID, bucket.Name, file.Key); err != nil {
+		return err
+	}
+	if err := s.tiering.deleteArchivedCopy(ctx, bucket.Name, file); err != nil {
+		return err
+	}
+
+	if err := s.repository.DeleteFile(ctx, file.ID); err != nil {
+		return fmt.Errorf("failed to delete file metadata: %w", err)
+	}
+	s.replication.ObjectDeleted(ctx, bucket.ID, file.Key)
+	s.events.ObjectDeleted(bucket.ID, file.Key)
+
+	return nil
+}
+
+// deleteFromStorage removes key from storage. On a versioned bucket this
+// only adds a delete marker, which is recorded so older versions stay
+// listable and restorable.
+func deleteFromStorage(ctx context.Context, storage domain.StoragePort, repo domain.RepositoryPort, bucketID, bucketName, key string) error {
+	if !versioningEnabled(ctx, repo, bucketID) {
+		if err := storage.DeleteObject(ctx, bucketName, key); err != nil {
+			return fmt.Errorf("failed to delete object from storage: %w", err)
+		}
+		return nil
+	}
+
+	deletion, err := storage.DeleteObjectVersion(ctx, bucketName, key, "")
+	if err != nil {
+		return fmt.Errorf("failed to delete object from storage: %w", err)
+	}
+	return recordDeleteMarker(ctx, repo, bucketID, key, deletion)
+}
@@ -0,0 +1,223 @@
+package application
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+)
+
+var (
+	ErrInvalidEncryptionRequest = errors.New("invalid server-side encryption request")
+	ErrSSECustomerKeyRequired   = errors.New("object is encrypted with a customer-provided key; supply the key to access it")
+	ErrSSECustomerKeyMismatch   = errors.New("the customer-provided key does not match the key the object was stored with")
+	// ErrEncryptionUnavailable is returned for encrypted reads and writes
+	// when storage is reached over plain HTTP, where MinIO refuses SSE-C
+	ErrEncryptionUnavailable = fmt.Errorf("%w: server-side encryption requires a TLS connection to storage", ErrInvalidEncryptionRequest)
+)
+
+// EncryptionService manages server-side encryption keys. Server-managed
+// objects get a random data key that is stored sealed with the master key
+// (envelope encryption); SSE-C keys are never stored, only their MD5.
+type EncryptionService struct {
+	repo       domain.RepositoryPort
+	aead       cipher.AEAD
+	storageTLS bool
+}
+
+// NewEncryptionService creates the key manager. Changing masterKey makes
+// existing server-managed objects unreadable. Both modes reach storage as
+// SSE-C, so without storageTLS every encrypted request is refused.
+func NewEncryptionService(repo domain.RepositoryPort, masterKey string, storageTLS bool) (*EncryptionService, error) {
+	if masterKey == "" {
+		return nil, fmt.Errorf("encryption master key is required")
+	}
+	key := sha256.Sum256([]byte(masterKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encryption cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encryption cipher: %w", err)
+	}
+	return &EncryptionService{repo: repo, aead: aead, storageTLS: storageTLS}, nil
+}
+
+// Available reports whether storage can be handed encryption keys
+func (s *EncryptionService) Available() bool {
+	return s.storageTLS
+}
+
+// ForWrite picks the key a new object in bucketID is written with: the
+// request's SSE options, else AES256 when the bucket encrypts by default.
+// It returns nil for an unencrypted object.
+func (s *EncryptionService) ForWrite(ctx context.Context, bucketID string, input dto.SSEInput) (*domain.ObjectEncryption, error) {
+	if input.CustomerAlgorithm != "" || input.CustomerKey != "" {
+		if input.ServerSideEncryption != "" {
+			return nil, fmt.Errorf("%w: SSE-C cannot be combined with x-amz-server-side-encryption", ErrInvalidEncryptionRequest)
+		}
+		if !s.storageTLS {
+			return nil, ErrEncryptionUnavailable
+		}
+		return customerKey(input)
+	}
+
+	switch input.ServerSideEncryption {
+	case domain.EncryptionSSES3:
+		if !s.storageTLS {
+			return nil, ErrEncryptionUnavailable
+		}
+		return s.newDataKey()
+	case "":
+	default:
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidEncryptionRequest, input.ServerSideEncryption)
+	}
+
+	enabled, err := s.repo.GetBucketEncryption(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bucket encryption: %w", err)
+	}
+	if !enabled {
+		return nil, nil
+	}
+	if !s.storageTLS {
+		return nil, ErrEncryptionUnavailable
+	}
+	return s.newDataKey()
+}
+
+// ForRead returns the key needed to read file, checking a customer key
+// against the one the object was written with. It returns nil for an
+// unencrypted file.
+func (s *EncryptionService) ForRead(file *domain.File, input dto.SSEInput) (*domain.ObjectEncryption, error) {
+	if file.Encryption != domain.EncryptionNone && !s.storageTLS {
+		return nil, ErrEncryptionUnavailable
+	}
+
+	switch file.Encryption {
+	case domain.EncryptionNone:
+		return nil, nil
+	case domain.EncryptionSSES3:
+		return s.openDataKey(file.EncryptionKey)
+	case domain.EncryptionSSEC:
+		if input.CustomerKey == "" {
+			return nil, ErrSSECustomerKeyRequired
+		}
+		enc, err := customerKey(input)
+		if err != nil {
+			return nil, err
+		}
+		if enc.KeyMD5 != file.SSECustomerKeyMD5 {
+			return nil, ErrSSECustomerKeyMismatch
+		}
+		return enc, nil
+	}
+	return nil, fmt.Errorf("unknown encryption mode %q", file.Encryption)
+}
+
+// ForUpload returns the data key of a multipart upload started with
+// server-managed encryption, or nil
+func (s *EncryptionService) ForUpload(upload *domain.MultipartUpload) (*domain.ObjectEncryption, error) {
+	if upload.Encryption == domain.EncryptionNone {
+		return nil, nil
+	}
+	if !s.storageTLS {
+		return nil, ErrEncryptionUnavailable
+	}
+	return s.openDataKey(upload.EncryptionKey)
+}
+
+// ForCopy returns the keys to read file with and to write its copy
+// into destBucketID. source carries the copy-source SSE-C headers, which an
+// SSE-C object cannot be copied without. Encrypted objects are re-keyed with
+// a server-managed key and never copied out unencrypted.
+func (s *EncryptionService) ForCopy(ctx context.Context, destBucketID string, file *domain.File, source dto.SSEInput) (*domain.ObjectEncryption, *domain.ObjectEncryption, error) {
+	srcEnc, err := s.ForRead(file, source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var dstInput dto.SSEInput
+	if srcEnc != nil {
+		dstInput.ServerSideEncryption = domain.EncryptionSSES3
+	}
+	dstEnc, err := s.ForWrite(ctx, destBucketID, dstInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	return srcEnc, dstEnc, nil
+}
+
+func (s *EncryptionService) newDataKey() (*domain.ObjectEncryption, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := s.aead.Seal(nonce, nonce, key, nil)
+
+	return &domain.ObjectEncryption{
+		Mode:       domain.EncryptionSSES3,
+		Key:        key,
+		WrappedKey: base64.StdEncoding.EncodeToString(sealed),
+	}, nil
+}
+
+func (s *EncryptionService) openDataKey(wrapped string) (*domain.ObjectEncryption, error) {
+	sealed, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return nil, fmt.Errorf("malformed data key")
+	}
+
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	key, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	return &domain.ObjectEncryption{Mode: domain.EncryptionSSES3, Key: key, WrappedKey: wrapped}, nil
+}
+
+// customerKey validates SSE-C headers: AES256, a 256-bit key and, when
+// given, a matching MD5
+func customerKey(input dto.SSEInput) (*domain.ObjectEncryption, error) {
+	if input.CustomerAlgorithm != domain.EncryptionSSES3 {
+		return nil, fmt.Errorf("%w: customer algorithm must be AES256", ErrInvalidEncryptionRequest)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(input.CustomerKey)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%w: customer key must be a base64 encoded 256-bit key", ErrInvalidEncryptionRequest)
+	}
+
+	sum := md5.Sum(key)
+	keyMD5 := base64.StdEncoding.EncodeToString(sum[:])
+	if input.CustomerKeyMD5 != "" && input.CustomerKeyMD5 != keyMD5 {
+		return nil, fmt.Errorf("%w: customer key MD5 does not match the key", ErrInvalidEncryptionRequest)
+	}
+
+	return &domain.ObjectEncryption{Mode: domain.EncryptionSSEC, Key: key, KeyMD5: keyMD5}, nil
+}
+
+// setFileEncryption records enc on the files row being written
+func setFileEncryption(file *domain.File, enc *domain.ObjectEncryption) {
+	if enc == nil {
+		return
+	}
+	file.Encryption = enc.Mode
+	file.EncryptionKey = enc.WrappedKey
+	file.SSECustomerKeyMD5 = enc.KeyMD5
+}
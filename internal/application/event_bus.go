@@ -0,0 +1,152 @@
+package application
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"s3/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	eventQueueSize      = 1024
+	eventPublishTimeout = 10 * time.Second
+	webhookQueueTimeout = 5 * time.Second
+)
+
+// EventBus publishes object and bucket events for downstream consumers:
+// through an EventPublisher, with the event type as the topic, and to the
+// bucket's webhooks. Either may be nil. Webhook deliveries are written to
+// the durable webhook queue before the request that raised the event
+// returns, so they survive a restart. Broker events are queued in memory
+// and sent by Run, so a slow broker never holds up a request; when that
+// queue is full new events are dropped and logged. A nil EventBus publishes
+// nothing.
+type EventBus struct {
+	publisher domain.EventPublisher
+	webhooks  *WebhookService
+	queue     chan domain.Event
+}
+
+func NewEventBus(publisher domain.EventPublisher, webhooks *WebhookService) *EventBus {
+	return &EventBus{publisher: publisher, webhooks: webhooks, queue: make(chan domain.Event, eventQueueSize)}
+}
+
+// Run publishes queued events to the broker until ctx is done
+func (b *EventBus) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-b.queue:
+			sendCtx, cancel := context.WithTimeout(ctx, eventPublishTimeout)
+			if err := b.publisher.Publish(sendCtx, event.Type, event); err != nil {
+				log.Printf("events: failed to publish %s %s for %s: %v", event.Type, event.ID, event.BucketID, err)
+			}
+			cancel()
+		}
+	}
+}
+
+// ObjectCreated reports a new object or a new version of one
+func (b *EventBus) ObjectCreated(file *domain.File) {
+	b.publish(domain.Event{Type: domain.EventObjectCreated, BucketID: file.BucketID, Object: eventObject(file)})
+}
+
+// ObjectDeleted reports that key was removed from bucketID
+func (b *EventBus) ObjectDeleted(bucketID, key string) {
+	b.publish(domain.Event{Type: domain.EventObjectDeleted, BucketID: bucketID,
+		Object: &domain.EventObject{BucketID: bucketID, Key: key}})
+}
+
+// ObjectCopied reports that file was created as a copy of sourceKey in
+// sourceBucketID
+func (b *EventBus) ObjectCopied(sourceBucketID, sourceKey string, file *domain.File) {
+	b.publish(domain.Event{Type: domain.EventObjectCopied, BucketID: file.BucketID, Object: eventObject(file),
+		Source: &domain.EventObject{BucketID: sourceBucketID, Key: sourceKey}})
+}
+
+// ObjectMoved reports that file now lives where it was moved to from
+// sourceKey in sourceBucketID
+func (b *EventBus) ObjectMoved(sourceBucketID, sourceKey string, file *domain.File) {
+	b.publish(domain.Event{Type: domain.EventObjectMoved, BucketID: file.BucketID, Object: eventObject(file),
+		Source: &domain.EventObject{BucketID: sourceBucketID, Key: sourceKey}})
+}
+
+// ObjectMetadataUpdated reports new user metadata on file
+func (b *EventBus) ObjectMetadataUpdated(file *domain.File) {
+	b.publish(domain.Event{Type: domain.EventObjectMetadataUpdated, BucketID: file.BucketID, Object: eventObject(file),
+		Data: map[string]interface{}{"metadata": file.Metadata}})
+}
+
+// MultipartCompleted reports that a multipart upload was assembled into
+// file; an object.created event is reported for file as well
+func (b *EventBus) MultipartCompleted(uploadID string, parts int, file *domain.File) {
+	b.publish(domain.Event{Type: domain.EventMultipartCompleted, BucketID: file.BucketID, Object: eventObject(file),
+		Data: map[string]interface{}{"upload_id": uploadID, "parts": parts}})
+}
+
+// BucketCreated reports a new bucket
+func (b *EventBus) BucketCreated(bucket *domain.Bucket) {
+	b.publish(domain.Event{Type: domain.EventBucketCreated, BucketID: bucket.ID,
+		Data: map[string]interface{}{"name": bucket.Name, "owner_id": bucket.OwnerID}})
+}
+
+// BucketUpdated reports a change to a bucket's settings; setting names what
+// changed, e.g. "versioning", and value is its new value
+func (b *EventBus) BucketUpdated(bucketID, setting string, value interface{}) {
+	b.publish(domain.Event{Type: domain.EventBucketUpdated, BucketID: bucketID,
+		Data: map[string]interface{}{"setting": setting, "value": value}})
+}
+
+// BucketDeleted reports that a bucket was removed
+func (b *EventBus) BucketDeleted(bucketID string) {
+	b.publish(domain.Event{Type: domain.EventBucketDeleted, BucketID: bucketID})
+}
+
+// PolicyUpdated reports a new version of a bucket's policy
+func (b *EventBus) PolicyUpdated(bucketID string, version *domain.PolicyVersion) {
+	data := map[string]interface{}{"version": version.Version}
+	if version.RestoredFrom != nil {
+		data["restored_from"] = *version.RestoredFrom
+	}
+	b.publish(domain.Event{Type: domain.EventPolicyUpdated, BucketID: bucketID, Actor: version.UpdatedBy, Data: data})
+}
+
+func (b *EventBus) publish(event domain.Event) {
+	if b == nil {
+		return
+	}
+	event.ID = uuid.New().String()
+	event.Time = time.Now().UTC()
+	if b.webhooks != nil {
+		// Not tied to the request, which may already be cancelled once
+		// the change it reports has been made
+		ctx, cancel := context.WithTimeout(context.Background(), webhookQueueTimeout)
+		b.webhooks.triggerEvent(ctx, event)
+		cancel()
+	}
+	if b.publisher != nil {
+		select {
+		case b.queue <- event:
+		default:
+			log.Printf("events: queue full, dropping %s for %s", event.Type, event.BucketID)
+		}
+	}
+}
+
+func eventObject(file *domain.File) *domain.EventObject {
+	return &domain.EventObject{
+		BucketID:     file.BucketID,
+		Key:          file.Key,
+		FileID:       file.ID,
+		VersionID:    file.Version,
+		Size:         file.Size,
+		ETag:         file.ETag,
+		ContentType:  file.MediaType(),
+		StorageClass: file.StorageClass,
+		Metadata:     file.Metadata,
+	}
+}
@@ -0,0 +1,370 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+
+	"github.com/google/uuid"
+)
+
+const day = 24 * time.Hour
+
+// LifecycleService applies bucket lifecycle rules: it expires current
+// objects and noncurrent versions, transitions objects to cold storage
+// classes and aborts stale multipart uploads. It also removes restored
+// copies of cold objects once they expire. Run drives it periodically;
+// RunBucket with dryRun reports what a run would do.
+type LifecycleService struct {
+	repo        domain.RepositoryPort
+	storage     domain.StoragePort
+	tiering     *TieringService
+	replication *ReplicationService
+	events      *EventBus
+
+	// mu keeps the periodic worker and on-demand runs from racing each other
+	mu sync.Mutex
+}
+
+func NewLifecycleService(repo domain.RepositoryPort, storage domain.StoragePort, tiering *TieringService, replication *ReplicationService, events *EventBus) *LifecycleService {
+	return &LifecycleService{repo: repo, storage: storage, tiering: tiering, replication: replication, events: events}
+}
+
+// Run applies lifecycle rules to every bucket each interval until ctx is done
+func (s *LifecycleService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.RunAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunAll applies lifecycle rules to every bucket; failures are logged and
+// recorded, and do not stop the other buckets.
+func (s *LifecycleService) RunAll(ctx context.Context) {
+	buckets, err := s.repo.ListBuckets(ctx)
+	if err != nil {
+		log.Printf("lifecycle: failed to list buckets: %v", err)
+		return
+	}
+
+	for _, bucket := range buckets {
+		report, err := s.RunBucket(ctx, bucket.ID, false)
+		if err != nil {
+			log.Printf("lifecycle: bucket %s: %v", bucket.ID, err)
+			continue
+		}
+		if report.Count > 0 {
+			log.Printf("lifecycle: bucket %s: %d actions, %d failed", bucket.ID, report.Count, report.Failed)
+		}
+	}
+}
+
+// RunBucket evaluates the bucket's lifecycle rules and, unless dryRun is
+// set, carries out and records the resulting actions.
+func (s *LifecycleService) RunBucket(ctx context.Context, bucketID string, dryRun bool) (*dto.LifecycleReport, error) {
+	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+
+	rules, err := s.repo.GetLifecycleRules(ctx, bucket.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lifecycle rules: %w", err)
+	}
+
+	if !dryRun {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	now := time.Now()
+	report := &dto.LifecycleReport{
+		BucketID:    bucket.ID,
+		RunID:       uuid.New().String(),
+		DryRun:      dryRun,
+		EvaluatedAt: now,
+		Rules:       len(rules),
+		Actions:     []dto.LifecycleActionInfo{},
+	}
+
+	actions, err := s.plan(ctx, &bucket, rules, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range actions {
+		action := &actions[i]
+		action.ID = uuid.New().String()
+		action.RunID = report.RunID
+		action.BucketID = bucket.ID
+		action.ExecutedAt = now
+
+		if !dryRun {
+			if err := s.execute(ctx, &bucket, action); err != nil {
+				action.Error = err.Error()
+				report.Failed++
+			}
+			action.ExecutedAt = time.Now()
+		}
+		report.Actions = append(report.Actions, lifecycleActionInfo(action))
+	}
+	report.Count = len(report.Actions)
+
+	if !dryRun && len(actions) > 0 {
+		if err := s.repo.SaveLifecycleActions(ctx, actions); err != nil {
+			return nil, fmt.Errorf("failed to record lifecycle actions: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+// History returns the most recent actions the worker took on a bucket
+func (s *LifecycleService) History(ctx context.Context, bucketID string, limit int) (*dto.LifecycleHistoryOutput, error) {
+	if _, err := s.repo.GetBucketByID(ctx, bucketID); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	actions, err := s.repo.ListLifecycleActions(ctx, bucketID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lifecycle actions: %w", err)
+	}
+
+	output := &dto.LifecycleHistoryOutput{
+		BucketID: bucketID,
+		Actions:  make([]dto.LifecycleActionInfo, 0, len(actions)),
+	}
+	for i := range actions {
+		output.Actions = append(output.Actions, lifecycleActionInfo(&actions[i]))
+	}
+	output.Count = len(output.Actions)
+
+	return output, nil
+}
+
+// plan works out which actions the enabled rules call for at now. Each
+// object, version or upload is acted on by the first rule that covers it;
+// an object due for expiry is not also transitioned.
+func (s *LifecycleService) plan(ctx context.Context, bucket *domain.Bucket, rules []domain.LifecycleRule, now time.Time) ([]domain.LifecycleAction, error) {
+	var enabled []domain.LifecycleRule
+	var expiresNoncurrent, abortsUploads bool
+	for _, rule := range rules {
+		if !rule.IsEnabled() {
+			continue
+		}
+		enabled = append(enabled, rule)
+		expiresNoncurrent = expiresNoncurrent || rule.NoncurrentVersionExpirationDays > 0
+		abortsUploads = abortsUploads || rule.AbortIncompleteMultipartUploadDays > 0
+	}
+
+	var actions []domain.LifecycleAction
+
+	// Listed even without rules: restored copies expire regardless
+	files, err := s.repo.ListFiles(ctx, bucket.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	// Cold storage keeps whole objects, not versions, so versioned buckets
+	// are never transitioned
+	transitions := !versioningEnabled(ctx, s.repo, bucket.ID)
+	for _, file := range files {
+		if action, ok := planObject(&file, enabled, now, transitions); ok {
+			actions = append(actions, action)
+		}
+	}
+
+	if expiresNoncurrent {
+		versions, err := s.repo.ListObjectVersions(ctx, bucket.ID, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list object versions: %w", err)
+		}
+		actions = append(actions, planNoncurrentVersions(versions, enabled, now)...)
+	}
+
+	if abortsUploads {
+		uploads, err := s.repo.ListMultipartUploadsByBucket(ctx, bucket.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list multipart uploads: %w", err)
+		}
+		actions = append(actions, planUploads(uploads, enabled, now)...)
+	}
+
+	return actions, nil
+}
+
+// planNoncurrentVersions picks the noncurrent versions due for expiry at
+// now. versions come grouped by key, newest first: a version became
+// noncurrent when the one listed before it was written.
+func planNoncurrentVersions(versions []domain.ObjectVersion, rules []domain.LifecycleRule, now time.Time) []domain.LifecycleAction {
+	var actions []domain.LifecycleAction
+	for i, version := range versions {
+		if version.IsLatest || i == 0 || versions[i-1].Key != version.Key {
+			continue
+		}
+		noncurrentSince := versions[i-1].CreatedAt
+		for _, rule := range rules {
+			if rule.NoncurrentVersionExpirationDays > 0 && rule.Matches(version.Key) &&
+				!now.Before(noncurrentSince.Add(time.Duration(rule.NoncurrentVersionExpirationDays)*day)) {
+				actions = append(actions, domain.LifecycleAction{
+					RuleID: rule.ID, Action: domain.LifecycleExpireNoncurrentVersion,
+					Key: version.Key, VersionID: version.VersionID,
+				})
+				break
+			}
+		}
+	}
+	return actions
+}
+
+// planUploads picks the multipart uploads due to be aborted at now
+func planUploads(uploads []domain.MultipartUpload, rules []domain.LifecycleRule, now time.Time) []domain.LifecycleAction {
+	var actions []domain.LifecycleAction
+	for _, upload := range uploads {
+		for _, rule := range rules {
+			if rule.AbortIncompleteMultipartUploadDays > 0 && rule.Matches(upload.Key) &&
+				!now.Before(upload.CreatedAt.Add(time.Duration(rule.AbortIncompleteMultipartUploadDays)*day)) {
+				actions = append(actions, domain.LifecycleAction{
+					RuleID: rule.ID, Action: domain.LifecycleAbortMultipartUpload,
+					Key: upload.Key, UploadID: upload.UploadID,
+				})
+				break
+			}
+		}
+	}
+	return actions
+}
+
+// planObject picks the action, if any, due for a current object at now
+func planObject(file *domain.File, rules []domain.LifecycleRule, now time.Time, transitions bool) (domain.LifecycleAction, bool) {
+	age := func(days int) bool {
+		return !now.Before(file.CreatedAt.Add(time.Duration(days) * day))
+	}
+
+	// Locked objects never expire; they can still be transitioned
+	expirable := file.CanRemove(now, false)
+	for _, rule := range rules {
+		if expirable && rule.ExpirationDays > 0 && rule.Matches(file.Key) && age(rule.ExpirationDays) {
+			return domain.LifecycleAction{RuleID: rule.ID, Action: domain.LifecycleExpireObject, Key: file.Key}, true
+		}
+	}
+
+	if file.RestoreExpiresAt != nil && !file.IsRestored(now) {
+		return domain.LifecycleAction{
+			Action: domain.LifecycleExpireRestoredCopy, Key: file.Key, StorageClass: file.StorageClass,
+		}, true
+	}
+
+	if !transitions || domain.IsColdStorageClass(file.StorageClass) || file.IsEncrypted() {
+		return domain.LifecycleAction{}, false
+	}
+	for _, rule := range rules {
+		if rule.TransitionDays > 0 && rule.Matches(file.Key) && age(rule.TransitionDays) {
+			return domain.LifecycleAction{
+				RuleID: rule.ID, Action: domain.LifecycleTransitionObject,
+				Key: file.Key, StorageClass: rule.TransitionStorageClass,
+			}, true
+		}
+	}
+
+	return domain.LifecycleAction{}, false
+}
+
+func (s *LifecycleService) execute(ctx context.Context, bucket *domain.Bucket, action *domain.LifecycleAction) error {
+	switch action.Action {
+	case domain.LifecycleExpireObject:
+		file, err := s.repo.GetFileByKey(ctx, bucket.ID, action.Key)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrObjectNotFound, action.Key)
+		}
+		// The lock may have been set since the action was planned
+		if err := checkRemovable(file, false); err != nil {
+			return err
+		}
+		// on a versioned bucket this leaves a delete marker, like S3
+		if err := deleteFromStorage(ctx, s.storage, s.repo, bucket.ID, bucket.Name, file.Key); err != nil {
+			return err
+		}
+		if err := s.tiering.deleteArchivedCopy(ctx, bucket.Name, file); err != nil {
+			return err
+		}
+		if err := s.repo.DeleteFile(ctx, file.ID); err != nil {
+			return fmt.Errorf("failed to delete file metadata: %w", err)
+		}
+		s.replication.ObjectDeleted(ctx, bucket.ID, file.Key)
+		s.events.ObjectDeleted(bucket.ID, file.Key)
+
+	case domain.LifecycleTransitionObject:
+		file, err := s.repo.GetFileByKey(ctx, bucket.ID, action.Key)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrObjectNotFound, action.Key)
+		}
+		if err := s.tiering.Transition(ctx, bucket, file, action.StorageClass); err != nil {
+			return err
+		}
+
+	case domain.LifecycleExpireRestoredCopy:
+		file, err := s.repo.GetFileByKey(ctx, bucket.ID, action.Key)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrObjectNotFound, action.Key)
+		}
+		if err := s.tiering.ExpireRestoredCopy(ctx, bucket, file); err != nil {
+			return err
+		}
+
+	case domain.LifecycleExpireNoncurrentVersion:
+		if _, err := s.storage.DeleteObjectVersion(ctx, bucket.Name, action.Key, action.VersionID); err != nil {
+			return fmt.Errorf("failed to delete object version from storage: %w", err)
+		}
+		if _, err := s.repo.DeleteObjectVersion(ctx, bucket.ID, action.VersionID); err != nil {
+			return fmt.Errorf("failed to delete object version: %w", err)
+		}
+
+	case domain.LifecycleAbortMultipartUpload:
+		upload, err := s.repo.GetMultipartUploadByUploadID(ctx, action.UploadID)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUploadNotFound, err)
+		}
+		if err := s.storage.AbortMultipartUpload(ctx, bucket.Name, upload.Key, upload.StorageUploadID); err != nil {
+			return fmt.Errorf("failed to abort upload: %w", err)
+		}
+		upload.Status = "aborted"
+		upload.UpdatedAt = time.Now()
+		if err := s.repo.UpdateMultipartUpload(ctx, upload); err != nil {
+			return fmt.Errorf("failed to update upload: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("unknown lifecycle action %q", action.Action)
+	}
+
+	return nil
+}
+
+func lifecycleActionInfo(action *domain.LifecycleAction) dto.LifecycleActionInfo {
+	return dto.LifecycleActionInfo{
+		RuleID:       action.RuleID,
+		Action:       string(action.Action),
+		Key:          action.Key,
+		VersionID:    action.VersionID,
+		UploadID:     action.UploadID,
+		StorageClass: action.StorageClass,
+		Error:        action.Error,
+		ExecutedAt:   action.ExecutedAt,
+	}
+}
@@ -0,0 +1,125 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"s3/internal/domain"
+)
+
+func TestPlanObject(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.Add(-time.Duration(days) * day) }
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+
+	rules := []domain.LifecycleRule{
+		{ID: "logs", Prefix: "logs/", Status: domain.LifecycleStatusEnabled, ExpirationDays: 30, TransitionDays: 7, TransitionStorageClass: domain.StorageClassGlacier},
+		{ID: "all", Prefix: "", Status: domain.LifecycleStatusEnabled, ExpirationDays: 365},
+	}
+
+	tests := []struct {
+		name        string
+		file        domain.File
+		transitions bool
+		want        domain.LifecycleActionType
+		rule        string
+	}{
+		{"young object", domain.File{Key: "logs/a", CreatedAt: daysAgo(1)}, true, "", ""},
+		{"expires on the day", domain.File{Key: "logs/a", CreatedAt: daysAgo(30)}, true, domain.LifecycleExpireObject, "logs"},
+		{"first matching rule wins", domain.File{Key: "logs/a", CreatedAt: daysAgo(400)}, true, domain.LifecycleExpireObject, "logs"},
+		{"prefix must match", domain.File{Key: "data/a", CreatedAt: daysAgo(30)}, true, "", ""},
+		{"catch-all rule", domain.File{Key: "data/a", CreatedAt: daysAgo(365)}, true, domain.LifecycleExpireObject, "all"},
+		{"transition before expiry", domain.File{Key: "logs/a", CreatedAt: daysAgo(7)}, true, domain.LifecycleTransitionObject, "logs"},
+		{"no transitions on versioned buckets", domain.File{Key: "logs/a", CreatedAt: daysAgo(7)}, false, "", ""},
+		{"cold objects are not transitioned again", domain.File{Key: "logs/a", CreatedAt: daysAgo(7), StorageClass: domain.StorageClassGlacier}, true, "", ""},
+		{"encrypted objects are not transitioned", domain.File{Key: "logs/a", CreatedAt: daysAgo(7), Encryption: domain.EncryptionSSES3}, true, "", ""},
+		{"legal hold blocks expiry", domain.File{Key: "logs/a", CreatedAt: daysAgo(30), LegalHold: true}, true, domain.LifecycleTransitionObject, "logs"},
+		{"retention blocks expiry", domain.File{Key: "logs/a", CreatedAt: daysAgo(30), RetentionMode: domain.RetentionGovernance, RetainUntil: &later}, true, domain.LifecycleTransitionObject, "logs"},
+		{"expired retention does not", domain.File{Key: "logs/a", CreatedAt: daysAgo(30), RetentionMode: domain.RetentionGovernance, RetainUntil: &earlier}, true, domain.LifecycleExpireObject, "logs"},
+		{"restored copy expires", domain.File{Key: "data/a", CreatedAt: daysAgo(1), StorageClass: domain.StorageClassGlacier, RestoreExpiresAt: &earlier}, true, domain.LifecycleExpireRestoredCopy, ""},
+		{"restored copy still valid", domain.File{Key: "data/a", CreatedAt: daysAgo(1), StorageClass: domain.StorageClassGlacier, RestoreExpiresAt: &later}, true, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := planObject(&tt.file, rules, now, tt.transitions)
+			if ok != (tt.want != "") || got.Action != tt.want || got.RuleID != tt.rule {
+				t.Errorf("planObject() = %+v, %v, want action %q by rule %q", got, ok, tt.want, tt.rule)
+			}
+			if ok && got.Key != tt.file.Key {
+				t.Errorf("planObject() key = %q, want %q", got.Key, tt.file.Key)
+			}
+		})
+	}
+}
+
+func TestPlanNoncurrentVersions(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.Add(-time.Duration(days) * day) }
+
+	rules := []domain.LifecycleRule{
+		{ID: "tmp", Prefix: "tmp/", Status: domain.LifecycleStatusEnabled, NoncurrentVersionExpirationDays: 1},
+		{ID: "all", Prefix: "", Status: domain.LifecycleStatusEnabled, NoncurrentVersionExpirationDays: 30},
+	}
+	// Grouped by key, newest first, as ListObjectVersions returns them
+	versions := []domain.ObjectVersion{
+		{Key: "a", VersionID: "a3", IsLatest: true, CreatedAt: daysAgo(10)},
+		{Key: "a", VersionID: "a2", CreatedAt: daysAgo(40)},
+		{Key: "a", VersionID: "a1", CreatedAt: daysAgo(50)},
+		{Key: "b", VersionID: "b2", IsLatest: true, CreatedAt: daysAgo(31)},
+		{Key: "b", VersionID: "b1", CreatedAt: daysAgo(60)},
+		{Key: "tmp/c", VersionID: "c2", IsLatest: true, IsDeleteMarker: true, CreatedAt: daysAgo(2)},
+		{Key: "tmp/c", VersionID: "c1", CreatedAt: daysAgo(5)},
+		{Key: "tmp/d", VersionID: "d1", CreatedAt: daysAgo(90)},
+	}
+
+	got := planNoncurrentVersions(versions, rules, now)
+
+	// a2 has only been noncurrent since a3 was written 10 days ago; a1
+	// since a2 was written 40 days ago. d1 is listed first for its key, so
+	// nothing replaced it.
+	want := []struct{ rule, version string }{
+		{"all", "a1"},
+		{"all", "b1"},
+		{"tmp", "c1"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("planNoncurrentVersions() = %+v, want %d actions", got, len(want))
+	}
+	for i, w := range want {
+		if got[i].Action != domain.LifecycleExpireNoncurrentVersion || got[i].RuleID != w.rule || got[i].VersionID != w.version {
+			t.Errorf("action %d = %+v, want %s to expire %s", i, got[i], w.rule, w.version)
+		}
+	}
+}
+
+func TestPlanUploads(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.Add(-time.Duration(days) * day) }
+
+	rules := []domain.LifecycleRule{
+		{ID: "expire-only", Prefix: "", Status: domain.LifecycleStatusEnabled, ExpirationDays: 1},
+		{ID: "uploads", Prefix: "uploads/", Status: domain.LifecycleStatusEnabled, AbortIncompleteMultipartUploadDays: 7},
+	}
+
+	tests := []struct {
+		name   string
+		upload domain.MultipartUpload
+		want   bool
+	}{
+		{"stale upload", domain.MultipartUpload{UploadID: "u1", Key: "uploads/a", CreatedAt: daysAgo(7)}, true},
+		{"recent upload", domain.MultipartUpload{UploadID: "u2", Key: "uploads/a", CreatedAt: daysAgo(6)}, false},
+		{"prefix must match", domain.MultipartUpload{UploadID: "u3", Key: "other/a", CreatedAt: daysAgo(30)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planUploads([]domain.MultipartUpload{tt.upload}, rules, now)
+			if (len(got) == 1) != tt.want {
+				t.Fatalf("planUploads() = %+v, want abort %v", got, tt.want)
+			}
+			if tt.want && (got[0].Action != domain.LifecycleAbortMultipartUpload || got[0].RuleID != "uploads" ||
+				got[0].UploadID != tt.upload.UploadID || got[0].Key != tt.upload.Key) {
+				t.Errorf("planUploads() = %+v, want rule uploads to abort %s", got[0], tt.upload.UploadID)
+			}
+		})
+	}
+}
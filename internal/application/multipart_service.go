@@ -1,89 +1,130 @@
 package application
 
 import (
-	"bytes"
 	"context"
-	"crypto/md5"
-	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrInvalidMultipartPart reports a part that breaks the S3 multipart rules
+	ErrInvalidMultipartPart = errors.New("invalid multipart part")
+	ErrUploadNotFound       = errors.New("upload not found")
+)
+
 type MultipartService struct {
-	repo    domain.RepositoryPort
-	storage domain.StoragePort
+	repo        domain.RepositoryPort
+	storage     domain.StoragePort
+	encryption  *EncryptionService
+	quotas      *QuotaService
+	replication *ReplicationService
+	events      *EventBus
 }
 
-func NewMultipartService(repo domain.RepositoryPort, storage domain.StoragePort) *MultipartService {
-	return &MultipartService{repo: repo, storage: storage}
+func NewMultipartService(repo domain.RepositoryPort, storage domain.StoragePort, encryption *EncryptionService, quotas *QuotaService, replication *ReplicationService, events *EventBus) *MultipartService {
+	return &MultipartService{repo: repo, storage: storage, encryption: encryption, quotas: quotas, replication: replication, events: events}
 }
 
 func (s *MultipartService) InitiateMultipartUpload(ctx context.Context, input dto.InitiateMultipartUploadInput) (*dto.InitiateMultipartUploadOutput, error) {
+	bucket, err := s.repo.GetBucketByID(ctx, input.BucketID)
+	if err != nil {
+		return nil, fmt.Errorf("bucket not found: %w", err)
+	}
+
+	enc, err := s.encryption.ForWrite(ctx, bucket.ID, input.Encryption)
+	if err != nil {
+		return nil, err
+	}
+	// Parts are sent in later requests; only a server-managed key can be
+	// recovered for them
+	if enc != nil && enc.Mode == domain.EncryptionSSEC {
+		return nil, fmt.Errorf("%w: SSE-C is not supported for multipart uploads", ErrInvalidEncryptionRequest)
+	}
+
+	// Content-MD5 would cover the request body, not the object
+	sums, err := expectedChecksums(dto.ChecksumInput{SHA256: input.Checksums.SHA256, CRC32C: input.Checksums.CRC32C})
+	if err != nil {
+		return nil, err
+	}
+
+	storageUploadID, err := s.storage.NewMultipartUpload(ctx, bucket.Name, input.Key, input.ContentType, input.Metadata, enc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initiate upload: %w", err)
+	}
+
 	upload := &domain.MultipartUpload{
-		ID:        uuid.New().String(),
-		UploadID:  uuid.New().String(),
-		BucketID:  input.BucketID,
-		Key:       input.Key,
-		Status:    "initiated",
-		Parts:     []domain.Part{},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:              uuid.New().String(),
+		UploadID:        uuid.New().String(),
+		StorageUploadID: storageUploadID,
+		BucketID:        input.BucketID,
+		Key:             input.Key,
+		Status:          "initiated",
+		Parts:           []domain.Part{},
+		ContentType:     input.ContentType,
+		Metadata:        input.Metadata,
+		ChecksumSHA256:  sums.SHA256,
+		ChecksumCRC32C:  sums.CRC32C,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	if enc != nil {
+		upload.Encryption = enc.Mode
+		upload.EncryptionKey = enc.WrappedKey
 	}
 
 	if err := s.repo.SaveMultipartUpload(ctx, upload); err != nil {
+		s.storage.AbortMultipartUpload(ctx, bucket.Name, input.Key, storageUploadID)
 		return nil, fmt.Errorf("failed to initiate upload: %w", err)
 	}
 
 	return &dto.InitiateMultipartUploadOutput{
 		UploadID:  upload.UploadID,
 		BucketID:  upload.BucketID,
-		Key:       upload.Key,
-		CreatedAt: upload.CreatedAt,
+		Key:        upload.Key,
+		CreatedAt:  upload.CreatedAt,
+		Encryption: upload.Encryption,
 	}, nil
 }
 
 func (s *MultipartService) UploadPart(ctx context.Context, input dto.UploadPartInput) (*dto.UploadPartOutput, error) {
-	upload, err := s.repo.GetMultipartUploadByUploadID(ctx, input.UploadID)
-	if err != nil {
-		return nil, fmt.Errorf("upload not found: %w", err)
+	if input.PartNumber < 1 || input.PartNumber > domain.MaxParts {
+		return nil, fmt.Errorf("%w: part number must be between 1 and %d", ErrInvalidMultipartPart, domain.MaxParts)
+	}
+	if input.Size > domain.MaxPartSize {
+		return nil, fmt.Errorf("%w: part exceeds the %d byte maximum", ErrInvalidMultipartPart, domain.MaxPartSize)
 	}
 
-	if upload.Status != "initiated" {
-		return nil, fmt.Errorf("upload is %s", upload.Status)
+	upload, bucket, err := s.activeUpload(ctx, input.BucketID, input.UploadID)
+	if err != nil {
+		return nil, err
 	}
 
-	bucket, err := s.repo.GetBucketByID(ctx, input.BucketID)
+	enc, err := s.encryption.ForUpload(upload)
 	if err != nil {
-		return nil, fmt.Errorf("bucket not found: %w", err)
+		return nil, err
 	}
 
-	// Store part with special key
-	partKey := fmt.Sprintf("%s.part.%s.%d", upload.Key, upload.UploadID, input.PartNumber)
-	
-	if err := s.storage.SaveObject(ctx, bucket.Name, partKey, input.Data, nil); err != nil {
+	part, err := s.storage.PutObjectPart(ctx, bucket.Name, upload.Key, upload.StorageUploadID, input.PartNumber, input.Body, input.Size, enc)
+	if err != nil {
 		return nil, fmt.Errorf("failed to save part: %w", err)
 	}
-
-	// Calculate ETag
-	hash := md5.Sum(input.Data)
-	etag := hex.EncodeToString(hash[:])
-
-	part := domain.Part{
-		PartNumber: input.PartNumber,
-		ETag:       etag,
-		Size:       int64(len(input.Data)),
-		UploadedAt: time.Now(),
+	// A part that fails verification once stored is not recorded, so it is
+	// never used to complete the upload
+	if err := drainBody(input.Body); err != nil {
+		return nil, fmt.Errorf("failed to save part: %w", err)
 	}
 
-	upload.Parts = append(upload.Parts, part)
-	upload.UpdatedAt = time.Now()
-
-	if err := s.repo.UpdateMultipartUpload(ctx, upload); err != nil {
+	// Re-uploading a part number replaces the earlier part
+	if err := s.repo.SaveMultipartPart(ctx, upload.UploadID, part); err != nil {
 		return nil, fmt.Errorf("failed to update upload: %w", err)
 	}
 
@@ -95,58 +136,75 @@ func (s *MultipartService) UploadPart(ctx context.Context, input dto.UploadPartI
 }
 
 func (s *MultipartService) CompleteMultipartUpload(ctx context.Context, input dto.CompleteMultipartUploadInput) (*dto.CompleteMultipartUploadOutput, error) {
-	upload, err := s.repo.GetMultipartUploadByUploadID(ctx, input.UploadID)
+	upload, bucket, err := s.activeUpload(ctx, input.BucketID, input.UploadID)
 	if err != nil {
-		return nil, fmt.Errorf("upload not found: %w", err)
+		return nil, err
 	}
 
-	bucket, err := s.repo.GetBucketByID(ctx, input.BucketID)
-	if err != nil {
-		return nil, fmt.Errorf("bucket not found: %w", err)
+	if len(input.Parts) == 0 {
+		return nil, fmt.Errorf("%w: at least one part is required", ErrInvalidMultipartPart)
+	}
+	if len(input.Parts) > domain.MaxParts {
+		return nil, fmt.Errorf("%w: at most %d parts are allowed", ErrInvalidMultipartPart, domain.MaxParts)
+	}
+
+	uploaded := make(map[int]domain.Part, len(upload.Parts))
+	for _, part := range upload.Parts {
+		uploaded[part.PartNumber] = part
 	}
 
 	// Verify all parts
+	parts := make([]domain.Part, 0, len(input.Parts))
 	for _, reqPart := range input.Parts {
-		found := false
-		for _, uploadedPart := range upload.Parts {
-			if uploadedPart.PartNumber == reqPart.PartNumber && uploadedPart.ETag == reqPart.ETag {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return nil, fmt.Errorf("part %d with etag %s not found", reqPart.PartNumber, reqPart.ETag)
+		part, ok := uploaded[reqPart.PartNumber]
+		if !ok || part.ETag != strings.Trim(reqPart.ETag, `"`) {
+			return nil, fmt.Errorf("%w: part %d with etag %s not found", ErrInvalidMultipartPart, reqPart.PartNumber, reqPart.ETag)
 		}
+		parts = append(parts, part)
 	}
 
 	// Sort parts by part number
-	sort.Slice(upload.Parts, func(i, j int) bool {
-		return upload.Parts[i].PartNumber < upload.Parts[j].PartNumber
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
 	})
 
-	// Combine all parts
-	var combinedData bytes.Buffer
 	var totalSize int64
-
-	for _, part := range upload.Parts {
-		partKey := fmt.Sprintf("%s.part.%s.%d", upload.Key, upload.UploadID, part.PartNumber)
-		data, err := s.storage.GetObject(ctx, bucket.Name, partKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get part %d: %w", part.PartNumber, err)
+	for i, part := range parts {
+		if i > 0 && part.PartNumber == parts[i-1].PartNumber {
+			return nil, fmt.Errorf("%w: part %d listed twice", ErrInvalidMultipartPart, part.PartNumber)
+		}
+		if i < len(parts)-1 && part.Size < domain.MinPartSize {
+			return nil, fmt.Errorf("%w: part %d is smaller than the %d byte minimum", ErrInvalidMultipartPart, part.PartNumber, domain.MinPartSize)
 		}
-		combinedData.Write(data)
 		totalSize += part.Size
 	}
 
-	// Save final object
-	finalData := combinedData.Bytes()
-	if err := s.storage.SaveObject(ctx, bucket.Name, upload.Key, finalData, nil); err != nil {
-		return nil, fmt.Errorf("failed to save final object: %w", err)
+	if err := checkOverwritable(ctx, s.repo, input.BucketID, upload.Key); err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.quotas.reserve(ctx, bucket, upload.Key, totalSize)
+	if err != nil {
+		return nil, err
 	}
+	defer reservation.release(ctx)
 
-	// Calculate final ETag
-	hash := md5.Sum(finalData)
-	finalETag := hex.EncodeToString(hash[:])
+	// The backend composes the final object from the stored parts
+	info, err := s.storage.CompleteMultipartUpload(ctx, bucket.Name, upload.Key, upload.StorageUploadID, parts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete upload: %w", err)
+	}
+
+	finalETag, err := domain.MultipartETag(parts)
+	if err != nil {
+		finalETag = info.ETag
+	}
+
+	sums, err := s.verifyChecksums(ctx, bucket, upload)
+	if err != nil {
+		s.discardObject(ctx, bucket.Name, upload.Key, info.VersionID)
+		return nil, err
+	}
 
 	// Save file metadata
 	file := domain.File{
@@ -154,45 +212,117 @@ func (s *MultipartService) CompleteMultipartUpload(ctx context.Context, input dt
 		BucketID:  input.BucketID,
 		Key:       upload.Key,
 		Size:      totalSize,
+		Version:   info.VersionID,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
-	}
-	s.repo.SaveFile(ctx, file)
 
-	// Clean up parts
-	for _, part := range upload.Parts {
-		partKey := fmt.Sprintf("%s.part.%s.%d", upload.Key, upload.UploadID, part.PartNumber)
-		s.storage.DeleteObject(ctx, bucket.Name, partKey)
+		MimeType:    upload.ContentType,
+		ContentType: upload.ContentType,
+		Metadata:    upload.Metadata,
+
+		Encryption:    upload.Encryption,
+		EncryptionKey: upload.EncryptionKey,
+		ETag:           finalETag,
+		ChecksumSHA256: sums.SHA256,
+		ChecksumCRC32C: sums.CRC32C,
+	}
+	// A failure from here on leaves the assembled object without a files
+	// row, so it is removed again unless a version row already points at it
+	if err := applyDefaultRetention(ctx, s.repo, input.BucketID, &file); err != nil {
+		s.discardObject(ctx, bucket.Name, upload.Key, info.VersionID)
+		return nil, err
+	}
+	if _, err := recordObjectVersion(ctx, s.repo, &file); err != nil {
+		s.discardObject(ctx, bucket.Name, upload.Key, info.VersionID)
+		return nil, err
+	}
+	if err := s.repo.SaveFile(ctx, file); err != nil {
+		s.discardUnversioned(ctx, bucket.Name, upload.Key, info)
+		return nil, fmt.Errorf("failed to save file metadata: %w", err)
 	}
+	reservation.commit(ctx, totalSize)
+	s.replication.ObjectCreated(ctx, input.BucketID, upload.Key)
+	s.events.ObjectCreated(&file)
+	s.events.MultipartCompleted(upload.UploadID, len(parts), &file)
 
 	// Update upload status
 	upload.Status = "completed"
+	upload.Parts = parts
 	upload.UpdatedAt = time.Now()
-	s.repo.UpdateMultipartUpload(ctx, upload)
+	if err := s.repo.UpdateMultipartUpload(ctx, upload); err != nil {
+		return nil, fmt.Errorf("failed to update upload: %w", err)
+	}
 
 	return &dto.CompleteMultipartUploadOutput{
 		Key:      upload.Key,
 		Location: fmt.Sprintf("/%s/%s", bucket.Name, upload.Key),
 		ETag:     finalETag,
 		Size:     totalSize,
+
+		Encryption: upload.Encryption,
 	}, nil
 }
 
-func (s *MultipartService) AbortMultipartUpload(ctx context.Context, bucketID, uploadID string) error {
-	upload, err := s.repo.GetMultipartUploadByUploadID(ctx, uploadID)
+// verifyChecksums reads the completed object back when whole-object
+// checksums were declared at initiation and returns them once it matches
+func (s *MultipartService) verifyChecksums(ctx context.Context, bucket *domain.Bucket, upload *domain.MultipartUpload) (domain.Checksums, error) {
+	expected := domain.Checksums{SHA256: upload.ChecksumSHA256, CRC32C: upload.ChecksumCRC32C}
+	if expected.SHA256 == "" && expected.CRC32C == "" {
+		return expected, nil
+	}
+
+	enc, err := s.encryption.ForUpload(upload)
+	if err != nil {
+		return expected, err
+	}
+	body, _, err := s.storage.GetObjectStream(ctx, bucket.Name, upload.Key, enc)
 	if err != nil {
-		return fmt.Errorf("upload not found: %w", err)
+		return expected, fmt.Errorf("failed to verify upload: %w", err)
 	}
+	defer body.Close()
 
-	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	hasher := domain.NewChecksumHasher(expected.CRC32C != "")
+	if _, err := io.Copy(hasher, body); err != nil {
+		return expected, fmt.Errorf("failed to verify upload: %w", err)
+	}
+	if algorithm := hasher.Sum().Mismatch(expected); algorithm != "" {
+		return expected, fmt.Errorf("%w: %s", ErrChecksumMismatch, algorithm)
+	}
+	return expected, nil
+}
+
+// discardObject removes a completed object that failed verification; on a
+// versioned bucket only the version that was just written
+func (s *MultipartService) discardObject(ctx context.Context, bucketName, key, versionID string) {
+	var err error
+	if versionID != "" {
+		_, err = s.storage.DeleteObjectVersion(ctx, bucketName, key, versionID)
+	} else {
+		err = s.storage.DeleteObject(ctx, bucketName, key)
+	}
 	if err != nil {
-		return fmt.Errorf("bucket not found: %w", err)
+		log.Printf("multipart: failed to discard rejected object %s/%s: %v", bucketName, key, err)
 	}
+}
 
-	// Delete all uploaded parts
-	for _, part := range upload.Parts {
-		partKey := fmt.Sprintf("%s.part.%s.%d", upload.Key, upload.UploadID, part.PartNumber)
-		s.storage.DeleteObject(ctx, bucket.Name, partKey)
+// discardUnversioned removes an object whose files row could not be saved
+// when the bucket does not keep versions of it
+func (s *MultipartService) discardUnversioned(ctx context.Context, bucketName, key string, info *domain.ObjectInfo) {
+	if info.VersionID != "" && info.VersionID != "null" {
+		return
+	}
+	s.discardObject(ctx, bucketName, key, "")
+}
+
+func (s *MultipartService) AbortMultipartUpload(ctx context.Context, bucketID, uploadID string) error {
+	upload, bucket, err := s.activeUpload(ctx, bucketID, uploadID)
+	if err != nil {
+		return err
+	}
+
+	// Discards every uploaded part on the backend
+	if err := s.storage.AbortMultipartUpload(ctx, bucket.Name, upload.Key, upload.StorageUploadID); err != nil {
+		return fmt.Errorf("failed to abort upload: %w", err)
 	}
 
 	// Update status
@@ -203,12 +333,48 @@ func (s *MultipartService) AbortMultipartUpload(ctx context.Context, bucketID, u
 	return nil
 }
 
+// activeUpload loads an in-progress upload together with the bucket it
+// belongs to.
+func (s *MultipartService) activeUpload(ctx context.Context, bucketID, uploadID string) (*domain.MultipartUpload, *domain.Bucket, error) {
+	upload, err := s.repo.GetMultipartUploadByUploadID(ctx, uploadID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrUploadNotFound, err)
+	}
+
+	if upload.BucketID != bucketID {
+		return nil, nil, fmt.Errorf("%w: upload not in specified bucket", ErrUploadNotFound)
+	}
+
+	if upload.Status != "initiated" {
+		return nil, nil, fmt.Errorf("%w: upload is %s", ErrUploadNotFound, upload.Status)
+	}
+
+	if upload.StorageUploadID == "" {
+		return nil, nil, fmt.Errorf("upload was started before native multipart support; start a new one")
+	}
+
+	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("bucket not found: %w", err)
+	}
+
+	return upload, &bucket, nil
+}
+
 func (s *MultipartService) ListParts(ctx context.Context, bucketID, uploadID string) (*dto.ListPartsOutput, error) {
 	upload, err := s.repo.GetMultipartUploadByUploadID(ctx, uploadID)
 	if err != nil {
-		return nil, fmt.Errorf("upload not found: %w", err)
+		return nil, fmt.Errorf("%w: %v", ErrUploadNotFound, err)
+	}
+	// The caller's access was checked against bucketID only
+	if upload.BucketID != bucketID {
+		return nil, fmt.Errorf("%w: upload not in specified bucket", ErrUploadNotFound)
 	}
 
+	sort.Slice(upload.Parts, func(i, j int) bool {
+		return upload.Parts[i].PartNumber < upload.Parts[j].PartNumber
+	})
+
 	parts := make([]dto.PartInfo, len(upload.Parts))
 	for i, part := range upload.Parts {
 		parts[i] = dto.PartInfo{
@@ -221,6 +387,7 @@ func (s *MultipartService) ListParts(ctx context.Context, bucketID, uploadID str
 
 	return &dto.ListPartsOutput{
 		UploadID: upload.UploadID,
+		Key:      upload.Key,
 		Parts:    parts,
 		Total:    len(parts),
 	}, nil
@@ -246,4 +413,4 @@ func (s *MultipartService) ListMultipartUploads(ctx context.Context, bucketID st
 		Uploads: uploadInfos,
 		Total:   len(uploadInfos),
 	}, nil
-}
\ No newline at end of file
+}
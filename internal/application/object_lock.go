@@ -0,0 +1,230 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+)
+
+var (
+	ErrObjectLocked         = errors.New("object is protected by object lock")
+	ErrInvalidObjectLock    = errors.New("invalid object lock request")
+	ErrObjectLockNotEnabled = errors.New("object lock is not enabled on this bucket")
+)
+
+// ObjectLockService manages a bucket's object lock (WORM) configuration and
+// the retention and legal hold of its objects. Locks protect the current
+// object of a key: the services that delete, move or overwrite objects
+// refuse to touch a locked one.
+type ObjectLockService struct {
+	repo domain.RepositoryPort
+}
+
+func NewObjectLockService(repo domain.RepositoryPort) *ObjectLockService {
+	return &ObjectLockService{repo: repo}
+}
+
+// SetBucketObjectLock enables object lock on a bucket and sets its default
+// retention. Once enabled, object lock cannot be turned off again.
+func (s *ObjectLockService) SetBucketObjectLock(ctx context.Context, bucketID string, input dto.ObjectLockConfigurationInput) (*dto.ObjectLockConfigurationOutput, error) {
+	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+
+	config := domain.ObjectLockConfiguration{
+		Enabled:              *input.Enabled,
+		DefaultMode:          input.DefaultMode,
+		DefaultRetentionDays: input.DefaultRetentionDays,
+	}
+	if !config.Enabled {
+		current, err := s.repo.GetBucketObjectLock(ctx, bucket.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get object lock configuration: %w", err)
+		}
+		if current.Enabled {
+			return nil, fmt.Errorf("%w: object lock cannot be disabled once enabled", ErrInvalidObjectLock)
+		}
+		if config.DefaultMode != "" || config.DefaultRetentionDays != 0 {
+			return nil, fmt.Errorf("%w: a default retention requires object lock to be enabled", ErrInvalidObjectLock)
+		}
+	}
+	if (config.DefaultMode == "") != (config.DefaultRetentionDays == 0) {
+		return nil, fmt.Errorf("%w: default mode and days must be set together", ErrInvalidObjectLock)
+	}
+	if config.DefaultMode != "" && !domain.IsRetentionMode(config.DefaultMode) {
+		return nil, fmt.Errorf("%w: unknown retention mode %q", ErrInvalidObjectLock, config.DefaultMode)
+	}
+	if config.DefaultRetentionDays < 0 {
+		return nil, fmt.Errorf("%w: default retention days must be positive", ErrInvalidObjectLock)
+	}
+
+	if err := s.repo.SetBucketObjectLock(ctx, bucket.ID, config); err != nil {
+		return nil, fmt.Errorf("failed to save object lock configuration: %w", err)
+	}
+	return objectLockOutput(bucket.ID, &config), nil
+}
+
+func (s *ObjectLockService) GetBucketObjectLock(ctx context.Context, bucketID string) (*dto.ObjectLockConfigurationOutput, error) {
+	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+	config, err := s.repo.GetBucketObjectLock(ctx, bucket.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object lock configuration: %w", err)
+	}
+	return objectLockOutput(bucket.ID, config), nil
+}
+
+// PutObjectRetention sets the retention of a file. A compliance retention
+// can only be extended; shortening or removing a governance retention
+// requires bypassGovernance.
+func (s *ObjectLockService) PutObjectRetention(ctx context.Context, bucketID, fileID string, input dto.ObjectRetentionInput, bypassGovernance bool) (*dto.ObjectRetentionOutput, error) {
+	file, err := s.lockableFile(ctx, bucketID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if input.Mode != "" && !domain.IsRetentionMode(input.Mode) {
+		return nil, fmt.Errorf("%w: unknown retention mode %q", ErrInvalidObjectLock, input.Mode)
+	}
+	if (input.Mode == "") != (input.RetainUntil == nil) {
+		return nil, fmt.Errorf("%w: mode and retain_until must be set together", ErrInvalidObjectLock)
+	}
+	if input.RetainUntil != nil && !input.RetainUntil.After(now) {
+		return nil, fmt.Errorf("%w: retain_until must be in the future", ErrInvalidObjectLock)
+	}
+
+	if file.UnderRetention(now) {
+		extends := input.RetainUntil != nil && input.Mode == file.RetentionMode && !input.RetainUntil.Before(*file.RetainUntil)
+		switch {
+		case extends:
+		case file.RetentionMode == domain.RetentionCompliance:
+			return nil, fmt.Errorf("%w: a compliance retention can only be extended", ErrObjectLocked)
+		case !bypassGovernance:
+			return nil, fmt.Errorf("%w: changing a governance retention requires bypassing governance", ErrObjectLocked)
+		}
+	}
+
+	if err := s.repo.UpdateFileRetention(ctx, file.ID, input.Mode, input.RetainUntil); err != nil {
+		return nil, fmt.Errorf("failed to save retention: %w", err)
+	}
+	file.RetentionMode, file.RetainUntil = input.Mode, input.RetainUntil
+	return objectRetentionOutput(file), nil
+}
+
+func (s *ObjectLockService) GetObjectRetention(ctx context.Context, bucketID, fileID string) (*dto.ObjectRetentionOutput, error) {
+	file, err := s.bucketFile(ctx, bucketID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	return objectRetentionOutput(file), nil
+}
+
+// PutObjectLegalHold places or lifts a legal hold. A legal hold has no
+// expiry and blocks deletion regardless of retention.
+func (s *ObjectLockService) PutObjectLegalHold(ctx context.Context, bucketID, fileID string, on bool) (*dto.ObjectLegalHoldOutput, error) {
+	file, err := s.lockableFile(ctx, bucketID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateFileLegalHold(ctx, file.ID, on); err != nil {
+		return nil, fmt.Errorf("failed to save legal hold: %w", err)
+	}
+	return &dto.ObjectLegalHoldOutput{FileID: file.ID, Key: file.Key, LegalHold: on}, nil
+}
+
+func (s *ObjectLockService) GetObjectLegalHold(ctx context.Context, bucketID, fileID string) (*dto.ObjectLegalHoldOutput, error) {
+	file, err := s.bucketFile(ctx, bucketID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ObjectLegalHoldOutput{FileID: file.ID, Key: file.Key, LegalHold: file.LegalHold}, nil
+}
+
+// lockableFile resolves a file in a bucket that has object lock enabled
+func (s *ObjectLockService) lockableFile(ctx context.Context, bucketID, fileID string) (*domain.File, error) {
+	file, err := s.bucketFile(ctx, bucketID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	config, err := s.repo.GetBucketObjectLock(ctx, file.BucketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object lock configuration: %w", err)
+	}
+	if !config.Enabled {
+		return nil, ErrObjectLockNotEnabled
+	}
+	return file, nil
+}
+
+func (s *ObjectLockService) bucketFile(ctx context.Context, bucketID, fileID string) (*domain.File, error) {
+	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+	file, err := s.repo.GetFileByID(ctx, fileID)
+	if err != nil || file.BucketID != bucket.ID {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, fileID)
+	}
+	return file, nil
+}
+
+func objectLockOutput(bucketID string, config *domain.ObjectLockConfiguration) *dto.ObjectLockConfigurationOutput {
+	return &dto.ObjectLockConfigurationOutput{
+		BucketID:             bucketID,
+		Enabled:              config.Enabled,
+		DefaultMode:          config.DefaultMode,
+		DefaultRetentionDays: config.DefaultRetentionDays,
+	}
+}
+
+func objectRetentionOutput(file *domain.File) *dto.ObjectRetentionOutput {
+	return &dto.ObjectRetentionOutput{
+		FileID:      file.ID,
+		Key:         file.Key,
+		Mode:        file.RetentionMode,
+		RetainUntil: file.RetainUntil,
+		Active:      file.UnderRetention(time.Now()),
+	}
+}
+
+// checkRemovable returns ErrObjectLocked when file may not be deleted or
+// moved. Only a governance retention yields to bypassGovernance.
+func checkRemovable(file *domain.File, bypassGovernance bool) error {
+	if file.CanRemove(time.Now(), bypassGovernance) {
+		return nil
+	}
+	if file.LegalHold {
+		return fmt.Errorf("%w: %s is under a legal hold", ErrObjectLocked, file.Key)
+	}
+	return fmt.Errorf("%w: %s is retained until %s", ErrObjectLocked, file.Key, file.RetainUntil.Format(time.RFC3339))
+}
+
+// checkOverwritable returns ErrObjectLocked when key in bucketID holds a
+// locked object that a write would replace
+func checkOverwritable(ctx context.Context, repo domain.RepositoryPort, bucketID, key string) error {
+	existing, err := repo.GetFileByKey(ctx, bucketID, key)
+	if err != nil {
+		return nil
+	}
+	return checkRemovable(existing, false)
+}
+
+// applyDefaultRetention gives a new file the bucket's default retention
+func applyDefaultRetention(ctx context.Context, repo domain.RepositoryPort, bucketID string, file *domain.File) error {
+	config, err := repo.GetBucketObjectLock(ctx, bucketID)
+	if err != nil {
+		return fmt.Errorf("failed to get object lock configuration: %w", err)
+	}
+	if until := config.DefaultRetainUntil(file.CreatedAt); until != nil {
+		file.RetentionMode, file.RetainUntil = config.DefaultMode, until
+	}
+	return nil
+}
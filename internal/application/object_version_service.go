@@ -0,0 +1,338 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrObjectVersionNotFound = errors.New("object version not found")
+	ErrVersionIsDeleteMarker = errors.New("version is a delete marker")
+)
+
+// ObjectVersionService exposes the version history of objects in buckets
+// with versioning enabled. Versions are recorded by the upload, copy,
+// multipart and delete paths as they write to storage.
+type ObjectVersionService struct {
+	storage     domain.StoragePort
+	repo        domain.RepositoryPort
+	encryption  *EncryptionService
+	replication *ReplicationService
+	events      *EventBus
+}
+
+func NewObjectVersionService(storage domain.StoragePort, repo domain.RepositoryPort, encryption *EncryptionService, replication *ReplicationService, events *EventBus) *ObjectVersionService {
+	return &ObjectVersionService{storage: storage, repo: repo, encryption: encryption, replication: replication, events: events}
+}
+
+// ListObjectVersions lists the versions of key, newest first; an empty key
+// lists every version in the bucket.
+func (s *ObjectVersionService) ListObjectVersions(ctx context.Context, bucketID, key string) (*dto.ListObjectVersionsOutput, error) {
+	if _, err := s.repo.GetBucketByID(ctx, bucketID); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+
+	versions, err := s.repo.ListObjectVersions(ctx, bucketID, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list object versions: %w", err)
+	}
+
+	output := &dto.ListObjectVersionsOutput{
+		BucketID: bucketID,
+		Key:      key,
+		Versions: make([]dto.ObjectVersionInfo, 0, len(versions)),
+	}
+	for i := range versions {
+		output.Versions = append(output.Versions, ObjectVersionInfo(&versions[i]))
+	}
+	output.Count = len(output.Versions)
+
+	return output, nil
+}
+
+func (s *ObjectVersionService) GetObjectVersion(ctx context.Context, bucketID, versionID string) (*domain.ObjectVersion, error) {
+	version, err := s.repo.GetObjectVersion(ctx, bucketID, versionID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectVersionNotFound, versionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object version: %w", err)
+	}
+	return version, nil
+}
+
+// DownloadObjectVersion streams a specific version; the caller must close
+// the reader. Delete markers have no content. sse carries the customer key
+// of an SSE-C version.
+func (s *ObjectVersionService) DownloadObjectVersion(ctx context.Context, bucketID, versionID string, sse dto.SSEInput) (io.ReadCloser, *domain.ObjectVersion, error) {
+	bucket, version, err := s.resolveVersion(ctx, bucketID, versionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version.IsDeleteMarker {
+		return nil, nil, fmt.Errorf("%w: %s", ErrVersionIsDeleteMarker, versionID)
+	}
+
+	enc, err := s.versionEncryption(version, sse)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, _, err := s.storage.GetObjectVersionStream(ctx, bucket.Name, version.Key, versionID, enc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve object version: %w", err)
+	}
+
+	return body, version, nil
+}
+
+// DeleteObjectVersion permanently removes one version (or delete marker).
+// When it was the latest, the next newest version becomes current again.
+func (s *ObjectVersionService) DeleteObjectVersion(ctx context.Context, bucketID, versionID string) (*dto.DeleteObjectVersionOutput, error) {
+	bucket, version, err := s.resolveVersion(ctx, bucketID, versionID)
+	if err != nil {
+		return nil, err
+	}
+	// Deleting the latest version replaces the current object
+	if version.IsLatest {
+		if err := checkOverwritable(ctx, s.repo, bucket.ID, version.Key); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.storage.DeleteObjectVersion(ctx, bucket.Name, version.Key, versionID); err != nil {
+		return nil, fmt.Errorf("failed to delete object version from storage: %w", err)
+	}
+
+	latest, err := s.repo.DeleteObjectVersion(ctx, bucket.ID, versionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete object version: %w", err)
+	}
+
+	output := &dto.DeleteObjectVersionOutput{Deleted: ObjectVersionInfo(version)}
+	output.Deleted.IsLatest = false
+	if version.IsLatest {
+		if err := syncLatestFile(ctx, s.repo, bucket.ID, version.Key, latest); err != nil {
+			return nil, err
+		}
+		// The key now reads as its next newest version, or as deleted
+		if latest == nil || latest.IsDeleteMarker {
+			s.replication.ObjectDeleted(ctx, bucket.ID, version.Key)
+			s.events.ObjectDeleted(bucket.ID, version.Key)
+		} else {
+			file := fileFromVersion(latest)
+			s.replication.ObjectCreated(ctx, bucket.ID, version.Key)
+			s.events.ObjectCreated(&file)
+		}
+	}
+	if latest != nil {
+		info := ObjectVersionInfo(latest)
+		output.Latest = &info
+	}
+
+	return output, nil
+}
+
+// RestoreObjectVersion makes an older version current by copying it over
+// the key, which adds it as a new latest version; history is kept intact.
+// The copy is encrypted like the version, so sse must carry the customer key
+// of an SSE-C version.
+func (s *ObjectVersionService) RestoreObjectVersion(ctx context.Context, bucketID, versionID string, sse dto.SSEInput) (*domain.ObjectVersion, error) {
+	bucket, version, err := s.resolveVersion(ctx, bucketID, versionID)
+	if err != nil {
+		return nil, err
+	}
+	if version.IsDeleteMarker {
+		return nil, fmt.Errorf("%w: %s", ErrVersionIsDeleteMarker, versionID)
+	}
+	if err := checkOverwritable(ctx, s.repo, bucket.ID, version.Key); err != nil {
+		return nil, err
+	}
+
+	enc, err := s.versionEncryption(version, sse)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.storage.RestoreObjectVersion(ctx, bucket.Name, version.Key, versionID, enc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore object version: %w", err)
+	}
+
+	// The restored copy is a new, hot object: it keeps the content and its
+	// checksums but not the lock of the version it was copied from
+	file := fileFromVersion(version)
+	file.Version = info.VersionID
+	file.StorageClass = domain.StorageClassStandard
+	file.RetentionMode, file.RetainUntil, file.LegalHold = "", nil, false
+	file.CreatedAt = time.Now()
+	if err := applyDefaultRetention(ctx, s.repo, bucket.ID, &file); err != nil {
+		return nil, err
+	}
+
+	restored, err := recordObjectVersion(ctx, s.repo, &file)
+	if err != nil {
+		return nil, err
+	}
+	if restored == nil {
+		return nil, fmt.Errorf("storage did not return a version id for the restored object")
+	}
+	if err := s.repo.SaveFile(ctx, file); err != nil {
+		return nil, fmt.Errorf("failed to save file metadata: %w", err)
+	}
+	s.replication.ObjectCreated(ctx, bucket.ID, file.Key)
+	s.events.ObjectCreated(&file)
+
+	return restored, nil
+}
+
+// versionEncryption returns the key a version is read with, or nil
+func (s *ObjectVersionService) versionEncryption(version *domain.ObjectVersion, sse dto.SSEInput) (*domain.ObjectEncryption, error) {
+	file := fileFromVersion(version)
+	return s.encryption.ForRead(&file, sse)
+}
+
+func (s *ObjectVersionService) resolveVersion(ctx context.Context, bucketID, versionID string) (*domain.Bucket, *domain.ObjectVersion, error) {
+	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+
+	version, err := s.GetObjectVersion(ctx, bucket.ID, versionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &bucket, version, nil
+}
+
+// ObjectVersionInfo converts a version for API output
+func ObjectVersionInfo(version *domain.ObjectVersion) dto.ObjectVersionInfo {
+	return dto.ObjectVersionInfo{
+		VersionID:      version.VersionID,
+		Key:            version.Key,
+		IsLatest:       version.IsLatest,
+		IsDeleteMarker: version.IsDeleteMarker,
+		Size:           version.Size,
+		ETag:           version.ETag,
+		ContentType:    version.ContentType,
+		Metadata:       version.Metadata,
+		CreatedAt:      version.CreatedAt,
+	}
+}
+
+// versioningEnabled reports whether writes to the bucket create versions
+func versioningEnabled(ctx context.Context, repo domain.RepositoryPort, bucketID string) bool {
+	status, err := repo.GetBucketVersioning(ctx, bucketID)
+	return err == nil && status == domain.VersioningEnabled
+}
+
+// recordObjectVersion records the object just written to storage, as
+// described by the files row about to be saved for it, as the latest version
+// of its key. It does nothing (and returns nil) unless the bucket has
+// versioning enabled and storage assigned a version id.
+func recordObjectVersion(ctx context.Context, repo domain.RepositoryPort, file *domain.File) (*domain.ObjectVersion, error) {
+	if file.Version == "" || file.Version == "null" || !versioningEnabled(ctx, repo, file.BucketID) {
+		return nil, nil
+	}
+
+	version := &domain.ObjectVersion{
+		ID:             uuid.New().String(),
+		BucketID:       file.BucketID,
+		Key:            file.Key,
+		VersionID:      file.Version,
+		Size:           file.Size,
+		ETag:           file.ETag,
+		ContentType:    file.MediaType(),
+		Metadata:       file.Metadata,
+		ChecksumSHA256: file.ChecksumSHA256,
+		ChecksumCRC32C: file.ChecksumCRC32C,
+		StorageClass:   file.StorageClass,
+		RetentionMode:  file.RetentionMode,
+		RetainUntil:    file.RetainUntil,
+		LegalHold:      file.LegalHold,
+		CreatedAt:      time.Now(),
+
+		Encryption:        file.Encryption,
+		EncryptionKey:     file.EncryptionKey,
+		SSECustomerKeyMD5: file.SSECustomerKeyMD5,
+	}
+	if err := repo.SaveObjectVersion(ctx, version); err != nil {
+		return nil, fmt.Errorf("failed to record object version: %w", err)
+	}
+	return version, nil
+}
+
+// recordDeleteMarker records the delete marker storage created for key
+func recordDeleteMarker(ctx context.Context, repo domain.RepositoryPort, bucketID, key string, deletion *domain.ObjectDeletion) error {
+	if deletion == nil || !deletion.DeleteMarker || deletion.VersionID == "" {
+		return nil
+	}
+
+	marker := &domain.ObjectVersion{
+		ID:             uuid.New().String(),
+		BucketID:       bucketID,
+		Key:            key,
+		VersionID:      deletion.VersionID,
+		IsDeleteMarker: true,
+		CreatedAt:      time.Now(),
+	}
+	if err := repo.SaveObjectVersion(ctx, marker); err != nil {
+		return fmt.Errorf("failed to record delete marker: %w", err)
+	}
+	return nil
+}
+
+// syncLatestFile points the files row of key at its latest version, and
+// removes the row when the key now reads as deleted.
+func syncLatestFile(ctx context.Context, repo domain.RepositoryPort, bucketID, key string, latest *domain.ObjectVersion) error {
+	if latest == nil || latest.IsDeleteMarker {
+		file, err := repo.GetFileByKey(ctx, bucketID, key)
+		if err != nil {
+			return nil
+		}
+		if err := repo.DeleteFile(ctx, file.ID); err != nil {
+			return fmt.Errorf("failed to delete file metadata: %w", err)
+		}
+		return nil
+	}
+
+	if err := repo.SaveFile(ctx, fileFromVersion(latest)); err != nil {
+		return fmt.Errorf("failed to save file metadata: %w", err)
+	}
+	return nil
+}
+
+// fileFromVersion is the files row of a key whose current version is version
+func fileFromVersion(version *domain.ObjectVersion) domain.File {
+	return domain.File{
+		ID:             generateID(),
+		BucketID:       version.BucketID,
+		Key:            version.Key,
+		Size:           version.Size,
+		MimeType:       version.ContentType,
+		ContentType:    version.ContentType,
+		Metadata:       version.Metadata,
+		Version:        version.VersionID,
+		StorageClass:   version.StorageClass,
+		ETag:           version.ETag,
+		ChecksumSHA256: version.ChecksumSHA256,
+		ChecksumCRC32C: version.ChecksumCRC32C,
+		RetentionMode:  version.RetentionMode,
+		RetainUntil:    version.RetainUntil,
+		LegalHold:      version.LegalHold,
+		CreatedAt:      version.CreatedAt,
+
+		Encryption:        version.Encryption,
+		EncryptionKey:     version.EncryptionKey,
+		SSECustomerKeyMD5: version.SSECustomerKeyMD5,
+	}
+}
@@ -1 +1,89 @@
-package application
\ No newline at end of file
+package application
+
+import (
+	"context"
+	"errors"
+	"os"
+	"s3/internal/domain"
+	"strings"
+)
+
+// PolicyService resolves request targets for the policy enforcer
+type PolicyService struct {
+	repo domain.RepositoryPort
+}
+
+func NewPolicyService(repo domain.RepositoryPort) *PolicyService {
+	return &PolicyService{repo: repo}
+}
+
+// ResolveBucket looks a bucket up by id, then by name. It returns nil, nil
+// when neither exists.
+func (s *PolicyService) ResolveBucket(ctx context.Context, bucketRef string) (*domain.Bucket, error) {
+	bucket, err := s.repo.GetBucketByID(ctx, bucketRef)
+	if err == nil {
+		return &bucket, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	bucket, err = s.repo.GetBucketByName(ctx, bucketRef)
+	if err == nil {
+		return &bucket, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// ResolveFileKey returns the key of fileID, which must live in bucketRef.
+// It returns "", nil when the bucket holds no such file.
+func (s *PolicyService) ResolveFileKey(ctx context.Context, bucketRef, fileID string) (string, error) {
+	file, err := s.repo.GetFileByID(ctx, fileID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	bucket, err := s.ResolveBucket(ctx, bucketRef)
+	if err != nil {
+		return "", err
+	}
+	if bucket == nil || file.BucketID != bucket.ID {
+		return "", nil
+	}
+
+	return file.Key, nil
+}
+
+// ActorRoles returns the roles bound to actor, read like IsAdmin from the
+// environment: USER_ROLES=user:abc=auditor|ops;user:def=ops. Admins always
+// hold the "admin" role.
+func (s *PolicyService) ActorRoles(ctx context.Context, actor string) []string {
+	var roles []string
+	if role, ok := strings.CutPrefix(actor, "role:"); ok {
+		roles = append(roles, role)
+	}
+	if IsAdmin(actor) {
+		roles = append(roles, "admin")
+	}
+
+	for _, binding := range strings.Split(os.Getenv("USER_ROLES"), ";") {
+		principal, list, ok := strings.Cut(strings.TrimSpace(binding), "=")
+		if !ok || principal != actor {
+			continue
+		}
+		for _, role := range strings.Split(list, "|") {
+			if role = strings.TrimSpace(role); role != "" {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	return roles
+}
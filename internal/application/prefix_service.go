@@ -2,26 +2,40 @@ package application
 
 import (
 	"archive/zip"
-	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
+	"log"
 	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// maxListKeys is the S3 page size limit for object listings
+const maxListKeys = 1000
+
 type PrefixService struct {
-	repo    domain.RepositoryPort
-	storage domain.StoragePort
+	repo        domain.RepositoryPort
+	storage     domain.StoragePort
+	encryption  *EncryptionService
+	quotas      *QuotaService
+	replication *ReplicationService
+	events      *EventBus
 }
 
-func NewPrefixService(repo domain.RepositoryPort, storage domain.StoragePort) *PrefixService {
+func NewPrefixService(repo domain.RepositoryPort, storage domain.StoragePort, encryption *EncryptionService, quotas *QuotaService, replication *ReplicationService, events *EventBus) *PrefixService {
 	return &PrefixService{
-		repo:    repo,
-		storage: storage,
+		repo:        repo,
+		storage:     storage,
+		encryption:  encryption,
+		quotas:      quotas,
+		replication: replication,
+		events:      events,
 	}
 }
 
@@ -49,6 +63,90 @@ func (s *PrefixService) ListByPrefix(ctx context.Context, input dto.ListByPrefix
 	}, nil
 }
 
+// ListObjectsV2 lists a bucket the way S3 ListObjectsV2 does: keys after
+// StartAfter (or the continuation token), rolled up into common prefixes at
+// the first Delimiter past Prefix, at most MaxKeys entries per page.
+func (s *PrefixService) ListObjectsV2(ctx context.Context, input dto.ListObjectsInput) (*dto.ListObjectsOutput, error) {
+	bucket, err := s.repo.GetBucketByName(ctx, input.BucketName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, input.BucketName)
+	}
+
+	maxKeys := input.MaxKeys
+	if maxKeys <= 0 || maxKeys > maxListKeys {
+		maxKeys = maxListKeys
+	}
+
+	marker := input.StartAfter
+	if input.ContinuationToken != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(input.ContinuationToken)
+		if err != nil {
+			return nil, fmt.Errorf("invalid continuation token")
+		}
+		marker = string(decoded)
+	}
+
+	files, err := s.repo.ListFilesByPrefix(ctx, bucket.ID, input.Prefix, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	// Database collation may not be byte order, which S3 listings use
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Key < files[j].Key
+	})
+
+	output := &dto.ListObjectsOutput{}
+	last := ""
+	count := 0
+
+	for _, file := range files {
+		if file.Key <= marker {
+			continue
+		}
+		// A marker ending in the delimiter is a common prefix already returned
+		if input.Delimiter != "" && strings.HasSuffix(marker, input.Delimiter) && strings.HasPrefix(file.Key, marker) {
+			continue
+		}
+
+		entry := file.Key
+		isPrefix := false
+		if input.Delimiter != "" {
+			rest := strings.TrimPrefix(file.Key, input.Prefix)
+			if i := strings.Index(rest, input.Delimiter); i >= 0 {
+				entry = input.Prefix + rest[:i+len(input.Delimiter)]
+				isPrefix = true
+			}
+		}
+		if isPrefix && entry == last {
+			continue
+		}
+
+		if count == maxKeys {
+			output.IsTruncated = true
+			output.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(last))
+			break
+		}
+
+		if isPrefix {
+			output.CommonPrefixes = append(output.CommonPrefixes, entry)
+		} else {
+			output.Objects = append(output.Objects, dto.FileInfo{
+				Key:         file.Key,
+				Size:        file.Size,
+				ContentType: file.ContentType,
+				Metadata:    file.Metadata,
+				CreatedAt:   file.CreatedAt,
+				StorageClass: file.StorageClass,
+			})
+		}
+		last = entry
+		count++
+	}
+
+	return output, nil
+}
+
 // DeleteByPrefix deletes files by prefix
 func (s *PrefixService) DeleteByPrefix(ctx context.Context, input dto.DeleteByPrefixInput) (*dto.DeleteByPrefixOutput, error) {
 	bucket, err := s.repo.GetBucketByID(ctx, input.BucketID)
@@ -62,8 +160,15 @@ func (s *PrefixService) DeleteByPrefix(ctx context.Context, input dto.DeleteByPr
 	}
 
 	deletedKeys := []string{}
+	lockedKeys := []string{}
+	now := time.Now()
 
 	for _, file := range files {
+		if !file.CanRemove(now, false) {
+			lockedKeys = append(lockedKeys, file.Key)
+			continue
+		}
+
 		if err := s.storage.DeleteObject(ctx, bucket.Name, file.Key); err != nil {
 			continue
 		}
@@ -72,12 +177,15 @@ func (s *PrefixService) DeleteByPrefix(ctx context.Context, input dto.DeleteByPr
 			continue
 		}
 
+		s.replication.ObjectDeleted(ctx, bucket.ID, file.Key)
+		s.events.ObjectDeleted(bucket.ID, file.Key)
 		deletedKeys = append(deletedKeys, file.Key)
 	}
 
 	return &dto.DeleteByPrefixOutput{
 		DeletedCount: len(deletedKeys),
 		DeletedKeys:  deletedKeys,
+		LockedKeys:   lockedKeys,
 	}, nil
 }
 
@@ -104,38 +212,83 @@ func (s *PrefixService) CopyByPrefix(ctx context.Context, input dto.CopyByPrefix
 	}
 
 	copiedKeys := []string{}
+	var failedKeys []dto.PrefixKeyError
+	fail := func(key string, err error) {
+		failedKeys = append(failedKeys, dto.PrefixKeyError{Key: key, Error: err.Error()})
+	}
 
-	for _, file := range files {
+	for i := range files {
+		file := &files[i]
 		newKey := strings.Replace(file.Key, input.SourcePrefix, input.DestPrefix, 1)
 
-		if err := s.storage.CopyObject(ctx, srcBucket.Name, file.Key, destBucket.Name, newKey); err != nil {
-			continue
-		}
-
-		newFile := domain.File{
-			ID:          uuid.New().String(),
-			BucketID:    destBucketID,
-			Key:         newKey,
-			Size:        file.Size,
-			ContentType: file.ContentType,
-			Metadata:    file.Metadata,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
-
-		if err := s.repo.SaveFile(ctx, newFile); err != nil {
+		newFile, err := s.copyObject(ctx, &srcBucket, &destBucket, file, newKey, input.SourceEncryption)
+		if err != nil {
+			fail(file.Key, err)
 			continue
 		}
 
+		s.replication.ObjectCreated(ctx, destBucket.ID, newKey)
+		s.events.ObjectCopied(srcBucket.ID, file.Key, newFile)
 		copiedKeys = append(copiedKeys, newKey)
 	}
 
 	return &dto.CopyByPrefixOutput{
 		CopiedCount: len(copiedKeys),
 		CopiedKeys:  copiedKeys,
+		FailedKeys:  failedKeys,
 	}, nil
 }
 
+// copyObject copies one object of a prefix to newKey in destBucket, holding
+// room in the destination's quotas until its files row is saved
+func (s *PrefixService) copyObject(ctx context.Context, srcBucket, destBucket *domain.Bucket, file *domain.File, newKey string, sourceSSE dto.SSEInput) (*domain.File, error) {
+	if file.IsArchived(time.Now()) {
+		return nil, ErrObjectArchived
+	}
+	if err := checkOverwritable(ctx, s.repo, destBucket.ID, newKey); err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.quotas.reserve(ctx, destBucket, newKey, file.Size)
+	if err != nil {
+		return nil, err
+	}
+	defer reservation.release(ctx)
+
+	srcEnc, dstEnc, err := s.encryption.ForCopy(ctx, destBucket.ID, file, sourceSSE)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.CopyObject(ctx, srcBucket.Name, file.Key, destBucket.Name, newKey, srcEnc, dstEnc); err != nil {
+		return nil, fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	newFile := domain.File{
+		ID:          uuid.New().String(),
+		BucketID:    destBucket.ID,
+		Key:         newKey,
+		Size:        file.Size,
+		MimeType:    file.MediaType(),
+		ContentType: file.MediaType(),
+		Metadata:    file.Metadata,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	setFileEncryption(&newFile, dstEnc)
+	newFile.ETag, newFile.ChecksumSHA256, newFile.ChecksumCRC32C = file.ETag, file.ChecksumSHA256, file.ChecksumCRC32C
+	// A copy gets the destination's default retention, not the source's lock
+	if err := applyDefaultRetention(ctx, s.repo, destBucket.ID, &newFile); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveFile(ctx, newFile); err != nil {
+		return nil, fmt.Errorf("failed to save file metadata: %w", err)
+	}
+	reservation.commit(ctx, newFile.Size)
+	return &newFile, nil
+}
+
 // GetSizeByPrefix gets total size of files by prefix
 func (s *PrefixService) GetSizeByPrefix(ctx context.Context, input dto.GetSizeByPrefixInput) (*dto.GetSizeByPrefixOutput, error) {
 	files, err := s.repo.ListFilesByPrefix(ctx, input.BucketID, input.Prefix, 0)
@@ -188,82 +341,157 @@ func (s *PrefixService) ArchiveByPrefix(ctx context.Context, input dto.ArchiveBy
 		format = "zip"
 	}
 
-	var archiveData []byte
-	var archiveErr error
-
-	switch format {
-	case "zip":
-		archiveData, archiveErr = s.createZipArchive(ctx, bucket.Name, files)
-	default:
+	if format != "zip" {
 		return nil, fmt.Errorf("unsupported archive format: %s", format)
 	}
 
-	if archiveErr != nil {
-		return nil, fmt.Errorf("failed to create archive: %w", archiveErr)
-	}
-
 	archiveKey := input.ArchiveName
 	if !strings.HasSuffix(archiveKey, "."+format) {
 		archiveKey += "." + format
 	}
+	if err := checkOverwritable(ctx, s.repo, bucket.ID, archiveKey); err != nil {
+		return nil, err
+	}
+
+	// SSE-C objects cannot be read without their key and archived ones
+	// have no hot copy, so they are left out and reported
+	var entries []archiveEntry
+	var failedKeys []dto.PrefixKeyError
+	for i := range files {
+		file := &files[i]
+		if file.IsArchived(time.Now()) {
+			failedKeys = append(failedKeys, dto.PrefixKeyError{Key: file.Key, Error: ErrObjectArchived.Error()})
+			continue
+		}
+		enc, err := s.encryption.ForRead(file, dto.SSEInput{})
+		if err != nil {
+			failedKeys = append(failedKeys, dto.PrefixKeyError{Key: file.Key, Error: err.Error()})
+			continue
+		}
+		entries = append(entries, archiveEntry{key: file.Key, enc: enc})
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no readable files found with prefix: %s", input.Prefix)
+	}
 
-	if err := s.storage.SaveObject(ctx, bucket.Name, archiveKey, archiveData, map[string]string{
+	// The archive's size is only known once it is written, so it is held
+	// to the room its quotas have left while it streams
+	reservation, err := s.quotas.reserve(ctx, &bucket, archiveKey, -1)
+	if err != nil {
+		return nil, err
+	}
+	defer reservation.release(ctx)
+
+	// The archive is a new object and gets the bucket's default encryption
+	archiveEnc, err := s.encryption.ForWrite(ctx, bucket.ID, dto.SSEInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the archive straight into storage instead of in memory
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(s.writeZipArchive(ctx, bucket.Name, entries, pw))
+	}()
+
+	limited, err := reservation.limit(ctx, pr, -1)
+	if err != nil {
+		pr.CloseWithError(err)
+		return nil, err
+	}
+	info, err := s.storage.SaveObjectStream(ctx, bucket.Name, archiveKey, limited, -1, "application/zip", map[string]string{
 		"archive-type": format,
-		"file-count":   fmt.Sprintf("%d", len(files)),
-	}); err != nil {
+		"file-count":   fmt.Sprintf("%d", len(entries)),
+	}, archiveEnc)
+	pr.CloseWithError(err)
+	if err != nil {
+		if limited.err != nil {
+			return nil, limited.err
+		}
 		return nil, fmt.Errorf("failed to save archive: %w", err)
 	}
+	if err := reservation.resize(ctx, info.Size); err != nil {
+		s.discardObject(ctx, bucket.Name, archiveKey, info.VersionID)
+		return nil, err
+	}
 
 	archiveFile := domain.File{
 		ID:          uuid.New().String(),
 		BucketID:    input.BucketID,
 		Key:         archiveKey,
-		Size:        int64(len(archiveData)),
+		Size:        info.Size,
 		ContentType: "application/zip",
 		Metadata: map[string]string{
 			"archive-type": format,
-			"file-count":   fmt.Sprintf("%d", len(files)),
+			"file-count":   fmt.Sprintf("%d", len(entries)),
 		},
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
+	setFileEncryption(&archiveFile, archiveEnc)
+	if err := applyDefaultRetention(ctx, s.repo, bucket.ID, &archiveFile); err != nil {
+		return nil, err
+	}
 
 	if err := s.repo.SaveFile(ctx, archiveFile); err != nil {
 		return nil, fmt.Errorf("failed to save archive metadata: %w", err)
 	}
+	reservation.commit(ctx, archiveFile.Size)
+	s.replication.ObjectCreated(ctx, bucket.ID, archiveKey)
+	s.events.ObjectCreated(&archiveFile)
 
 	return &dto.ArchiveByPrefixOutput{
 		ArchiveKey:  archiveKey,
-		FileCount:   len(files),
-		ArchiveSize: int64(len(archiveData)),
+		FileCount:   len(entries),
+		ArchiveSize: info.Size,
+		Encryption:  archiveFile.Encryption,
+		FailedKeys:  failedKeys,
 	}, nil
 }
 
-func (s *PrefixService) createZipArchive(ctx context.Context, bucketName string, files []domain.File) ([]byte, error) {
-	buf := new(bytes.Buffer)
-	zipWriter := zip.NewWriter(buf)
+// discardObject removes an archive that went over quota; on a versioned
+// bucket only the version that was just written
+func (s *PrefixService) discardObject(ctx context.Context, bucketName, key, versionID string) {
+	var err error
+	if versionID != "" {
+		_, err = s.storage.DeleteObjectVersion(ctx, bucketName, key, versionID)
+	} else {
+		err = s.storage.DeleteObject(ctx, bucketName, key)
+	}
+	if err != nil {
+		log.Printf("prefix: failed to discard rejected object %s/%s: %v", bucketName, key, err)
+	}
+}
+
+// archiveEntry is an object to add to an archive with the key to read it
+type archiveEntry struct {
+	key string
+	enc *domain.ObjectEncryption
+}
 
-	for _, file := range files {
-		data, err := s.storage.GetObject(ctx, bucketName, file.Key)
+func (s *PrefixService) writeZipArchive(ctx context.Context, bucketName string, entries []archiveEntry, w io.Writer) error {
+	zipWriter := zip.NewWriter(w)
+
+	for _, entry := range entries {
+		body, _, err := s.storage.GetObjectStream(ctx, bucketName, entry.key, entry.enc)
 		if err != nil {
-			continue
+			return fmt.Errorf("failed to read %s: %w", entry.key, err)
 		}
 
-		writer, err := zipWriter.Create(file.Key)
+		writer, err := zipWriter.Create(entry.key)
 		if err != nil {
-			continue
+			body.Close()
+			return err
 		}
 
-		if _, err := writer.Write(data); err != nil {
-			continue
+		_, err = io.Copy(writer, body)
+		body.Close()
+		if err != nil {
+			return err
 		}
 	}
 
-	if err := zipWriter.Close(); err != nil {
-		return nil, err
-	}
-
-	return buf.Bytes(), nil
+	return zipWriter.Close()
 }
 
 // SetMetadataByPrefix sets metadata for files by prefix
@@ -283,6 +511,8 @@ func (s *PrefixService) SetMetadataByPrefix(ctx context.Context, input dto.SetMe
 			continue
 		}
 
+		s.replication.MetadataUpdated(ctx, file.BucketID, file.Key)
+		s.events.ObjectMetadataUpdated(&file)
 		updatedKeys = append(updatedKeys, file.Key)
 	}
 
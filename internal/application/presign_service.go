@@ -5,25 +5,43 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrPresignInvalid  = errors.New("invalid presigned URL")
+	ErrPresignExpired  = errors.New("presigned URL has expired")
+	ErrPresignRevoked  = errors.New("presigned URL has been revoked")
+	ErrPresignNotFound = errors.New("presigned URL not found")
+)
+
 type PresignService struct {
-	repo      domain.RepositoryPort
-	storage   domain.StoragePort
-	secretKey string
+	repo        domain.RepositoryPort
+	storage     domain.StoragePort
+	encryption  *EncryptionService
+	quotas      *QuotaService
+	replication *ReplicationService
+	events      *EventBus
+	secretKey   string
 }
 
-func NewPresignService(repo domain.RepositoryPort, storage domain.StoragePort, secretKey string) *PresignService {
+func NewPresignService(repo domain.RepositoryPort, storage domain.StoragePort, encryption *EncryptionService, quotas *QuotaService, replication *ReplicationService, events *EventBus, secretKey string) *PresignService {
 	return &PresignService{
-		repo:      repo,
-		storage:   storage,
-		secretKey: secretKey,
+		repo:        repo,
+		storage:     storage,
+		encryption:  encryption,
+		quotas:      quotas,
+		replication: replication,
+		events:      events,
+		secretKey:   secretKey,
 	}
 
 }
@@ -141,6 +159,15 @@ func (s *PresignService) GenerateDownloadURL(ctx context.Context, input dto.Gene
 	}, nil
 }
 
+// GetPresignedURL looks up a presigned URL by id
+func (s *PresignService) GetPresignedURL(ctx context.Context, urlID string) (*domain.PresignedURL, error) {
+	presignedURL, err := s.repo.GetPresignedURLByID(ctx, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPresignNotFound, err)
+	}
+	return presignedURL, nil
+}
+
 // RevokePresignedURL revokes a presigned URL
 func (s *PresignService) RevokePresignedURL(ctx context.Context, urlID string) error {
 	presignedURL, err := s.repo.GetPresignedURLByID(ctx, urlID)
@@ -304,4 +331,172 @@ func (s *PresignService) generateMultipartSignedURL(urlID, bucketName, key strin
 
 	return fmt.Sprintf("/api/v1/buckets/%s/objects/%s?urlId=%s&part=%d&expires=%d&method=%s&signature=%s",
 		bucketName, key, urlID, partNumber, expires, method, signature)
-}
\ No newline at end of file
+}
+
+// OpenPresignedObject verifies a GET/HEAD presigned request and opens the
+// object it grants access to; the caller must close the returned body.
+func (s *PresignService) OpenPresignedObject(ctx context.Context, input dto.PresignedRequestInput) (io.ReadCloser, *dto.PresignedObjectOutput, error) {
+	_, bucket, err := s.verifyPresignedRequest(ctx, input, "GET")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Presigned URLs carry no SSE-C key, so only server-managed objects open
+	var enc *domain.ObjectEncryption
+	if file, err := s.repo.GetFileByKey(ctx, bucket.ID, input.Key); err == nil {
+		if enc, err = s.encryption.ForRead(file, dto.SSEInput{}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	body, info, err := s.storage.GetObjectStream(ctx, input.BucketName, input.Key, enc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	return body, &dto.PresignedObjectOutput{
+		Key:          input.Key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+	}, nil
+}
+
+// PutPresignedObject verifies a PUT presigned request, streams body into the
+// bucket and records the resulting file.
+func (s *PresignService) PutPresignedObject(ctx context.Context, input dto.PresignedRequestInput, body io.Reader, size int64, contentType string) (*dto.PresignedUploadOutput, error) {
+	presignedURL, bucket, err := s.verifyPresignedRequest(ctx, input, "PUT")
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	if err := checkOverwritable(ctx, s.repo, bucket.ID, presignedURL.Key); err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.quotas.reserve(ctx, bucket, presignedURL.Key, size)
+	if err != nil {
+		return nil, err
+	}
+	defer reservation.release(ctx)
+
+	enc, err := s.encryption.ForWrite(ctx, bucket.ID, dto.SSEInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	limited, err := reservation.limit(ctx, body, size)
+	if err != nil {
+		return nil, err
+	}
+	hashed, err := newChecksumReader(limited, size, dto.ChecksumInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.storage.SaveObjectStream(ctx, bucket.Name, presignedURL.Key, hashed, size, contentType, presignedURL.Metadata, enc)
+	if err != nil {
+		if limited.err != nil {
+			return nil, limited.err
+		}
+		return nil, fmt.Errorf("failed to save object to storage: %w", err)
+	}
+	if err := reservation.resize(ctx, info.Size); err != nil {
+		if info.VersionID != "" {
+			_, _ = s.storage.DeleteObjectVersion(ctx, bucket.Name, presignedURL.Key, info.VersionID)
+		} else {
+			_ = s.storage.DeleteObject(ctx, bucket.Name, presignedURL.Key)
+		}
+		return nil, err
+	}
+
+	file := domain.File{
+		ID:          uuid.New().String(),
+		BucketID:    bucket.ID,
+		Key:         presignedURL.Key,
+		Size:        info.Size,
+		MimeType:    contentType,
+		ContentType: contentType,
+		Metadata:    presignedURL.Metadata,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	setFileEncryption(&file, enc)
+	setFileChecksums(&file, hashed.Sum())
+	if err := applyDefaultRetention(ctx, s.repo, bucket.ID, &file); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveFile(ctx, file); err != nil {
+		return nil, fmt.Errorf("failed to save file metadata: %w", err)
+	}
+	reservation.commit(ctx, file.Size)
+	s.replication.ObjectCreated(ctx, bucket.ID, file.Key)
+	s.events.ObjectCreated(&file)
+
+	return &dto.PresignedUploadOutput{
+		FileID:    file.ID,
+		Key:       file.Key,
+		Size:      file.Size,
+		ETag:      file.ETag,
+		CreatedAt: file.CreatedAt,
+	}, nil
+}
+
+// presignTypes is the kind of URL each method may be served for
+var presignTypes = map[string]string{
+	"GET": "download",
+	"PUT": "upload",
+}
+
+// verifyPresignedRequest checks the signature, expiry and revocation state of
+// a presigned request and that it targets the object the URL was issued for
+// with the method it was issued for.
+func (s *PresignService) verifyPresignedRequest(ctx context.Context, input dto.PresignedRequestInput, method string) (*domain.PresignedURL, *domain.Bucket, error) {
+	if input.SignedFor != method {
+		return nil, nil, fmt.Errorf("%w: URL is not valid for %s", ErrPresignInvalid, input.Method)
+	}
+
+	params := fmt.Sprintf("urlId=%s&expires=%s&method=%s", input.URLID, input.Expires, input.SignedFor)
+	expected, _ := hex.DecodeString(s.signString(params))
+	given, err := hex.DecodeString(input.Signature)
+	if err != nil || !hmac.Equal(expected, given) {
+		return nil, nil, fmt.Errorf("%w: signature mismatch", ErrPresignInvalid)
+	}
+
+	expires, err := strconv.ParseInt(input.Expires, 10, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: bad expires value", ErrPresignInvalid)
+	}
+	if time.Now().Unix() > expires {
+		return nil, nil, ErrPresignExpired
+	}
+
+	presignedURL, err := s.repo.GetPresignedURLByID(ctx, input.URLID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrPresignNotFound, err)
+	}
+	if presignedURL.Revoked {
+		return nil, nil, ErrPresignRevoked
+	}
+	if presignedURL.Type != presignTypes[method] {
+		return nil, nil, fmt.Errorf("%w: a %s URL is not valid for %s", ErrPresignInvalid, presignedURL.Type, method)
+	}
+	if time.Now().After(presignedURL.ExpiresAt) {
+		return nil, nil, ErrPresignExpired
+	}
+
+	bucket, err := s.repo.GetBucketByID(ctx, presignedURL.BucketID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("bucket not found: %w", err)
+	}
+	if bucket.Name != input.BucketName || presignedURL.Key != input.Key {
+		return nil, nil, fmt.Errorf("%w: URL was issued for a different object", ErrPresignInvalid)
+	}
+
+	return presignedURL, &bucket, nil
+}
@@ -0,0 +1,307 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+)
+
+var (
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+	ErrInvalidQuota  = errors.New("invalid quota")
+)
+
+// EventQuotaWarning is sent to a bucket's webhooks when a write takes the
+// bucket's or its owner's usage past the quota's warning level
+const EventQuotaWarning = "quota.warning"
+
+// QuotaService manages storage quotas per bucket and per bucket owner.
+// Usage is counted by the database as files are saved and deleted; writes
+// reserve their size first so concurrent uploads cannot overshoot a limit.
+type QuotaService struct {
+	repo     domain.RepositoryPort
+	webhooks *WebhookService
+}
+
+func NewQuotaService(repo domain.RepositoryPort, webhooks *WebhookService) *QuotaService {
+	return &QuotaService{repo: repo, webhooks: webhooks}
+}
+
+// SetBucketQuota sets the limits of one bucket
+func (s *QuotaService) SetBucketQuota(ctx context.Context, bucketID string, input dto.SetQuotaInput) (*dto.QuotaInfo, error) {
+	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+	return s.setQuota(ctx, domain.QuotaSubject{Scope: domain.QuotaScopeBucket, SubjectID: bucket.ID}, input)
+}
+
+// SetOwnerQuota sets the limits shared by every bucket of ownerID
+func (s *QuotaService) SetOwnerQuota(ctx context.Context, ownerID string, input dto.SetQuotaInput) (*dto.QuotaInfo, error) {
+	if ownerID == "" {
+		return nil, fmt.Errorf("%w: owner id is required", ErrInvalidQuota)
+	}
+	return s.setQuota(ctx, domain.QuotaSubject{Scope: domain.QuotaScopeOwner, SubjectID: ownerID}, input)
+}
+
+func (s *QuotaService) setQuota(ctx context.Context, subject domain.QuotaSubject, input dto.SetQuotaInput) (*dto.QuotaInfo, error) {
+	if input.MaxBytes < 0 || input.MaxObjects < 0 {
+		return nil, fmt.Errorf("%w: limits cannot be negative", ErrInvalidQuota)
+	}
+	warning := domain.DefaultQuotaWarningPercent
+	if input.WarningPercent != nil {
+		warning = *input.WarningPercent
+	}
+	if warning < 0 || warning > 100 {
+		return nil, fmt.Errorf("%w: warning_percent must be between 0 and 100", ErrInvalidQuota)
+	}
+
+	quota := &domain.Quota{
+		QuotaSubject:   subject,
+		MaxBytes:       input.MaxBytes,
+		MaxObjects:     input.MaxObjects,
+		WarningPercent: warning,
+	}
+	if err := s.repo.SetQuota(ctx, quota); err != nil {
+		return nil, err
+	}
+	return s.quotaInfo(ctx, subject)
+}
+
+// BucketQuotaStatus reports the usage of a bucket and of its owner
+func (s *QuotaService) BucketQuotaStatus(ctx context.Context, bucketID string) (*dto.QuotaStatusOutput, error) {
+	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+
+	bucketInfo, err := s.quotaInfo(ctx, domain.QuotaSubject{Scope: domain.QuotaScopeBucket, SubjectID: bucket.ID})
+	if err != nil {
+		return nil, err
+	}
+	ownerInfo, err := s.quotaInfo(ctx, domain.QuotaSubject{Scope: domain.QuotaScopeOwner, SubjectID: bucket.OwnerID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.QuotaStatusOutput{BucketID: bucket.ID, Bucket: *bucketInfo, Owner: *ownerInfo}, nil
+}
+
+// OwnerQuotaStatus reports the usage of every bucket of ownerID together
+func (s *QuotaService) OwnerQuotaStatus(ctx context.Context, ownerID string) (*dto.QuotaInfo, error) {
+	return s.quotaInfo(ctx, domain.QuotaSubject{Scope: domain.QuotaScopeOwner, SubjectID: ownerID})
+}
+
+func (s *QuotaService) quotaInfo(ctx context.Context, subject domain.QuotaSubject) (*dto.QuotaInfo, error) {
+	quota, err := s.getQuota(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	info := QuotaInfo(quota)
+	return &info, nil
+}
+
+// getQuota returns the subject's quota, or an unlimited one with no usage
+// when it has none yet
+func (s *QuotaService) getQuota(ctx context.Context, subject domain.QuotaSubject) (*domain.Quota, error) {
+	quota, err := s.repo.GetQuota(ctx, subject.Scope, subject.SubjectID)
+	if err == nil {
+		return quota, nil
+	}
+	if errors.Is(err, domain.ErrNotFound) {
+		return &domain.Quota{QuotaSubject: subject, WarningPercent: domain.DefaultQuotaWarningPercent}, nil
+	}
+	return nil, fmt.Errorf("failed to get quota: %w", err)
+}
+
+// QuotaInfo converts a quota for API output
+func QuotaInfo(quota *domain.Quota) dto.QuotaInfo {
+	percent := quota.UsagePercent(quota.UsedBytes, quota.UsedObjects)
+	return dto.QuotaInfo{
+		Scope:           quota.Scope,
+		SubjectID:       quota.SubjectID,
+		MaxBytes:        quota.MaxBytes,
+		MaxObjects:      quota.MaxObjects,
+		WarningPercent:  quota.WarningPercent,
+		UsedBytes:       quota.UsedBytes,
+		UsedObjects:     quota.UsedObjects,
+		ReservedBytes:   quota.ReservedBytes,
+		ReservedObjects: quota.ReservedObjects,
+		UsagePercent:    percent,
+		Warning:         quota.Limited() && quota.WarningPercent > 0 && percent >= float64(quota.WarningPercent),
+		UpdatedAt:       quota.UpdatedAt,
+	}
+}
+
+// quotaReservation is room held in a bucket's and its owner's quotas for a
+// write in flight. The write calls commit once its files row is saved, or
+// release when it fails.
+type quotaReservation struct {
+	s            *QuotaService
+	bucketID     string
+	key          string
+	subjects     []domain.QuotaSubject
+	existingSize int64 // size of the object the write replaces
+	bytes        int64
+	objects      int64
+	done         bool
+}
+
+// reserve admits a write of size bytes (-1 when not yet known) to key in
+// bucket. Overwriting a key only needs room for the growth in size.
+func (s *QuotaService) reserve(ctx context.Context, bucket *domain.Bucket, key string, size int64) (*quotaReservation, error) {
+	return s.reserveIn(ctx, bucket, key, size, true)
+}
+
+// reserveMove admits moving an object of size bytes from source to key in
+// dest. The owner's quota only needs room when the move changes owner.
+func (s *QuotaService) reserveMove(ctx context.Context, source, dest *domain.Bucket, key string, size int64) (*quotaReservation, error) {
+	return s.reserveIn(ctx, dest, key, size, source.OwnerID != dest.OwnerID)
+}
+
+func (s *QuotaService) reserveIn(ctx context.Context, bucket *domain.Bucket, key string, size int64, withOwner bool) (*quotaReservation, error) {
+	r := &quotaReservation{
+		s:        s,
+		bucketID: bucket.ID,
+		key:      key,
+		subjects: []domain.QuotaSubject{{Scope: domain.QuotaScopeBucket, SubjectID: bucket.ID}},
+		objects:  1,
+	}
+	if withOwner {
+		r.subjects = append(r.subjects, domain.QuotaSubject{Scope: domain.QuotaScopeOwner, SubjectID: bucket.OwnerID})
+	}
+	if existing, err := s.repo.GetFileByKey(ctx, bucket.ID, key); err == nil {
+		r.existingSize, r.objects = existing.Size, 0
+	}
+
+	if err := r.hold(ctx, r.growth(size), r.objects); err != nil {
+		return nil, err
+	}
+	r.bytes = r.growth(size)
+	return r, nil
+}
+
+// resize adjusts the reservation to the size the write turned out to have
+func (r *quotaReservation) resize(ctx context.Context, size int64) error {
+	want := r.growth(size)
+	switch {
+	case want > r.bytes:
+		if err := r.hold(ctx, want-r.bytes, 0); err != nil {
+			return err
+		}
+	case want < r.bytes:
+		if err := r.s.repo.ReleaseQuota(ctx, r.subjects, r.bytes-want, 0); err != nil {
+			return err
+		}
+	}
+	r.bytes = want
+	return nil
+}
+
+func (r *quotaReservation) hold(ctx context.Context, bytes, objects int64) error {
+	if bytes == 0 && objects == 0 {
+		return nil
+	}
+	exceeded, err := r.s.repo.ReserveQuota(ctx, r.subjects, bytes, objects)
+	if err != nil {
+		return err
+	}
+	if exceeded != nil {
+		return fmt.Errorf("%w: %s %s", ErrQuotaExceeded, exceeded.Scope, exceeded.SubjectID)
+	}
+	return nil
+}
+
+// limit wraps the body of a write whose size was not known up front so it
+// fails as soon as it outgrows the room its quotas had left, rather than
+// once it has been stored
+func (r *quotaReservation) limit(ctx context.Context, body io.Reader, size int64) (*quotaLimitReader, error) {
+	limited := &quotaLimitReader{r: body, remaining: -1}
+	if size >= 0 {
+		return limited, nil
+	}
+
+	for _, subject := range r.subjects {
+		quota, err := r.s.getQuota(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		if quota.MaxBytes <= 0 {
+			continue
+		}
+		room := max(quota.MaxBytes-quota.UsedBytes-quota.ReservedBytes+r.existingSize, 0)
+		if limited.remaining < 0 || room < limited.remaining {
+			limited.remaining, limited.subject = room, subject
+		}
+	}
+	return limited, nil
+}
+
+func (r *quotaReservation) growth(size int64) int64 {
+	if size <= r.existingSize {
+		return 0
+	}
+	return size - r.existingSize
+}
+
+// commit hands the reservation over to the saved files row, which the
+// database now counts, and raises warnings for quotas the write pushed past
+// their warning level
+func (r *quotaReservation) commit(ctx context.Context, size int64) {
+	r.release(ctx)
+
+	for _, subject := range r.subjects {
+		quota, err := r.s.getQuota(ctx, subject)
+		if err != nil {
+			log.Printf("quota: failed to check %s %s: %v", subject.Scope, subject.SubjectID, err)
+			continue
+		}
+		if !quota.WarningCrossed(size-r.existingSize, r.objects) {
+			continue
+		}
+		if r.s.webhooks != nil {
+			r.s.webhooks.TriggerWebhook(ctx, r.bucketID, EventQuotaWarning,
+				dto.QuotaWarningEvent{BucketID: r.bucketID, Key: r.key, Quota: QuotaInfo(quota)})
+		}
+	}
+}
+
+// release gives the reserved room back; it does nothing after commit
+func (r *quotaReservation) release(ctx context.Context) {
+	if r == nil || r.done {
+		return
+	}
+	r.done = true
+	if err := r.s.repo.ReleaseQuota(ctx, r.subjects, r.bytes, r.objects); err != nil {
+		log.Printf("quota: failed to release reservation for %s/%s: %v", r.bucketID, r.key, err)
+	}
+}
+
+// quotaLimitReader fails once more than remaining bytes have been read
+type quotaLimitReader struct {
+	r         io.Reader
+	remaining int64 // -1 for no limit
+	subject   domain.QuotaSubject
+
+	err error
+}
+
+func (l *quotaLimitReader) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	n, err := l.r.Read(p)
+	if l.remaining >= 0 {
+		l.remaining -= int64(n)
+		if l.remaining < 0 {
+			l.err = fmt.Errorf("%w: %s %s", ErrQuotaExceeded, l.subject.Scope, l.subject.SubjectID)
+			return n, l.err
+		}
+	}
+	return n, err
+}
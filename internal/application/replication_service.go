@@ -0,0 +1,554 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidReplicationRule = errors.New("invalid replication rule")
+	// errReplicationRuleGone fails a task straight away: its rule was
+	// removed or disabled after the task was queued
+	errReplicationRuleGone = errors.New("replication rule no longer applies")
+)
+
+const (
+	// replicationMaxAttempts is how often a task is tried before it is
+	// marked FAILED; failed tasks can be retried by hand
+	replicationMaxAttempts = 8
+	replicationBatchSize   = 50
+	// replicationLease keeps a claimed task from other workers while one
+	// attempt runs
+	replicationLease = 5 * time.Minute
+)
+
+// ReplicationService copies objects to other buckets according to each
+// bucket's replication rules. Writes and deletes queue a task per matching
+// rule (ObjectCreated, ObjectDeleted, MetadataUpdated); Run works the queue
+// in the background and retries failed tasks with backoff. A task always
+// brings the destination up to date with the source as it is when the task
+// runs, so repeated or reordered events are harmless.
+type ReplicationService struct {
+	repo       domain.RepositoryPort
+	storage    domain.StoragePort
+	encryption *EncryptionService
+	quotas     *QuotaService
+	tiering    *TieringService
+	events     *EventBus
+
+	// wake nudges the worker when new tasks are queued
+	wake chan struct{}
+}
+
+func NewReplicationService(repo domain.RepositoryPort, storage domain.StoragePort, encryption *EncryptionService, quotas *QuotaService, tiering *TieringService, events *EventBus) *ReplicationService {
+	return &ReplicationService{
+		repo:       repo,
+		storage:    storage,
+		encryption: encryption,
+		quotas:     quotas,
+		tiering:    tiering,
+		events:     events,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// SetReplicationRules replaces the bucket's replication rules
+func (s *ReplicationService) SetReplicationRules(ctx context.Context, bucketID string, input dto.SetReplicationInput) (*dto.ReplicationConfigurationOutput, error) {
+	if _, err := s.repo.GetBucketByID(ctx, bucketID); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+
+	rules := make([]domain.ReplicationRule, 0, len(input.Rules))
+	seen := make(map[string]bool, len(input.Rules))
+	for i, in := range input.Rules {
+		rule := domain.ReplicationRule{
+			ID:                in.ID,
+			Status:            in.Status,
+			Prefix:            in.Prefix,
+			DestinationBucket: in.DestinationBucket,
+			ReplicateDeletes:  in.ReplicateDeletes,
+			ReplicateMetadata: in.ReplicateMetadata == nil || *in.ReplicateMetadata,
+		}
+		if rule.ID == "" {
+			rule.ID = fmt.Sprintf("rule-%d", i+1)
+		}
+		if seen[rule.ID] {
+			return nil, fmt.Errorf("%w: duplicate rule id %q", ErrInvalidReplicationRule, rule.ID)
+		}
+		seen[rule.ID] = true
+
+		if rule.Status != domain.ReplicationRuleEnabled && rule.Status != domain.ReplicationRuleDisabled {
+			return nil, fmt.Errorf("%w: rule %q: status must be Enabled or Disabled", ErrInvalidReplicationRule, rule.ID)
+		}
+		if rule.DestinationBucket == bucketID {
+			return nil, fmt.Errorf("%w: rule %q: a bucket cannot replicate to itself", ErrInvalidReplicationRule, rule.ID)
+		}
+		if _, err := s.repo.GetBucketByID(ctx, rule.DestinationBucket); err != nil {
+			return nil, fmt.Errorf("%w: rule %q: destination bucket %s does not exist", ErrInvalidReplicationRule, rule.ID, rule.DestinationBucket)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := s.repo.ReplaceReplicationRules(ctx, bucketID, rules); err != nil {
+		return nil, err
+	}
+	return replicationConfiguration(bucketID, rules), nil
+}
+
+// GetReplicationRules returns the bucket's replication rules
+func (s *ReplicationService) GetReplicationRules(ctx context.Context, bucketID string) (*dto.ReplicationConfigurationOutput, error) {
+	if _, err := s.repo.GetBucketByID(ctx, bucketID); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+	rules, err := s.repo.GetReplicationRules(ctx, bucketID)
+	if err != nil {
+		return nil, err
+	}
+	return replicationConfiguration(bucketID, rules), nil
+}
+
+// ObjectCreated queues key for copying to every destination whose rule
+// covers it. It is called after the files row of a write has been saved.
+func (s *ReplicationService) ObjectCreated(ctx context.Context, bucketID, key string) {
+	s.enqueue(ctx, bucketID, key, domain.ReplicationOpPut, func(*domain.ReplicationRule) bool { return true })
+}
+
+// ObjectDeleted queues the removal of key's replicas where the rule
+// replicates deletes
+func (s *ReplicationService) ObjectDeleted(ctx context.Context, bucketID, key string) {
+	s.enqueue(ctx, bucketID, key, domain.ReplicationOpDelete, func(rule *domain.ReplicationRule) bool { return rule.ReplicateDeletes })
+}
+
+// MetadataUpdated queues a metadata update of key's replicas where the rule
+// replicates metadata
+func (s *ReplicationService) MetadataUpdated(ctx context.Context, bucketID, key string) {
+	s.enqueue(ctx, bucketID, key, domain.ReplicationOpMetadata, func(rule *domain.ReplicationRule) bool { return rule.ReplicateMetadata })
+}
+
+// enqueue never fails the write that triggered it: errors are logged, and a
+// backfill picks up anything that was missed
+func (s *ReplicationService) enqueue(ctx context.Context, bucketID, key, operation string, applies func(*domain.ReplicationRule) bool) {
+	if s == nil {
+		return
+	}
+	rules, err := s.repo.GetReplicationRules(ctx, bucketID)
+	if err != nil {
+		log.Printf("replication: failed to get rules of %s: %v", bucketID, err)
+		return
+	}
+
+	queued := false
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.IsEnabled() || !rule.Matches(key) || !applies(rule) {
+			continue
+		}
+		task := &domain.ReplicationTask{
+			ID:                uuid.New().String(),
+			BucketID:          bucketID,
+			Key:               key,
+			DestinationBucket: rule.DestinationBucket,
+			RuleID:            rule.ID,
+			Operation:         operation,
+		}
+		if err := s.repo.EnqueueReplication(ctx, task); err != nil {
+			log.Printf("replication: failed to queue %s of %s/%s: %v", operation, bucketID, key, err)
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		s.notify()
+	}
+}
+
+// Backfill queues every object of the bucket that its rules cover, e.g.
+// objects stored before a rule was added. Objects already replicated are
+// copied again.
+func (s *ReplicationService) Backfill(ctx context.Context, bucketID string) (*dto.ReplicationBackfillOutput, error) {
+	if _, err := s.repo.GetBucketByID(ctx, bucketID); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+	rules, err := s.repo.GetReplicationRules(ctx, bucketID)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.repo.ListFiles(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	output := &dto.ReplicationBackfillOutput{BucketID: bucketID, Scanned: len(files)}
+	for _, file := range files {
+		if file.ReplicationStatus == domain.ReplicationReplica {
+			continue
+		}
+		for i := range rules {
+			rule := &rules[i]
+			if !rule.IsEnabled() || !rule.Matches(file.Key) {
+				continue
+			}
+			if err := s.repo.EnqueueReplication(ctx, &domain.ReplicationTask{
+				ID:                uuid.New().String(),
+				BucketID:          bucketID,
+				Key:               file.Key,
+				DestinationBucket: rule.DestinationBucket,
+				RuleID:            rule.ID,
+				Operation:         domain.ReplicationOpPut,
+			}); err != nil {
+				return nil, err
+			}
+			output.Queued++
+		}
+	}
+
+	if output.Queued > 0 {
+		s.notify()
+	}
+	return output, nil
+}
+
+// Tasks lists the bucket's replication tasks, optionally only those with
+// status
+func (s *ReplicationService) Tasks(ctx context.Context, bucketID, status string, limit int) (*dto.ReplicationTasksOutput, error) {
+	if _, err := s.repo.GetBucketByID(ctx, bucketID); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	tasks, err := s.repo.ListReplicationTasks(ctx, bucketID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &dto.ReplicationTasksOutput{BucketID: bucketID, Tasks: make([]dto.ReplicationTaskInfo, 0, len(tasks))}
+	for _, task := range tasks {
+		info := dto.ReplicationTaskInfo{
+			Key:               task.Key,
+			DestinationBucket: task.DestinationBucket,
+			RuleID:            task.RuleID,
+			Operation:         task.Operation,
+			Status:            task.Status,
+			Attempts:          task.Attempts,
+			LastError:         task.LastError,
+			UpdatedAt:         task.UpdatedAt,
+		}
+		if task.Status == domain.ReplicationPending {
+			next := task.NextAttemptAt
+			info.NextAttemptAt = &next
+		}
+		output.Tasks = append(output.Tasks, info)
+	}
+	output.Count = len(output.Tasks)
+	return output, nil
+}
+
+// RetryFailed makes the bucket's failed tasks pending again
+func (s *ReplicationService) RetryFailed(ctx context.Context, bucketID string) (*dto.ReplicationRetryOutput, error) {
+	if _, err := s.repo.GetBucketByID(ctx, bucketID); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+	count, err := s.repo.RetryFailedReplication(ctx, bucketID)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		s.notify()
+	}
+	return &dto.ReplicationRetryOutput{BucketID: bucketID, Retried: count}, nil
+}
+
+// notify wakes the worker without waiting for it
+func (s *ReplicationService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run works through due tasks whenever new ones are queued and at least
+// once per interval, until ctx is done
+func (s *ReplicationService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.ProcessDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// ProcessDue runs every task that is due and returns how many were tried
+func (s *ReplicationService) ProcessDue(ctx context.Context) int {
+	processed := 0
+	for ctx.Err() == nil {
+		tasks, err := s.repo.ClaimReplicationTasks(ctx, replicationBatchSize, replicationLease)
+		if err != nil {
+			log.Printf("replication: %v", err)
+			return processed
+		}
+		if len(tasks) == 0 {
+			return processed
+		}
+
+		for i := range tasks {
+			s.attempt(ctx, &tasks[i])
+			processed++
+		}
+	}
+	return processed
+}
+
+// attempt runs a task once and records the outcome: COMPLETED, PENDING with
+// a backed-off retry, or FAILED once the attempts are used up
+func (s *ReplicationService) attempt(ctx context.Context, task *domain.ReplicationTask) {
+	err := s.execute(ctx, task)
+
+	task.Attempts++
+	task.NextAttemptAt = time.Now()
+	switch {
+	case err == nil:
+		task.Status = domain.ReplicationCompleted
+		task.LastError = ""
+	case task.Attempts >= replicationMaxAttempts, errors.Is(err, errReplicationRuleGone):
+		task.Status = domain.ReplicationFailed
+		task.LastError = err.Error()
+	default:
+		task.Status = domain.ReplicationPending
+		task.LastError = err.Error()
+		task.NextAttemptAt = time.Now().Add(replicationBackoff(task.Attempts))
+	}
+	if err != nil {
+		log.Printf("replication: %s %s/%s to %s (attempt %d): %v",
+			task.Operation, task.BucketID, task.Key, task.DestinationBucket, task.Attempts, err)
+	}
+
+	if err := s.repo.FinishReplicationTask(ctx, task); err != nil {
+		log.Printf("replication: %v", err)
+	}
+}
+
+// replicationBackoff doubles the wait after each failed attempt, from 30s
+// up to an hour
+func replicationBackoff(attempts int) time.Duration {
+	wait := 30 * time.Second
+	for i := 1; i < attempts && wait < time.Hour; i++ {
+		wait *= 2
+	}
+	if wait > time.Hour {
+		wait = time.Hour
+	}
+	return wait
+}
+
+func (s *ReplicationService) execute(ctx context.Context, task *domain.ReplicationTask) error {
+	rule, err := s.rule(ctx, task)
+	if err != nil {
+		return err
+	}
+
+	source, err := s.repo.GetBucketByID(ctx, task.BucketID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBucketNotFound, task.BucketID)
+	}
+	dest, err := s.repo.GetBucketByID(ctx, task.DestinationBucket)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBucketNotFound, task.DestinationBucket)
+	}
+
+	file, err := s.repo.GetFileByKey(ctx, source.ID, task.Key)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	replica, err := s.repo.GetFileByKey(ctx, dest.ID, task.Key)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+
+	switch task.Operation {
+	case domain.ReplicationOpDelete:
+		// A newer write has brought the object back; its own task copies it
+		if file != nil || replica == nil {
+			return nil
+		}
+		return s.deleteReplica(ctx, &dest, replica)
+	case domain.ReplicationOpMetadata:
+		if file == nil {
+			return nil
+		}
+		if replica != nil && replica.ReplicationStatus == domain.ReplicationReplica {
+			replica.Metadata = file.Metadata
+			if err := s.repo.UpdateFile(ctx, replica); err != nil {
+				return fmt.Errorf("failed to update replica metadata: %w", err)
+			}
+			s.events.ObjectMetadataUpdated(replica)
+			return nil
+		}
+		// No replica yet: make a full copy
+	}
+
+	// The source is gone; a delete task handles the replica if asked to
+	if file == nil {
+		return nil
+	}
+	return s.copyObject(ctx, rule, &source, &dest, file)
+}
+
+// rule returns the enabled rule a task was queued for; tasks of rules that
+// have since been removed or disabled fail
+func (s *ReplicationService) rule(ctx context.Context, task *domain.ReplicationTask) (*domain.ReplicationRule, error) {
+	rules, err := s.repo.GetReplicationRules(ctx, task.BucketID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		if rules[i].ID == task.RuleID && rules[i].IsEnabled() && rules[i].DestinationBucket == task.DestinationBucket {
+			return &rules[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", errReplicationRuleGone, task.RuleID)
+}
+
+// copyObject writes file into dest as a replica, the way CopyFile copies
+// objects, except that the result is marked REPLICA and queues nothing
+func (s *ReplicationService) copyObject(ctx context.Context, rule *domain.ReplicationRule, source, dest *domain.Bucket, file *domain.File) error {
+	if file.IsArchived(time.Now()) {
+		return fmt.Errorf("%w: %s", ErrObjectArchived, file.Key)
+	}
+	if err := checkOverwritable(ctx, s.repo, dest.ID, file.Key); err != nil {
+		return err
+	}
+
+	reservation, err := s.quotas.reserve(ctx, dest, file.Key, file.Size)
+	if err != nil {
+		return err
+	}
+	defer reservation.release(ctx)
+
+	srcEnc, dstEnc, err := s.encryption.ForCopy(ctx, dest.ID, file, dto.SSEInput{})
+	if err != nil {
+		return err
+	}
+	if err := s.storage.CopyObject(ctx, source.Name, file.Key, dest.Name, file.Key, srcEnc, dstEnc); err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+
+	var metadata map[string]string
+	if rule.ReplicateMetadata {
+		metadata = file.Metadata
+	}
+
+	var versionID string
+	if versioningEnabled(ctx, s.repo, dest.ID) {
+		info, err := s.storage.StatObject(ctx, dest.Name, file.Key, dstEnc)
+		if err != nil {
+			return fmt.Errorf("failed to stat replica: %w", err)
+		}
+		versionID = info.VersionID
+	}
+
+	replica := domain.File{
+		ID:                uuid.New().String(),
+		BucketID:          dest.ID,
+		Key:               file.Key,
+		Size:              file.Size,
+		MimeType:          file.MimeType,
+		ContentType:       file.ContentType,
+		Metadata:          metadata,
+		Version:           versionID,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+		ReplicationStatus: domain.ReplicationReplica,
+	}
+	setFileEncryption(&replica, dstEnc)
+	replica.ETag, replica.ChecksumSHA256, replica.ChecksumCRC32C = file.ETag, file.ChecksumSHA256, file.ChecksumCRC32C
+	// A failure from here on leaves the replica without a files row, so it
+	// is removed again unless a version row already points at it
+	if err := applyDefaultRetention(ctx, s.repo, dest.ID, &replica); err != nil {
+		s.discardReplica(ctx, dest.Name, file.Key, versionID)
+		return err
+	}
+	if _, err := recordObjectVersion(ctx, s.repo, &replica); err != nil {
+		s.discardReplica(ctx, dest.Name, file.Key, versionID)
+		return err
+	}
+
+	if err := s.repo.SaveFile(ctx, replica); err != nil {
+		if versionID == "" || versionID == "null" {
+			s.discardReplica(ctx, dest.Name, file.Key, "")
+		}
+		return fmt.Errorf("failed to save replica metadata: %w", err)
+	}
+	reservation.commit(ctx, replica.Size)
+	s.events.ObjectCreated(&replica)
+	return nil
+}
+
+// discardReplica removes a replica that could not be recorded; on a
+// versioned bucket only the version that was just written
+func (s *ReplicationService) discardReplica(ctx context.Context, bucketName, key, versionID string) {
+	var err error
+	if versionID != "" {
+		_, err = s.storage.DeleteObjectVersion(ctx, bucketName, key, versionID)
+	} else {
+		err = s.storage.DeleteObject(ctx, bucketName, key)
+	}
+	if err != nil {
+		log.Printf("replication: failed to discard unrecorded replica %s/%s: %v", bucketName, key, err)
+	}
+}
+
+// deleteReplica removes a replica whose source was deleted. Objects in the
+// destination that were not written by replication are left alone.
+func (s *ReplicationService) deleteReplica(ctx context.Context, dest *domain.Bucket, replica *domain.File) error {
+	if replica.ReplicationStatus != domain.ReplicationReplica {
+		return nil
+	}
+	if err := checkRemovable(replica, false); err != nil {
+		return err
+	}
+
+	if err := deleteFromStorage(ctx, s.storage, s.repo, dest.ID, dest.Name, replica.Key); err != nil {
+		return err
+	}
+	if err := s.tiering.deleteArchivedCopy(ctx, dest.Name, replica); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteFile(ctx, replica.ID); err != nil {
+		return fmt.Errorf("failed to delete replica metadata: %w", err)
+	}
+	s.events.ObjectDeleted(dest.ID, replica.Key)
+	return nil
+}
+
+func replicationConfiguration(bucketID string, rules []domain.ReplicationRule) *dto.ReplicationConfigurationOutput {
+	output := &dto.ReplicationConfigurationOutput{BucketID: bucketID, Rules: make([]dto.ReplicationRuleInfo, 0, len(rules))}
+	for _, rule := range rules {
+		output.Rules = append(output.Rules, dto.ReplicationRuleInfo{
+			ID:                rule.ID,
+			Status:            rule.Status,
+			Prefix:            rule.Prefix,
+			DestinationBucket: rule.DestinationBucket,
+			ReplicateDeletes:  rule.ReplicateDeletes,
+			ReplicateMetadata: rule.ReplicateMetadata,
+		})
+	}
+	return output
+}
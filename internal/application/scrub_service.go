@@ -0,0 +1,196 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+)
+
+// ScrubService re-reads stored objects and compares them with the checksums
+// recorded at upload, flagging any whose data no longer matches. Run drives
+// it periodically; ScrubBucket runs it on demand.
+type ScrubService struct {
+	repo       domain.RepositoryPort
+	storage    domain.StoragePort
+	encryption *EncryptionService
+
+	// mu keeps the periodic worker and on-demand runs from racing each other
+	mu sync.Mutex
+}
+
+func NewScrubService(repo domain.RepositoryPort, storage domain.StoragePort, encryption *EncryptionService) *ScrubService {
+	return &ScrubService{repo: repo, storage: storage, encryption: encryption}
+}
+
+// Run scrubs every bucket each interval until ctx is done
+func (s *ScrubService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.RunAll(ctx)
+	}
+}
+
+// RunAll scrubs every bucket; a failing bucket does not stop the others
+func (s *ScrubService) RunAll(ctx context.Context) {
+	buckets, err := s.repo.ListBuckets(ctx)
+	if err != nil {
+		log.Printf("scrub: failed to list buckets: %v", err)
+		return
+	}
+
+	for _, bucket := range buckets {
+		report, err := s.ScrubBucket(ctx, bucket.ID)
+		if err != nil {
+			log.Printf("scrub: bucket %s: %v", bucket.ID, err)
+			continue
+		}
+		if report.Mismatched > 0 || report.Failed > 0 {
+			log.Printf("scrub: bucket %s: %d of %d objects mismatched, %d unreadable",
+				bucket.ID, report.Mismatched, report.Scanned, report.Failed)
+		}
+	}
+}
+
+// ScrubBucket verifies every object in the bucket that has checksums and
+// records the outcome on its files row
+func (s *ScrubService) ScrubBucket(ctx context.Context, bucketID string) (*dto.ScrubReport, error) {
+	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+
+	files, err := s.repo.ListFiles(ctx, bucket.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := &dto.ScrubReport{
+		BucketID:  bucket.ID,
+		StartedAt: time.Now(),
+		Flagged:   []dto.ScrubFinding{},
+	}
+
+	for i := range files {
+		file := &files[i]
+		report.Scanned++
+
+		// Archived data is in the cold tier and SSE-C keys are never stored
+		if !file.HasChecksums() || file.IsArchived(time.Now()) || file.Encryption == domain.EncryptionSSEC {
+			report.Skipped++
+			continue
+		}
+
+		mismatch, err := s.verify(ctx, &bucket, file)
+		if err != nil {
+			log.Printf("scrub: %s/%s: %v", bucket.ID, file.Key, err)
+			report.Failed++
+			continue
+		}
+
+		// The listing is a snapshot: an object overwritten or deleted while
+		// it was read is not the one verified, so it is left for next time
+		if !s.unchanged(ctx, file) {
+			report.Skipped++
+			continue
+		}
+
+		scrubbedAt := time.Now()
+		if err := s.repo.UpdateFileScrub(ctx, file.ID, scrubbedAt, mismatch); err != nil {
+			log.Printf("scrub: %s/%s: %v", bucket.ID, file.Key, err)
+		}
+
+		if mismatch == "" {
+			report.Verified++
+			continue
+		}
+		report.Mismatched++
+		report.Flagged = append(report.Flagged, dto.ScrubFinding{
+			FileID: file.ID, Key: file.Key, Error: mismatch, ScrubbedAt: &scrubbedAt,
+		})
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// verify re-reads the object and describes how it differs from the files
+// row, or returns "" when it matches. err is set when it could not be read.
+func (s *ScrubService) verify(ctx context.Context, bucket *domain.Bucket, file *domain.File) (string, error) {
+	enc, err := s.encryption.ForRead(file, dto.SSEInput{})
+	if err != nil {
+		return "", err
+	}
+
+	body, _, err := s.storage.GetObjectStream(ctx, bucket.Name, file.Key, enc)
+	if err != nil {
+		return "", fmt.Errorf("failed to read object: %w", err)
+	}
+	defer body.Close()
+
+	expected := file.Checksums()
+	hasher := domain.NewChecksumHasher(expected.CRC32C != "")
+	size, err := io.Copy(hasher, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read object: %w", err)
+	}
+
+	if size != file.Size {
+		return fmt.Sprintf("size is %d bytes, expected %d", size, file.Size), nil
+	}
+	if algorithm := hasher.Sum().Mismatch(expected); algorithm != "" {
+		return algorithm + " checksum mismatch", nil
+	}
+	return "", nil
+}
+
+// unchanged reports whether the files row still describes the object that
+// was listed
+func (s *ScrubService) unchanged(ctx context.Context, file *domain.File) bool {
+	current, err := s.repo.GetFileByID(ctx, file.ID)
+	if err != nil {
+		return false
+	}
+	return current.ETag == file.ETag && current.UpdatedAt.Equal(file.UpdatedAt) && current.CreatedAt.Equal(file.CreatedAt)
+}
+
+// FlaggedObjects lists the objects whose last scrub found a mismatch
+func (s *ScrubService) FlaggedObjects(ctx context.Context, bucketID string) (*dto.FlaggedObjectsOutput, error) {
+	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+
+	files, err := s.repo.ListFiles(ctx, bucket.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	output := &dto.FlaggedObjectsOutput{BucketID: bucket.ID, Objects: []dto.ScrubFinding{}}
+	for _, file := range files {
+		if file.ScrubError == "" {
+			continue
+		}
+		output.Objects = append(output.Objects, dto.ScrubFinding{
+			FileID: file.ID, Key: file.Key, Error: file.ScrubError, ScrubbedAt: file.ScrubbedAt,
+		})
+	}
+	output.Count = len(output.Objects)
+	return output, nil
+}
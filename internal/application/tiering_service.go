@@ -0,0 +1,152 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+)
+
+var (
+	ErrObjectArchived       = errors.New("object is in a cold storage class; restore it before reading")
+	ErrObjectNotArchived    = errors.New("object is not in a cold storage class")
+	ErrColdStorageDisabled  = errors.New("no cold storage tier is configured")
+	ErrInvalidStorageClass  = errors.New("invalid storage class")
+	ErrInvalidRestoreDays   = errors.New("restore days must be between 1 and 365")
+	ErrTransitionNotAllowed = errors.New("storage class transitions are not supported on versioned buckets")
+	ErrTransitionEncrypted  = errors.New("storage class transitions are not supported for encrypted objects")
+)
+
+const maxRestoreDays = 365
+
+// TieringService moves objects between the hot object store and the cold
+// tier. Cold objects keep their files row; their data lives only in the cold
+// tier until a restore puts a temporary copy back in the hot store.
+type TieringService struct {
+	storage domain.StoragePort
+	cold    domain.ColdStoragePort // nil when no cold tier is configured
+	repo    domain.RepositoryPort
+}
+
+func NewTieringService(storage domain.StoragePort, cold domain.ColdStoragePort, repo domain.RepositoryPort) *TieringService {
+	return &TieringService{storage: storage, cold: cold, repo: repo}
+}
+
+// Transition moves file into the cold storage class. The hot copy is only
+// removed once the cold copy is written and recorded.
+func (s *TieringService) Transition(ctx context.Context, bucket *domain.Bucket, file *domain.File, storageClass string) error {
+	if s.cold == nil {
+		return ErrColdStorageDisabled
+	}
+	if !domain.IsColdStorageClass(storageClass) {
+		return fmt.Errorf("%w: %q", ErrInvalidStorageClass, storageClass)
+	}
+	if domain.IsColdStorageClass(file.StorageClass) {
+		return nil
+	}
+	// A hot delete on a versioned bucket only adds a delete marker
+	if versioningEnabled(ctx, s.repo, bucket.ID) {
+		return ErrTransitionNotAllowed
+	}
+	// The cold tier stores plaintext and has no key to decrypt with
+	if file.IsEncrypted() {
+		return ErrTransitionEncrypted
+	}
+
+	body, info, err := s.storage.GetObjectStream(ctx, bucket.Name, file.Key, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read object: %w", err)
+	}
+	defer body.Close()
+
+	if err := s.cold.PutObject(ctx, bucket.Name, file.Key, body, info.Size); err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateFileStorageClass(ctx, file.ID, storageClass, nil); err != nil {
+		return fmt.Errorf("failed to record transition: %w", err)
+	}
+	file.StorageClass = storageClass
+	file.RestoreExpiresAt = nil
+
+	if err := s.storage.DeleteObject(ctx, bucket.Name, file.Key); err != nil {
+		return fmt.Errorf("transitioned, but failed to remove hot copy: %w", err)
+	}
+	return nil
+}
+
+// RestoreObject makes a cold object readable for days by copying it back to
+// the hot store. Restoring an object that is already restored only moves
+// the expiry.
+func (s *TieringService) RestoreObject(ctx context.Context, bucketID, fileID string, days int) (*dto.RestoreObjectOutput, error) {
+	if days < 1 || days > maxRestoreDays {
+		return nil, ErrInvalidRestoreDays
+	}
+
+	bucket, err := s.repo.GetBucketByID(ctx, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketID)
+	}
+
+	file, err := s.repo.GetFileByID(ctx, fileID)
+	if err != nil || file.BucketID != bucket.ID {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, fileID)
+	}
+	if !domain.IsColdStorageClass(file.StorageClass) {
+		return nil, ErrObjectNotArchived
+	}
+	if s.cold == nil {
+		return nil, ErrColdStorageDisabled
+	}
+
+	now := time.Now()
+	alreadyRestored := file.IsRestored(now)
+	if !alreadyRestored {
+		body, err := s.cold.GetObject(ctx, bucket.Name, file.Key)
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+
+		if _, err := s.storage.SaveObjectStream(ctx, bucket.Name, file.Key, body, file.Size, file.MimeType, file.Metadata, nil); err != nil {
+			return nil, fmt.Errorf("failed to write restored copy: %w", err)
+		}
+	}
+
+	expiresAt := now.Add(time.Duration(days) * day)
+	if err := s.repo.UpdateFileStorageClass(ctx, file.ID, file.StorageClass, &expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to record restore: %w", err)
+	}
+
+	return &dto.RestoreObjectOutput{
+		FileID:           file.ID,
+		BucketID:         bucket.ID,
+		Key:              file.Key,
+		StorageClass:     file.StorageClass,
+		RestoreExpiresAt: expiresAt,
+		AlreadyRestored:  alreadyRestored,
+	}, nil
+}
+
+// ExpireRestoredCopy removes the temporary hot copy of a restored cold object
+func (s *TieringService) ExpireRestoredCopy(ctx context.Context, bucket *domain.Bucket, file *domain.File) error {
+	if err := s.storage.DeleteObject(ctx, bucket.Name, file.Key); err != nil {
+		return fmt.Errorf("failed to remove restored copy: %w", err)
+	}
+	if err := s.repo.UpdateFileStorageClass(ctx, file.ID, file.StorageClass, nil); err != nil {
+		return fmt.Errorf("failed to record restore expiry: %w", err)
+	}
+	file.RestoreExpiresAt = nil
+	return nil
+}
+
+// deleteArchivedCopy removes the cold copy of a file that is being deleted
+func (s *TieringService) deleteArchivedCopy(ctx context.Context, bucketName string, file *domain.File) error {
+	if !domain.IsColdStorageClass(file.StorageClass) || s.cold == nil {
+		return nil
+	}
+	return s.cold.DeleteObject(ctx, bucketName, file.Key)
+}
@@ -2,7 +2,10 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"time"
 
 	"s3/internal/infrastructure/dto"
@@ -11,31 +14,55 @@ import (
 
 )
 
+// Lookup failures that callers map to "no such bucket" / "no such key"
+var (
+	ErrBucketNotFound = errors.New("bucket not found")
+	ErrObjectNotFound = errors.New("object not found")
+)
+
 type UploadService struct {
-	storage    domain.StoragePort
-	repository domain.RepositoryPort
+	storage     domain.StoragePort
+	repository  domain.RepositoryPort
+	encryption  *EncryptionService
+	quotas      *QuotaService
+	replication *ReplicationService
+	events      *EventBus
 }
 
-func NewUploadService(storage domain.StoragePort, repository domain.RepositoryPort) *UploadService {
+func NewUploadService(storage domain.StoragePort, repository domain.RepositoryPort, encryption *EncryptionService, quotas *QuotaService, replication *ReplicationService, events *EventBus) *UploadService {
 	return &UploadService{
-		storage:    storage,
-		repository: repository,
+		storage:     storage,
+		repository:  repository,
+		encryption:  encryption,
+		quotas:      quotas,
+		replication: replication,
+		events:      events,
 	}
 }
 
 type UploadFileInput struct {
 	BucketID string
 	Key      string
-	Data     []byte
+	Body     io.Reader
+	Size     int64 // -1 when unknown
 	MimeType string
 	Metadata map[string]string
+	// Per-request SSE options; the bucket default applies when empty
+	Encryption dto.SSEInput
+	// Client checksums to verify the content against
+	Checksums dto.ChecksumInput
 }
 
 type UploadFileOutput struct {
-	FileID    string
-	Key       string
-	Size      int64
-	CreatedAt time.Time
+	FileID            string
+	Key               string
+	Size              int64
+	ETag              string
+	CreatedAt         time.Time
+	Encryption        string
+	SSECustomerKeyMD5 string
+	ChecksumSHA256    string
+	ChecksumCRC32C    string
 }
 
 func (s *UploadService) UploadFile(ctx context.Context, input UploadFileInput) (*UploadFileOutput, error) {
@@ -51,39 +78,127 @@ fmt.Println(input.BucketID)
 fmt.Println("++++")
 	
 	if err != nil {
-		return nil, fmt.Errorf("bucket not found: %w", err)
+		return nil, fmt.Errorf("%w: %v", ErrBucketNotFound, err)
 	}
 	
-	// Save to MinIO using bucket name
-	err = s.storage.SaveObject(ctx, bucket.Name, input.Key, input.Data, input.Metadata)
+	if err := checkOverwritable(ctx, s.repository, bucket.ID, input.Key); err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.quotas.reserve(ctx, &bucket, input.Key, input.Size)
 	if err != nil {
+		return nil, err
+	}
+	defer reservation.release(ctx)
+
+	enc, err := s.encryption.ForWrite(ctx, bucket.ID, input.Encryption)
+	if err != nil {
+		return nil, err
+	}
+
+	limited, err := reservation.limit(ctx, input.Body, input.Size)
+	if err != nil {
+		return nil, err
+	}
+	body, err := newChecksumReader(limited, input.Size, input.Checksums)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stream to MinIO using bucket name
+	info, err := s.storage.SaveObjectStream(ctx, bucket.Name, input.Key, body, input.Size, input.MimeType, input.Metadata, enc)
+	if err != nil {
+		if limited.err != nil {
+			return nil, limited.err
+		}
+		if body.err != nil {
+			return nil, body.err
+		}
 		return nil, fmt.Errorf("failed to save object to storage: %w", err)
 	}
+	if err := body.finish(); err != nil {
+		s.discardObject(ctx, bucket.Name, input.Key, info.VersionID)
+		return nil, err
+	}
+	// Streamed uploads only learn their size now
+	if err := reservation.resize(ctx, info.Size); err != nil {
+		s.discardObject(ctx, bucket.Name, input.Key, info.VersionID)
+		return nil, err
+	}
+	sums := body.Sum()
+	// MinIO's ETag is not the content MD5 for streamed or encrypted objects
+	info.ETag = sums.MD5
 
 	// Save to DB using bucket UUID
 	file := domain.File{
 		ID:        generateID(),
 		BucketID:  bucket.ID, // Use UUID here
 		Key:       input.Key,
-		Size:      int64(len(input.Data)),
-		MimeType:  input.MimeType,
-		Metadata:  input.Metadata,
+		Size:        info.Size,
+		MimeType:    input.MimeType,
+		ContentType: input.MimeType,
+		Metadata:    input.Metadata,
+		Version:     info.VersionID,
 		CreatedAt: time.Now(),
 	}
+	setFileEncryption(&file, enc)
+	setFileChecksums(&file, sums)
+	// A failure from here on leaves the object without a files row, so it
+	// is removed again unless a version row already points at it
+	if err := applyDefaultRetention(ctx, s.repository, bucket.ID, &file); err != nil {
+		s.discardObject(ctx, bucket.Name, input.Key, info.VersionID)
+		return nil, err
+	}
+	if _, err := recordObjectVersion(ctx, s.repository, &file); err != nil {
+		s.discardObject(ctx, bucket.Name, input.Key, info.VersionID)
+		return nil, err
+	}
 
 	err = s.repository.SaveFile(ctx, file)
 	if err != nil {
+		s.discardUnversioned(ctx, bucket.Name, input.Key, info)
 		return nil, fmt.Errorf("failed to save file metadata: %w", err)
 	}
+	reservation.commit(ctx, file.Size)
+	s.replication.ObjectCreated(ctx, bucket.ID, file.Key)
+	s.events.ObjectCreated(&file)
 
 	return &UploadFileOutput{
-		FileID:    file.ID,
-		Key:       file.Key,
-		Size:      file.Size,
-		CreatedAt: file.CreatedAt,
+		FileID:            file.ID,
+		Key:               file.Key,
+		Size:              file.Size,
+		ETag:              info.ETag,
+		CreatedAt:         file.CreatedAt,
+		Encryption:        file.Encryption,
+		SSECustomerKeyMD5: file.SSECustomerKeyMD5,
+		ChecksumSHA256:    file.ChecksumSHA256,
+		ChecksumCRC32C:    file.ChecksumCRC32C,
 	}, nil
 }
 
+// discardObject removes an object written by a rejected upload; on a
+// versioned bucket only the version that was just written
+func (s *UploadService) discardObject(ctx context.Context, bucketName, key, versionID string) {
+	var err error
+	if versionID != "" {
+		_, err = s.storage.DeleteObjectVersion(ctx, bucketName, key, versionID)
+	} else {
+		err = s.storage.DeleteObject(ctx, bucketName, key)
+	}
+	if err != nil {
+		log.Printf("upload: failed to discard rejected object %s/%s: %v", bucketName, key, err)
+	}
+}
+
+// discardUnversioned removes an object whose files row could not be saved
+// when the bucket does not keep versions of it
+func (s *UploadService) discardUnversioned(ctx context.Context, bucketName, key string, info *domain.ObjectInfo) {
+	if info.VersionID != "" && info.VersionID != "null" {
+		return
+	}
+	s.discardObject(ctx, bucketName, key, "")
+}
+
 // Simple ID generator (you can use UUID library later)
 func generateID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
@@ -111,13 +226,24 @@ func (s *UploadService) GetFileInfo(ctx context.Context, bucketName, fileID stri
 	}
 	
 	return &dto.FileInfoOutput{
-		FileID:    file.ID,
-		BucketID:  bucket.Name,
-		Key:       file.Key,
-		Size:      file.Size,
-		MimeType:  file.MimeType,
-		Metadata:  file.Metadata,
-		CreatedAt: file.CreatedAt,
+		FileID:            file.ID,
+		BucketID:          bucket.Name,
+		Key:               file.Key,
+		Size:              file.Size,
+		MimeType:          file.MimeType,
+		Metadata:          file.Metadata,
+		CreatedAt:         file.CreatedAt,
+		StorageClass:      file.StorageClass,
+		RestoreExpiresAt:  file.RestoreExpiresAt,
+		Encryption:        file.Encryption,
+		ETag:              file.ETag,
+		ChecksumSHA256:    file.ChecksumSHA256,
+		ChecksumCRC32C:    file.ChecksumCRC32C,
+		ScrubError:        file.ScrubError,
+		RetentionMode:     file.RetentionMode,
+		RetainUntil:       file.RetainUntil,
+		LegalHold:         file.LegalHold,
+		ReplicationStatus: file.ReplicationStatus,
 	}, nil
 }
 
@@ -140,13 +266,24 @@ func (s *UploadService) ListFiles(ctx context.Context, bucketName string) ([]dto
 	var output []dto.FileInfoOutput
 	for _, file := range files {
 		output = append(output, dto.FileInfoOutput{
-			FileID:    file.ID,
-			BucketID:  bucketName,
-			Key:       file.Key,
-			Size:      file.Size,
-			MimeType:  file.MimeType,
-			Metadata:  file.Metadata,
-			CreatedAt: file.CreatedAt,
+			FileID:            file.ID,
+			BucketID:          bucketName,
+			Key:               file.Key,
+			Size:              file.Size,
+			MimeType:          file.MimeType,
+			Metadata:          file.Metadata,
+			CreatedAt:         file.CreatedAt,
+			StorageClass:      file.StorageClass,
+			RestoreExpiresAt:  file.RestoreExpiresAt,
+			Encryption:        file.Encryption,
+			ETag:              file.ETag,
+			ChecksumSHA256:    file.ChecksumSHA256,
+			ChecksumCRC32C:    file.ChecksumCRC32C,
+			ScrubError:        file.ScrubError,
+			RetentionMode:     file.RetentionMode,
+			RetainUntil:       file.RetainUntil,
+			LegalHold:         file.LegalHold,
+			ReplicationStatus: file.ReplicationStatus,
 		})
 	}
 	
@@ -156,50 +293,213 @@ func (s *UploadService) ListFiles(ctx context.Context, bucketName string) ([]dto
 
 
 
-func (s *UploadService) DownloadFile(ctx context.Context, bucketId, fileID string) ([]byte, *dto.FileInfoOutput, error) {
- 
+// StatFile returns the metadata needed to serve a download, including the
+// object's current ETag and modification time in storage. Archived objects
+// have no hot copy to stat, so their details come from the files row.
+func (s *UploadService) StatFile(ctx context.Context, bucketId, fileID string, sse dto.SSEInput) (*dto.FileInfoOutput, error) {
+	bucket, file, err := s.resolveFile(ctx, bucketId, fileID)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := s.encryption.ForRead(file, sse)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &dto.FileInfoOutput{
+		FileID:            file.ID,
+		BucketID:          file.BucketID,
+		Key:               file.Key,
+		Size:              file.Size,
+		MimeType:          file.MimeType,
+		Metadata:          file.Metadata,
+		CreatedAt:         file.CreatedAt,
+		LastModified:      file.CreatedAt,
+		StorageClass:      file.StorageClass,
+		RestoreExpiresAt:  file.RestoreExpiresAt,
+		Encryption:        file.Encryption,
+		SSECustomerKeyMD5: file.SSECustomerKeyMD5,
+		ETag:              file.ETag,
+		ChecksumSHA256:    file.ChecksumSHA256,
+		ChecksumCRC32C:    file.ChecksumCRC32C,
+		ScrubError:        file.ScrubError,
+		RetentionMode:     file.RetentionMode,
+		RetainUntil:       file.RetainUntil,
+		LegalHold:         file.LegalHold,
+		ReplicationStatus: file.ReplicationStatus,
+	}
+	if file.IsArchived(time.Now()) {
+		return output, nil
+	}
+
+	info, err := s.storage.StatObject(ctx, bucket.Name, file.Key, enc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve file: %w", err)
+	}
+	output.Size = info.Size
+	output.LastModified = info.LastModified
+	// The recorded content MD5 is the ETag; the backend's is the fallback
+	// for objects written before checksums were recorded
+	if output.ETag == "" {
+		output.ETag = info.ETag
+	}
+
+	return output, nil
+}
+
+// DownloadFileRange returns a stream of length bytes of the file starting at
+// offset (length -1 reads to the end); the caller must close it.
+func (s *UploadService) DownloadFileRange(ctx context.Context, bucketId, fileID string, offset, length int64, sse dto.SSEInput) (io.ReadCloser, error) {
+	bucket, file, err := s.resolveFile(ctx, bucketId, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.IsArchived(time.Now()) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectArchived, file.Key)
+	}
+	enc, err := s.encryption.ForRead(file, sse)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := s.storage.GetObjectRange(ctx, bucket.Name, file.Key, offset, length, enc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve file: %w", err)
+	}
 
+	return body, nil
+}
 
+func (s *UploadService) resolveFile(ctx context.Context, bucketId, fileID string) (*domain.Bucket, *domain.File, error) {
 	bucket, err := s.repository.GetBucketByID(ctx, bucketId)
 	if err != nil {
 		return nil, nil, fmt.Errorf("bucket not found: %w", err)
 	}
-	
-	// Get file metadata
+
 	file, err := s.repository.GetFileByID(ctx, fileID)
 	if err != nil {
 		return nil, nil, fmt.Errorf("file not found: %w", err)
 	}
 
- 
-	
 	if file.BucketID != bucket.ID {
 		return nil, nil, fmt.Errorf("file not in specified bucket")
 	}
-	
-	// Get file data from storage
-	data, err := s.storage.GetObject(ctx, bucket.Name, file.Key)
+
+	return &bucket, file, nil
+}
+
+
+
+
+// StatObject is StatFile addressed by bucket name and object key.
+func (s *UploadService) StatObject(ctx context.Context, bucketName, key string, sse dto.SSEInput) (*dto.FileInfoOutput, error) {
+	bucket, file, err := s.resolveObject(ctx, bucketName, key)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to retrieve file: %w", err)
+		return nil, err
 	}
-	
-metadata := &dto.FileInfoOutput{
-    FileID:    file.ID,
-    BucketID:  file.BucketID,     // ✅ include this if your File struct has it
-    Key:       file.Key,
-    Size:      file.Size,
-    MimeType:  file.MimeType,
-    Metadata:  file.Metadata,     // ✅ include if available
-    CreatedAt: file.CreatedAt,
+	enc, err := s.encryption.ForRead(file, sse)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &dto.FileInfoOutput{
+		FileID:            file.ID,
+		BucketID:          bucket.Name,
+		Key:               file.Key,
+		Size:              file.Size,
+		MimeType:          file.MediaType(),
+		Metadata:          file.Metadata,
+		CreatedAt:         file.CreatedAt,
+		LastModified:      file.CreatedAt,
+		StorageClass:      file.StorageClass,
+		RestoreExpiresAt:  file.RestoreExpiresAt,
+		Encryption:        file.Encryption,
+		SSECustomerKeyMD5: file.SSECustomerKeyMD5,
+		ETag:              file.ETag,
+		ChecksumSHA256:    file.ChecksumSHA256,
+		ChecksumCRC32C:    file.ChecksumCRC32C,
+		ScrubError:        file.ScrubError,
+		RetentionMode:     file.RetentionMode,
+		RetainUntil:       file.RetainUntil,
+		LegalHold:         file.LegalHold,
+		ReplicationStatus: file.ReplicationStatus,
+	}
+	if file.IsArchived(time.Now()) {
+		return output, nil
+	}
+
+	info, err := s.storage.StatObject(ctx, bucket.Name, file.Key, enc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrObjectNotFound, err)
+	}
+	output.Size = info.Size
+	output.MimeType = info.ContentType
+	output.LastModified = info.LastModified
+	if output.ETag == "" {
+		output.ETag = info.ETag
+	}
+
+	return output, nil
 }
 
-	
-	return data, metadata, nil
+// GetObjectRange is DownloadFileRange addressed by bucket name and object key.
+func (s *UploadService) GetObjectRange(ctx context.Context, bucketName, key string, offset, length int64, sse dto.SSEInput) (io.ReadCloser, error) {
+	bucket, file, err := s.resolveObject(ctx, bucketName, key)
+	if err != nil {
+		return nil, err
+	}
+	if file.IsArchived(time.Now()) {
+		return nil, fmt.Errorf("%w: %s/%s", ErrObjectArchived, bucketName, key)
+	}
+	enc, err := s.encryption.ForRead(file, sse)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := s.storage.GetObjectRange(ctx, bucket.Name, file.Key, offset, length, enc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve file: %w", err)
+	}
+
+	return body, nil
 }
 
+// CopyObject copies srcKey in srcBucket to dstKey in dstBucket, addressed by
+// bucket name and object key. sourceSSE unlocks an SSE-C source.
+func (s *UploadService) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, sourceSSE dto.SSEInput) (*dto.FileInfoOutput, error) {
+	bucket, file, err := s.resolveObject(ctx, srcBucket, srcKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repository.GetBucketByName(ctx, dstBucket); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, dstBucket)
+	}
+
+	if _, err := s.CopyFile(ctx, bucket.ID, file.ID, dto.CopyFileInput{
+		DestinationBucket: dstBucket,
+		NewKey:            dstKey,
+		SourceEncryption:  sourceSSE,
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.StatObject(ctx, dstBucket, dstKey, dto.SSEInput{})
+}
 
+func (s *UploadService) resolveObject(ctx context.Context, bucketName, key string) (*domain.Bucket, *domain.File, error) {
+	bucket, err := s.repository.GetBucketByName(ctx, bucketName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrBucketNotFound, bucketName)
+	}
 
+	file, err := s.repository.GetFileByKey(ctx, bucket.ID, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s/%s", ErrObjectNotFound, bucketName, key)
+	}
 
+	return &bucket, file, nil
+}
 
 func (s *UploadService) UpdateFileMetadata(ctx context.Context, bucketID, fileID string, input dto.UpdateFileMetadataInput) (*dto.FileInfoOutput, error) {
 	bucket, err := s.repository.GetBucketByID(ctx, bucketID)
@@ -222,6 +522,8 @@ func (s *UploadService) UpdateFileMetadata(ctx context.Context, bucketID, fileID
 	if err := s.repository.UpdateFile(ctx, file); err != nil {
 		return nil, fmt.Errorf("failed to update metadata: %w", err)
 	}
+	s.replication.MetadataUpdated(ctx, bucket.ID, file.Key)
+	s.events.ObjectMetadataUpdated(file)
 	
 	return &dto.FileInfoOutput{
 		FileID:    file.ID,
@@ -256,33 +558,74 @@ func (s *UploadService) CopyFile(ctx context.Context, sourceBucketID, fileID str
 		return nil, fmt.Errorf("file not in specified bucket")
 	}
 	
+	if file.IsArchived(time.Now()) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectArchived, file.Key)
+	}
+
 	newKey := input.NewKey
 	if newKey == "" {
 		newKey = file.Key
 	}
- 
+	if err := checkOverwritable(ctx, s.repository, destBucket.ID, newKey); err != nil {
+		return nil, err
+	}
 
+	reservation, err := s.quotas.reserve(ctx, &destBucket, newKey, file.Size)
+	if err != nil {
+		return nil, err
+	}
+	defer reservation.release(ctx)
 
+	srcEnc, dstEnc, err := s.encryption.ForCopy(ctx, destBucket.ID, file, input.SourceEncryption)
+	if err != nil {
+		return nil, err
+	}
 
 	// Copy in storage
-	if err := s.storage.CopyObject(ctx, sourceBucket.Name, file.Key, destBucket.Name, newKey); err != nil {
+	if err := s.storage.CopyObject(ctx, sourceBucket.Name, file.Key, destBucket.Name, newKey, srcEnc, dstEnc); err != nil {
 		return nil, fmt.Errorf("failed to copy file: %w", err)
 	}
+
+	// The copy is a new version of newKey when the destination is versioned
+	var versionID string
+	if versioningEnabled(ctx, s.repository, destBucket.ID) {
+		info, err := s.storage.StatObject(ctx, destBucket.Name, newKey, dstEnc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat copied file: %w", err)
+		}
+		versionID = info.VersionID
+	}
 	
 	// Create new file record
 	newFile := domain.File{
 		ID:        generateID(),
 		BucketID:  destBucket.ID,
-		Key:       newKey,
-		Size:      file.Size,
-		MimeType:  file.MimeType,
-		Metadata:  file.Metadata,
+		Key:         newKey,
+		Size:        file.Size,
+		MimeType:    file.MediaType(),
+		ContentType: file.MediaType(),
+		Metadata:    file.Metadata,
+		Version:     versionID,
 		CreatedAt: time.Now(),
 	}
+	setFileEncryption(&newFile, dstEnc)
+	// Same content, same checksums
+	newFile.ETag, newFile.ChecksumSHA256, newFile.ChecksumCRC32C = file.ETag, file.ChecksumSHA256, file.ChecksumCRC32C
+	// A copy is a new object: it gets the destination's default retention,
+	// not the source's lock
+	if err := applyDefaultRetention(ctx, s.repository, destBucket.ID, &newFile); err != nil {
+		return nil, err
+	}
+	if _, err := recordObjectVersion(ctx, s.repository, &newFile); err != nil {
+		return nil, err
+	}
 	
 	if err := s.repository.SaveFile(ctx, newFile); err != nil {
 		return nil, fmt.Errorf("failed to save file metadata: %w", err)
 	}
+	reservation.commit(ctx, newFile.Size)
+	s.replication.ObjectCreated(ctx, destBucket.ID, newFile.Key)
+	s.events.ObjectCopied(sourceBucket.ID, file.Key, &newFile)
 	
 	return &dto.FileInfoOutput{
 		FileID:    newFile.ID,
@@ -290,8 +633,9 @@ func (s *UploadService) CopyFile(ctx context.Context, sourceBucketID, fileID str
 		Key:       newFile.Key,
 		Size:      newFile.Size,
 		MimeType:  newFile.MimeType,
-		Metadata:  newFile.Metadata,
-		CreatedAt: newFile.CreatedAt,
+		Metadata:   newFile.Metadata,
+		CreatedAt:  newFile.CreatedAt,
+		Encryption: newFile.Encryption,
 	}, nil
 }
 
@@ -323,13 +667,40 @@ func (s *UploadService) MoveFile(ctx context.Context, sourceBucketName, fileID s
 		return nil, fmt.Errorf("file not in specified bucket")
 	}
 	
+	if file.IsArchived(time.Now()) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectArchived, file.Key)
+	}
+
+	// A move removes the source object; its lock travels with the row
+	if err := checkRemovable(file, false); err != nil {
+		return nil, err
+	}
+
 	newKey := input.NewKey
 	if newKey == "" {
 		newKey = file.Key
 	}
+	if err := checkOverwritable(ctx, s.repository, destBucket.ID, newKey); err != nil {
+		return nil, err
+	}
+
+	// Moving within a bucket does not change its usage
+	var reservation *quotaReservation
+	if destBucket.ID != sourceBucket.ID {
+		reservation, err = s.quotas.reserveMove(ctx, &sourceBucket, &destBucket, newKey, file.Size)
+		if err != nil {
+			return nil, err
+		}
+		defer reservation.release(ctx)
+	}
 	
+	srcEnc, dstEnc, err := s.encryption.ForCopy(ctx, destBucket.ID, file, input.SourceEncryption)
+	if err != nil {
+		return nil, err
+	}
+
 	// Copy to destination
-	if err := s.storage.CopyObject(ctx, sourceBucket.Name, file.Key, destBucket.Name, newKey); err != nil {
+	if err := s.storage.CopyObject(ctx, sourceBucket.Name, file.Key, destBucket.Name, newKey, srcEnc, dstEnc); err != nil {
 		return nil, fmt.Errorf("failed to move file: %w", err)
 	}
 	
@@ -337,14 +708,26 @@ func (s *UploadService) MoveFile(ctx context.Context, sourceBucketName, fileID s
 	if err := s.storage.DeleteObject(ctx, sourceBucket.Name, file.Key); err != nil {
 		return nil, fmt.Errorf("failed to delete source file: %w", err)
 	}
+	oldKey := file.Key
 	
 	// Update DB record
 	file.BucketID = destBucket.ID
 	file.Key = newKey
+	file.Encryption, file.EncryptionKey, file.SSECustomerKeyMD5 = "", "", ""
+	setFileEncryption(file, dstEnc)
 	
 	if err := s.repository.UpdateFile(ctx, file); err != nil {
 		return nil, fmt.Errorf("failed to update file metadata: %w", err)
 	}
+	if err := s.repository.UpdateFileEncryption(ctx, file.ID, file.Encryption, file.EncryptionKey, file.SSECustomerKeyMD5); err != nil {
+		return nil, fmt.Errorf("failed to update file encryption: %w", err)
+	}
+	if reservation != nil {
+		reservation.commit(ctx, file.Size)
+	}
+	s.replication.ObjectDeleted(ctx, sourceBucket.ID, oldKey)
+	s.replication.ObjectCreated(ctx, destBucket.ID, file.Key)
+	s.events.ObjectMoved(sourceBucket.ID, oldKey, file)
 	
 	return &dto.FileInfoOutput{
 		FileID:    file.ID,
@@ -353,6 +736,7 @@ func (s *UploadService) MoveFile(ctx context.Context, sourceBucketName, fileID s
 		Size:      file.Size,
 		MimeType:  file.MimeType,
 		Metadata:  file.Metadata,
-		CreatedAt: file.CreatedAt,
+		CreatedAt:  file.CreatedAt,
+		Encryption: file.Encryption,
 	}, nil
-}
\ No newline at end of file
+}
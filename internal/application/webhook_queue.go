@@ -0,0 +1,179 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"s3/internal/domain"
+)
+
+const (
+	// webhookTimeout bounds one delivery attempt
+	webhookTimeout = 10 * time.Second
+	// webhookLease is how long a claimed delivery is held by a worker before
+	// another may pick it up again
+	webhookLease = 2 * time.Minute
+	// maxWebhookResponse is how much of a response body is kept
+	maxWebhookResponse = 64 << 10
+
+	webhookBackoffBase = 10 * time.Second
+	webhookBackoffMax  = time.Hour
+)
+
+// errWebhookInactive dead-letters deliveries to a disabled webhook without
+// sending them
+var errWebhookInactive = errors.New("webhook is inactive")
+
+// WebhookQueueConfig tunes webhook delivery; zero fields take the defaults
+type WebhookQueueConfig struct {
+	// Workers is how many deliveries are sent at once in total
+	Workers int
+	// MaxAttempts is how many times a delivery is tried before it is
+	// dead-lettered
+	MaxAttempts int
+	// EndpointConcurrency is how many deliveries may be in flight to one
+	// webhook at once, unless the webhook sets its own max_concurrency
+	EndpointConcurrency int
+}
+
+func (c WebhookQueueConfig) withDefaults() WebhookQueueConfig {
+	if c.Workers <= 0 {
+		c.Workers = 4
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 8
+	}
+	if c.EndpointConcurrency <= 0 {
+		c.EndpointConcurrency = 2
+	}
+	return c
+}
+
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers queued webhook events with a pool of workers until ctx is
+// done. It looks for due deliveries whenever events are queued or a worker
+// frees up, and at least once per interval for retries.
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	jobs := make(chan domain.WebhookQueueItem)
+	var workers sync.WaitGroup
+	for i := 0; i < s.queue.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for item := range jobs {
+				s.attempt(ctx, &item)
+				s.notify()
+			}
+		}()
+	}
+	defer workers.Wait()
+	defer close(jobs)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.dispatch(ctx, jobs)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// dispatch hands due deliveries to the workers until none can be claimed
+func (s *WebhookService) dispatch(ctx context.Context, jobs chan<- domain.WebhookQueueItem) {
+	for ctx.Err() == nil {
+		items, err := s.repo.ClaimWebhookQueue(ctx, s.queue.Workers, webhookLease, s.queue.EndpointConcurrency)
+		if err != nil {
+			log.Printf("webhooks: %v", err)
+			return
+		}
+		if len(items) == 0 {
+			return
+		}
+
+		for _, item := range items {
+			select {
+			case jobs <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// attempt sends a queued delivery once and records the outcome: DELIVERED,
+// PENDING with a backed-off retry, or DEAD once the attempts are used up
+func (s *WebhookService) attempt(ctx context.Context, item *domain.WebhookQueueItem) {
+	item.Attempts++
+	err := s.send(ctx, item)
+
+	item.NextAttemptAt = time.Now()
+	switch {
+	case err == nil:
+		item.Status = domain.WebhookQueueDelivered
+		item.LastError = ""
+	case item.Attempts >= s.queue.MaxAttempts, errors.Is(err, errWebhookInactive):
+		item.Status = domain.WebhookQueueDead
+		item.LastError = err.Error()
+	default:
+		item.Status = domain.WebhookQueuePending
+		item.LastError = err.Error()
+		item.NextAttemptAt = time.Now().Add(webhookBackoff(item.Attempts))
+	}
+	if err != nil {
+		log.Printf("webhooks: %s %s to webhook %s (attempt %d): %v", item.Event, item.ID, item.WebhookID, item.Attempts, err)
+	}
+
+	if err := s.repo.FinishWebhookQueueItem(ctx, item); err != nil {
+		log.Printf("webhooks: %v", err)
+	}
+}
+
+func (s *WebhookService) send(ctx context.Context, item *domain.WebhookQueueItem) error {
+	webhook, err := s.repo.GetWebhookByID(ctx, item.WebhookID)
+	if err != nil {
+		return fmt.Errorf("webhook not found: %w", err)
+	}
+	if !webhook.Active {
+		return errWebhookInactive
+	}
+
+	delivery, err := s.deliverWebhook(ctx, webhook, item.ID, item.Attempts, item.Event, []byte(item.Payload))
+	if err != nil {
+		return err
+	}
+	if !delivery.Success {
+		return errors.New(delivery.ErrorMessage)
+	}
+	return nil
+}
+
+// webhookBackoff doubles the wait after each failed attempt, from 10s up to
+// an hour, and picks a random point in the upper half of it so retries to a
+// recovering endpoint do not arrive all at once
+func webhookBackoff(attempts int) time.Duration {
+	wait := webhookBackoffBase
+	for i := 1; i < attempts && wait < webhookBackoffMax; i++ {
+		wait *= 2
+	}
+	if wait > webhookBackoffMax {
+		wait = webhookBackoffMax
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
@@ -0,0 +1,31 @@
+package application
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		wait     time.Duration
+	}{
+		{0, 10 * time.Second},
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{9, 2560 * time.Second},
+		{10, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		// The wait is random, so sample it: every delay falls in the upper
+		// half of the window for the attempt
+		for i := 0; i < 100; i++ {
+			got := webhookBackoff(tt.attempts)
+			if got < tt.wait/2 || got > tt.wait {
+				t.Fatalf("webhookBackoff(%d) = %v, want between %v and %v", tt.attempts, got, tt.wait/2, tt.wait)
+			}
+		}
+	}
+}
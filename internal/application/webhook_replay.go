@@ -0,0 +1,127 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+
+	"github.com/google/uuid"
+)
+
+// maxWebhookReplay caps how many events one replay queues again
+const maxWebhookReplay = 1000
+
+// RedeliverWebhook queues the payload of one logged delivery again, as a
+// new event with a fresh set of attempts. The payload is sent unchanged, so
+// consumers see the same event id as before.
+func (s *WebhookService) RedeliverWebhook(ctx context.Context, webhookID, deliveryID string) (*dto.WebhookRedeliveryOutput, error) {
+	webhook, err := s.replayableWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := s.repo.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil || delivery.WebhookID != webhook.ID {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookDeliveryNotFound, deliveryID)
+	}
+
+	item, err := s.requeue(ctx, queuedEvent(delivery))
+	if err != nil {
+		return nil, err
+	}
+	s.notify()
+
+	return &dto.WebhookRedeliveryOutput{DeliveryID: delivery.ID, QueueID: item.ID, Event: item.Event}, nil
+}
+
+// ReplayFailedWebhooks queues every dead-lettered event of the webhook
+// again. An event is replayed only once, so calling it again picks up where
+// a truncated replay stopped.
+func (s *WebhookService) ReplayFailedWebhooks(ctx context.Context, webhookID string) (*dto.WebhookReplayOutput, error) {
+	webhook, err := s.replayableWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := s.repo.ListDeadWebhookDeliveries(ctx, webhook.ID, maxWebhookReplay)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]domain.WebhookQueueItem, len(deliveries))
+	for i := range deliveries {
+		events[i] = *queuedEvent(&deliveries[i])
+	}
+	return s.replay(ctx, webhook.ID, events)
+}
+
+// ReplayWebhooks queues every event queued for the webhook in [from, to)
+// again, whether or not it was delivered or even attempted
+func (s *WebhookService) ReplayWebhooks(ctx context.Context, webhookID string, input dto.WebhookReplayInput) (*dto.WebhookReplayOutput, error) {
+	if !input.To.After(input.From) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidWebhook)
+	}
+	webhook, err := s.replayableWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.repo.ListWebhookQueueBetween(ctx, webhook.ID, input.From, input.To, maxWebhookReplay)
+	if err != nil {
+		return nil, err
+	}
+	return s.replay(ctx, webhook.ID, events)
+}
+
+// replayableWebhook loads a webhook that events can be queued for
+func (s *WebhookService) replayableWebhook(ctx context.Context, webhookID string) (*domain.Webhook, error) {
+	webhook, err := s.repo.GetWebhookByID(ctx, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookNotFound, webhookID)
+	}
+	if !webhook.Active {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhook, errWebhookInactive)
+	}
+	return webhook, nil
+}
+
+func (s *WebhookService) replay(ctx context.Context, webhookID string, events []domain.WebhookQueueItem) (*dto.WebhookReplayOutput, error) {
+	output := &dto.WebhookReplayOutput{WebhookID: webhookID, Truncated: len(events) == maxWebhookReplay}
+	for i := range events {
+		if _, err := s.requeue(ctx, &events[i]); err != nil {
+			log.Printf("webhooks: failed to replay event %s: %v", events[i].ID, err)
+			continue
+		}
+		output.Queued++
+	}
+	if output.Queued > 0 {
+		s.notify()
+	}
+	return output, nil
+}
+
+// requeue queues the stored payload of a queued event again as a replay of it
+func (s *WebhookService) requeue(ctx context.Context, event *domain.WebhookQueueItem) (*domain.WebhookQueueItem, error) {
+	item := &domain.WebhookQueueItem{
+		ID:        uuid.New().String(),
+		WebhookID: event.WebhookID,
+		Event:     event.Event,
+		Payload:   event.Payload,
+		ReplayOf:  event.ID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.EnqueueWebhook(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// queuedEvent is the queued event a logged delivery was an attempt for
+func queuedEvent(delivery *domain.WebhookDelivery) *domain.WebhookQueueItem {
+	return &domain.WebhookQueueItem{
+		ID:        delivery.QueueID,
+		WebhookID: delivery.WebhookID,
+		Event:     delivery.Event,
+		Payload:   delivery.Payload,
+	}
+}
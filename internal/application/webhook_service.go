@@ -3,45 +3,99 @@ package application
 import (
 	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
+	"s3/pkg/webhooksig"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidWebhook          = errors.New("invalid webhook")
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookSecretForbidden  = errors.New("forbidden: only the bucket owner can rotate the webhook secret")
+)
+
+// EventWebhookTest is sent by TestWebhook
+const EventWebhookTest = "webhook.test"
+
+const (
+	// defaultWebhookSecretGrace is how long a rotated-out secret keeps signing
+	defaultWebhookSecretGrace = 24 * time.Hour
+	// maxWebhookFilterKey caps the key prefix and suffix of a filter
+	maxWebhookFilterKey = 1024
+)
+
+// webhookEvents are the events a webhook can subscribe to. A subscription
+// may also be "*" for every event or end in ".*" for a family of them, such
+// as "object.*".
+var webhookEvents = []string{
+	domain.EventObjectCreated,
+	domain.EventObjectDeleted,
+	domain.EventObjectCopied,
+	domain.EventObjectMoved,
+	domain.EventObjectMetadataUpdated,
+	domain.EventMultipartCompleted,
+	domain.EventBucketUpdated,
+	domain.EventBucketDeleted,
+	domain.EventPolicyUpdated,
+	EventQuotaWarning,
+}
+
+// WebhookService manages webhooks and delivers events to them. Events are
+// stored in a queue before they are sent and delivered by the workers of
+// Run, with retries; see webhook_queue.go.
 type WebhookService struct {
-	repo domain.RepositoryPort
+	repo  domain.RepositoryPort
+	queue WebhookQueueConfig
+
+	// wake nudges the delivery workers when events are queued
+	wake chan struct{}
 }
 
-func NewWebhookService(repo domain.RepositoryPort) *WebhookService {
-	return &WebhookService{repo: repo}
+func NewWebhookService(repo domain.RepositoryPort, queue WebhookQueueConfig) *WebhookService {
+	return &WebhookService{repo: repo, queue: queue.withDefaults(), wake: make(chan struct{}, 1)}
 }
 
 func (s *WebhookService) CreateWebhook(ctx context.Context, input dto.CreateWebhookInput) (*dto.CreateWebhookOutput, error) {
+	if err := validateWebhookEvents(input.Events); err != nil {
+		return nil, err
+	}
+	filter, err := newWebhookFilter(input.Filter)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetBucketByID(ctx, input.BucketID); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, input.BucketID)
+	}
+
 	secret := input.Secret
 	if secret == "" {
 		secret = generateSecret()
 	}
 
 	webhook := &domain.Webhook{
-		ID:        uuid.New().String(),
-		BucketID:  input.BucketID,
-		Name:      input.Name,
-		URL:       input.URL,
-		Events:    input.Events,
-		Secret:    secret,
-		Active:    true,
-		Headers:   input.Headers,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:             uuid.New().String(),
+		BucketID:       input.BucketID,
+		Name:           input.Name,
+		URL:            input.URL,
+		Events:         input.Events,
+		Secret:         secret,
+		Active:         true,
+		Headers:        input.Headers,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+		MaxConcurrency: input.MaxConcurrency,
+		Filter:         filter,
 	}
 
 	if err := s.repo.SaveWebhook(ctx, webhook); err != nil {
@@ -66,12 +120,14 @@ func (s *WebhookService) ListWebhooks(ctx context.Context, bucketID string) (*dt
 	webhookInfos := make([]dto.WebhookInfo, len(webhooks))
 	for i, wh := range webhooks {
 		webhookInfos[i] = dto.WebhookInfo{
-			ID:        wh.ID,
-			Name:      wh.Name,
-			URL:       wh.URL,
-			Events:    wh.Events,
-			Active:    wh.Active,
-			CreatedAt: wh.CreatedAt,
+			ID:             wh.ID,
+			Name:           wh.Name,
+			URL:            wh.URL,
+			Events:         wh.Events,
+			Active:         wh.Active,
+			CreatedAt:      wh.CreatedAt,
+			MaxConcurrency: wh.MaxConcurrency,
+			Filter:         webhookFilterInfo(wh.Filter),
 		}
 	}
 
@@ -88,18 +144,35 @@ func (s *WebhookService) GetWebhook(ctx context.Context, webhookID string) (*dto
 	}
 
 	return &dto.GetWebhookOutput{
-		ID:        webhook.ID,
-		BucketID:  webhook.BucketID,
-		Name:      webhook.Name,
-		URL:       webhook.URL,
-		Events:    webhook.Events,
-		Active:    webhook.Active,
-		Headers:   webhook.Headers,
-		CreatedAt: webhook.CreatedAt,
-		UpdatedAt: webhook.UpdatedAt,
+		ID:                      webhook.ID,
+		BucketID:                webhook.BucketID,
+		Name:                    webhook.Name,
+		URL:                     webhook.URL,
+		Events:                  webhook.Events,
+		Active:                  webhook.Active,
+		Headers:                 webhook.Headers,
+		CreatedAt:               webhook.CreatedAt,
+		UpdatedAt:               webhook.UpdatedAt,
+		MaxConcurrency:          webhook.MaxConcurrency,
+		Filter:                  webhookFilterInfo(webhook.Filter),
+		PreviousSecretExpiresAt: rotationEnd(webhook, time.Now()),
 	}, nil
 }
 
+// WebhookBucket returns the id of the bucket a webhook belongs to, so requests
+// on the webhook can be authorized against that bucket. A webhook whose bucket
+// no longer exists is reported as not found.
+func (s *WebhookService) WebhookBucket(ctx context.Context, webhookID string) (string, error) {
+	webhook, err := s.repo.GetWebhookByID(ctx, webhookID)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrWebhookNotFound, webhookID)
+	}
+	if _, err := s.repo.GetBucketByID(ctx, webhook.BucketID); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrWebhookNotFound, webhookID)
+	}
+	return webhook.BucketID, nil
+}
+
 func (s *WebhookService) UpdateWebhook(ctx context.Context, webhookID string, input dto.UpdateWebhookInput) error {
 	webhook, err := s.repo.GetWebhookByID(ctx, webhookID)
 	if err != nil {
@@ -113,6 +186,9 @@ func (s *WebhookService) UpdateWebhook(ctx context.Context, webhookID string, in
 		webhook.URL = *input.URL
 	}
 	if input.Events != nil {
+		if err := validateWebhookEvents(input.Events); err != nil {
+			return err
+		}
 		webhook.Events = input.Events
 	}
 	if input.Active != nil {
@@ -121,6 +197,16 @@ func (s *WebhookService) UpdateWebhook(ctx context.Context, webhookID string, in
 	if input.Headers != nil {
 		webhook.Headers = input.Headers
 	}
+	if input.MaxConcurrency != nil {
+		webhook.MaxConcurrency = *input.MaxConcurrency
+	}
+	if input.Filter != nil {
+		filter, err := newWebhookFilter(input.Filter)
+		if err != nil {
+			return err
+		}
+		webhook.Filter = filter
+	}
 
 	webhook.UpdatedAt = time.Now()
 
@@ -131,6 +217,56 @@ func (s *WebhookService) UpdateWebhook(ctx context.Context, webhookID string, in
 	return nil
 }
 
+// RotateWebhookSecret replaces the webhook's secret. Until the grace period
+// ends deliveries are signed with both the new and the old secret; a second
+// rotation within it drops the oldest secret. The new secret is returned, so
+// only the owner of the webhook's bucket may rotate it.
+func (s *WebhookService) RotateWebhookSecret(ctx context.Context, webhookID, actor string, input dto.RotateWebhookSecretInput) (*dto.RotateWebhookSecretOutput, error) {
+	webhook, err := s.repo.GetWebhookByID(ctx, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookNotFound, webhookID)
+	}
+	bucket, err := s.repo.GetBucketByID(ctx, webhook.BucketID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookNotFound, webhookID)
+	}
+	if actor != "user:"+bucket.OwnerID {
+		return nil, ErrWebhookSecretForbidden
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		secret = generateSecret()
+	}
+	if secret == webhook.Secret {
+		return nil, fmt.Errorf("%w: the new secret must differ from the current one", ErrInvalidWebhook)
+	}
+	grace := defaultWebhookSecretGrace
+	if input.GracePeriodHours != nil {
+		grace = time.Duration(*input.GracePeriodHours) * time.Hour
+	}
+
+	now := time.Now()
+	webhook.PreviousSecret, webhook.PreviousSecretExpiresAt = "", nil
+	if grace > 0 {
+		expiresAt := now.Add(grace)
+		webhook.PreviousSecret, webhook.PreviousSecretExpiresAt = webhook.Secret, &expiresAt
+	}
+	webhook.Secret = secret
+	webhook.UpdatedAt = now
+
+	if err := s.repo.UpdateWebhook(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+
+	return &dto.RotateWebhookSecretOutput{
+		ID:                      webhook.ID,
+		Secret:                  secret,
+		PreviousSecretExpiresAt: webhook.PreviousSecretExpiresAt,
+		RotatedAt:               now,
+	}, nil
+}
+
 func (s *WebhookService) DeleteWebhook(ctx context.Context, webhookID string) error {
 	if err := s.repo.DeleteWebhook(ctx, webhookID); err != nil {
 		return fmt.Errorf("failed to delete webhook: %w", err)
@@ -144,15 +280,12 @@ func (s *WebhookService) TestWebhook(ctx context.Context, webhookID string) (*dt
 		return nil, fmt.Errorf("webhook not found: %w", err)
 	}
 
-	testPayload := map[string]interface{}{
-		"event": "webhook.test",
-		"timestamp": time.Now().Unix(),
-		"data": map[string]string{
-			"message": "This is a test webhook delivery",
-		},
-	}
+	testPayload := newWebhookPayload(uuid.New().String(), EventWebhookTest, webhook.BucketID, time.Now(), map[string]string{
+		"message": "This is a test webhook delivery",
+	})
 
-	delivery, err := s.deliverWebhook(ctx, webhook, "webhook.test", testPayload)
+	body, _ := json.Marshal(testPayload)
+	delivery, err := s.deliverWebhook(ctx, webhook, "", 1, EventWebhookTest, body)
 	if err != nil {
 		return &dto.TestWebhookOutput{
 			Success:      false,
@@ -169,8 +302,36 @@ func (s *WebhookService) TestWebhook(ctx context.Context, webhookID string) (*dt
 	}, nil
 }
 
-func (s *WebhookService) GetWebhookDeliveries(ctx context.Context, webhookID string) (*dto.WebhookDeliveriesOutput, error) {
-	deliveries, err := s.repo.ListWebhookDeliveries(ctx, webhookID, 50)
+// GetWebhookDeliveries returns a page of the webhook's delivery log, newest
+// first. event may be an event type or a family such as "object.*", and
+// status "success" or "failed"; empty matches everything.
+func (s *WebhookService) GetWebhookDeliveries(ctx context.Context, webhookID, event, status string, limit, offset int) (*dto.WebhookDeliveriesOutput, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	filter := domain.WebhookDeliveryFilter{Limit: limit, Offset: offset}
+
+	if event != "" && event != "*" {
+		if !eventMatches(event, EventWebhookTest) {
+			if err := validateWebhookEvents([]string{event}); err != nil {
+				return nil, err
+			}
+		}
+		filter.Event = event
+	}
+	switch status {
+	case "":
+	case "success", "failed":
+		success := status == "success"
+		filter.Success = &success
+	default:
+		return nil, fmt.Errorf("%w: status must be success or failed", ErrInvalidWebhook)
+	}
+
+	deliveries, total, err := s.repo.ListWebhookDeliveries(ctx, webhookID, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get deliveries: %w", err)
 	}
@@ -179,6 +340,8 @@ func (s *WebhookService) GetWebhookDeliveries(ctx context.Context, webhookID str
 	for i, d := range deliveries {
 		deliveryInfos[i] = dto.WebhookDeliveryInfo{
 			ID:           d.ID,
+			QueueID:      d.QueueID,
+			Attempt:      d.Attempt,
 			Event:        d.Event,
 			StatusCode:   d.StatusCode,
 			Success:      d.Success,
@@ -189,107 +352,273 @@ func (s *WebhookService) GetWebhookDeliveries(ctx context.Context, webhookID str
 
 	return &dto.WebhookDeliveriesOutput{
 		Deliveries: deliveryInfos,
-		Total:      len(deliveries),
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
 	}, nil
 }
 
-func (s *WebhookService) TriggerWebhook(ctx context.Context, bucketID, event string, payload interface{}) {
+// TriggerWebhook queues event with data for the bucket's active webhooks
+// that subscribe to it
+func (s *WebhookService) TriggerWebhook(ctx context.Context, bucketID, event string, data interface{}) {
+	s.trigger(ctx, newWebhookPayload(uuid.New().String(), event, bucketID, time.Now(), data), nil)
+}
+
+// triggerEvent sends an event from the event bus, keeping its id so
+// consumers of both can tell they saw the same event
+func (s *WebhookService) triggerEvent(ctx context.Context, event domain.Event) {
+	data := dto.WebhookEventData{
+		Actor:   event.Actor,
+		Object:  webhookObject(event.Object),
+		Source:  webhookObject(event.Source),
+		Details: event.Data,
+	}
+	s.trigger(ctx, newWebhookPayload(event.ID, event.Type, event.BucketID, event.Time, data), event.Object)
+}
+
+// trigger queues payload for the bucket's webhooks that subscribe to it and
+// whose filter matches object, the object it is about, if any
+func (s *WebhookService) trigger(ctx context.Context, payload dto.WebhookPayload, object *domain.EventObject) {
+	bucketID, event := payload.BucketID, payload.Event
 	webhooks, err := s.repo.ListWebhooksByBucket(ctx, bucketID)
 	if err != nil {
+		log.Printf("webhooks: failed to list webhooks of %s for %s: %v", bucketID, event, err)
+		return
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("webhooks: failed to encode %s %s: %v", event, payload.ID, err)
 		return
 	}
 
+	queued := false
 	for _, webhook := range webhooks {
 		if !webhook.Active {
 			continue
 		}
 
-		if !containsEvent(webhook.Events, event) {
+		if !subscribesTo(webhook.Events, event) {
 			continue
 		}
 
-		go func(wh domain.Webhook) {
-			s.deliverWebhook(context.Background(), &wh, event, payload)
-		}(webhook)
+		if !webhook.Filter.Matches(object) {
+			continue
+		}
+
+		item := &domain.WebhookQueueItem{
+			ID:        uuid.New().String(),
+			WebhookID: webhook.ID,
+			Event:     event,
+			Payload:   string(body),
+			CreatedAt: time.Now(),
+		}
+		if err := s.repo.EnqueueWebhook(ctx, item); err != nil {
+			log.Printf("webhooks: %s %s for webhook %s: %v", event, payload.ID, webhook.ID, err)
+			continue
+		}
+		queued = true
+	}
+	if queued {
+		s.notify()
 	}
 }
 
-func (s *WebhookService) deliverWebhook(ctx context.Context, webhook *domain.Webhook, event string, payload interface{}) (*domain.WebhookDelivery, error) {
-	payloadBytes, _ := json.Marshal(payload)
+// deliverWebhook makes one attempt to POST body to the webhook and logs it
+// in webhook_deliveries. queueID is empty for test deliveries.
+func (s *WebhookService) deliverWebhook(ctx context.Context, webhook *domain.Webhook, queueID string, attempt int, event string, payloadBytes []byte) (*domain.WebhookDelivery, error) {
 	payloadStr := string(payloadBytes)
+	record := func(delivery *domain.WebhookDelivery) *domain.WebhookDelivery {
+		delivery.ID = uuid.New().String()
+		delivery.WebhookID = webhook.ID
+		delivery.QueueID = queueID
+		delivery.Attempt = attempt
+		delivery.Event = event
+		delivery.Payload = payloadStr
+		delivery.DeliveredAt = time.Now()
+		if err := s.repo.SaveWebhookDelivery(ctx, delivery); err != nil {
+			log.Printf("webhooks: failed to log delivery to %s: %v", webhook.ID, err)
+		}
+		return delivery
+	}
 
-	signature := generateSignature(webhook.Secret, payloadBytes)
+	deliveryID := queueID
+	if deliveryID == "" {
+		deliveryID = uuid.New().String()
+	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", webhook.URL, bytes.NewBuffer(payloadBytes))
 	if err != nil {
-		return s.saveFailedDelivery(ctx, webhook.ID, event, payloadStr, err.Error()), err
+		return record(&domain.WebhookDelivery{ErrorMessage: err.Error()}), err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Webhook-Signature", signature)
-	req.Header.Set("X-Webhook-Event", event)
-
 	for k, v := range webhook.Headers {
 		req.Header.Set(k, v)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhooksig.SignatureHeader, webhooksig.Header(now, deliveryID, payloadBytes, webhook.SigningSecrets(now)...))
+	req.Header.Set(webhooksig.DeliveryHeader, deliveryID)
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Version", dto.WebhookPayloadVersion)
+
+	client := &http.Client{Timeout: webhookTimeout}
 	resp, err := client.Do(req)
 	if err != nil {
-		return s.saveFailedDelivery(ctx, webhook.ID, event, payloadStr, err.Error()), err
+		return record(&domain.WebhookDelivery{ErrorMessage: err.Error()}), err
 	}
 	defer resp.Body.Close()
 
-	responseBody, _ := io.ReadAll(resp.Body)
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponse))
 
 	delivery := &domain.WebhookDelivery{
-		ID:          uuid.New().String(),
-		WebhookID:   webhook.ID,
-		Event:       event,
-		Payload:     payloadStr,
-		StatusCode:  resp.StatusCode,
-		Response:    string(responseBody),
-		Success:     resp.StatusCode >= 200 && resp.StatusCode < 300,
-		DeliveredAt: time.Now(),
+		StatusCode: resp.StatusCode,
+		Response:   string(responseBody),
+		Success:    resp.StatusCode >= 200 && resp.StatusCode < 300,
 	}
 
 	if !delivery.Success {
 		delivery.ErrorMessage = fmt.Sprintf("HTTP %d", resp.StatusCode)
 	}
 
-	s.repo.SaveWebhookDelivery(ctx, delivery)
-	return delivery, nil
+	return record(delivery), nil
 }
 
-func (s *WebhookService) saveFailedDelivery(ctx context.Context, webhookID, event, payload, errorMsg string) *domain.WebhookDelivery {
-	delivery := &domain.WebhookDelivery{
-		ID:           uuid.New().String(),
-		WebhookID:    webhookID,
-		Event:        event,
-		Payload:      payload,
-		Success:      false,
-		ErrorMessage: errorMsg,
-		DeliveredAt:  time.Now(),
-	}
-	s.repo.SaveWebhookDelivery(ctx, delivery)
-	return delivery
+// rotationEnd is when the webhook's previous secret stops signing, or nil
+// outside a rotation
+func rotationEnd(webhook *domain.Webhook, now time.Time) *time.Time {
+	if len(webhook.SigningSecrets(now)) < 2 {
+		return nil
+	}
+	return webhook.PreviousSecretExpiresAt
 }
 
 func generateSecret() string {
 	return uuid.New().String()
 }
 
-func generateSignature(secret string, payload []byte) string {
-	h := hmac.New(sha256.New, []byte(secret))
-	h.Write(payload)
-	return hex.EncodeToString(h.Sum(nil))
+func newWebhookPayload(id, event, bucketID string, at time.Time, data interface{}) dto.WebhookPayload {
+	return dto.WebhookPayload{
+		Version:   dto.WebhookPayloadVersion,
+		ID:        id,
+		Event:     event,
+		Timestamp: at.Unix(),
+		Time:      at.UTC(),
+		BucketID:  bucketID,
+		Data:      data,
+	}
 }
 
-func containsEvent(events []string, event string) bool {
-	for _, e := range events {
-		if e == event {
+func webhookObject(object *domain.EventObject) *dto.WebhookObject {
+	if object == nil {
+		return nil
+	}
+	return &dto.WebhookObject{
+		BucketID:     object.BucketID,
+		Key:          object.Key,
+		FileID:       object.FileID,
+		VersionID:    object.VersionID,
+		Size:         object.Size,
+		ETag:         object.ETag,
+		ContentType:  object.ContentType,
+		StorageClass: object.StorageClass,
+	}
+}
+
+// subscribesTo reports whether any of the subscriptions matches event
+func subscribesTo(subscriptions []string, event string) bool {
+	for _, subscription := range subscriptions {
+		if eventMatches(subscription, event) {
 			return true
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+
+// eventMatches reports whether a subscription covers event: an exact name,
+// "*" for every event, or "family.*" for every event in the family
+func eventMatches(subscription, event string) bool {
+	if subscription == "*" || subscription == event {
+		return true
+	}
+	if family, ok := strings.CutSuffix(subscription, ".*"); ok {
+		return strings.HasPrefix(event, family+".")
+	}
+	return false
+}
+
+// validateWebhookEvents rejects subscriptions that can never match an event
+func validateWebhookEvents(subscriptions []string) error {
+	if len(subscriptions) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrInvalidWebhook)
+	}
+	for _, subscription := range subscriptions {
+		matched := false
+		for _, event := range webhookEvents {
+			if eventMatches(subscription, event) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, subscription)
+		}
+	}
+	return nil
+}
+
+// newWebhookFilter validates a filter from the API; an empty one is nil
+func newWebhookFilter(input *dto.WebhookFilter) (*domain.WebhookFilter, error) {
+	if input == nil {
+		return nil, nil
+	}
+	filter := &domain.WebhookFilter{
+		KeyPrefix:   input.KeyPrefix,
+		KeySuffix:   input.KeySuffix,
+		ContentType: strings.ToLower(strings.TrimSpace(input.ContentType)),
+		MinSize:     input.MinSize,
+		MaxSize:     input.MaxSize,
+		Metadata:    input.Metadata,
+	}
+	if filter.IsEmpty() {
+		return nil, nil
+	}
+
+	if len(filter.KeyPrefix) > maxWebhookFilterKey || len(filter.KeySuffix) > maxWebhookFilterKey {
+		return nil, fmt.Errorf("%w: key_prefix and key_suffix may be at most %d bytes", ErrInvalidWebhook, maxWebhookFilterKey)
+	}
+	if filter.ContentType != "" {
+		family, subtype, ok := strings.Cut(filter.ContentType, "/")
+		if !ok || family == "" || family == "*" || subtype == "" || strings.ContainsAny(filter.ContentType, " ;,") ||
+			(strings.Contains(subtype, "*") && subtype != "*") {
+			return nil, fmt.Errorf("%w: content_type must be a media type such as image/jpeg or image/*", ErrInvalidWebhook)
+		}
+	}
+	if (filter.MinSize != nil && *filter.MinSize < 0) || (filter.MaxSize != nil && *filter.MaxSize < 0) {
+		return nil, fmt.Errorf("%w: min_size and max_size must not be negative", ErrInvalidWebhook)
+	}
+	if filter.MinSize != nil && filter.MaxSize != nil && *filter.MinSize > *filter.MaxSize {
+		return nil, fmt.Errorf("%w: min_size must not be more than max_size", ErrInvalidWebhook)
+	}
+	for key := range filter.Metadata {
+		if strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("%w: metadata keys must not be empty", ErrInvalidWebhook)
+		}
+	}
+	return filter, nil
+}
+
+func webhookFilterInfo(filter *domain.WebhookFilter) *dto.WebhookFilter {
+	if filter == nil {
+		return nil
+	}
+	return &dto.WebhookFilter{
+		KeyPrefix:   filter.KeyPrefix,
+		KeySuffix:   filter.KeySuffix,
+		ContentType: filter.ContentType,
+		MinSize:     filter.MinSize,
+		MaxSize:     filter.MaxSize,
+		Metadata:    filter.Metadata,
+	}
+}
@@ -0,0 +1,118 @@
+package application
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+)
+
+func TestEventMatches(t *testing.T) {
+	tests := []struct {
+		subscription string
+		event        string
+		want         bool
+	}{
+		{"object.created", "object.created", true},
+		{"object.created", "object.deleted", false},
+		{"*", "bucket.deleted", true},
+		{"object.*", "object.metadata_updated", true},
+		{"object.*", "multipart.completed", false},
+		{"object.*", "object", false},
+		{"obj.*", "object.created", false},
+		{"object.created.*", "object.created", false},
+		{"", "object.created", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subscription+" "+tt.event, func(t *testing.T) {
+			if got := eventMatches(tt.subscription, tt.event); got != tt.want {
+				t.Errorf("eventMatches(%q, %q) = %v, want %v", tt.subscription, tt.event, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWebhookEvents(t *testing.T) {
+	tests := []struct {
+		name          string
+		subscriptions []string
+		wantErr       bool
+	}{
+		{"exact events", []string{domain.EventObjectCreated, domain.EventBucketDeleted}, false},
+		{"every event", []string{"*"}, false},
+		{"event family", []string{"object.*", "multipart.*"}, false},
+		{"quota warnings", []string{EventQuotaWarning}, false},
+		{"no events", nil, true},
+		{"unknown event", []string{domain.EventObjectCreated, "object.renamed"}, true},
+		{"unknown family", []string{"user.*"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWebhookEvents(tt.subscriptions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateWebhookEvents(%q) = %v, want error %v", tt.subscriptions, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidWebhook) {
+				t.Errorf("validateWebhookEvents(%q) = %v, want %v", tt.subscriptions, err, ErrInvalidWebhook)
+			}
+		})
+	}
+}
+
+func TestNewWebhookFilter(t *testing.T) {
+	size := func(n int64) *int64 { return &n }
+
+	tests := []struct {
+		name    string
+		input   *dto.WebhookFilter
+		want    *domain.WebhookFilter
+		wantErr bool
+	}{
+		{"no filter", nil, nil, false},
+		{"empty filter", &dto.WebhookFilter{}, nil, false},
+		{"key conditions", &dto.WebhookFilter{KeyPrefix: "logs/", KeySuffix: ".gz"}, &domain.WebhookFilter{KeyPrefix: "logs/", KeySuffix: ".gz"}, false},
+		{"content type is normalised", &dto.WebhookFilter{ContentType: " Image/JPEG "}, &domain.WebhookFilter{ContentType: "image/jpeg"}, false},
+		{"content type family", &dto.WebhookFilter{ContentType: "image/*"}, &domain.WebhookFilter{ContentType: "image/*"}, false},
+		{"size range", &dto.WebhookFilter{MinSize: size(0), MaxSize: size(0)}, &domain.WebhookFilter{MinSize: size(0), MaxSize: size(0)}, false},
+		{"long key prefix", &dto.WebhookFilter{KeyPrefix: strings.Repeat("a", maxWebhookFilterKey+1)}, nil, true},
+		{"long key suffix", &dto.WebhookFilter{KeySuffix: strings.Repeat("a", maxWebhookFilterKey+1)}, nil, true},
+		{"content type without subtype", &dto.WebhookFilter{ContentType: "image"}, nil, true},
+		{"content type without family", &dto.WebhookFilter{ContentType: "/jpeg"}, nil, true},
+		{"every content type", &dto.WebhookFilter{ContentType: "*/*"}, nil, true},
+		{"partial subtype wildcard", &dto.WebhookFilter{ContentType: "image/jp*"}, nil, true},
+		{"content type with parameters", &dto.WebhookFilter{ContentType: "text/plain; charset=utf-8"}, nil, true},
+		{"content type list", &dto.WebhookFilter{ContentType: "image/png,image/gif"}, nil, true},
+		{"negative min size", &dto.WebhookFilter{MinSize: size(-1)}, nil, true},
+		{"negative max size", &dto.WebhookFilter{MaxSize: size(-1)}, nil, true},
+		{"min size above max size", &dto.WebhookFilter{MinSize: size(2), MaxSize: size(1)}, nil, true},
+		{"empty metadata key", &dto.WebhookFilter{Metadata: map[string]string{" ": "x"}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newWebhookFilter(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newWebhookFilter() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidWebhook) {
+					t.Errorf("newWebhookFilter() error = %v, want %v", err, ErrInvalidWebhook)
+				}
+				return
+			}
+			if !sameWebhookFilter(got, tt.want) {
+				t.Errorf("newWebhookFilter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func sameWebhookFilter(a, b *domain.WebhookFilter) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	sameSize := func(x, y *int64) bool { return (x == nil) == (y == nil) && (x == nil || *x == *y) }
+	return a.KeyPrefix == b.KeyPrefix && a.KeySuffix == b.KeySuffix && a.ContentType == b.ContentType &&
+		sameSize(a.MinSize, b.MinSize) && sameSize(a.MaxSize, b.MaxSize) && len(a.Metadata) == len(b.Metadata)
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+const (
+	AccessKeyStatusActive   = "active"
+	AccessKeyStatusInactive = "inactive"
+)
+
+// AccessKey is an S3-style credential pair owned by a user. SigV4 needs the
+// secret itself to derive the signing key, so SecretKey holds it sealed
+// (encrypted) rather than hashed; SecretHash is the SHA-256 of the plain
+// secret and is what x-api-key requests are matched against.
+type AccessKey struct {
+	AccessKeyID string     `json:"access_key_id"`
+	SecretKey   string     `json:"-"`
+	SecretHash  string     `json:"-"`
+	UserID      string     `json:"user_id"`
+	Status      string     `json:"status"` // active, inactive
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+}
+
+func (k *AccessKey) IsActive() bool {
+	return k.Status == AccessKeyStatusActive
+}
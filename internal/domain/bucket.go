@@ -11,10 +11,3 @@ type Bucket struct {
     UpdatedAt time.Time `json:"updated_at"`
     Policy    *Policy   `json:"policy,omitempty"`
 }
-
-
-// Example domain method — pure logic, no SDK
-func (b *Bucket) CanStore(size int64) bool {
-    // limit max bucket size or apply some quota logic
-    return size < 5_000_000_000 // 5GB
-}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"strings"
+)
+
+// Checksums of an object's content, hex encoded. MD5 is also the ETag of
+// objects uploaded in one request; CRC32C is only computed on request.
+type Checksums struct {
+	MD5    string
+	SHA256 string
+	CRC32C string
+}
+
+// Mismatch compares c against expected, skipping checksums either side does
+// not have, and returns the name of the first one that differs or "".
+func (c Checksums) Mismatch(expected Checksums) string {
+	differs := func(got, want string) bool {
+		return got != "" && want != "" && !strings.EqualFold(got, want)
+	}
+	switch {
+	case differs(c.MD5, expected.MD5):
+		return "MD5"
+	case differs(c.SHA256, expected.SHA256):
+		return "SHA256"
+	case differs(c.CRC32C, expected.CRC32C):
+		return "CRC32C"
+	}
+	return ""
+}
+
+// Checksums returns the stored checksums of the file. Multipart ETags are
+// not a digest of the content and are left out.
+func (f *File) Checksums() Checksums {
+	sums := Checksums{SHA256: f.ChecksumSHA256, CRC32C: f.ChecksumCRC32C}
+	if f.ETag != "" && !strings.Contains(f.ETag, "-") {
+		sums.MD5 = f.ETag
+	}
+	return sums
+}
+
+// HasChecksums reports whether the file has a content digest to verify
+func (f *File) HasChecksums() bool {
+	sums := f.Checksums()
+	return sums.MD5 != "" || sums.SHA256 != ""
+}
+
+// ChecksumHasher computes object checksums while the content is written
+// through it
+type ChecksumHasher struct {
+	md5    hash.Hash
+	sha256 hash.Hash
+	crc32c hash.Hash32 // nil unless requested
+}
+
+func NewChecksumHasher(withCRC32C bool) *ChecksumHasher {
+	h := &ChecksumHasher{md5: md5.New(), sha256: sha256.New()}
+	if withCRC32C {
+		h.crc32c = crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	}
+	return h
+}
+
+func (h *ChecksumHasher) Write(p []byte) (int, error) {
+	h.md5.Write(p)
+	h.sha256.Write(p)
+	if h.crc32c != nil {
+		h.crc32c.Write(p)
+	}
+	return len(p), nil
+}
+
+// Sum returns the checksums of everything written so far
+func (h *ChecksumHasher) Sum() Checksums {
+	sums := Checksums{
+		MD5:    hex.EncodeToString(h.md5.Sum(nil)),
+		SHA256: hex.EncodeToString(h.sha256.Sum(nil)),
+	}
+	if h.crc32c != nil {
+		sums.CRC32C = hex.EncodeToString(h.crc32c.Sum(nil))
+	}
+	return sums
+}
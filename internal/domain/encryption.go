@@ -0,0 +1,28 @@
+package domain
+
+// Server-side encryption modes recorded on files. AES256 objects are
+// encrypted with server-managed keys; SSE-C objects with a key the client
+// sends on every request.
+const (
+	EncryptionNone  = ""
+	EncryptionSSES3 = "AES256"
+	EncryptionSSEC  = "SSE-C"
+)
+
+// ObjectEncryption is the key an object is written or read with. Storage
+// sees both modes as a customer key: server-managed objects use a random
+// per-object data key that is only stored wrapped by the master key.
+type ObjectEncryption struct {
+	Mode string
+	// Key is the 256-bit AES key handed to storage
+	Key []byte
+	// WrappedKey is Key sealed with the master key (AES256 only)
+	WrappedKey string
+	// KeyMD5 is the base64 MD5 of the customer key (SSE-C only)
+	KeyMD5 string
+}
+
+// IsEncrypted reports whether the file is stored with server-side encryption
+func (f *File) IsEncrypted() bool {
+	return f.Encryption != EncryptionNone
+}
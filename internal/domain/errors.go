@@ -0,0 +1,7 @@
+package domain
+
+import "errors"
+
+// ErrNotFound is returned by RepositoryPort implementations when a record
+// does not exist, so services can tell a miss from a failure.
+var ErrNotFound = errors.New("record not found")
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// Event types published on the event bus and to webhooks; the type is also
+// the topic
+const (
+	EventObjectCreated         = "object.created"
+	EventObjectDeleted         = "object.deleted"
+	EventObjectCopied          = "object.copied"
+	EventObjectMoved           = "object.moved"
+	EventObjectMetadataUpdated = "object.metadata_updated"
+	EventMultipartCompleted    = "multipart.completed"
+	EventBucketCreated         = "bucket.created"
+	EventBucketUpdated         = "bucket.updated"
+	EventBucketDeleted         = "bucket.deleted"
+	EventPolicyUpdated         = "policy.updated"
+)
+
+// Event is a change to a bucket or one of its objects, as published to
+// downstream consumers. Object is set for object events and Source for
+// copies and moves.
+type Event struct {
+	ID       string                 `json:"id"`
+	Type     string                 `json:"type"`
+	Time     time.Time              `json:"time"`
+	BucketID string                 `json:"bucket_id"`
+	Actor    string                 `json:"actor,omitempty"`
+	Object   *EventObject           `json:"object,omitempty"`
+	Source   *EventObject           `json:"source,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// EventObject identifies the object an event is about. FileID is empty when
+// only the key is known, as for deletes. Metadata is used to filter webhooks
+// and is not published.
+type EventObject struct {
+	BucketID     string            `json:"bucket_id"`
+	Key          string            `json:"key"`
+	FileID       string            `json:"file_id,omitempty"`
+	VersionID    string            `json:"version_id,omitempty"`
+	Size         int64             `json:"size,omitempty"`
+	ETag         string            `json:"etag,omitempty"`
+	ContentType  string            `json:"content_type,omitempty"`
+	StorageClass string            `json:"storage_class,omitempty"`
+	Metadata     map[string]string `json:"-"`
+}
@@ -11,10 +11,46 @@ type File struct {
     MimeType    string            `gorm:"size:255"`
     ContentType string            `gorm:"size:255"`
     Metadata    map[string]string `gorm:"type:jsonb"` // use "json" if MySQL
+    StorageClass string           `gorm:"size:50;default:STANDARD"`
+    // RestoreExpiresAt is when the temporary hot copy of a cold object is
+    // removed again; nil when no restore has been made
+    RestoreExpiresAt *time.Time
+    // Encryption is the SSE mode (EncryptionSSES3, EncryptionSSEC or none);
+    // EncryptionKey holds the wrapped data key of server-managed objects and
+    // SSECustomerKeyMD5 the fingerprint of the customer key of SSE-C ones
+    Encryption        string
+    EncryptionKey     string
+    SSECustomerKeyMD5 string
+    // ETag is the hex MD5 of the content (the S3 multipart ETag for
+    // multipart uploads); the checksums are hex too and empty when unknown
+    ETag           string
+    ChecksumSHA256 string
+    ChecksumCRC32C string
+    // ScrubbedAt is when the scrub job last re-read the object; ScrubError
+    // is set when the stored data no longer matched its checksums
+    ScrubbedAt *time.Time
+    ScrubError string
+    // Object lock: the file cannot be deleted or overwritten while under a
+    // legal hold or before RetainUntil (see CanRemove)
+    RetentionMode string
+    RetainUntil   *time.Time
+    LegalHold     bool
+    // ReplicationStatus is PENDING, COMPLETED or FAILED while the object is
+    // covered by replication rules, REPLICA on copies and empty otherwise
+    ReplicationStatus string
     CreatedAt   time.Time         `gorm:"autoCreateTime"`
     UpdatedAt   time.Time         `gorm:"autoUpdateTime"`
 }
 
+// MediaType is the object's content type. Older write paths set only one of
+// ContentType and MimeType, so either is used.
+func (f *File) MediaType() string {
+    if f.ContentType != "" {
+        return f.ContentType
+    }
+    return f.MimeType
+}
+
 func (f *File) IsImage() bool {
     return f.MimeType == "image/png" || f.MimeType == "image/jpeg"
 }
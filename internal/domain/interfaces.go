@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"io"
 	"s3/internal/infrastructure/dto"
 	"time"
 )
@@ -9,23 +10,60 @@ import (
 type StoragePort interface {
 	SaveObject(ctx context.Context, bucket, key string, data []byte, metadata map[string]string) error
 	GetObject(ctx context.Context, bucket, key string) ([]byte, error)
+
+	// Streaming variants; size may be -1 when the length is not known up front.
+	// enc is the server-side encryption key of the object, nil for none; an
+	// encrypted object can only be read, stat'ed or copied with its key.
+	SaveObjectStream(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string, metadata map[string]string, enc *ObjectEncryption) (*ObjectInfo, error)
+	GetObjectStream(ctx context.Context, bucket, key string, enc *ObjectEncryption) (io.ReadCloser, *ObjectInfo, error)
+
+	// Ranged read of length bytes starting at offset; length -1 reads to the end.
+	GetObjectRange(ctx context.Context, bucket, key string, offset, length int64, enc *ObjectEncryption) (io.ReadCloser, error)
+	StatObject(ctx context.Context, bucket, key string, enc *ObjectEncryption) (*ObjectInfo, error)
+
+	// Native multipart upload; completion is composed by the backend.
+	NewMultipartUpload(ctx context.Context, bucket, key, contentType string, metadata map[string]string, enc *ObjectEncryption) (string, error)
+	PutObjectPart(ctx context.Context, bucket, key, uploadID string, partNumber int, body io.Reader, size int64, enc *ObjectEncryption) (*Part, error)
+	CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []Part) (*ObjectInfo, error)
+	AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error
+
 	DeleteObject(ctx context.Context, bucket, key string) error
+
+	// Versioned access. DeleteObjectVersion with an empty versionID deletes
+	// the current object, which on a versioned bucket adds a delete marker;
+	// RestoreObjectVersion copies a version over the key as its new latest.
+	GetObjectVersionStream(ctx context.Context, bucket, key, versionID string, enc *ObjectEncryption) (io.ReadCloser, *ObjectInfo, error)
+	DeleteObjectVersion(ctx context.Context, bucket, key, versionID string) (*ObjectDeletion, error)
+	RestoreObjectVersion(ctx context.Context, bucket, key, versionID string, enc *ObjectEncryption) (*ObjectInfo, error)
 	CreateBucket(ctx context.Context, name string) (string, error)
 	DeleteBucket(ctx context.Context, bucketId string) error
 
 	SetBucketVersioning(ctx context.Context, name string, enabled bool) error
 	RenameBucket(ctx context.Context, oldName string, newName string)error
 
+	// srcEnc decrypts the source, dstEnc encrypts the copy
 	CopyObject(
 		ctx context.Context,
 		srcBucket string,
 		srcKey string,
 		dstBucket string,
 		dstKey string,
+		srcEnc *ObjectEncryption,
+		dstEnc *ObjectEncryption,
 	) error
 	GetBucketVersioning(ctx context.Context, bucketId string) (*dto.VersioningOutput, error)
 }
 
+// ColdStoragePort is the archive tier objects in a cold storage class are
+// moved to. Objects are addressed by the hot bucket name and key.
+type ColdStoragePort interface {
+	// Name identifies the backend in logs and responses
+	Name() string
+	PutObject(ctx context.Context, bucket, key string, body io.Reader, size int64) error
+	GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+	DeleteObject(ctx context.Context, bucket, key string) error
+}
+
 type RepositoryPort interface {
 	// Files
 	SaveFile(ctx context.Context, file File) error
@@ -34,6 +72,16 @@ type RepositoryPort interface {
 	ListFiles(ctx context.Context, bucketID string) ([]File, error)
 	UpdateFile(ctx context.Context, file *File) error
 	DeleteFile(ctx context.Context, id string) error
+	// UpdateFileStorageClass records a tier transition or restore
+	UpdateFileStorageClass(ctx context.Context, id, storageClass string, restoreExpiresAt *time.Time) error
+	// UpdateFileEncryption records the key a file was rewritten with
+	UpdateFileEncryption(ctx context.Context, id, mode, wrappedKey, customerKeyMD5 string) error
+	// UpdateFileScrub records an integrity scrub; scrubError is empty
+	// when the object matched its checksums
+	UpdateFileScrub(ctx context.Context, id string, scrubbedAt time.Time, scrubError string) error
+	// Object lock: an empty mode clears the retention
+	UpdateFileRetention(ctx context.Context, id, mode string, retainUntil *time.Time) error
+	UpdateFileLegalHold(ctx context.Context, id string, on bool) error
 
 	// Buckets
 	SaveBucket(ctx context.Context, bucket *Bucket) (Bucket, error)
@@ -78,7 +126,13 @@ type RepositoryPort interface {
 	UpdateWebhook(ctx context.Context, webhook *Webhook) error
 	DeleteWebhook(ctx context.Context, id string) error
 	SaveWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error
-	ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, id string) (*WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, webhookID string, filter WebhookDeliveryFilter) ([]WebhookDelivery, int, error)
+	ListDeadWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]WebhookDelivery, error)
+	ListWebhookQueueBetween(ctx context.Context, webhookID string, from, to time.Time, limit int) ([]WebhookQueueItem, error)
+	EnqueueWebhook(ctx context.Context, item *WebhookQueueItem) error
+	ClaimWebhookQueue(ctx context.Context, limit int, lease time.Duration, defaultConcurrency int) ([]WebhookQueueItem, error)
+	FinishWebhookQueueItem(ctx context.Context, item *WebhookQueueItem) error
 
 	// Analytics
 	GetAccessLogsByDateRange(ctx context.Context, start, end time.Time) ([]AccessLog, error)
@@ -90,22 +144,71 @@ type RepositoryPort interface {
 	}, error)
 	SaveAccessLog(ctx context.Context, log *AccessLog) error
 
+	// Access keys
+	SaveAccessKey(ctx context.Context, key *AccessKey) error
+	GetAccessKeyByID(ctx context.Context, accessKeyID string) (*AccessKey, error)
+	GetAccessKeyBySecretHash(ctx context.Context, secretHash string) (*AccessKey, error)
+	ListAccessKeysByUser(ctx context.Context, userID string) ([]AccessKey, error)
+	UpdateAccessKeyStatus(ctx context.Context, accessKeyID, status string) error
+	DeleteAccessKey(ctx context.Context, accessKeyID string) error
+	TouchAccessKey(ctx context.Context, accessKeyID string, usedAt time.Time) error
+	ListUnsealedAccessKeys(ctx context.Context, sealedPrefix string) ([]AccessKey, error)
+
 	// Multipart Uploads
 	SaveMultipartUpload(ctx context.Context, upload *MultipartUpload) error
 	GetMultipartUploadByUploadID(ctx context.Context, uploadID string) (*MultipartUpload, error)
 	UpdateMultipartUpload(ctx context.Context, upload *MultipartUpload) error
+	// SaveMultipartPart records an uploaded part, replacing an earlier
+	// upload of the same part number
+	SaveMultipartPart(ctx context.Context, uploadID string, part *Part) error
 	ListMultipartUploadsByBucket(ctx context.Context, bucketID string) ([]MultipartUpload, error)
 
 	// 🔐 Policy-related operations
-	IncrementPolicyVersionAndUpdateBucket(ctx context.Context, bucket *Bucket) error
-	AppendPolicyHistory(ctx context.Context, bucketID string, policy *Policy, actor string) error
+	// SaveBucketPolicy stores bucket.Policy and appends it to the policy
+	// history as the next version; entry.Version is filled in.
+	SaveBucketPolicy(ctx context.Context, bucket *Bucket, entry *PolicyVersion) error
+	ListPolicyVersions(ctx context.Context, bucketID string) ([]PolicyVersion, error)
+	GetPolicyVersion(ctx context.Context, bucketID string, version int) (*PolicyVersion, error)
 	// Versioning
 	SetBucketVersioning(ctx context.Context, bucketID string, status VersioningStatus) error
 	GetBucketVersioning(ctx context.Context, bucketID string) (VersioningStatus, error)
 
+	// Default encryption (buckets.encryption_enabled)
+	SetBucketEncryption(ctx context.Context, bucketID string, enabled bool) error
+	GetBucketEncryption(ctx context.Context, bucketID string) (bool, error)
+	// Object lock configuration (buckets.object_lock_*)
+	SetBucketObjectLock(ctx context.Context, bucketID string, config ObjectLockConfiguration) error
+	GetBucketObjectLock(ctx context.Context, bucketID string) (*ObjectLockConfiguration, error)
+	// Storage quotas; usage is maintained by the database as files change.
+	// ReserveQuota returns the subject whose limit a write would exceed.
+	SetQuota(ctx context.Context, quota *Quota) error
+	GetQuota(ctx context.Context, scope, subjectID string) (*Quota, error)
+	ReserveQuota(ctx context.Context, subjects []QuotaSubject, bytes, objects int64) (*QuotaSubject, error)
+	ReleaseQuota(ctx context.Context, subjects []QuotaSubject, bytes, objects int64) error
+	// Replication. Enqueueing and finishing a task also refresh the
+	// replication status of the source file.
+	ReplaceReplicationRules(ctx context.Context, bucketID string, rules []ReplicationRule) error
+	GetReplicationRules(ctx context.Context, bucketID string) ([]ReplicationRule, error)
+	EnqueueReplication(ctx context.Context, task *ReplicationTask) error
+	ClaimReplicationTasks(ctx context.Context, limit int, lease time.Duration) ([]ReplicationTask, error)
+	FinishReplicationTask(ctx context.Context, task *ReplicationTask) error
+	ListReplicationTasks(ctx context.Context, bucketID, status string, limit int) ([]ReplicationTask, error)
+	RetryFailedReplication(ctx context.Context, bucketID string) (int64, error)
+	// Object versions; SaveObjectVersion marks the new version as the
+	// latest. DeleteObjectVersion returns the version that is latest
+	// afterwards, or nil when none is left.
+	SaveObjectVersion(ctx context.Context, version *ObjectVersion) error
+	ListObjectVersions(ctx context.Context, bucketID, key string) ([]ObjectVersion, error)
+	GetObjectVersion(ctx context.Context, bucketID, versionID string) (*ObjectVersion, error)
+	DeleteObjectVersion(ctx context.Context, bucketID, versionID string) (*ObjectVersion, error)
+
 
 GetLifecycleRules(ctx context.Context, bucketID string) ([]LifecycleRule, error)
-    UpsertLifecycleRule(ctx context.Context, bucketID string, ruleJSON []byte) error
+    // ReplaceLifecycleRules swaps the bucket's whole rule set, as an S3
+    // PutBucketLifecycleConfiguration does
+    ReplaceLifecycleRules(ctx context.Context, bucketID string, rules []LifecycleRule) error
+    SaveLifecycleActions(ctx context.Context, actions []LifecycleAction) error
+    ListLifecycleActions(ctx context.Context, bucketID string, limit int) ([]LifecycleAction, error)
 
 
 }
@@ -121,6 +224,8 @@ type SystemPort interface {
 	HealthCheck() error
 }
 
+// EventPublisher sends events to a message broker. Topics are event types
+// such as "object.created"; Consume follows a topic until ctx is done.
 type EventPublisher interface {
 	Publish(ctx context.Context, topic string, payload interface{}) error
 	Consume(ctx context.Context, topic string) error
@@ -1,5 +1,15 @@
 package domain
 
+import (
+    "strings"
+    "time"
+)
+
+const (
+    LifecycleStatusEnabled  = "Enabled"
+    LifecycleStatusDisabled = "Disabled"
+)
+
 type LifecycleRule struct {
     ID                    string `json:"id"`
     Prefix                string `json:"prefix"`
@@ -7,6 +17,48 @@ type LifecycleRule struct {
     ExpirationDays        int    `json:"expiration_days,omitempty"`
     TransitionDays        int    `json:"transition_days,omitempty"`
     TransitionStorageClass string `json:"transition_storage_class,omitempty"`
+    // NoncurrentVersionExpirationDays removes versions this many days after
+    // a newer version replaced them
+    NoncurrentVersionExpirationDays int `json:"noncurrent_version_expiration_days,omitempty"`
+    // AbortIncompleteMultipartUploadDays aborts uploads left unfinished
+    // this many days after they were initiated
+    AbortIncompleteMultipartUploadDays int `json:"abort_incomplete_multipart_upload_days,omitempty"`
 }
 
- 
\ No newline at end of file
+func (r *LifecycleRule) IsEnabled() bool {
+    return r.Status == LifecycleStatusEnabled
+}
+
+// Matches reports whether key falls under the rule's prefix
+func (r *LifecycleRule) Matches(key string) bool {
+    return strings.HasPrefix(key, r.Prefix)
+}
+
+// LifecycleActionType is what the lifecycle worker did (or would do)
+type LifecycleActionType string
+
+const (
+    LifecycleExpireObject           LifecycleActionType = "expire_object"
+    LifecycleExpireNoncurrentVersion LifecycleActionType = "expire_noncurrent_version"
+    LifecycleAbortMultipartUpload   LifecycleActionType = "abort_multipart_upload"
+    LifecycleTransitionObject       LifecycleActionType = "transition_object"
+    // LifecycleExpireRestoredCopy removes the temporary hot copy of a cold
+    // object once its restore period is over
+    LifecycleExpireRestoredCopy     LifecycleActionType = "expire_restored_copy"
+)
+
+// LifecycleAction is one step taken by the lifecycle worker. Error is set
+// when the step failed; failed steps are retried on the next run.
+type LifecycleAction struct {
+    ID         string              `json:"id"`
+    RunID      string              `json:"run_id"`
+    BucketID   string              `json:"bucket_id"`
+    RuleID     string              `json:"rule_id"`
+    Action     LifecycleActionType `json:"action"`
+    Key        string              `json:"key"`
+    VersionID  string              `json:"version_id,omitempty"`
+    UploadID   string              `json:"upload_id,omitempty"`
+    StorageClass string            `json:"storage_class,omitempty"`
+    Error      string              `json:"error,omitempty"`
+    ExecutedAt time.Time           `json:"executed_at"`
+}
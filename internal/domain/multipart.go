@@ -1,16 +1,40 @@
 package domain
 
-import "time"
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// S3 multipart limits
+const (
+	MinPartSize = 5 << 20 // every part but the last
+	MaxPartSize = 5 << 30
+	MaxParts    = 10000
+)
 
 type MultipartUpload struct {
-	ID        string    `json:"id"`
-	UploadID  string    `json:"upload_id"`
-	BucketID  string    `json:"bucket_id"`
-	Key       string    `json:"key"`
-	Status    string    `json:"status"` // initiated, completed, aborted
-	Parts     []Part    `json:"parts"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              string    `json:"id"`
+	UploadID        string    `json:"upload_id"`
+	StorageUploadID string    `json:"-"` // upload ID issued by the storage backend
+	BucketID        string    `json:"bucket_id"`
+	Key             string    `json:"key"`
+	Status          string    `json:"status"` // initiated, completed, aborted
+	Parts           []Part    `json:"parts"`
+	// Server-managed encryption chosen at initiation; parts and the
+	// completed object use the same data key
+	Encryption      string    `json:"encryption,omitempty"`
+	EncryptionKey   string    `json:"-"`
+	// ContentType is given at initiation and set on the completed object
+	ContentType     string    `json:"content_type,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	// Whole-object checksums declared at initiation, hex encoded; the
+	// completed object is verified against them
+	ChecksumSHA256  string    `json:"checksum_sha256,omitempty"`
+	ChecksumCRC32C  string    `json:"checksum_crc32c,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 type Part struct {
@@ -18,4 +42,18 @@ type Part struct {
 	ETag       string    `json:"etag"`
 	Size       int64     `json:"size"`
 	UploadedAt time.Time `json:"uploaded_at"`
-}
\ No newline at end of file
+}
+
+// MultipartETag computes the S3-style ETag of a completed multipart object:
+// the MD5 of the concatenated binary part MD5s, suffixed with the part count.
+func MultipartETag(parts []Part) (string, error) {
+	hash := md5.New()
+	for _, part := range parts {
+		sum, err := hex.DecodeString(part.ETag)
+		if err != nil {
+			return "", fmt.Errorf("part %d has a malformed etag %q", part.PartNumber, part.ETag)
+		}
+		hash.Write(sum)
+	}
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(hash.Sum(nil)), len(parts)), nil
+}
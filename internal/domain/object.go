@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// ObjectInfo describes an object as reported by the storage backend.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+	// VersionID is set by backends with versioning enabled on the bucket
+	VersionID string
+}
+
+// ObjectDeletion reports what a delete did on a versioned bucket: removed a
+// version outright, or added a delete marker with its own version id.
+type ObjectDeletion struct {
+	VersionID    string
+	DeleteMarker bool
+}
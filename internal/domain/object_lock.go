@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+// Object lock retention modes. A GOVERNANCE retention can be lifted early
+// by callers allowed to bypass it; a COMPLIANCE retention cannot be
+// shortened or removed by anyone.
+const (
+	RetentionGovernance = "GOVERNANCE"
+	RetentionCompliance = "COMPLIANCE"
+)
+
+// IsRetentionMode reports whether mode is a valid retention mode
+func IsRetentionMode(mode string) bool {
+	return mode == RetentionGovernance || mode == RetentionCompliance
+}
+
+// ObjectLockConfiguration is a bucket's object lock setting. Once enabled it
+// cannot be turned off; new objects get the default retention, if any.
+type ObjectLockConfiguration struct {
+	Enabled              bool
+	DefaultMode          string
+	DefaultRetentionDays int
+}
+
+// DefaultRetainUntil returns when the default retention of an object
+// created at now ends, or nil when the bucket has none
+func (c *ObjectLockConfiguration) DefaultRetainUntil(now time.Time) *time.Time {
+	if c == nil || !c.Enabled || c.DefaultMode == "" || c.DefaultRetentionDays <= 0 {
+		return nil
+	}
+	until := now.AddDate(0, 0, c.DefaultRetentionDays)
+	return &until
+}
+
+// UnderRetention reports whether the file's retain-until date is still ahead
+func (f *File) UnderRetention(now time.Time) bool {
+	return f.RetainUntil != nil && now.Before(*f.RetainUntil)
+}
+
+// IsLocked reports whether the file is under a legal hold or an active
+// retention at now
+func (f *File) IsLocked(now time.Time) bool {
+	return f.LegalHold || f.UnderRetention(now)
+}
+
+// CanRemove reports whether the file may be deleted or overwritten at now.
+// Only a governance retention yields to bypassGovernance.
+func (f *File) CanRemove(now time.Time, bypassGovernance bool) bool {
+	if f.LegalHold {
+		return false
+	}
+	if !f.UnderRetention(now) {
+		return true
+	}
+	return f.RetentionMode == RetentionGovernance && bypassGovernance
+}
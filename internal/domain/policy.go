@@ -17,8 +17,71 @@ const (
 	ActionDeleteObject Action = "s3:DeleteObject"
 	ActionListBucket   Action = "s3:ListBucket"
 	ActionAll          Action = "s3:*"
+
+	ActionAbortMultipartUpload       Action = "s3:AbortMultipartUpload"
+	ActionListMultipartUploadParts   Action = "s3:ListMultipartUploadParts"
+	ActionListBucketMultipartUploads Action = "s3:ListBucketMultipartUploads"
+
+	ActionListBucketVersions   Action = "s3:ListBucketVersions"
+	ActionGetObjectVersion     Action = "s3:GetObjectVersion"
+	ActionDeleteObjectVersion  Action = "s3:DeleteObjectVersion"
+	ActionRestoreObject        Action = "s3:RestoreObject"
+
+	ActionDeleteBucket               Action = "s3:DeleteBucket"
+	ActionPutBucket                  Action = "s3:PutBucket"
+	ActionGetBucketPolicy            Action = "s3:GetBucketPolicy"
+	ActionPutBucketPolicy            Action = "s3:PutBucketPolicy"
+	ActionGetBucketVersioning        Action = "s3:GetBucketVersioning"
+	ActionPutBucketVersioning        Action = "s3:PutBucketVersioning"
+	ActionGetLifecycleConfiguration  Action = "s3:GetLifecycleConfiguration"
+	ActionPutLifecycleConfiguration  Action = "s3:PutLifecycleConfiguration"
+	ActionGetEncryptionConfiguration Action = "s3:GetEncryptionConfiguration"
+	ActionPutEncryptionConfiguration Action = "s3:PutEncryptionConfiguration"
+	ActionGetIntegrityReport         Action = "s3:GetIntegrityReport"
+	ActionRunIntegrityScrub          Action = "s3:RunIntegrityScrub"
+	ActionGetBucketQuota             Action = "s3:GetBucketQuota"
+	ActionPutBucketQuota             Action = "s3:PutBucketQuota"
+	ActionGetBucketNotification      Action = "s3:GetBucketNotification"
+	ActionPutBucketNotification      Action = "s3:PutBucketNotification"
+
+	ActionGetReplicationConfiguration Action = "s3:GetReplicationConfiguration"
+	ActionPutReplicationConfiguration Action = "s3:PutReplicationConfiguration"
+	// ActionReplicateObject is checked on the destination when rules are set
+	ActionReplicateObject Action = "s3:ReplicateObject"
+
+	ActionGetBucketObjectLockConfiguration Action = "s3:GetBucketObjectLockConfiguration"
+	ActionPutBucketObjectLockConfiguration Action = "s3:PutBucketObjectLockConfiguration"
+	ActionGetObjectRetention               Action = "s3:GetObjectRetention"
+	ActionPutObjectRetention               Action = "s3:PutObjectRetention"
+	ActionGetObjectLegalHold               Action = "s3:GetObjectLegalHold"
+	ActionPutObjectLegalHold               Action = "s3:PutObjectLegalHold"
+	ActionBypassGovernanceRetention        Action = "s3:BypassGovernanceRetention"
 )
 
+// IsObjectAction reports whether the action targets objects
+// ("arn:mys3:::bucket/key") rather than the bucket itself.
+func (a Action) IsObjectAction() bool {
+	switch a {
+	case ActionGetObject, ActionPutObject, ActionDeleteObject,
+		ActionAbortMultipartUpload, ActionListMultipartUploadParts,
+		ActionGetObjectVersion, ActionDeleteObjectVersion, ActionRestoreObject,
+		ActionGetObjectRetention, ActionPutObjectRetention,
+		ActionGetObjectLegalHold, ActionPutObjectLegalHold, ActionBypassGovernanceRetention,
+		ActionReplicateObject:
+		return true
+	}
+	return false
+}
+
+// PolicyResource builds the resource string policies match against: the
+// bucket ARN, or the object ARN when key is set.
+func PolicyResource(bucketID, key string) string {
+	if key == "" {
+		return "arn:mys3:::" + bucketID
+	}
+	return "arn:mys3:::" + bucketID + "/" + key
+}
+
 type Effect string
 
 const (
@@ -26,16 +89,44 @@ const (
 	EffectDeny  Effect = "Deny"
 )
 
-// Principal can be "user:<id>", "role:<name>", "public"
+// Principal can be "user:<id>", "role:<name>", "public" or "*"; "*" may also
+// be used as a wildcard inside a principal, e.g. "user:*".
 type Principal string
 
-// Statement is a single policy statement
+// Statement is a single policy statement. Exactly one of Action/NotAction
+// and one of Resource/NotResource must be set.
 type Statement struct {
-	Effect    Effect     `json:"Effect"`
-	Principal []Principal `json:"Principal"`
-	Action    []Action   `json:"Action"`
-	Resource  []string   `json:"Resource"` // use patterns, e.g., "arn:mys3:::bucketId/*"
-	Condition map[string]interface{}  `json:"condition,omitempty"`
+	Effect      Effect      `json:"Effect"`
+	Principal   []Principal `json:"Principal"`
+	Action      []Action    `json:"Action,omitempty"`
+	NotAction   []Action    `json:"NotAction,omitempty"`
+	Resource    []string    `json:"Resource,omitempty"` // use patterns, e.g., "arn:mys3:::bucketId/*"
+	NotResource []string    `json:"NotResource,omitempty"`
+	// Condition maps an operator to condition keys and their allowed
+	// values, e.g. {"IpAddress": {"aws:SourceIp": ["10.0.0.0/8"]}}
+	Condition map[string]interface{} `json:"condition,omitempty"`
+}
+
+// conditionOperators are the supported condition operators; each may also be
+// used with an "IfExists" suffix.
+var conditionOperators = map[string]bool{
+	"StringEquals": true, "StringNotEquals": true,
+	"StringEqualsIgnoreCase": true, "StringNotEqualsIgnoreCase": true,
+	"StringLike": true, "StringNotLike": true,
+	"NumericEquals": true, "NumericNotEquals": true,
+	"NumericLessThan": true, "NumericLessThanEquals": true,
+	"NumericGreaterThan": true, "NumericGreaterThanEquals": true,
+	"DateEquals": true, "DateNotEquals": true,
+	"DateLessThan": true, "DateLessThanEquals": true,
+	"DateGreaterThan": true, "DateGreaterThanEquals": true,
+	"Bool":      true,
+	"IpAddress": true, "NotIpAddress": true,
+	"Null": true,
+}
+
+// IsConditionOperator reports whether op is a supported condition operator
+func IsConditionOperator(op string) bool {
+	return conditionOperators[strings.TrimSuffix(op, "IfExists")]
 }
 
 // Policy is the container for statements
@@ -45,6 +136,19 @@ type Policy struct {
 	CreatedAt time.Time   `json:"-"`
 }
 
+// PolicyVersion is one entry in a bucket's policy history. Every change to
+// the bucket policy appends a version; a rollback appends a copy of an older
+// one with RestoredFrom set.
+type PolicyVersion struct {
+	ID           string    `json:"id"`
+	BucketID     string    `json:"bucket_id"`
+	Version      int       `json:"version"`
+	Policy       *Policy   `json:"policy"`
+	UpdatedBy    string    `json:"updated_by"`
+	RestoredFrom *int      `json:"restored_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 // Validate performs basic structural validation; extend as needed or use JSON Schema
 func (p *Policy) Validate() error {
 	if p == nil {
@@ -63,11 +167,19 @@ func (p *Policy) Validate() error {
 		if len(s.Principal) == 0 {
 			return fmt.Errorf("statement[%d]: principal required", i)
 		}
-		if len(s.Action) == 0 {
-			return fmt.Errorf("statement[%d]: action required", i)
+		if (len(s.Action) == 0) == (len(s.NotAction) == 0) {
+			return fmt.Errorf("statement[%d]: exactly one of Action or NotAction required", i)
+		}
+		if (len(s.Resource) == 0) == (len(s.NotResource) == 0) {
+			return fmt.Errorf("statement[%d]: exactly one of Resource or NotResource required", i)
 		}
-		if len(s.Resource) == 0 {
-			return fmt.Errorf("statement[%d]: resource required", i)
+		for op, block := range s.Condition {
+			if !IsConditionOperator(op) {
+				return fmt.Errorf("statement[%d]: unsupported condition operator %q", i, op)
+			}
+			if _, ok := block.(map[string]interface{}); !ok {
+				return fmt.Errorf("statement[%d]: condition %q must map keys to values", i, op)
+			}
 		}
 	}
 	return nil
@@ -0,0 +1,65 @@
+package domain
+
+import "time"
+
+// Quota scopes: a bucket, or every bucket of one owner
+const (
+	QuotaScopeBucket = "bucket"
+	QuotaScopeOwner  = "owner"
+)
+
+// DefaultQuotaWarningPercent is the usage level that raises a warning when a
+// quota does not set its own
+const DefaultQuotaWarningPercent = 80
+
+// QuotaSubject names what a quota applies to: a bucket id or an owner id
+type QuotaSubject struct {
+	Scope     string
+	SubjectID string
+}
+
+// Quota is the limit and current usage of one subject. A zero MaxBytes or
+// MaxObjects means that dimension is unlimited. Reserved counts writes that
+// have been admitted but not yet recorded as files.
+type Quota struct {
+	QuotaSubject
+	MaxBytes        int64
+	MaxObjects      int64
+	WarningPercent  int
+	UsedBytes       int64
+	UsedObjects     int64
+	ReservedBytes   int64
+	ReservedObjects int64
+	UpdatedAt       time.Time
+}
+
+// Limited reports whether the quota caps anything
+func (q *Quota) Limited() bool {
+	return q.MaxBytes > 0 || q.MaxObjects > 0
+}
+
+// UsagePercent is the fuller of the two dimensions, as a percentage of its
+// limit, given bytes and objects in use
+func (q *Quota) UsagePercent(bytes, objects int64) float64 {
+	var percent float64
+	if q.MaxBytes > 0 {
+		percent = float64(bytes) * 100 / float64(q.MaxBytes)
+	}
+	if q.MaxObjects > 0 {
+		if p := float64(objects) * 100 / float64(q.MaxObjects); p > percent {
+			percent = p
+		}
+	}
+	return percent
+}
+
+// WarningCrossed reports whether adding bytes and objects took usage from
+// below the warning level to at or above it
+func (q *Quota) WarningCrossed(bytes, objects int64) bool {
+	if !q.Limited() || q.WarningPercent <= 0 {
+		return false
+	}
+	level := float64(q.WarningPercent)
+	before := q.UsagePercent(q.UsedBytes-bytes, q.UsedObjects-objects)
+	return before < level && q.UsagePercent(q.UsedBytes, q.UsedObjects) >= level
+}
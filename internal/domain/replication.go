@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Replication status of a file. Source objects go from PENDING to COMPLETED
+// or FAILED; copies written by replication are marked REPLICA and are never
+// replicated further, so rules pointing both ways cannot loop.
+const (
+	ReplicationPending   = "PENDING"
+	ReplicationCompleted = "COMPLETED"
+	ReplicationFailed    = "FAILED"
+	ReplicationReplica   = "REPLICA"
+)
+
+const (
+	ReplicationRuleEnabled  = "Enabled"
+	ReplicationRuleDisabled = "Disabled"
+)
+
+// What a replication task brings over to the destination
+const (
+	ReplicationOpPut      = "PUT"
+	ReplicationOpDelete   = "DELETE"
+	ReplicationOpMetadata = "METADATA"
+)
+
+// ReplicationRule copies objects under Prefix to DestinationBucket.
+// ReplicateDeletes removes the replica when the source object is deleted;
+// ReplicateMetadata carries user metadata over, including later updates.
+type ReplicationRule struct {
+	ID                string
+	Status            string
+	Prefix            string
+	DestinationBucket string
+	ReplicateDeletes  bool
+	ReplicateMetadata bool
+}
+
+func (r *ReplicationRule) IsEnabled() bool {
+	return r.Status == ReplicationRuleEnabled
+}
+
+// Matches reports whether key falls under the rule's prefix
+func (r *ReplicationRule) Matches(key string) bool {
+	return strings.HasPrefix(key, r.Prefix)
+}
+
+// ReplicationTask brings one key of a bucket up to date in one destination.
+// There is a single task per key and destination: a newer event resets it
+// to PENDING with the new operation and bumps Seq, so a worker still busy
+// with the previous event cannot mark the new one done.
+type ReplicationTask struct {
+	ID                string
+	BucketID          string
+	Key               string
+	DestinationBucket string
+	RuleID            string
+	Operation         string
+	Status            string
+	Attempts          int
+	LastError         string
+	Seq               int64
+	NextAttemptAt     time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// Storage classes. STANDARD objects live in the hot object store; objects in
+// a cold class live in the cold tier and must be restored before they can be
+// read.
+const (
+	StorageClassStandard = "STANDARD"
+	StorageClassGlacier  = "GLACIER"
+)
+
+// IsColdStorageClass reports whether objects of class live in the cold tier
+func IsColdStorageClass(class string) bool {
+	return class == StorageClassGlacier
+}
+
+// IsArchived reports whether the file's data is only in the cold tier at
+// now, i.e. it is in a cold class and has no unexpired restored copy.
+func (f *File) IsArchived(now time.Time) bool {
+	return IsColdStorageClass(f.StorageClass) && !f.IsRestored(now)
+}
+
+// IsRestored reports whether a temporary hot copy of a cold file is
+// available at now
+func (f *File) IsRestored(now time.Time) bool {
+	return f.RestoreExpiresAt != nil && now.Before(*f.RestoreExpiresAt)
+}
@@ -1,8 +1,38 @@
 package domain
 
+import "time"
+
 type VersioningStatus string
 
 const (
 	VersioningEnabled   VersioningStatus = "Enabled"
 	VersioningSuspended VersioningStatus = "Suspended"
 )
+
+// ObjectVersion is one version of an object in a bucket with versioning
+// enabled. Exactly one version of a key is the latest; when that is a delete
+// marker the key reads as deleted.
+type ObjectVersion struct {
+	ID             string            `json:"id"`
+	BucketID       string            `json:"bucket_id"`
+	Key            string            `json:"key"`
+	VersionID      string            `json:"version_id"`
+	IsLatest       bool              `json:"is_latest"`
+	IsDeleteMarker bool              `json:"is_delete_marker"`
+	Size           int64             `json:"size"`
+	ETag           string            `json:"etag,omitempty"`
+	ContentType    string            `json:"content_type,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	ChecksumSHA256 string            `json:"checksum_sha256,omitempty"`
+	ChecksumCRC32C string            `json:"checksum_crc32c,omitempty"`
+	StorageClass   string            `json:"storage_class,omitempty"`
+	// SSE mode and key of this version, as on File
+	Encryption        string `json:"encryption,omitempty"`
+	EncryptionKey     string `json:"-"`
+	SSECustomerKeyMD5 string `json:"sse_customer_key_md5,omitempty"`
+	// Object lock state of this version (see File.CanRemove)
+	RetentionMode string     `json:"retention_mode,omitempty"`
+	RetainUntil   *time.Time `json:"retain_until,omitempty"`
+	LegalHold     bool       `json:"legal_hold,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -1,23 +1,115 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Webhook struct {
-	ID          string            `json:"id"`
-	BucketID    string            `json:"bucket_id"`
-	Name        string            `json:"name"`
-	URL         string            `json:"url"`
-	Events      []string          `json:"events"` // object.created, object.deleted, etc.
-	Secret      string            `json:"secret"`
-	Active      bool              `json:"active"`
-	Headers     map[string]string `json:"headers,omitempty"`
-	CreatedAt   time.Time         `json:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at"`
+	ID       string            `json:"id"`
+	BucketID string            `json:"bucket_id"`
+	Name     string            `json:"name"`
+	URL      string            `json:"url"`
+	Events   []string          `json:"events"` // object.created, object.deleted, etc.
+	Secret   string            `json:"secret"`
+	Active   bool              `json:"active"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	// MaxConcurrency caps the deliveries in flight to this webhook at once;
+	// 0 uses the server default
+	MaxConcurrency int `json:"max_concurrency"`
+	// Filter narrows the object events delivered to this webhook; nil
+	// delivers every subscribed event
+	Filter *WebhookFilter `json:"filter,omitempty"`
+	// PreviousSecret is the secret replaced by the last rotation. It signs
+	// deliveries alongside Secret until PreviousSecretExpiresAt.
+	PreviousSecret          string     `json:"-"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+	CreatedAt               time.Time  `json:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at"`
 }
 
+// WebhookFilter selects the objects a webhook hears about. Every condition
+// that is set must hold. Events that are not about an object, such as
+// bucket.updated, are not filtered. Deletes only know the key, so they never
+// match content type, size or metadata conditions.
+type WebhookFilter struct {
+	KeyPrefix string `json:"key_prefix,omitempty"`
+	KeySuffix string `json:"key_suffix,omitempty"`
+	// ContentType is a media type such as "image/jpeg", or "image/*" for
+	// every subtype
+	ContentType string            `json:"content_type,omitempty"`
+	MinSize     *int64            `json:"min_size,omitempty"`
+	MaxSize     *int64            `json:"max_size,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// IsEmpty reports whether the filter has no conditions
+func (f *WebhookFilter) IsEmpty() bool {
+	return f == nil || (f.KeyPrefix == "" && f.KeySuffix == "" && f.ContentType == "" &&
+		f.MinSize == nil && f.MaxSize == nil && len(f.Metadata) == 0)
+}
+
+// Matches reports whether an event about object passes the filter
+func (f *WebhookFilter) Matches(object *EventObject) bool {
+	if f == nil || object == nil {
+		return true
+	}
+	if !strings.HasPrefix(object.Key, f.KeyPrefix) || !strings.HasSuffix(object.Key, f.KeySuffix) {
+		return false
+	}
+	if f.ContentType == "" && f.MinSize == nil && f.MaxSize == nil && len(f.Metadata) == 0 {
+		return true
+	}
+	if object.FileID == "" {
+		return false
+	}
+
+	if f.ContentType != "" && !contentTypeMatches(f.ContentType, object.ContentType) {
+		return false
+	}
+	if f.MinSize != nil && object.Size < *f.MinSize {
+		return false
+	}
+	if f.MaxSize != nil && object.Size > *f.MaxSize {
+		return false
+	}
+	for key, value := range f.Metadata {
+		if got, ok := object.Metadata[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+// contentTypeMatches compares media types case-insensitively, ignoring
+// parameters such as charset; pattern may end in "/*"
+func contentTypeMatches(pattern, contentType string) bool {
+	contentType, _, _ = strings.Cut(contentType, ";")
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	pattern = strings.ToLower(pattern)
+	if family, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(contentType, family+"/")
+	}
+	return contentType == pattern
+}
+
+// SigningSecrets returns the secrets deliveries are signed with at now: the
+// current secret and, during a rotation, the previous one
+func (w *Webhook) SigningSecrets(now time.Time) []string {
+	secrets := []string{w.Secret}
+	if w.PreviousSecret != "" && w.PreviousSecretExpiresAt != nil && now.Before(*w.PreviousSecretExpiresAt) {
+		secrets = append(secrets, w.PreviousSecret)
+	}
+	return secrets
+}
+
+// WebhookDelivery is one attempt to deliver an event. QueueID is the queued
+// event it belongs to and Attempt counts the attempts made for it.
 type WebhookDelivery struct {
 	ID           string    `json:"id"`
 	WebhookID    string    `json:"webhook_id"`
+	QueueID      string    `json:"queue_id,omitempty"`
+	Attempt      int       `json:"attempt"`
 	Event        string    `json:"event"`
 	Payload      string    `json:"payload"`
 	StatusCode   int       `json:"status_code"`
@@ -25,4 +117,41 @@ type WebhookDelivery struct {
 	Success      bool      `json:"success"`
 	ErrorMessage string    `json:"error_message,omitempty"`
 	DeliveredAt  time.Time `json:"delivered_at"`
-}
\ No newline at end of file
+}
+
+// Webhook queue statuses: PENDING waits for its next attempt, SENDING is
+// held by a worker until its lease ends, DELIVERED succeeded and DEAD used
+// up its attempts
+const (
+	WebhookQueuePending   = "PENDING"
+	WebhookQueueSending   = "SENDING"
+	WebhookQueueDelivered = "DELIVERED"
+	WebhookQueueDead      = "DEAD"
+)
+
+// WebhookQueueItem is an event waiting to be delivered to one webhook. It is
+// stored before the first attempt so deliveries survive a restart.
+// ReplayOf is the item an explicit redelivery or replay was queued for.
+type WebhookQueueItem struct {
+	ID            string    `json:"id"`
+	WebhookID     string    `json:"webhook_id"`
+	Event         string    `json:"event"`
+	Payload       string    `json:"payload"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	ReplayOf      string    `json:"replay_of,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// WebhookDeliveryFilter selects a page of a webhook's delivery log. Event is
+// an event type or a family such as "object.*"; a nil Success matches both
+// outcomes.
+type WebhookDeliveryFilter struct {
+	Event   string
+	Success *bool
+	Limit   int
+	Offset  int
+}
@@ -0,0 +1,71 @@
+package domain
+
+import "testing"
+
+func TestWebhookFilterMatches(t *testing.T) {
+	size := func(n int64) *int64 { return &n }
+	photo := &EventObject{
+		Key: "photos/cat.JPG", FileID: "f1", Size: 2048,
+		ContentType: "Image/JPEG; charset=binary", Metadata: map[string]string{"team": "pets"},
+	}
+	deleted := &EventObject{Key: "photos/cat.JPG"}
+
+	tests := []struct {
+		name   string
+		filter *WebhookFilter
+		object *EventObject
+		want   bool
+	}{
+		{"no filter", nil, photo, true},
+		{"not about an object", &WebhookFilter{KeyPrefix: "photos/"}, nil, true},
+		{"key prefix and suffix", &WebhookFilter{KeyPrefix: "photos/", KeySuffix: ".JPG"}, photo, true},
+		{"key prefix mismatch", &WebhookFilter{KeyPrefix: "videos/"}, photo, false},
+		{"key suffix is case sensitive", &WebhookFilter{KeySuffix: ".jpg"}, photo, false},
+		{"key conditions match deletes", &WebhookFilter{KeyPrefix: "photos/"}, deleted, true},
+		{"content type", &WebhookFilter{ContentType: "image/jpeg"}, photo, true},
+		{"content type family", &WebhookFilter{ContentType: "image/*"}, photo, true},
+		{"content type mismatch", &WebhookFilter{ContentType: "image/png"}, photo, false},
+		{"content type never matches deletes", &WebhookFilter{ContentType: "image/*"}, deleted, false},
+		{"size within bounds", &WebhookFilter{MinSize: size(2048), MaxSize: size(2048)}, photo, true},
+		{"too small", &WebhookFilter{MinSize: size(2049)}, photo, false},
+		{"too large", &WebhookFilter{MaxSize: size(2047)}, photo, false},
+		{"size never matches deletes", &WebhookFilter{MaxSize: size(1 << 30)}, deleted, false},
+		{"metadata", &WebhookFilter{Metadata: map[string]string{"team": "pets"}}, photo, true},
+		{"metadata value mismatch", &WebhookFilter{Metadata: map[string]string{"team": "ops"}}, photo, false},
+		{"metadata key missing", &WebhookFilter{Metadata: map[string]string{"owner": "pets"}}, photo, false},
+		{"every condition must hold", &WebhookFilter{KeyPrefix: "photos/", ContentType: "image/*", MaxSize: size(1024)}, photo, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.object); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentTypeMatches(t *testing.T) {
+	tests := []struct {
+		pattern     string
+		contentType string
+		want        bool
+	}{
+		{"text/plain", "text/plain", true},
+		{"text/plain", "TEXT/Plain", true},
+		{"text/plain", "text/plain; charset=utf-8", true},
+		{"text/plain", " text/plain ", true},
+		{"text/plain", "text/html", false},
+		{"text/*", "text/html", true},
+		{"text/*", "text", false},
+		{"text/*", "textual/html", false},
+		{"text/*", "", false},
+		{"image/jpeg", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.contentType, func(t *testing.T) {
+			if got := contentTypeMatches(tt.pattern, tt.contentType); got != tt.want {
+				t.Errorf("contentTypeMatches(%q, %q) = %v, want %v", tt.pattern, tt.contentType, got, tt.want)
+			}
+		})
+	}
+}
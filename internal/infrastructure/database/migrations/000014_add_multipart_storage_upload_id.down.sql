@@ -0,0 +1 @@
+ALTER TABLE multipart_uploads DROP COLUMN IF EXISTS storage_upload_id;
@@ -0,0 +1,2 @@
+-- Upload ID issued by the storage backend for native multipart uploads
+ALTER TABLE multipart_uploads ADD COLUMN storage_upload_id TEXT NOT NULL DEFAULT '';
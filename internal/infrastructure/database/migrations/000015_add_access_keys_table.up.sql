@@ -0,0 +1,10 @@
+CREATE TABLE access_keys (
+    access_key_id VARCHAR(128) PRIMARY KEY,
+    secret_key VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP
+);
+
+CREATE INDEX idx_access_keys_user ON access_keys(user_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_access_keys_secret_hash;
+ALTER TABLE access_keys DROP COLUMN IF EXISTS secret_hash;
@@ -0,0 +1,5 @@
+ALTER TABLE access_keys ADD COLUMN secret_hash VARCHAR(64) NOT NULL DEFAULT '';
+
+UPDATE access_keys SET secret_hash = encode(sha256(secret_key::bytea), 'hex');
+
+CREATE UNIQUE INDEX idx_access_keys_secret_hash ON access_keys(secret_hash) WHERE secret_hash <> '';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_policies_updated_by;
+DROP INDEX IF EXISTS idx_policies_bucket_version;
+DROP TABLE IF EXISTS policies;
+ALTER TABLE buckets DROP COLUMN IF EXISTS policy_version;
@@ -0,0 +1,16 @@
+ALTER TABLE buckets ADD COLUMN IF NOT EXISTS policy_version INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE policies (
+    id UUID PRIMARY KEY,
+    bucket_id VARCHAR(255) NOT NULL REFERENCES buckets(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    policy_document JSONB NOT NULL,
+    updated_by TEXT NOT NULL,
+    restored_from INTEGER,
+    previous_version_id UUID REFERENCES policies(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_policies_bucket_version ON policies(bucket_id, version);
+CREATE INDEX idx_policies_updated_by ON policies(updated_by);
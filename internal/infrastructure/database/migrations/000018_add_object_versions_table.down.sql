@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_object_versions_key;
+DROP INDEX IF EXISTS idx_object_versions_version;
+DROP TABLE IF EXISTS object_versions;
+ALTER TABLE buckets DROP COLUMN IF EXISTS versioning_status;
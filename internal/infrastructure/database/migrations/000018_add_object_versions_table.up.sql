@@ -0,0 +1,19 @@
+ALTER TABLE buckets ADD COLUMN IF NOT EXISTS versioning_status VARCHAR(16) NOT NULL DEFAULT 'Suspended';
+ALTER TABLE files ADD COLUMN IF NOT EXISTS version VARCHAR(255);
+
+CREATE TABLE object_versions (
+    id VARCHAR(255) PRIMARY KEY,
+    bucket_id VARCHAR(255) NOT NULL REFERENCES buckets(id) ON DELETE CASCADE,
+    object_key VARCHAR(500) NOT NULL,
+    version_id VARCHAR(255) NOT NULL,
+    is_latest BOOLEAN NOT NULL DEFAULT FALSE,
+    is_delete_marker BOOLEAN NOT NULL DEFAULT FALSE,
+    size BIGINT NOT NULL DEFAULT 0,
+    etag VARCHAR(100),
+    content_type VARCHAR(255),
+    metadata JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_object_versions_version ON object_versions(bucket_id, version_id);
+CREATE INDEX idx_object_versions_key ON object_versions(bucket_id, object_key, created_at DESC);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_lifecycle_actions_bucket;
+DROP TABLE IF EXISTS lifecycle_actions;
+DROP INDEX IF EXISTS idx_lifecycle_rules_bucket_rule;
+DROP TABLE IF EXISTS bucket_lifecycle_rules;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS bucket_lifecycle_rules (
+    id VARCHAR(255) PRIMARY KEY,
+    bucket_id VARCHAR(255) NOT NULL REFERENCES buckets(id) ON DELETE CASCADE,
+    rule JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE bucket_lifecycle_rules ADD COLUMN IF NOT EXISTS rule_id VARCHAR(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lifecycle_rules_bucket_rule ON bucket_lifecycle_rules(bucket_id, rule_id);
+
+CREATE TABLE lifecycle_actions (
+    id VARCHAR(255) PRIMARY KEY,
+    run_id VARCHAR(255) NOT NULL,
+    bucket_id VARCHAR(255) NOT NULL,
+    rule_id VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    object_key VARCHAR(500) NOT NULL,
+    version_id VARCHAR(255),
+    upload_id VARCHAR(255),
+    error TEXT,
+    executed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_lifecycle_actions_bucket ON lifecycle_actions(bucket_id, executed_at DESC);
@@ -0,0 +1,6 @@
+ALTER TABLE lifecycle_actions DROP COLUMN IF EXISTS storage_class;
+
+DROP INDEX IF EXISTS idx_files_cold;
+
+ALTER TABLE files DROP COLUMN IF EXISTS restore_expires_at;
+ALTER TABLE files ALTER COLUMN storage_class DROP NOT NULL;
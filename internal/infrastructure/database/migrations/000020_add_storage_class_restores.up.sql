@@ -0,0 +1,10 @@
+ALTER TABLE files ADD COLUMN IF NOT EXISTS storage_class VARCHAR(50) DEFAULT 'STANDARD';
+UPDATE files SET storage_class = 'STANDARD' WHERE storage_class IS NULL;
+ALTER TABLE files ALTER COLUMN storage_class SET NOT NULL;
+
+-- When the temporary hot copy of a restored cold object is removed again
+ALTER TABLE files ADD COLUMN IF NOT EXISTS restore_expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_files_cold ON files(bucket_id, storage_class) WHERE storage_class <> 'STANDARD';
+
+ALTER TABLE lifecycle_actions ADD COLUMN storage_class VARCHAR(50);
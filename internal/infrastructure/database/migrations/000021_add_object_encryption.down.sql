@@ -0,0 +1,6 @@
+ALTER TABLE multipart_uploads DROP COLUMN IF EXISTS encryption_key;
+ALTER TABLE multipart_uploads DROP COLUMN IF EXISTS encryption;
+
+ALTER TABLE files DROP COLUMN IF EXISTS sse_customer_key_md5;
+ALTER TABLE files DROP COLUMN IF EXISTS encryption_key;
+ALTER TABLE files DROP COLUMN IF EXISTS encryption;
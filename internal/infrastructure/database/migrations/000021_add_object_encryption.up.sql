@@ -0,0 +1,11 @@
+ALTER TABLE buckets ADD COLUMN IF NOT EXISTS encryption_enabled BOOLEAN DEFAULT false;
+
+-- SSE mode of each object: '' (none), 'AES256' (server-managed) or 'SSE-C'
+ALTER TABLE files ADD COLUMN encryption VARCHAR(20) NOT NULL DEFAULT '';
+-- Per-object data key sealed with the master key (AES256 only)
+ALTER TABLE files ADD COLUMN encryption_key TEXT;
+-- Base64 MD5 of the customer-provided key (SSE-C only)
+ALTER TABLE files ADD COLUMN sse_customer_key_md5 VARCHAR(64);
+
+ALTER TABLE multipart_uploads ADD COLUMN encryption VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE multipart_uploads ADD COLUMN encryption_key TEXT;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_files_scrub_error;
+
+ALTER TABLE files DROP COLUMN IF EXISTS scrub_error;
+ALTER TABLE files DROP COLUMN IF EXISTS scrubbed_at;
+ALTER TABLE files DROP COLUMN IF EXISTS checksum_crc32c;
+ALTER TABLE files DROP COLUMN IF EXISTS checksum_sha256;
@@ -0,0 +1,9 @@
+-- files.etag (hex MD5) exists since 000005; hex SHA-256 and CRC32C join it
+ALTER TABLE files ADD COLUMN checksum_sha256 VARCHAR(64);
+ALTER TABLE files ADD COLUMN checksum_crc32c VARCHAR(8);
+
+-- Last integrity scrub, and why the object failed it (NULL when it passed)
+ALTER TABLE files ADD COLUMN scrubbed_at TIMESTAMP;
+ALTER TABLE files ADD COLUMN scrub_error TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_files_scrub_error ON files(bucket_id) WHERE scrub_error IS NOT NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE files DROP COLUMN IF EXISTS legal_hold;
+ALTER TABLE files DROP COLUMN IF EXISTS retain_until;
+ALTER TABLE files DROP COLUMN IF EXISTS retention_mode;
+
+ALTER TABLE buckets DROP COLUMN IF EXISTS object_lock_days;
+ALTER TABLE buckets DROP COLUMN IF EXISTS object_lock_mode;
+ALTER TABLE buckets DROP COLUMN IF EXISTS object_lock_enabled;
@@ -0,0 +1,10 @@
+-- Bucket object lock; once enabled it stays on. The default retention is
+-- applied to new objects when both mode and days are set.
+ALTER TABLE buckets ADD COLUMN object_lock_enabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE buckets ADD COLUMN object_lock_mode VARCHAR(20);
+ALTER TABLE buckets ADD COLUMN object_lock_days INT;
+
+-- Per-object retention ('' | GOVERNANCE | COMPLIANCE) and legal hold
+ALTER TABLE files ADD COLUMN retention_mode VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE files ADD COLUMN retain_until TIMESTAMP;
+ALTER TABLE files ADD COLUMN legal_hold BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS buckets_drop_quota ON buckets;
+DROP FUNCTION IF EXISTS drop_bucket_quota();
+DROP TRIGGER IF EXISTS files_storage_usage ON files;
+DROP FUNCTION IF EXISTS track_storage_usage();
+DROP FUNCTION IF EXISTS adjust_storage_usage(TEXT, TEXT, BIGINT, BIGINT);
+DROP TABLE IF EXISTS storage_quotas;
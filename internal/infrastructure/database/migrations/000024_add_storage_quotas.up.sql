@@ -0,0 +1,79 @@
+-- Quotas and usage per bucket and per bucket owner. A limit of 0 means
+-- unlimited. used_* count current objects and are kept up to date by the
+-- files trigger below; reserved_* hold the size of writes in flight, so
+-- concurrent uploads cannot overshoot a limit together.
+CREATE TABLE IF NOT EXISTS storage_quotas (
+    scope VARCHAR(20) NOT NULL,
+    subject_id VARCHAR(255) NOT NULL,
+    max_bytes BIGINT NOT NULL DEFAULT 0,
+    max_objects BIGINT NOT NULL DEFAULT 0,
+    warning_percent INT NOT NULL DEFAULT 80,
+    used_bytes BIGINT NOT NULL DEFAULT 0,
+    used_objects BIGINT NOT NULL DEFAULT 0,
+    reserved_bytes BIGINT NOT NULL DEFAULT 0,
+    reserved_objects BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (scope, subject_id)
+);
+
+CREATE OR REPLACE FUNCTION adjust_storage_usage(p_scope TEXT, p_subject TEXT, p_bytes BIGINT, p_objects BIGINT) RETURNS void AS $$
+BEGIN
+    INSERT INTO storage_quotas (scope, subject_id, used_bytes, used_objects)
+    VALUES (p_scope, p_subject, p_bytes, p_objects)
+    ON CONFLICT (scope, subject_id) DO UPDATE
+    SET used_bytes = storage_quotas.used_bytes + p_bytes,
+        used_objects = storage_quotas.used_objects + p_objects,
+        updated_at = NOW();
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION track_storage_usage() RETURNS trigger AS $$
+DECLARE
+    v_owner TEXT;
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM adjust_storage_usage('bucket', OLD.bucket_id, -OLD.size, -1);
+        SELECT owner_id INTO v_owner FROM buckets WHERE id = OLD.bucket_id;
+        IF v_owner IS NOT NULL THEN
+            PERFORM adjust_storage_usage('owner', v_owner, -OLD.size, -1);
+        END IF;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM adjust_storage_usage('bucket', NEW.bucket_id, NEW.size, 1);
+        SELECT owner_id INTO v_owner FROM buckets WHERE id = NEW.bucket_id;
+        IF v_owner IS NOT NULL THEN
+            PERFORM adjust_storage_usage('owner', v_owner, NEW.size, 1);
+        END IF;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER files_storage_usage
+AFTER INSERT OR DELETE OR UPDATE OF size, bucket_id ON files
+FOR EACH ROW EXECUTE PROCEDURE track_storage_usage();
+
+-- A deleted bucket takes its quota with it
+CREATE OR REPLACE FUNCTION drop_bucket_quota() RETURNS trigger AS $$
+BEGIN
+    DELETE FROM storage_quotas WHERE scope = 'bucket' AND subject_id = OLD.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER buckets_drop_quota
+AFTER DELETE ON buckets
+FOR EACH ROW EXECUTE PROCEDURE drop_bucket_quota();
+
+-- Usage of the objects stored before quotas existed
+INSERT INTO storage_quotas (scope, subject_id, used_bytes, used_objects)
+SELECT 'bucket', b.id, COALESCE(SUM(f.size), 0), COUNT(f.id)
+FROM buckets b LEFT JOIN files f ON f.bucket_id = b.id
+GROUP BY b.id
+ON CONFLICT (scope, subject_id) DO NOTHING;
+
+INSERT INTO storage_quotas (scope, subject_id, used_bytes, used_objects)
+SELECT 'owner', b.owner_id, COALESCE(SUM(f.size), 0), COUNT(f.id)
+FROM buckets b LEFT JOIN files f ON f.bucket_id = b.id
+GROUP BY b.owner_id
+ON CONFLICT (scope, subject_id) DO NOTHING;
@@ -0,0 +1,4 @@
+ALTER TABLE files DROP COLUMN IF EXISTS replication_status;
+
+DROP TABLE IF EXISTS replication_tasks;
+DROP TABLE IF EXISTS bucket_replication_rules;
@@ -0,0 +1,37 @@
+-- Replication rules; a rule goes away with its source or destination bucket
+CREATE TABLE IF NOT EXISTS bucket_replication_rules (
+    bucket_id VARCHAR(255) NOT NULL REFERENCES buckets(id) ON DELETE CASCADE,
+    rule_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    prefix VARCHAR(500) NOT NULL DEFAULT '',
+    destination_bucket VARCHAR(255) NOT NULL REFERENCES buckets(id) ON DELETE CASCADE,
+    replicate_deletes BOOLEAN NOT NULL DEFAULT false,
+    replicate_metadata BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (bucket_id, rule_id)
+);
+
+-- One task per key and destination. seq is bumped by every new event so a
+-- worker finishing an older one leaves the task pending.
+CREATE TABLE IF NOT EXISTS replication_tasks (
+    id VARCHAR(255) PRIMARY KEY,
+    bucket_id VARCHAR(255) NOT NULL REFERENCES buckets(id) ON DELETE CASCADE,
+    object_key VARCHAR(500) NOT NULL,
+    destination_bucket VARCHAR(255) NOT NULL REFERENCES buckets(id) ON DELETE CASCADE,
+    rule_id VARCHAR(255) NOT NULL,
+    operation VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    seq BIGINT NOT NULL DEFAULT 1,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (bucket_id, object_key, destination_bucket)
+);
+
+CREATE INDEX idx_replication_tasks_due ON replication_tasks(next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX idx_replication_tasks_bucket ON replication_tasks(bucket_id, status, updated_at DESC);
+
+-- PENDING | COMPLETED | FAILED for source objects, REPLICA for copies
+ALTER TABLE files ADD COLUMN replication_status VARCHAR(20);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_queue;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS attempt;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS queue_id;
+
+DROP TABLE IF EXISTS webhook_queue;
+
+ALTER TABLE webhooks DROP COLUMN IF EXISTS max_concurrency;
@@ -0,0 +1,27 @@
+-- Deliveries in flight to one webhook at once; 0 uses the server default
+ALTER TABLE webhooks ADD COLUMN max_concurrency INT NOT NULL DEFAULT 0;
+
+-- Events waiting to be delivered, stored before the first attempt. A
+-- SENDING row is leased to a worker until next_attempt_at and is picked up
+-- again if that worker dies.
+CREATE TABLE IF NOT EXISTS webhook_queue (
+    id VARCHAR(255) PRIMARY KEY,
+    webhook_id VARCHAR(255) NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_queue_due ON webhook_queue(next_attempt_at) WHERE status IN ('PENDING', 'SENDING');
+CREATE INDEX idx_webhook_queue_webhook ON webhook_queue(webhook_id, status, created_at DESC);
+
+-- Every attempt is logged with its number and the queued event it was for
+ALTER TABLE webhook_deliveries ADD COLUMN queue_id VARCHAR(255);
+ALTER TABLE webhook_deliveries ADD COLUMN attempt INT NOT NULL DEFAULT 1;
+
+CREATE INDEX idx_webhook_deliveries_queue ON webhook_deliveries(queue_id) WHERE queue_id IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_webhook_queue_replay_of;
+
+ALTER TABLE webhook_queue DROP COLUMN IF EXISTS replay_of;
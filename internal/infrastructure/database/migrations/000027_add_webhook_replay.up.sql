@@ -0,0 +1,5 @@
+-- A redelivered or replayed event is queued again as a new item that points
+-- at the item it replays, so failed events are replayed only once
+ALTER TABLE webhook_queue ADD COLUMN replay_of VARCHAR(255);
+
+CREATE INDEX idx_webhook_queue_replay_of ON webhook_queue(replay_of) WHERE replay_of IS NOT NULL;
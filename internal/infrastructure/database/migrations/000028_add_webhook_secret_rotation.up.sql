@@ -0,0 +1,4 @@
+-- The secret replaced by the last rotation keeps signing deliveries
+-- alongside the new one until previous_secret_expires_at
+ALTER TABLE webhooks ADD COLUMN previous_secret VARCHAR(255);
+ALTER TABLE webhooks ADD COLUMN previous_secret_expires_at TIMESTAMP;
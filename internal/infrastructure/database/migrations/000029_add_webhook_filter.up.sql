@@ -0,0 +1,3 @@
+-- Key, content type, size and metadata conditions narrowing a webhook's
+-- object events; NULL delivers every subscribed event
+ALTER TABLE webhooks ADD COLUMN filter JSONB;
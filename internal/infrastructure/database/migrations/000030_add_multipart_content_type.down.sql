@@ -0,0 +1 @@
+ALTER TABLE multipart_uploads DROP COLUMN IF EXISTS content_type;
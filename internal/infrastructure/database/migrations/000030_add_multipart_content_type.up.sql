@@ -0,0 +1,10 @@
+-- Content type given when a multipart upload is initiated, for the
+-- completed object
+ALTER TABLE multipart_uploads ADD COLUMN content_type VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Uploads used to record only mime_type and batch copies only content_type;
+-- fill in whichever one is missing
+UPDATE files SET content_type = mime_type
+WHERE COALESCE(content_type, '') = '' AND COALESCE(mime_type, '') <> '';
+UPDATE files SET mime_type = content_type
+WHERE COALESCE(mime_type, '') = '' AND COALESCE(content_type, '') <> '' AND length(content_type) <= 100;
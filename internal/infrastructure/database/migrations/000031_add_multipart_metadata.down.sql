@@ -0,0 +1,3 @@
+ALTER TABLE multipart_uploads DROP COLUMN IF EXISTS checksum_crc32c;
+ALTER TABLE multipart_uploads DROP COLUMN IF EXISTS checksum_sha256;
+ALTER TABLE multipart_uploads DROP COLUMN IF EXISTS metadata;
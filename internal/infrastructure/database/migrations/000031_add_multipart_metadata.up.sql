@@ -0,0 +1,5 @@
+-- Metadata and whole-object checksums given when a multipart upload is
+-- initiated, for the completed object
+ALTER TABLE multipart_uploads ADD COLUMN metadata JSONB;
+ALTER TABLE multipart_uploads ADD COLUMN checksum_sha256 VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE multipart_uploads ADD COLUMN checksum_crc32c VARCHAR(8) NOT NULL DEFAULT '';
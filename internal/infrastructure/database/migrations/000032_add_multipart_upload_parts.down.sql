@@ -0,0 +1 @@
+DROP TABLE IF EXISTS multipart_upload_parts;
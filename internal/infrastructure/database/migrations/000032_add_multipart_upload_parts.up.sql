@@ -0,0 +1,17 @@
+-- Parts get a row each so uploads of parts in parallel cannot overwrite
+-- each other's entries in the parts array
+CREATE TABLE multipart_upload_parts (
+    upload_id VARCHAR(255) NOT NULL REFERENCES multipart_uploads(upload_id) ON DELETE CASCADE,
+    part_number INT NOT NULL,
+    etag VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    uploaded_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (upload_id, part_number)
+);
+
+INSERT INTO multipart_upload_parts (upload_id, part_number, etag, size, uploaded_at)
+SELECT u.upload_id, (p->>'part_number')::int, p->>'etag', (p->>'size')::bigint,
+       COALESCE((p->>'uploaded_at')::timestamptz, u.updated_at)
+FROM multipart_uploads u, jsonb_array_elements(u.parts) p
+WHERE jsonb_typeof(u.parts) = 'array'
+ON CONFLICT DO NOTHING;
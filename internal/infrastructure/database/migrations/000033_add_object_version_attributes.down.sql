@@ -0,0 +1,6 @@
+ALTER TABLE object_versions DROP COLUMN IF EXISTS legal_hold;
+ALTER TABLE object_versions DROP COLUMN IF EXISTS retain_until;
+ALTER TABLE object_versions DROP COLUMN IF EXISTS retention_mode;
+ALTER TABLE object_versions DROP COLUMN IF EXISTS storage_class;
+ALTER TABLE object_versions DROP COLUMN IF EXISTS checksum_crc32c;
+ALTER TABLE object_versions DROP COLUMN IF EXISTS checksum_sha256;
@@ -0,0 +1,19 @@
+-- Checksums, storage class and object lock state of each version, so a
+-- version that becomes current again gets its own back
+ALTER TABLE object_versions ADD COLUMN checksum_sha256 VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE object_versions ADD COLUMN checksum_crc32c VARCHAR(8) NOT NULL DEFAULT '';
+ALTER TABLE object_versions ADD COLUMN storage_class VARCHAR(50) NOT NULL DEFAULT 'STANDARD';
+ALTER TABLE object_versions ADD COLUMN retention_mode VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE object_versions ADD COLUMN retain_until TIMESTAMP;
+ALTER TABLE object_versions ADD COLUMN legal_hold BOOLEAN NOT NULL DEFAULT false;
+
+-- Current versions take what their files row has
+UPDATE object_versions v
+SET checksum_sha256 = COALESCE(f.checksum_sha256, ''),
+    checksum_crc32c = COALESCE(f.checksum_crc32c, ''),
+    storage_class = f.storage_class,
+    retention_mode = f.retention_mode,
+    retain_until = f.retain_until,
+    legal_hold = f.legal_hold
+FROM files f
+WHERE f.bucket_id = v.bucket_id AND f.version = v.version_id;
@@ -0,0 +1,3 @@
+ALTER TABLE object_versions DROP COLUMN IF EXISTS sse_customer_key_md5;
+ALTER TABLE object_versions DROP COLUMN IF EXISTS encryption_key;
+ALTER TABLE object_versions DROP COLUMN IF EXISTS encryption;
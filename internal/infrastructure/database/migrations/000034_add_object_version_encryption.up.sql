@@ -0,0 +1,14 @@
+-- SSE mode and key of each version (see 000021), so noncurrent versions of
+-- encrypted objects can still be read and restored
+ALTER TABLE object_versions ADD COLUMN encryption VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE object_versions ADD COLUMN encryption_key TEXT;
+ALTER TABLE object_versions ADD COLUMN sse_customer_key_md5 VARCHAR(64);
+
+-- Only current versions can be backfilled; the keys of older versions of
+-- encrypted objects were not kept
+UPDATE object_versions v
+SET encryption = f.encryption,
+    encryption_key = f.encryption_key,
+    sse_customer_key_md5 = f.sse_customer_key_md5
+FROM files f
+WHERE f.bucket_id = v.bucket_id AND f.version = v.version_id;
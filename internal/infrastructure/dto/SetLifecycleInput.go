@@ -13,4 +13,6 @@ type LifecycleRuleInput struct {
     ExpirationDays        int    `json:"expiration_days,omitempty"`
     TransitionDays        int    `json:"transition_days,omitempty"`
     TransitionStorageClass string `json:"transition_storage_class,omitempty"`
+    NoncurrentVersionExpirationDays    int `json:"noncurrent_version_expiration_days,omitempty"`
+    AbortIncompleteMultipartUploadDays int `json:"abort_incomplete_multipart_upload_days,omitempty"`
 }
@@ -0,0 +1,24 @@
+package dto
+
+import "time"
+
+// AccessKeySecretOutput is returned when a key is created or rotated; it is
+// the only time the secret is ever shown.
+type AccessKeySecretOutput struct {
+	AccessKeyID     string    `json:"access_key_id"`
+	SecretAccessKey string    `json:"secret_access_key"`
+	Status          string    `json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type AccessKeyInfo struct {
+	AccessKeyID string     `json:"access_key_id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+}
+
+type ListAccessKeysOutput struct {
+	AccessKeys []AccessKeyInfo `json:"access_keys"`
+	Total      int             `json:"total"`
+}
@@ -1,6 +1,9 @@
 package dto
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // CreateBucketOutput defines data returned after creating a bucket.
 type CreateBucketOutput struct {
@@ -33,19 +36,25 @@ type UpdatePolicyInput struct {
     Effect string `json:"effect" binding:"required,oneof=Allow Deny"`
 
     // Actions defines which operations are affected by this policy.
-    // e.g. ["upload", "delete", "list"]
-    Actions []string `json:"actions" binding:"required,min=1"`
+    // e.g. ["s3:GetObject", "s3:Put*"]
+    Actions []string `json:"actions"`
+
+    // NotActions applies the policy to every action except these
+    NotActions []string `json:"not_actions,omitempty"`
 
     // Resources specify what entities this policy applies to.
     // For simplicity, use bucket or object prefixes (e.g. "bucket/*", "bucket/photos/*").
-    Resources []string `json:"resources" binding:"required,min=1"`
+    Resources []string `json:"resources"`
+
+    // NotResources applies the policy to every resource except these
+    NotResources []string `json:"not_resources,omitempty"`
 
     // Principals defines which users, groups, or services are affected.
-    // e.g. ["user:123", "group:admins", "service:replicator"]
+    // e.g. ["user:123", "role:auditors", "*"]
     Principals []string `json:"principals" binding:"required,min=1"`
 
-    // Conditions can be used for advanced rules like time-based or IP-based restrictions.
-    // Optional, extensible.
+    // Conditions can be used for advanced rules like time-based or IP-based restrictions,
+    // e.g. {"IpAddress": {"aws:SourceIp": "10.0.0.0/8"}}. Optional.
     Conditions map[string]interface{} `json:"conditions,omitempty"`
 }
 
@@ -78,6 +87,44 @@ type BucketPolicyOutput struct {
 	Policy   string `json:"policy"`
 }
 
+// PolicyVersionsOutput lists a bucket's policy history, newest first
+type PolicyVersionsOutput struct {
+	BucketID string              `json:"bucket_id"`
+	Versions []PolicyVersionInfo `json:"versions"`
+	Count    int                 `json:"count"`
+}
+
+// PolicyVersionInfo is one policy version; RestoredFrom is set when the
+// version was created by rolling back to an earlier one.
+type PolicyVersionInfo struct {
+	Version      int             `json:"version"`
+	Policy       json.RawMessage `json:"policy"`
+	UpdatedBy    string          `json:"updated_by"`
+	RestoredFrom *int            `json:"restored_from,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// PolicyDiffOutput describes how the statements of version To differ from
+// those of version From.
+type PolicyDiffOutput struct {
+	BucketID        string                       `json:"bucket_id"`
+	From            int                          `json:"from"`
+	To              int                          `json:"to"`
+	DocumentVersion *PolicyDocumentVersionChange `json:"document_version,omitempty"`
+	Added           []json.RawMessage            `json:"added"`
+	Removed         []json.RawMessage            `json:"removed"`
+	Unchanged       int                          `json:"unchanged"`
+}
+
+type PolicyDocumentVersionChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type RollbackPolicyInput struct {
+	Version int `json:"version" binding:"required,min=1"`
+}
+
 
 
 type CreateBucketInput struct {
@@ -94,3 +141,38 @@ type PolicyStatement struct {
 }
 
  
+
+// SimulatePolicyInput describes a request to evaluate against a bucket
+// policy. Principal defaults to the caller and Resource to the bucket ARN
+// (or the object ARN when Key is set); Roles are looked up when omitted.
+// Policy, when given, is evaluated instead of the stored bucket policy.
+type SimulatePolicyInput struct {
+	Principal string            `json:"principal"`
+	Roles     []string          `json:"roles,omitempty"`
+	Action    string            `json:"action" binding:"required"`
+	Resource  string            `json:"resource,omitempty"`
+	Key       string            `json:"key,omitempty"`
+	Context   map[string]string `json:"context,omitempty"`
+	Policy    json.RawMessage   `json:"policy,omitempty"`
+}
+
+// SimulatePolicyOutput explains a simulated evaluation. Decision is the
+// policy's verdict; EffectiveDecision also applies the admin and owner rules
+// named in Bypass.
+type SimulatePolicyOutput struct {
+	BucketID          string            `json:"bucket_id"`
+	Principal         string            `json:"principal"`
+	Roles             []string          `json:"roles"`
+	Action            string            `json:"action"`
+	Resource          string            `json:"resource"`
+	Context           map[string]string `json:"context"`
+	PolicySource      string            `json:"policy_source"` // "bucket" or "request"
+	Decision          string            `json:"decision"`
+	ExplicitDeny      bool              `json:"explicit_deny"`
+	ImplicitDeny      bool              `json:"implicit_deny"`
+	MatchedStatement  *int              `json:"matched_statement,omitempty"`
+	Statement         json.RawMessage   `json:"statement,omitempty"`
+	Bypass            string            `json:"bypass,omitempty"`
+	EffectiveDecision string            `json:"effective_decision"`
+	Reason            string            `json:"reason"`
+}
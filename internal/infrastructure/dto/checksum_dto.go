@@ -0,0 +1,41 @@
+package dto
+
+import "time"
+
+// ChecksumInput carries the checksums a client sent with an upload:
+// Content-MD5 (base64) and x-checksum-sha256 / x-checksum-crc32c (hex or
+// base64). Algorithm "CRC32C" asks for a CRC32C without supplying one.
+type ChecksumInput struct {
+	ContentMD5 string
+	SHA256     string
+	CRC32C     string
+	Algorithm  string
+}
+
+// ScrubReport summarises one integrity scrub of a bucket
+type ScrubReport struct {
+	BucketID   string    `json:"bucket_id"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Scanned    int       `json:"scanned"`
+	Verified   int       `json:"verified"`
+	Mismatched int       `json:"mismatched"`
+	// Skipped objects are archived, SSE-C encrypted or have no checksums
+	Skipped int `json:"skipped"`
+	// Failed objects could not be read and were not flagged
+	Failed  int            `json:"failed"`
+	Flagged []ScrubFinding `json:"flagged"`
+}
+
+type ScrubFinding struct {
+	FileID     string     `json:"file_id"`
+	Key        string     `json:"key"`
+	Error      string     `json:"error"`
+	ScrubbedAt *time.Time `json:"scrubbed_at,omitempty"`
+}
+
+type FlaggedObjectsOutput struct {
+	BucketID string         `json:"bucket_id"`
+	Count    int            `json:"count"`
+	Objects  []ScrubFinding `json:"objects"`
+}
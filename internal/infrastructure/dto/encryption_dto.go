@@ -0,0 +1,20 @@
+package dto
+
+// SSEInput carries the server-side encryption headers of a request
+// (x-amz-server-side-encryption and x-amz-server-side-encryption-customer-*)
+type SSEInput struct {
+	ServerSideEncryption string
+	CustomerAlgorithm    string
+	CustomerKey          string // base64
+	CustomerKeyMD5       string // base64
+}
+
+type BucketEncryptionInput struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+type BucketEncryptionOutput struct {
+	BucketID  string `json:"bucket_id"`
+	Enabled   bool   `json:"enabled"`
+	Algorithm string `json:"algorithm,omitempty"`
+}
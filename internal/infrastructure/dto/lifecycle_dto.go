@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+// LifecycleActionInfo is one action taken (or, in a dry run, planned) by
+// the lifecycle worker
+type LifecycleActionInfo struct {
+	RuleID    string `json:"rule_id"`
+	Action    string `json:"action"`
+	Key       string `json:"key"`
+	VersionID string `json:"version_id,omitempty"`
+	UploadID  string `json:"upload_id,omitempty"`
+	// StorageClass is the target class of a transition
+	StorageClass string    `json:"storage_class,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	ExecutedAt   time.Time `json:"executed_at"`
+}
+
+// LifecycleReport summarises one lifecycle run over a bucket
+type LifecycleReport struct {
+	BucketID    string                `json:"bucket_id"`
+	RunID       string                `json:"run_id"`
+	DryRun      bool                  `json:"dry_run"`
+	EvaluatedAt time.Time             `json:"evaluated_at"`
+	Rules       int                   `json:"rules"`
+	Actions     []LifecycleActionInfo `json:"actions"`
+	Count       int                   `json:"count"`
+	Failed      int                   `json:"failed"`
+}
+
+type LifecycleHistoryOutput struct {
+	BucketID string                `json:"bucket_id"`
+	Actions  []LifecycleActionInfo `json:"actions"`
+	Count    int                   `json:"count"`
+}
@@ -1,26 +1,34 @@
 package dto
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 type InitiateMultipartUploadInput struct {
 	BucketID    string            `json:"-"`
 	Key         string            `json:"key" binding:"required"`
 	ContentType string            `json:"content_type"`
 	Metadata    map[string]string `json:"metadata"`
+	Encryption  SSEInput          `json:"-"` // from the request's SSE headers
+	// Whole-object SHA-256 and CRC32C from the request's checksum headers
+	Checksums ChecksumInput `json:"-"`
 }
 
 type InitiateMultipartUploadOutput struct {
-	UploadID  string    `json:"upload_id"`
-	BucketID  string    `json:"bucket_id"`
-	Key       string    `json:"key"`
-	CreatedAt time.Time `json:"created_at"`
+	UploadID   string    `json:"upload_id"`
+	BucketID   string    `json:"bucket_id"`
+	Key        string    `json:"key"`
+	CreatedAt  time.Time `json:"created_at"`
+	Encryption string    `json:"encryption,omitempty"`
 }
 
 type UploadPartInput struct {
-	BucketID   string `json:"-"`
-	UploadID   string `json:"-"`
-	PartNumber int    `json:"-"`
-	Data       []byte `json:"-"`
+	BucketID   string    `json:"-"`
+	UploadID   string    `json:"-"`
+	PartNumber int       `json:"-"`
+	Body       io.Reader `json:"-"`
+	Size       int64     `json:"-"` // -1 when unknown
 }
 
 type UploadPartOutput struct {
@@ -45,10 +53,13 @@ type CompleteMultipartUploadOutput struct {
 	Location string `json:"location"`
 	ETag     string `json:"etag"`
 	Size     int64  `json:"size"`
+
+	Encryption string `json:"encryption,omitempty"`
 }
 
 type ListPartsOutput struct {
 	UploadID string     `json:"upload_id"`
+	Key      string     `json:"key"`
 	Parts    []PartInfo `json:"parts"`
 	Total    int        `json:"total"`
 }
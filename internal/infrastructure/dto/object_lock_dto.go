@@ -0,0 +1,43 @@
+package dto
+
+import "time"
+
+type ObjectLockConfigurationInput struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+	// GOVERNANCE or COMPLIANCE; set together with DefaultRetentionDays
+	DefaultMode          string `json:"default_mode"`
+	DefaultRetentionDays int    `json:"default_retention_days"`
+}
+
+type ObjectLockConfigurationOutput struct {
+	BucketID             string `json:"bucket_id"`
+	Enabled              bool   `json:"enabled"`
+	DefaultMode          string `json:"default_mode,omitempty"`
+	DefaultRetentionDays int    `json:"default_retention_days,omitempty"`
+}
+
+// ObjectRetentionInput sets an object's retention; an empty mode and
+// retain_until remove it
+type ObjectRetentionInput struct {
+	Mode        string     `json:"mode"`
+	RetainUntil *time.Time `json:"retain_until"`
+}
+
+type ObjectRetentionOutput struct {
+	FileID      string     `json:"file_id"`
+	Key         string     `json:"key"`
+	Mode        string     `json:"mode,omitempty"`
+	RetainUntil *time.Time `json:"retain_until,omitempty"`
+	// Active is false once retain_until has passed
+	Active bool `json:"active"`
+}
+
+type ObjectLegalHoldInput struct {
+	LegalHold *bool `json:"legal_hold" binding:"required"`
+}
+
+type ObjectLegalHoldOutput struct {
+	FileID    string `json:"file_id"`
+	Key       string `json:"key"`
+	LegalHold bool   `json:"legal_hold"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// ObjectVersionInfo describes one version of an object
+type ObjectVersionInfo struct {
+	VersionID      string            `json:"version_id"`
+	Key            string            `json:"key"`
+	IsLatest       bool              `json:"is_latest"`
+	IsDeleteMarker bool              `json:"is_delete_marker"`
+	Size           int64             `json:"size"`
+	ETag           string            `json:"etag,omitempty"`
+	ContentType    string            `json:"content_type,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+type ListObjectVersionsOutput struct {
+	BucketID string              `json:"bucket_id"`
+	Key      string              `json:"key,omitempty"`
+	Versions []ObjectVersionInfo `json:"versions"`
+	Count    int                 `json:"count"`
+}
+
+// DeleteObjectVersionOutput reports the deleted version and the version of
+// the key that is latest afterwards, if any.
+type DeleteObjectVersionOutput struct {
+	Deleted ObjectVersionInfo  `json:"deleted"`
+	Latest  *ObjectVersionInfo `json:"latest,omitempty"`
+}
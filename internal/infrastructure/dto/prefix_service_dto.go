@@ -14,11 +14,12 @@ type ListByPrefixOutput struct {
 }
 
 type FileInfo struct {
-	Key         string            `json:"key"`
-	Size        int64             `json:"size"`
-	ContentType string            `json:"content_type"`
-	Metadata    map[string]string `json:"metadata"`
-	CreatedAt   time.Time         `json:"created_at"`
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ContentType  string            `json:"content_type"`
+	Metadata     map[string]string `json:"metadata"`
+	CreatedAt    time.Time         `json:"created_at"`
+	StorageClass string            `json:"storage_class,omitempty"`
 }
 
 type DeleteByPrefixInput struct {
@@ -29,6 +30,8 @@ type DeleteByPrefixInput struct {
 type DeleteByPrefixOutput struct {
 	DeletedCount int      `json:"deleted_count"`
 	DeletedKeys  []string `json:"deleted_keys"`
+	// Keys kept because they are under retention or a legal hold
+	LockedKeys []string `json:"locked_keys,omitempty"`
 }
 
 type CopyByPrefixInput struct {
@@ -36,11 +39,21 @@ type CopyByPrefixInput struct {
 	SourcePrefix string `json:"source_prefix" binding:"required"`
 	DestPrefix   string `json:"dest_prefix" binding:"required"`
 	DestBucketID string `json:"dest_bucket_id"`
+	// Unlocks SSE-C sources; from the request's copy-source SSE-C headers
+	SourceEncryption SSEInput `json:"-"`
 }
 
 type CopyByPrefixOutput struct {
 	CopiedCount int      `json:"copied_count"`
 	CopiedKeys  []string `json:"copied_keys"`
+	// Keys that could not be copied, with the reason
+	FailedKeys []PrefixKeyError `json:"failed_keys,omitempty"`
+}
+
+// PrefixKeyError reports why one key of a prefix operation was skipped
+type PrefixKeyError struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
 }
 
 type GetSizeByPrefixInput struct {
@@ -74,6 +87,9 @@ type ArchiveByPrefixOutput struct {
 	ArchiveKey  string `json:"archive_key"`
 	FileCount   int    `json:"file_count"`
 	ArchiveSize int64  `json:"archive_size"`
+	Encryption  string `json:"encryption,omitempty"`
+	// Keys left out of the archive, with the reason
+	FailedKeys []PrefixKeyError `json:"failed_keys,omitempty"`
 }
 
 type SetMetadataByPrefixInput struct {
@@ -85,4 +101,21 @@ type SetMetadataByPrefixInput struct {
 type SetMetadataByPrefixOutput struct {
 	UpdatedCount int      `json:"updated_count"`
 	UpdatedKeys  []string `json:"updated_keys"`
-}
\ No newline at end of file
+}
+
+// ListObjectsInput follows the S3 ListObjectsV2 request parameters
+type ListObjectsInput struct {
+	BucketName        string
+	Prefix            string
+	Delimiter         string
+	StartAfter        string
+	ContinuationToken string
+	MaxKeys           int
+}
+
+type ListObjectsOutput struct {
+	Objects               []FileInfo
+	CommonPrefixes        []string
+	IsTruncated           bool
+	NextContinuationToken string
+}
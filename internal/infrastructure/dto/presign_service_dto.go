@@ -119,4 +119,30 @@ type GenerateMultipartUploadURLsOutput struct {
 	UploadID  string             `json:"upload_id"`
 	Parts     []MultipartURLPart `json:"parts"`
 	ExpiresAt time.Time          `json:"expires_at"`
-}
\ No newline at end of file
+}
+// PresignedRequestInput carries the parts of an incoming presigned URL request
+type PresignedRequestInput struct {
+	BucketName string
+	Key        string
+	Method     string // HTTP method of the request (HEAD is served by GET URLs)
+	URLID      string
+	Expires    string
+	SignedFor  string // "method" query parameter the URL was signed for
+	Signature  string
+}
+
+type PresignedObjectOutput struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+type PresignedUploadOutput struct {
+	FileID    string    `json:"file_id"`
+	Key       string    `json:"key"`
+	Size      int64     `json:"size"`
+	ETag      string    `json:"etag"`
+	CreatedAt time.Time `json:"created_at"`
+}
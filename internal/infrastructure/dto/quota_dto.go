@@ -0,0 +1,41 @@
+package dto
+
+import "time"
+
+// SetQuotaInput sets a bucket or owner quota; 0 leaves a dimension
+// unlimited. WarningPercent defaults to 80; 0 disables the warning event.
+type SetQuotaInput struct {
+	MaxBytes       int64 `json:"max_bytes" binding:"min=0"`
+	MaxObjects     int64 `json:"max_objects" binding:"min=0"`
+	WarningPercent *int  `json:"warning_percent" binding:"omitempty,min=0,max=100"`
+}
+
+type QuotaInfo struct {
+	Scope           string    `json:"scope"`
+	SubjectID       string    `json:"subject_id"`
+	MaxBytes        int64     `json:"max_bytes"`
+	MaxObjects      int64     `json:"max_objects"`
+	WarningPercent  int       `json:"warning_percent"`
+	UsedBytes       int64     `json:"used_bytes"`
+	UsedObjects     int64     `json:"used_objects"`
+	ReservedBytes   int64     `json:"reserved_bytes"`
+	ReservedObjects int64     `json:"reserved_objects"`
+	UsagePercent    float64   `json:"usage_percent"`
+	Warning         bool      `json:"warning"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// QuotaStatusOutput is the bucket's quota and that of its owner; a write
+// must fit both
+type QuotaStatusOutput struct {
+	BucketID string    `json:"bucket_id"`
+	Bucket   QuotaInfo `json:"bucket"`
+	Owner    QuotaInfo `json:"owner"`
+}
+
+// QuotaWarningEvent is the data of a quota.warning webhook
+type QuotaWarningEvent struct {
+	BucketID string    `json:"bucket_id"`
+	Key      string    `json:"key"`
+	Quota    QuotaInfo `json:"quota"`
+}
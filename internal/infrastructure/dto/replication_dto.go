@@ -0,0 +1,66 @@
+package dto
+
+import "time"
+
+// SetReplicationInput replaces a bucket's replication rules; an empty list
+// turns replication off
+type SetReplicationInput struct {
+	Rules []ReplicationRuleInput `json:"rules"`
+}
+
+type ReplicationRuleInput struct {
+	ID                string `json:"id,omitempty"`
+	Status            string `json:"status" binding:"required,oneof=Enabled Disabled"`
+	Prefix            string `json:"prefix"`
+	DestinationBucket string `json:"destination_bucket" binding:"required"`
+	ReplicateDeletes  bool   `json:"replicate_deletes"`
+	// Defaults to true
+	ReplicateMetadata *bool `json:"replicate_metadata,omitempty"`
+}
+
+type ReplicationRuleInfo struct {
+	ID                string `json:"id"`
+	Status            string `json:"status"`
+	Prefix            string `json:"prefix"`
+	DestinationBucket string `json:"destination_bucket"`
+	ReplicateDeletes  bool   `json:"replicate_deletes"`
+	ReplicateMetadata bool   `json:"replicate_metadata"`
+}
+
+type ReplicationConfigurationOutput struct {
+	BucketID string                `json:"bucket_id"`
+	Rules    []ReplicationRuleInfo `json:"rules"`
+}
+
+// ReplicationTaskInfo is the replication state of one key in one destination
+type ReplicationTaskInfo struct {
+	Key               string `json:"key"`
+	DestinationBucket string `json:"destination_bucket"`
+	RuleID            string `json:"rule_id"`
+	Operation         string `json:"operation"`
+	Status            string `json:"status"`
+	Attempts          int    `json:"attempts"`
+	LastError         string `json:"last_error,omitempty"`
+	// Set while the task is pending
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type ReplicationTasksOutput struct {
+	BucketID string                `json:"bucket_id"`
+	Tasks    []ReplicationTaskInfo `json:"tasks"`
+	Count    int                   `json:"count"`
+}
+
+// ReplicationBackfillOutput reports how many existing objects a backfill
+// queued for replication
+type ReplicationBackfillOutput struct {
+	BucketID string `json:"bucket_id"`
+	Scanned  int    `json:"scanned"`
+	Queued   int    `json:"queued"`
+}
+
+type ReplicationRetryOutput struct {
+	BucketID string `json:"bucket_id"`
+	Retried  int64  `json:"retried"`
+}
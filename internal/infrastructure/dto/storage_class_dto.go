@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// RestoreObjectInput requests a temporary hot copy of a cold object
+type RestoreObjectInput struct {
+	Days int `json:"days" binding:"required,min=1,max=365"`
+}
+
+type RestoreObjectOutput struct {
+	FileID           string    `json:"file_id"`
+	BucketID         string    `json:"bucket_id"`
+	Key              string    `json:"key"`
+	StorageClass     string    `json:"storage_class"`
+	RestoreExpiresAt time.Time `json:"restore_expires_at"`
+	// AlreadyRestored is set when a hot copy existed and only its expiry
+	// was extended
+	AlreadyRestored bool `json:"already_restored"`
+}
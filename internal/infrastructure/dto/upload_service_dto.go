@@ -13,6 +13,33 @@ type FileInfoOutput struct {
     MimeType  string          `json:"mime_type"`
     Metadata  map[string]string `json:"metadata"`
     CreatedAt time.Time       `json:"created_at"`
+
+    // Set from storage when the file is opened for download
+    ETag         string    `json:"etag,omitempty"`
+    LastModified time.Time `json:"last_modified,omitempty"`
+
+    StorageClass string `json:"storage_class,omitempty"`
+    // Set while a temporary hot copy of a cold object is available
+    RestoreExpiresAt *time.Time `json:"restore_expires_at,omitempty"`
+
+    // AES256 or SSE-C; empty when the object is stored unencrypted
+    Encryption        string `json:"encryption,omitempty"`
+    SSECustomerKeyMD5 string `json:"sse_customer_key_md5,omitempty"`
+
+    // Hex checksums recorded at upload; ScrubError is set when the last
+    // integrity scrub found the stored data no longer matches them
+    ChecksumSHA256 string `json:"checksum_sha256,omitempty"`
+    ChecksumCRC32C string `json:"checksum_crc32c,omitempty"`
+    ScrubError     string `json:"scrub_error,omitempty"`
+
+    // Object lock state; see the retention and legal-hold endpoints
+    RetentionMode string     `json:"retention_mode,omitempty"`
+    RetainUntil   *time.Time `json:"retain_until,omitempty"`
+    LegalHold     bool       `json:"legal_hold,omitempty"`
+
+    // PENDING, COMPLETED or FAILED for objects covered by replication
+    // rules, REPLICA for copies made by replication
+    ReplicationStatus string `json:"replication_status,omitempty"`
 }
 
 
@@ -22,11 +49,13 @@ type UpdateFileMetadataInput struct {
 }
 
 type CopyFileInput struct {
-	DestinationBucket string `json:"destination_bucket"`
-	NewKey            string `json:"new_key,omitempty"`
+	DestinationBucket string   `json:"destination_bucket"`
+	NewKey            string   `json:"new_key,omitempty"`
+	SourceEncryption  SSEInput `json:"-"` // from the request's copy-source SSE-C headers
 }
 
 type MoveFileInput struct {
-	DestinationBucket string `json:"destination_bucket"`
-	NewKey            string `json:"new_key,omitempty"`
+	DestinationBucket string   `json:"destination_bucket"`
+	NewKey            string   `json:"new_key,omitempty"`
+	SourceEncryption  SSEInput `json:"-"` // from the request's copy-source SSE-C headers
 }
\ No newline at end of file
@@ -3,12 +3,25 @@ package dto
 import "time"
 
 type CreateWebhookInput struct {
-	BucketID string            `json:"bucket_id" binding:"required"`
-	Name     string            `json:"name" binding:"required"`
-	URL      string            `json:"url" binding:"required,url"`
-	Events   []string          `json:"events" binding:"required,min=1"`
-	Secret   string            `json:"secret"`
-	Headers  map[string]string `json:"headers"`
+	BucketID       string            `json:"bucket_id" binding:"required"`
+	Name           string            `json:"name" binding:"required"`
+	URL            string            `json:"url" binding:"required,url"`
+	Events         []string          `json:"events" binding:"required,min=1"`
+	Secret         string            `json:"secret"`
+	Headers        map[string]string `json:"headers"`
+	MaxConcurrency int               `json:"max_concurrency" binding:"min=0,max=100"`
+	Filter         *WebhookFilter    `json:"filter"`
+}
+
+// WebhookFilter narrows a webhook's object events to matching objects; see
+// docs/webhooks.md. Every condition that is set must hold.
+type WebhookFilter struct {
+	KeyPrefix   string            `json:"key_prefix,omitempty"`
+	KeySuffix   string            `json:"key_suffix,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	MinSize     *int64            `json:"min_size,omitempty"`
+	MaxSize     *int64            `json:"max_size,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
 }
 
 type CreateWebhookOutput struct {
@@ -25,32 +38,57 @@ type ListWebhooksOutput struct {
 }
 
 type WebhookInfo struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	URL       string    `json:"url"`
-	Events    []string  `json:"events"`
-	Active    bool      `json:"active"`
-	CreatedAt time.Time `json:"created_at"`
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	URL            string    `json:"url"`
+	Events         []string  `json:"events"`
+	Active         bool           `json:"active"`
+	MaxConcurrency int            `json:"max_concurrency"`
+	Filter         *WebhookFilter `json:"filter,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
 }
 
 type GetWebhookOutput struct {
-	ID        string            `json:"id"`
-	BucketID  string            `json:"bucket_id"`
-	Name      string            `json:"name"`
-	URL       string            `json:"url"`
-	Events    []string          `json:"events"`
-	Active    bool              `json:"active"`
-	Headers   map[string]string `json:"headers"`
-	CreatedAt time.Time         `json:"created_at"`
-	UpdatedAt time.Time         `json:"updated_at"`
+	ID             string            `json:"id"`
+	BucketID       string            `json:"bucket_id"`
+	Name           string            `json:"name"`
+	URL            string            `json:"url"`
+	Events         []string          `json:"events"`
+	Active         bool              `json:"active"`
+	Headers        map[string]string `json:"headers"`
+	MaxConcurrency int               `json:"max_concurrency"`
+	Filter         *WebhookFilter    `json:"filter,omitempty"`
+	// PreviousSecretExpiresAt is when the secret replaced by a rotation
+	// stops signing deliveries; unset outside a rotation
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+	CreatedAt               time.Time  `json:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at"`
 }
 
 type UpdateWebhookInput struct {
-	Name    *string            `json:"name"`
-	URL     *string            `json:"url"`
-	Events  []string           `json:"events"`
-	Active  *bool              `json:"active"`
-	Headers map[string]string  `json:"headers"`
+	Name           *string           `json:"name"`
+	URL            *string           `json:"url"`
+	Events         []string          `json:"events"`
+	Active         *bool             `json:"active"`
+	Headers        map[string]string `json:"headers"`
+	MaxConcurrency *int              `json:"max_concurrency" binding:"omitempty,min=0,max=100"`
+	// Filter replaces the webhook's filter; {} removes it
+	Filter *WebhookFilter `json:"filter"`
+}
+
+// RotateWebhookSecretInput replaces a webhook's secret. Secret is generated
+// when empty, and the old secret keeps signing deliveries for
+// GracePeriodHours (default 24) so receivers can switch over.
+type RotateWebhookSecretInput struct {
+	Secret           string `json:"secret"`
+	GracePeriodHours *int   `json:"grace_period_hours" binding:"omitempty,min=0,max=168"`
+}
+
+type RotateWebhookSecretOutput struct {
+	ID                      string     `json:"id"`
+	Secret                  string     `json:"secret"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+	RotatedAt               time.Time  `json:"rotated_at"`
 }
 
 type TestWebhookOutput struct {
@@ -64,13 +102,77 @@ type TestWebhookOutput struct {
 type WebhookDeliveriesOutput struct {
 	Deliveries []WebhookDeliveryInfo `json:"deliveries"`
 	Total      int                   `json:"total"`
+	Limit      int                   `json:"limit"`
+	Offset     int                   `json:"offset"`
 }
 
 type WebhookDeliveryInfo struct {
 	ID           string    `json:"id"`
+	QueueID      string    `json:"queue_id,omitempty"`
+	Attempt      int       `json:"attempt"`
 	Event        string    `json:"event"`
 	StatusCode   int       `json:"status_code"`
 	Success      bool      `json:"success"`
 	ErrorMessage string    `json:"error_message,omitempty"`
 	DeliveredAt  time.Time `json:"delivered_at"`
-}
\ No newline at end of file
+}
+
+// WebhookRedeliveryOutput reports the event queued again for a delivery
+type WebhookRedeliveryOutput struct {
+	DeliveryID string `json:"delivery_id"`
+	QueueID    string `json:"queue_id"`
+	Event      string `json:"event"`
+}
+
+// WebhookReplayInput selects the events queued in [from, to) for replay
+type WebhookReplayInput struct {
+	From time.Time `json:"from" binding:"required"`
+	To   time.Time `json:"to" binding:"required"`
+}
+
+// WebhookReplayOutput reports how many events a replay queued again.
+// Truncated means the limit was reached and more events are left.
+type WebhookReplayOutput struct {
+	WebhookID string `json:"webhook_id"`
+	Queued    int    `json:"queued"`
+	Truncated bool   `json:"truncated"`
+}
+
+// WebhookPayloadVersion is the version of the webhook payload schema. It
+// changes only when a field is removed or changes meaning; new fields may be
+// added within a version.
+const WebhookPayloadVersion = "1"
+
+// WebhookPayload is the JSON body of every webhook delivery
+type WebhookPayload struct {
+	Version   string      `json:"version"`
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	Timestamp int64       `json:"timestamp"`
+	Time      time.Time   `json:"time"`
+	BucketID  string      `json:"bucket_id"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookEventData is the data of object, multipart, bucket and policy
+// events. Object is set for object and multipart events, Source for copies
+// and moves; Details carries event-specific fields such as the new
+// metadata or the policy version.
+type WebhookEventData struct {
+	Actor   string                 `json:"actor,omitempty"`
+	Object  *WebhookObject         `json:"object,omitempty"`
+	Source  *WebhookObject         `json:"source,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// WebhookObject identifies the object an event is about
+type WebhookObject struct {
+	BucketID     string `json:"bucket_id"`
+	Key          string `json:"key"`
+	FileID       string `json:"file_id,omitempty"`
+	VersionID    string `json:"version_id,omitempty"`
+	Size         int64  `json:"size,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	ContentType  string `json:"content_type,omitempty"`
+	StorageClass string `json:"storage_class,omitempty"`
+}
@@ -0,0 +1,119 @@
+package event
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+)
+
+// memoryMessageLimit is how many events the in-memory publisher keeps; older
+// ones are forgotten
+const memoryMessageLimit = 1000
+
+// Message is one event held by the in-memory publisher
+type Message struct {
+	Topic   string
+	Payload []byte
+}
+
+// MemoryEventPublisher keeps the last memoryMessageLimit published events in
+// memory. It stands in for a broker in tests and local runs without NATS;
+// topics match the same wildcards as NATS subjects ("*" for one token, ">"
+// for the rest).
+type MemoryEventPublisher struct {
+	mu          sync.Mutex
+	messages    []Message
+	subscribers map[chan Message]string
+}
+
+func NewMemoryEventPublisher() *MemoryEventPublisher {
+	return &MemoryEventPublisher{subscribers: make(map[chan Message]string)}
+}
+
+// Publish implements domain.EventPublisher
+func (p *MemoryEventPublisher) Publish(ctx context.Context, topic string, payload interface{}) error {
+	data, err := encodePayload(payload)
+	if err != nil {
+		return err
+	}
+	msg := Message{Topic: topic, Payload: data}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.messages) >= memoryMessageLimit {
+		copy(p.messages, p.messages[1:])
+		p.messages = p.messages[:len(p.messages)-1]
+	}
+	p.messages = append(p.messages, msg)
+	for ch, pattern := range p.subscribers {
+		if !topicMatches(pattern, topic) {
+			continue
+		}
+		select {
+		case ch <- msg:
+		default:
+			log.Printf("event: consumer of %s is behind, dropping %s", pattern, topic)
+		}
+	}
+	return nil
+}
+
+// Consume implements domain.EventPublisher: it logs the events published on
+// topic from now on until ctx is done
+func (p *MemoryEventPublisher) Consume(ctx context.Context, topic string) error {
+	ch := make(chan Message, 64)
+	p.mu.Lock()
+	p.subscribers[ch] = topic
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.subscribers, ch)
+		p.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-ch:
+			log.Printf("event %s: %s", msg.Topic, msg.Payload)
+		}
+	}
+}
+
+// Messages returns the retained events published on topics matching topic
+func (p *MemoryEventPublisher) Messages(topic string) []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []Message
+	for _, msg := range p.messages {
+		if topicMatches(topic, msg.Topic) {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// Reset forgets every published event
+func (p *MemoryEventPublisher) Reset() {
+	p.mu.Lock()
+	p.messages = nil
+	p.mu.Unlock()
+}
+
+// topicMatches reports whether topic matches pattern, a dot-separated topic
+// where "*" matches one token and a trailing ">" matches one or more
+func topicMatches(pattern, topic string) bool {
+	want := strings.Split(pattern, ".")
+	got := strings.Split(topic, ".")
+	for i, token := range want {
+		if token == ">" {
+			return i == len(want)-1 && len(got) > i
+		}
+		if i >= len(got) || (token != "*" && token != got[i]) {
+			return false
+		}
+	}
+	return len(want) == len(got)
+}
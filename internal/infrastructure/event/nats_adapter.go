@@ -0,0 +1,142 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"s3/internal/domain"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// natsStreamMaxAge is how long the stream keeps events for consumers that
+// fall behind
+const natsStreamMaxAge = 7 * 24 * time.Hour
+
+// NATSEventPublisher publishes events to a NATS JetStream stream. A topic
+// is published on the subject <prefix>.<topic>, and the stream captures
+// every subject under the prefix. The connection is retried in the
+// background, so the server starts even while NATS is down; the stream is
+// created on the first publish that reaches it.
+type NATSEventPublisher struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	stream string
+	prefix string
+
+	mu    sync.Mutex
+	ready bool
+}
+
+func NewNATSEventPublisher(url, stream, prefix string) (*NATSEventPublisher, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("s3"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open JetStream: %w", err)
+	}
+	return &NATSEventPublisher{conn: conn, js: js, stream: stream, prefix: prefix}, nil
+}
+
+// Publish implements domain.EventPublisher. Events are sent with their id as
+// the JetStream message id, so a retried publish is not stored twice.
+func (p *NATSEventPublisher) Publish(ctx context.Context, topic string, payload interface{}) error {
+	if err := p.ensureStream(ctx); err != nil {
+		return err
+	}
+	data, err := encodePayload(payload)
+	if err != nil {
+		return err
+	}
+
+	var opts []jetstream.PublishOpt
+	if event, ok := payload.(domain.Event); ok {
+		opts = append(opts, jetstream.WithMsgID(event.ID))
+	}
+	if _, err := p.js.Publish(ctx, p.subject(topic), data, opts...); err != nil {
+		return fmt.Errorf("failed to publish %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Consume implements domain.EventPublisher: it logs the events published on
+// topic from now on until ctx is done. Topics may use NATS wildcards, e.g.
+// "object.*" or ">".
+func (p *NATSEventPublisher) Consume(ctx context.Context, topic string) error {
+	if err := p.ensureStream(ctx); err != nil {
+		return err
+	}
+	consumer, err := p.js.CreateOrUpdateConsumer(ctx, p.stream, jetstream.ConsumerConfig{
+		FilterSubject:     p.subject(topic),
+		DeliverPolicy:     jetstream.DeliverNewPolicy,
+		AckPolicy:         jetstream.AckExplicitPolicy,
+		InactiveThreshold: time.Minute,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create consumer for %s: %w", topic, err)
+	}
+
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		log.Printf("event %s: %s", msg.Subject(), msg.Data())
+		msg.Ack()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", topic, err)
+	}
+	defer consumeCtx.Stop()
+
+	<-ctx.Done()
+	return nil
+}
+
+// Close flushes pending publishes and closes the connection
+func (p *NATSEventPublisher) Close() error {
+	return p.conn.Drain()
+}
+
+func (p *NATSEventPublisher) subject(topic string) string {
+	return p.prefix + "." + topic
+}
+
+func (p *NATSEventPublisher) ensureStream(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ready {
+		return nil
+	}
+	_, err := p.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     p.stream,
+		Subjects: []string{p.prefix + ".>"},
+		Storage:  jetstream.FileStorage,
+		MaxAge:   natsStreamMaxAge,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create stream %s: %w", p.stream, err)
+	}
+	p.ready = true
+	return nil
+}
+
+// encodePayload sends []byte payloads as they are and anything else as JSON
+func encodePayload(payload interface{}) ([]byte, error) {
+	if data, ok := payload.([]byte); ok {
+		return data, nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+	return data, nil
+}
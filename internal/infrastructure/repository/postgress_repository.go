@@ -8,14 +8,16 @@ import (
 	"fmt"
 	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 )
 
 var (
 	// ErrNotFound is returned when a record is not found
-	ErrNotFound = errors.New("record not found")
+	ErrNotFound = domain.ErrNotFound
 	// ErrDuplicate is returned when a unique constraint is violated
 	ErrDuplicate = errors.New("duplicate record")
 )
@@ -66,28 +68,161 @@ func (r *PostgresRepository) SetBucketVersioning(ctx context.Context, bucketID s
 
 	return nil
 }
-func (r *PostgresRepository) AppendPolicyHistory(ctx context.Context, bucketID string, policy *domain.Policy, actor string) error {
-	policyJSON, err := json.Marshal(policy)
+
+// SaveObjectVersion records a new version of a key as its latest
+func (r *PostgresRepository) SaveObjectVersion(ctx context.Context, version *domain.ObjectVersion) error {
+	metadataJSON, err := json.Marshal(version.Metadata)
 	if err != nil {
-		return fmt.Errorf("failed to marshal policy for history: %w", err)
+		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
-	query := `
-		INSERT INTO bucket_policy_history (bucket_id, version, actor, policy, created_at)
-		VALUES ($1, COALESCE((SELECT MAX(version) + 1 FROM bucket_policy_history WHERE bucket_id = $1), 1), $2, $3::jsonb, NOW())
-	`
-	_, err = r.db.ExecContext(ctx, query, bucketID, actor, policyJSON)
+	return r.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE object_versions SET is_latest = FALSE
+			WHERE bucket_id = $1 AND object_key = $2 AND is_latest`,
+			version.BucketID, version.Key,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to clear latest version: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO object_versions (id, bucket_id, object_key, version_id, is_latest, is_delete_marker, size, etag, content_type, metadata, created_at,
+				checksum_sha256, checksum_crc32c, storage_class, retention_mode, retain_until, legal_hold,
+				encryption, encryption_key, sse_customer_key_md5)
+			VALUES ($1, $2, $3, $4, TRUE, $5, $6, $7, $8, $9, $10, $11, $12, COALESCE(NULLIF($13, ''), 'STANDARD'), $14, $15, $16,
+				$17, NULLIF($18, ''), NULLIF($19, ''))`,
+			version.ID, version.BucketID, version.Key, version.VersionID, version.IsDeleteMarker,
+			version.Size, version.ETag, version.ContentType, metadataJSON, version.CreatedAt,
+			version.ChecksumSHA256, version.ChecksumCRC32C, version.StorageClass,
+			version.RetentionMode, version.RetainUntil, version.LegalHold,
+			version.Encryption, version.EncryptionKey, version.SSECustomerKeyMD5,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save object version: %w", err)
+		}
+
+		version.IsLatest = true
+		return nil
+	})
+}
+
+// ListObjectVersions lists the versions of key, newest first; an empty key
+// lists every version in the bucket.
+func (r *PostgresRepository) ListObjectVersions(ctx context.Context, bucketID, key string) ([]domain.ObjectVersion, error) {
+	query := `SELECT ` + objectVersionColumns + `
+		FROM object_versions
+		WHERE bucket_id = $1 AND ($2 = '' OR object_key = $2)
+		ORDER BY object_key, created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, bucketID, key)
 	if err != nil {
-		return fmt.Errorf("failed to append policy history: %w", err)
+		return nil, fmt.Errorf("failed to list object versions: %w", err)
 	}
+	defer rows.Close()
 
-	return nil
+	var versions []domain.ObjectVersion
+	for rows.Next() {
+		version, err := scanObjectVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *version)
+	}
+
+	return versions, rows.Err()
+}
+
+func (r *PostgresRepository) GetObjectVersion(ctx context.Context, bucketID, versionID string) (*domain.ObjectVersion, error) {
+	query := `SELECT ` + objectVersionColumns + `
+		FROM object_versions WHERE bucket_id = $1 AND version_id = $2`
+
+	version, err := scanObjectVersion(r.db.QueryRowContext(ctx, query, bucketID, versionID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return version, err
+}
+
+// DeleteObjectVersion removes one version; when it was the latest, the next
+// newest version of the key is promoted.
+func (r *PostgresRepository) DeleteObjectVersion(ctx context.Context, bucketID, versionID string) (*domain.ObjectVersion, error) {
+	var latest *domain.ObjectVersion
+
+	err := r.WithTx(ctx, func(tx *sql.Tx) error {
+		var key string
+		err := tx.QueryRowContext(ctx, `
+			DELETE FROM object_versions WHERE bucket_id = $1 AND version_id = $2
+			RETURNING object_key`,
+			bucketID, versionID,
+		).Scan(&key)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("failed to delete object version: %w", err)
+		}
+
+		latest, err = scanObjectVersion(tx.QueryRowContext(ctx, `
+			UPDATE object_versions SET is_latest = TRUE
+			WHERE id = (
+				SELECT id FROM object_versions
+				WHERE bucket_id = $1 AND object_key = $2
+				ORDER BY created_at DESC
+				LIMIT 1
+			)
+			RETURNING `+objectVersionColumns,
+			bucketID, key,
+		))
+		if errors.Is(err, sql.ErrNoRows) {
+			latest = nil
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return latest, nil
 }
 
+const objectVersionColumns = `id, bucket_id, object_key, version_id, is_latest, is_delete_marker, size,
+	COALESCE(etag, ''), COALESCE(content_type, ''), metadata, created_at,
+	checksum_sha256, checksum_crc32c, storage_class, retention_mode, retain_until, legal_hold,
+	encryption, COALESCE(encryption_key, ''), COALESCE(sse_customer_key_md5, '')`
+
+func scanObjectVersion(row interface{ Scan(dest ...any) error }) (*domain.ObjectVersion, error) {
+	var version domain.ObjectVersion
+	var metadataJSON []byte
+	var retainUntil sql.NullTime
+	if err := row.Scan(&version.ID, &version.BucketID, &version.Key, &version.VersionID, &version.IsLatest,
+		&version.IsDeleteMarker, &version.Size, &version.ETag, &version.ContentType, &metadataJSON,
+		&version.CreatedAt, &version.ChecksumSHA256, &version.ChecksumCRC32C, &version.StorageClass,
+		&version.RetentionMode, &retainUntil, &version.LegalHold,
+		&version.Encryption, &version.EncryptionKey, &version.SSECustomerKeyMD5); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan object version: %w", err)
+	}
+	version.RetainUntil = nullTime(retainUntil)
+	if len(metadataJSON) > 0 {
+		if err := json.Unmarshal(metadataJSON, &version.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+	return &version, nil
+}
 // GetFileByKey implements domain.RepositoryPort.
 func (r *PostgresRepository) GetFileByKey(ctx context.Context, bucketID string, key string) (*domain.File, error) {
 	query := `
-		SELECT id, bucket_id, key, size, content_type, metadata, version, created_at, updated_at
+		SELECT id, bucket_id, key, size, COALESCE(content_type, ''), metadata, COALESCE(version, ''), created_at, updated_at,
+		       COALESCE(storage_class, 'STANDARD'), restore_expires_at,
+		       encryption, COALESCE(encryption_key, ''), COALESCE(sse_customer_key_md5, ''),
+		       COALESCE(etag, ''), COALESCE(checksum_sha256, ''), COALESCE(checksum_crc32c, ''),
+		       scrubbed_at, COALESCE(scrub_error, ''),
+		       retention_mode, retain_until, legal_hold, COALESCE(replication_status, '')
 		FROM files
 		WHERE bucket_id = $1 AND key = $2
 		LIMIT 1
@@ -95,6 +230,7 @@ func (r *PostgresRepository) GetFileByKey(ctx context.Context, bucketID string,
 
 	var file domain.File
 	var metadataJSON []byte
+	var restoreExpiresAt, scrubbedAt, retainUntil sql.NullTime
 
 	err := r.db.QueryRowContext(ctx, query, bucketID, key).Scan(
 		&file.ID,
@@ -106,14 +242,31 @@ func (r *PostgresRepository) GetFileByKey(ctx context.Context, bucketID string,
 		&file.Version,
 		&file.CreatedAt,
 		&file.UpdatedAt,
+		&file.StorageClass,
+		&restoreExpiresAt,
+		&file.Encryption,
+		&file.EncryptionKey,
+		&file.SSECustomerKeyMD5,
+		&file.ETag,
+		&file.ChecksumSHA256,
+		&file.ChecksumCRC32C,
+		&scrubbedAt,
+		&file.ScrubError,
+		&file.RetentionMode,
+		&retainUntil,
+		&file.LegalHold,
+		&file.ReplicationStatus,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("file not found: %s/%s", bucketID, key)
+			return nil, fmt.Errorf("file not found: %s/%s: %w", bucketID, key, ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get file: %w", err)
 	}
+	file.RestoreExpiresAt = nullTime(restoreExpiresAt)
+	file.ScrubbedAt = nullTime(scrubbedAt)
+	file.RetainUntil = nullTime(retainUntil)
 
 	if len(metadataJSON) > 0 {
 		if err := json.Unmarshal(metadataJSON, &file.Metadata); err != nil {
@@ -370,13 +523,20 @@ func (r *PostgresRepository) GetFileByID(ctx context.Context, id string) (*domai
 	defer cancel()
 
 	query := `
-		SELECT id, bucket_id, key, size, mime_type, metadata, created_at 
+		SELECT id, bucket_id, key, size, mime_type, metadata, created_at,
+		       COALESCE(storage_class, 'STANDARD'), restore_expires_at,
+		       encryption, COALESCE(encryption_key, ''), COALESCE(sse_customer_key_md5, ''),
+		       COALESCE(etag, ''), COALESCE(checksum_sha256, ''), COALESCE(checksum_crc32c, ''),
+		       scrubbed_at, COALESCE(scrub_error, ''),
+		       retention_mode, retain_until, legal_hold, COALESCE(replication_status, ''),
+		       COALESCE(updated_at, created_at)
 		FROM files 
 		WHERE id = $1
 	`
 
 	var file domain.File
 	var metadataJSON []byte
+	var restoreExpiresAt, scrubbedAt, retainUntil sql.NullTime
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&file.ID,
@@ -386,6 +546,21 @@ func (r *PostgresRepository) GetFileByID(ctx context.Context, id string) (*domai
 		&file.MimeType,
 		&metadataJSON,
 		&file.CreatedAt,
+		&file.StorageClass,
+		&restoreExpiresAt,
+		&file.Encryption,
+		&file.EncryptionKey,
+		&file.SSECustomerKeyMD5,
+		&file.ETag,
+		&file.ChecksumSHA256,
+		&file.ChecksumCRC32C,
+		&scrubbedAt,
+		&file.ScrubError,
+		&file.RetentionMode,
+		&retainUntil,
+		&file.LegalHold,
+		&file.ReplicationStatus,
+		&file.UpdatedAt,
 	)
 
 	if err != nil {
@@ -394,6 +569,9 @@ func (r *PostgresRepository) GetFileByID(ctx context.Context, id string) (*domai
 		}
 		return nil, fmt.Errorf("failed to get file: %w", err)
 	}
+	file.RestoreExpiresAt = nullTime(restoreExpiresAt)
+	file.ScrubbedAt = nullTime(scrubbedAt)
+	file.RetainUntil = nullTime(retainUntil)
 
 	if len(metadataJSON) > 0 {
 		if err := json.Unmarshal(metadataJSON, &file.Metadata); err != nil {
@@ -463,47 +641,179 @@ func (r *PostgresRepository) DeleteBucket(ctx context.Context, bucketID string)
 func NewPostgresRepository(db *sql.DB) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
-func (r *PostgresRepository) IncrementPolicyVersionAndUpdateBucket(ctx context.Context, bucket *domain.Bucket) error {
-	// Marshall policy
-	var policyParam interface{}
-	if bucket.Policy != nil {
-		b, err := json.Marshal(bucket.Policy)
+// SaveBucketPolicy updates the bucket's current policy and records it in
+// the policies table in one transaction, linking it to the version it
+// replaces.
+func (r *PostgresRepository) SaveBucketPolicy(ctx context.Context, bucket *domain.Bucket, entry *domain.PolicyVersion) error {
+	policyJSON, err := json.Marshal(bucket.Policy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal policy: %w", err)
+	}
+
+	return r.WithTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			UPDATE buckets
+			SET policy = $1::jsonb, updated_at = $2, policy_version = policy_version + 1
+			WHERE id = $3
+			RETURNING policy_version`,
+			policyJSON, bucket.UpdatedAt, bucket.ID,
+		).Scan(&entry.Version)
 		if err != nil {
-			return err
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("failed to update bucket policy: %w", err)
 		}
-		policyParam = b
-	} else {
-		policyParam = nil
+
+		var previousID sql.NullString
+		err = tx.QueryRowContext(ctx, `
+			SELECT id FROM policies
+			WHERE bucket_id = $1 AND version < $2
+			ORDER BY version DESC
+			LIMIT 1`,
+			bucket.ID, entry.Version,
+		).Scan(&previousID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to find previous policy version: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO policies (id, bucket_id, version, policy_document, updated_by, restored_from, previous_version_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4::jsonb, $5, $6, $7, $8, $8)`,
+			entry.ID, bucket.ID, entry.Version, policyJSON, entry.UpdatedBy, entry.RestoredFrom, previousID, entry.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record policy version: %w", err)
+		}
+
+		entry.BucketID = bucket.ID
+		entry.Policy = bucket.Policy
+		return nil
+	})
+}
+
+// ListPolicyVersions returns a bucket's policy history, newest first
+func (r *PostgresRepository) ListPolicyVersions(ctx context.Context, bucketID string) ([]domain.PolicyVersion, error) {
+	query := `SELECT id, bucket_id, version, policy_document, updated_by, restored_from, created_at
+		FROM policies WHERE bucket_id = $1 ORDER BY version DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policy versions: %w", err)
 	}
+	defer rows.Close()
 
-	query := `UPDATE buckets 
-              SET policy = $1::jsonb, updated_at = $2, policy_version = policy_version + 1
-              WHERE id = $3
-              RETURNING id, name, created_at, updated_at, policy, policy_version`
+	var versions []domain.PolicyVersion
+	for rows.Next() {
+		version, err := scanPolicyVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *version)
+	}
 
-	var returnedPolicy []byte
-	var policyVersion int
-	err := r.db.QueryRowContext(ctx, query, policyParam, bucket.UpdatedAt, bucket.ID).Scan(
-		&bucket.ID, &bucket.Name, &bucket.CreatedAt, &bucket.UpdatedAt, &returnedPolicy, &policyVersion,
-	)
+	return versions, rows.Err()
+}
+
+func (r *PostgresRepository) GetPolicyVersion(ctx context.Context, bucketID string, version int) (*domain.PolicyVersion, error) {
+	query := `SELECT id, bucket_id, version, policy_document, updated_by, restored_from, created_at
+		FROM policies WHERE bucket_id = $1 AND version = $2`
+
+	entry, err := scanPolicyVersion(r.db.QueryRowContext(ctx, query, bucketID, version))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return entry, err
+}
+
+func scanPolicyVersion(row interface{ Scan(dest ...any) error }) (*domain.PolicyVersion, error) {
+	var entry domain.PolicyVersion
+	var document []byte
+	var restoredFrom sql.NullInt64
+	if err := row.Scan(&entry.ID, &entry.BucketID, &entry.Version, &document, &entry.UpdatedBy,
+		&restoredFrom, &entry.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan policy version: %w", err)
+	}
+	if restoredFrom.Valid {
+		from := int(restoredFrom.Int64)
+		entry.RestoredFrom = &from
+	}
+	if err := json.Unmarshal(document, &entry.Policy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal policy version %d: %w", entry.Version, err)
+	}
+	return &entry, nil
+}
+
+// SetBucketEncryption turns the bucket's default encryption on or off
+func (r *PostgresRepository) SetBucketEncryption(ctx context.Context, bucketID string, enabled bool) error {
+	query := `UPDATE buckets SET encryption_enabled = $1, updated_at = NOW() WHERE id = $2`
+
+	res, err := r.db.ExecContext(ctx, query, enabled, bucketID)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to update encryption: %w", err)
 	}
-	bucket.Policy = nil
-	if len(returnedPolicy) > 0 {
-		if err := json.Unmarshal(returnedPolicy, &bucket.Policy); err != nil {
-			return err
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetBucketEncryption reports whether new objects in the bucket are
+// encrypted by default
+func (r *PostgresRepository) GetBucketEncryption(ctx context.Context, bucketID string) (bool, error) {
+	query := `SELECT COALESCE(encryption_enabled, false) FROM buckets WHERE id = $1`
+
+	var enabled bool
+	if err := r.db.QueryRowContext(ctx, query, bucketID).Scan(&enabled); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrNotFound
 		}
+		return false, fmt.Errorf("failed to get encryption: %w", err)
 	}
+	return enabled, nil
+}
+
+// SetBucketObjectLock stores the bucket's object lock configuration
+func (r *PostgresRepository) SetBucketObjectLock(ctx context.Context, bucketID string, config domain.ObjectLockConfiguration) error {
+	query := `
+		UPDATE buckets
+		SET object_lock_enabled = $1, object_lock_mode = NULLIF($2, ''), object_lock_days = NULLIF($3, 0), updated_at = NOW()
+		WHERE id = $4
+	`
 
+	res, err := r.db.ExecContext(ctx, query, config.Enabled, config.DefaultMode, config.DefaultRetentionDays, bucketID)
+	if err != nil {
+		return fmt.Errorf("failed to update object lock: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
 	return nil
 }
 
+// GetBucketObjectLock returns the bucket's object lock configuration
+func (r *PostgresRepository) GetBucketObjectLock(ctx context.Context, bucketID string) (*domain.ObjectLockConfiguration, error) {
+	query := `SELECT object_lock_enabled, COALESCE(object_lock_mode, ''), COALESCE(object_lock_days, 0) FROM buckets WHERE id = $1`
+
+	var config domain.ObjectLockConfiguration
+	err := r.db.QueryRowContext(ctx, query, bucketID).Scan(&config.Enabled, &config.DefaultMode, &config.DefaultRetentionDays)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get object lock: %w", err)
+	}
+	return &config, nil
+}
+
 func (r *PostgresRepository) GetBucketByName(ctx context.Context, name string) (domain.Bucket, error) {
 
  
 
-	query := `SELECT id, name, created_at, updated_at, policy FROM buckets WHERE name = $1`
+	query := `SELECT id, name, owner_id, created_at, updated_at, policy FROM buckets WHERE name = $1`
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -513,6 +823,7 @@ func (r *PostgresRepository) GetBucketByName(ctx context.Context, name string) (
 	err := r.db.QueryRowContext(ctx, query, name).Scan(
 		&bucket.ID,
 		&bucket.Name,
+		&bucket.OwnerID,
 		&bucket.CreatedAt,
 		&bucket.UpdatedAt,
 		&policyJSON,
@@ -534,17 +845,78 @@ func (r *PostgresRepository) GetBucketByName(ctx context.Context, name string) (
 	return bucket, nil
 
 }
-func (r *PostgresRepository) UpsertLifecycleRule(ctx context.Context, bucketID string, ruleJSON []byte) error {
-    query := `
-        INSERT INTO bucket_lifecycle_rules (bucket_id, rule, updated_at)
-        VALUES ($1, $2::jsonb, NOW())
-        ON CONFLICT (bucket_id)
-        DO UPDATE SET rule = EXCLUDED.rule, updated_at = NOW();
-    `
-    _, err := r.db.ExecContext(ctx, query, bucketID, ruleJSON)
-    return err
+// ReplaceLifecycleRules deletes the bucket's rules and stores rules in
+// their place
+func (r *PostgresRepository) ReplaceLifecycleRules(ctx context.Context, bucketID string, rules []domain.LifecycleRule) error {
+	return r.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM bucket_lifecycle_rules WHERE bucket_id = $1`, bucketID); err != nil {
+			return fmt.Errorf("failed to clear lifecycle rules: %w", err)
+		}
+
+		for _, rule := range rules {
+			ruleJSON, err := json.Marshal(rule)
+			if err != nil {
+				return fmt.Errorf("failed to marshal rule: %w", err)
+			}
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO bucket_lifecycle_rules (id, bucket_id, rule_id, rule, created_at, updated_at)
+				VALUES ($1, $2, $3, $4::jsonb, NOW(), NOW())`,
+				uuid.New().String(), bucketID, rule.ID, ruleJSON,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to save lifecycle rule %s: %w", rule.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// SaveLifecycleActions records what a lifecycle run did
+func (r *PostgresRepository) SaveLifecycleActions(ctx context.Context, actions []domain.LifecycleAction) error {
+	query := `
+		INSERT INTO lifecycle_actions (id, run_id, bucket_id, rule_id, action, object_key, version_id, upload_id, storage_class, error, executed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), $11)
+	`
+	for _, action := range actions {
+		_, err := r.db.ExecContext(ctx, query,
+			action.ID, action.RunID, action.BucketID, action.RuleID, action.Action, action.Key,
+			action.VersionID, action.UploadID, action.StorageClass, action.Error, action.ExecutedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save lifecycle action: %w", err)
+		}
+	}
+	return nil
 }
 
+// ListLifecycleActions returns the most recent lifecycle actions for a bucket
+func (r *PostgresRepository) ListLifecycleActions(ctx context.Context, bucketID string, limit int) ([]domain.LifecycleAction, error) {
+	query := `
+		SELECT id, run_id, bucket_id, rule_id, action, object_key,
+		       COALESCE(version_id, ''), COALESCE(upload_id, ''), COALESCE(storage_class, ''), COALESCE(error, ''), executed_at
+		FROM lifecycle_actions
+		WHERE bucket_id = $1
+		ORDER BY executed_at DESC
+		LIMIT $2
+	`
+	rows, err := r.db.QueryContext(ctx, query, bucketID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lifecycle actions: %w", err)
+	}
+	defer rows.Close()
+
+	var actions []domain.LifecycleAction
+	for rows.Next() {
+		var action domain.LifecycleAction
+		if err := rows.Scan(&action.ID, &action.RunID, &action.BucketID, &action.RuleID, &action.Action,
+			&action.Key, &action.VersionID, &action.UploadID, &action.StorageClass, &action.Error, &action.ExecutedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan lifecycle action: %w", err)
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, rows.Err()
+}
 
 func (r *PostgresRepository) GetLifecycleRules(ctx context.Context, bucketID string) ([]domain.LifecycleRule, error) {
     query := `SELECT rule FROM bucket_lifecycle_rules WHERE bucket_id = $1`
@@ -586,18 +958,44 @@ func (r *PostgresRepository) SaveFile(ctx context.Context, file domain.File) err
 	}
 
 	query := `
-		INSERT INTO files (id, bucket_id, key, size, mime_type, metadata, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO files (id, bucket_id, key, size, mime_type, metadata, created_at, version, storage_class,
+		                   encryption, encryption_key, sse_customer_key_md5,
+		                   etag, checksum_sha256, checksum_crc32c,
+		                   retention_mode, retain_until, legal_hold, replication_status, content_type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), COALESCE(NULLIF($9, ''), 'STANDARD'),
+		        $10, NULLIF($11, ''), NULLIF($12, ''),
+		        NULLIF($13, ''), NULLIF($14, ''), NULLIF($15, ''),
+		        $16, $17, $18, NULLIF($19, ''), $5)
 		ON CONFLICT (bucket_id, key) DO UPDATE 
 		SET size = EXCLUDED.size,
 		    mime_type = EXCLUDED.mime_type,
+		    content_type = EXCLUDED.content_type,
 		    metadata = EXCLUDED.metadata,
-		    created_at = EXCLUDED.created_at
+		    created_at = EXCLUDED.created_at,
+		    version = EXCLUDED.version,
+		    storage_class = EXCLUDED.storage_class,
+		    restore_expires_at = NULL,
+		    encryption = EXCLUDED.encryption,
+		    encryption_key = EXCLUDED.encryption_key,
+		    sse_customer_key_md5 = EXCLUDED.sse_customer_key_md5,
+		    etag = EXCLUDED.etag,
+		    checksum_sha256 = EXCLUDED.checksum_sha256,
+		    checksum_crc32c = EXCLUDED.checksum_crc32c,
+		    scrubbed_at = NULL,
+		    scrub_error = NULL,
+		    retention_mode = EXCLUDED.retention_mode,
+		    retain_until = EXCLUDED.retain_until,
+		    legal_hold = EXCLUDED.legal_hold,
+		    replication_status = EXCLUDED.replication_status,
+		    updated_at = NOW()
 	`
 
 	_, err = r.db.ExecContext(ctx, query,
 		file.ID, file.BucketID, file.Key, file.Size,
-		file.MimeType, metadataJSON, file.CreatedAt,
+		file.MediaType(), metadataJSON, file.CreatedAt, file.Version, file.StorageClass,
+		file.Encryption, file.EncryptionKey, file.SSECustomerKeyMD5,
+		file.ETag, file.ChecksumSHA256, file.ChecksumCRC32C,
+		file.RetentionMode, file.RetainUntil, file.LegalHold, file.ReplicationStatus,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to save file: %w", err)
@@ -612,7 +1010,13 @@ func (r *PostgresRepository) ListFiles(ctx context.Context, bucketID string) ([]
 	defer cancel()
 
 	query := `
-		SELECT id, bucket_id, key, size, mime_type, metadata, created_at
+		SELECT id, bucket_id, key, size, mime_type, metadata, created_at,
+		       COALESCE(storage_class, 'STANDARD'), restore_expires_at,
+		       encryption, COALESCE(encryption_key, ''), COALESCE(sse_customer_key_md5, ''),
+		       COALESCE(etag, ''), COALESCE(checksum_sha256, ''), COALESCE(checksum_crc32c, ''),
+		       scrubbed_at, COALESCE(scrub_error, ''),
+		       retention_mode, retain_until, legal_hold, COALESCE(replication_status, ''),
+		       COALESCE(updated_at, created_at)
 		FROM files
 		WHERE bucket_id = $1
 		ORDER BY created_at DESC
@@ -628,6 +1032,7 @@ func (r *PostgresRepository) ListFiles(ctx context.Context, bucketID string) ([]
 	for rows.Next() {
 		var file domain.File
 		var metadataJSON []byte
+		var restoreExpiresAt, scrubbedAt, retainUntil sql.NullTime
 
 		err := rows.Scan(
 			&file.ID,
@@ -637,10 +1042,28 @@ func (r *PostgresRepository) ListFiles(ctx context.Context, bucketID string) ([]
 			&file.MimeType,
 			&metadataJSON,
 			&file.CreatedAt,
+			&file.StorageClass,
+			&restoreExpiresAt,
+			&file.Encryption,
+			&file.EncryptionKey,
+			&file.SSECustomerKeyMD5,
+			&file.ETag,
+			&file.ChecksumSHA256,
+			&file.ChecksumCRC32C,
+			&scrubbedAt,
+			&file.ScrubError,
+			&file.RetentionMode,
+			&retainUntil,
+			&file.LegalHold,
+			&file.ReplicationStatus,
+			&file.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan file: %w", err)
 		}
+		file.RestoreExpiresAt = nullTime(restoreExpiresAt)
+		file.ScrubbedAt = nullTime(scrubbedAt)
+		file.RetainUntil = nullTime(retainUntil)
 
 		if len(metadataJSON) > 0 {
 			if err = json.Unmarshal(metadataJSON, &file.Metadata); err != nil {
@@ -693,6 +1116,100 @@ func (r *PostgresRepository) UpdateFile(ctx context.Context, file *domain.File)
 	return err
 }
 
+// UpdateFileStorageClass records a tier transition or restore of a file
+func (r *PostgresRepository) UpdateFileStorageClass(ctx context.Context, id, storageClass string, restoreExpiresAt *time.Time) error {
+	query := `UPDATE files SET storage_class = $1, restore_expires_at = $2, updated_at = NOW() WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, storageClass, restoreExpiresAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to update storage class: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return r.syncFileVersion(ctx, id)
+}
+
+// UpdateFileEncryption records the SSE mode and key of a rewritten file
+func (r *PostgresRepository) UpdateFileEncryption(ctx context.Context, id, mode, wrappedKey, customerKeyMD5 string) error {
+	query := `UPDATE files SET encryption = $1, encryption_key = NULLIF($2, ''), sse_customer_key_md5 = NULLIF($3, ''), updated_at = NOW() WHERE id = $4`
+
+	result, err := r.db.ExecContext(ctx, query, mode, wrappedKey, customerKeyMD5, id)
+	if err != nil {
+		return fmt.Errorf("failed to update encryption: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateFileScrub records the outcome of an integrity scrub of a file;
+// scrubError is empty when the object matched its checksums
+func (r *PostgresRepository) UpdateFileScrub(ctx context.Context, id string, scrubbedAt time.Time, scrubError string) error {
+	query := `UPDATE files SET scrubbed_at = $1, scrub_error = NULLIF($2, '') WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, scrubbedAt, scrubError, id)
+	if err != nil {
+		return fmt.Errorf("failed to record scrub: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateFileRetention sets or, with an empty mode, clears a file's retention
+func (r *PostgresRepository) UpdateFileRetention(ctx context.Context, id, mode string, retainUntil *time.Time) error {
+	query := `UPDATE files SET retention_mode = $1, retain_until = $2, updated_at = NOW() WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, mode, retainUntil, id)
+	if err != nil {
+		return fmt.Errorf("failed to update retention: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return r.syncFileVersion(ctx, id)
+}
+
+// UpdateFileLegalHold places or lifts a legal hold on a file
+func (r *PostgresRepository) UpdateFileLegalHold(ctx context.Context, id string, on bool) error {
+	query := `UPDATE files SET legal_hold = $1, updated_at = NOW() WHERE id = $2`
+
+	result, err := r.db.ExecContext(ctx, query, on, id)
+	if err != nil {
+		return fmt.Errorf("failed to update legal hold: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return r.syncFileVersion(ctx, id)
+}
+
+// syncFileVersion copies the storage class and object lock state of a file
+// to the version it is, so the version keeps them once it is noncurrent
+func (r *PostgresRepository) syncFileVersion(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE object_versions v
+		SET storage_class = f.storage_class, retention_mode = f.retention_mode,
+		    retain_until = f.retain_until, legal_hold = f.legal_hold
+		FROM files f
+		WHERE f.id = $1 AND v.bucket_id = f.bucket_id AND v.version_id = f.version`, id)
+	if err != nil {
+		return fmt.Errorf("failed to update object version: %w", err)
+	}
+	return nil
+}
+
+// nullTime converts a nullable timestamp column to a *time.Time
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
 // =============================================================================
 // BUCKET OPERATIONS
 // =============================================================================
@@ -784,7 +1301,7 @@ func (r *PostgresRepository) GetBucketByID(ctx context.Context, bucketID string)
 	defer cancel()
 
 	query := `
-		SELECT id, name, created_at, updated_at, policy
+		SELECT id, name, owner_id, created_at, updated_at, policy
 		FROM buckets
 		WHERE id = $1
 	`
@@ -795,6 +1312,7 @@ func (r *PostgresRepository) GetBucketByID(ctx context.Context, bucketID string)
 	err := r.db.QueryRowContext(ctx, query, bucketID).Scan(
 		&bucket.ID,
 		&bucket.Name,
+		&bucket.OwnerID,
 		&bucket.CreatedAt,
 		&bucket.UpdatedAt,
 		&policyJSON,
@@ -1033,7 +1551,9 @@ func (r *PostgresRepository) ListPresignedURLs(ctx context.Context, bucketID str
 // ListFilesByPrefix implements domain.RepositoryPort.
 func (r *PostgresRepository) ListFilesByPrefix(ctx context.Context, bucketID, prefix string, limit int) ([]domain.File, error) {
 	query := `
-		SELECT id, bucket_id, key, size, content_type, metadata, version, created_at, updated_at
+		SELECT id, bucket_id, key, size, COALESCE(content_type, ''), metadata, COALESCE(version, ''), created_at, updated_at,
+		       COALESCE(storage_class, 'STANDARD'), retention_mode, retain_until, legal_hold,
+		       COALESCE(replication_status, '')
 		FROM files
 		WHERE bucket_id = $1 AND key LIKE $2
 		ORDER BY key
@@ -1057,6 +1577,7 @@ func (r *PostgresRepository) ListFilesByPrefix(ctx context.Context, bucketID, pr
 	for rows.Next() {
 		var file domain.File
 		var metadataJSON []byte
+		var retainUntil sql.NullTime
 
 		err := rows.Scan(
 			&file.ID,
@@ -1068,11 +1589,17 @@ func (r *PostgresRepository) ListFilesByPrefix(ctx context.Context, bucketID, pr
 			&file.Version,
 			&file.CreatedAt,
 			&file.UpdatedAt,
+			&file.StorageClass,
+			&file.RetentionMode,
+			&retainUntil,
+			&file.LegalHold,
+			&file.ReplicationStatus,
 		)
 
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan file: %w", err)
 		}
+		file.RetainUntil = nullTime(retainUntil)
 
 		if len(metadataJSON) > 0 {
 			if err := json.Unmarshal(metadataJSON, &file.Metadata); err != nil {
@@ -1369,26 +1896,30 @@ func (r *PostgresRepository) SaveWebhook(ctx context.Context, webhook *domain.We
 	eventsJSON, _ := json.Marshal(webhook.Events)
 	headersJSON, _ := json.Marshal(webhook.Headers)
 
-	query := `INSERT INTO webhooks (id, bucket_id, name, url, events, secret, active, headers, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	query := `INSERT INTO webhooks (id, bucket_id, name, url, events, secret, active, headers, max_concurrency,
+		previous_secret, previous_secret_expires_at, filter, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12, $13, $14)`
 
 	_, err := r.db.ExecContext(ctx, query, webhook.ID, webhook.BucketID, webhook.Name,
 		webhook.URL, eventsJSON, webhook.Secret, webhook.Active, headersJSON,
-		webhook.CreatedAt, webhook.UpdatedAt)
+		webhook.MaxConcurrency, webhook.PreviousSecret, webhook.PreviousSecretExpiresAt,
+		webhookFilterJSON(webhook.Filter), webhook.CreatedAt, webhook.UpdatedAt)
 	return err
 }
 
 func (r *PostgresRepository) GetWebhookByID(ctx context.Context, id string) (*domain.Webhook, error) {
-	query := `SELECT id, bucket_id, name, url, events, secret, active, headers, created_at, updated_at
+	query := `SELECT id, bucket_id, name, url, events, secret, active, headers, max_concurrency,
+		COALESCE(previous_secret, ''), previous_secret_expires_at, filter, created_at, updated_at
 		FROM webhooks WHERE id = $1`
 
 	var webhook domain.Webhook
-	var eventsJSON, headersJSON []byte
+	var eventsJSON, headersJSON, filterJSON []byte
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&webhook.ID, &webhook.BucketID, &webhook.Name, &webhook.URL,
 		&eventsJSON, &webhook.Secret, &webhook.Active, &headersJSON,
-		&webhook.CreatedAt, &webhook.UpdatedAt)
+		&webhook.MaxConcurrency, &webhook.PreviousSecret, &webhook.PreviousSecretExpiresAt,
+		&filterJSON, &webhook.CreatedAt, &webhook.UpdatedAt)
 
 	if err != nil {
 		return nil, err
@@ -1396,11 +1927,15 @@ func (r *PostgresRepository) GetWebhookByID(ctx context.Context, id string) (*do
 
 	json.Unmarshal(eventsJSON, &webhook.Events)
 	json.Unmarshal(headersJSON, &webhook.Headers)
+	if len(filterJSON) > 0 {
+		json.Unmarshal(filterJSON, &webhook.Filter)
+	}
 	return &webhook, nil
 }
 
 func (r *PostgresRepository) ListWebhooksByBucket(ctx context.Context, bucketID string) ([]domain.Webhook, error) {
-	query := `SELECT id, bucket_id, name, url, events, secret, active, headers, created_at, updated_at
+	query := `SELECT id, bucket_id, name, url, events, secret, active, headers, max_concurrency,
+		COALESCE(previous_secret, ''), previous_secret_expires_at, filter, created_at, updated_at
 		FROM webhooks WHERE bucket_id = $1 ORDER BY created_at DESC`
 
 	rows, err := r.db.QueryContext(ctx, query, bucketID)
@@ -1412,13 +1947,17 @@ func (r *PostgresRepository) ListWebhooksByBucket(ctx context.Context, bucketID
 	webhooks := []domain.Webhook{}
 	for rows.Next() {
 		var wh domain.Webhook
-		var eventsJSON, headersJSON []byte
+		var eventsJSON, headersJSON, filterJSON []byte
 
 		rows.Scan(&wh.ID, &wh.BucketID, &wh.Name, &wh.URL, &eventsJSON,
-			&wh.Secret, &wh.Active, &headersJSON, &wh.CreatedAt, &wh.UpdatedAt)
+			&wh.Secret, &wh.Active, &headersJSON, &wh.MaxConcurrency, &wh.PreviousSecret,
+			&wh.PreviousSecretExpiresAt, &filterJSON, &wh.CreatedAt, &wh.UpdatedAt)
 
 		json.Unmarshal(eventsJSON, &wh.Events)
 		json.Unmarshal(headersJSON, &wh.Headers)
+		if len(filterJSON) > 0 {
+			json.Unmarshal(filterJSON, &wh.Filter)
+		}
 		webhooks = append(webhooks, wh)
 	}
 	return webhooks, nil
@@ -1428,46 +1967,94 @@ func (r *PostgresRepository) UpdateWebhook(ctx context.Context, webhook *domain.
 	eventsJSON, _ := json.Marshal(webhook.Events)
 	headersJSON, _ := json.Marshal(webhook.Headers)
 
-	query := `UPDATE webhooks SET name=$2, url=$3, events=$4, active=$5, headers=$6, updated_at=$7 WHERE id=$1`
+	query := `UPDATE webhooks SET name=$2, url=$3, events=$4, active=$5, headers=$6, max_concurrency=$7,
+		secret=$8, previous_secret=NULLIF($9, ''), previous_secret_expires_at=$10, filter=$11, updated_at=$12 WHERE id=$1`
 
 	_, err := r.db.ExecContext(ctx, query, webhook.ID, webhook.Name, webhook.URL,
-		eventsJSON, webhook.Active, headersJSON, webhook.UpdatedAt)
+		eventsJSON, webhook.Active, headersJSON, webhook.MaxConcurrency, webhook.Secret,
+		webhook.PreviousSecret, webhook.PreviousSecretExpiresAt, webhookFilterJSON(webhook.Filter), webhook.UpdatedAt)
 	return err
 }
 
+// webhookFilterJSON stores a missing filter as NULL
+func webhookFilterJSON(filter *domain.WebhookFilter) sql.NullString {
+	if filter == nil {
+		return sql.NullString{}
+	}
+	data, _ := json.Marshal(filter)
+	return sql.NullString{String: string(data), Valid: true}
+}
+
 func (r *PostgresRepository) DeleteWebhook(ctx context.Context, id string) error {
 	_, err := r.db.ExecContext(ctx, "DELETE FROM webhooks WHERE id=$1", id)
 	return err
 }
 
 func (r *PostgresRepository) SaveWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
-	query := `INSERT INTO webhook_deliveries (id, webhook_id, event, payload, status_code, response, success, error_message, delivered_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	query := `INSERT INTO webhook_deliveries (id, webhook_id, queue_id, attempt, event, payload, status_code, response, success, error_message, delivered_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10, $11)`
 
-	_, err := r.db.ExecContext(ctx, query, delivery.ID, delivery.WebhookID, delivery.Event,
-		delivery.Payload, delivery.StatusCode, delivery.Response, delivery.Success,
+	_, err := r.db.ExecContext(ctx, query, delivery.ID, delivery.WebhookID, delivery.QueueID, delivery.Attempt,
+		delivery.Event, delivery.Payload, delivery.StatusCode, delivery.Response, delivery.Success,
 		delivery.ErrorMessage, delivery.DeliveredAt)
 	return err
 }
 
-func (r *PostgresRepository) ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]domain.WebhookDelivery, error) {
-	query := `SELECT id, webhook_id, event, payload, status_code, response, success, error_message, delivered_at
-		FROM webhook_deliveries WHERE webhook_id=$1 ORDER BY delivered_at DESC LIMIT $2`
+func (r *PostgresRepository) GetWebhookDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	query := `SELECT id, webhook_id, COALESCE(queue_id, ''), attempt, event, payload, status_code, response, success, error_message, delivered_at
+		FROM webhook_deliveries WHERE id=$1`
 
-	rows, err := r.db.QueryContext(ctx, query, webhookID, limit)
+	var d domain.WebhookDelivery
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&d.ID, &d.WebhookID, &d.QueueID, &d.Attempt, &d.Event, &d.Payload,
+		&d.StatusCode, &d.Response, &d.Success, &d.ErrorMessage, &d.DeliveredAt)
 	if err != nil {
 		return nil, err
 	}
+	return &d, nil
+}
+
+// ListWebhookDeliveries returns a page of the webhook's delivery log, newest
+// first, and how many deliveries match the filter in all
+func (r *PostgresRepository) ListWebhookDeliveries(ctx context.Context, webhookID string, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, int, error) {
+	event := filter.Event
+	if strings.HasSuffix(event, ".*") {
+		event = strings.TrimSuffix(event, "*") + "%"
+	}
+	where := `WHERE webhook_id=$1 AND ($2::text = '' OR event LIKE $2) AND ($3::boolean IS NULL OR success = $3)`
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhook_deliveries `+where,
+		webhookID, event, filter.Success).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	query := `SELECT id, webhook_id, COALESCE(queue_id, ''), attempt, event, payload, status_code, response, success, error_message, delivered_at
+		FROM webhook_deliveries ` + where + ` ORDER BY delivered_at DESC LIMIT $4 OFFSET $5`
+
+	rows, err := r.db.QueryContext(ctx, query, webhookID, event, filter.Success, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]domain.WebhookDelivery, error) {
 	defer rows.Close()
 
 	deliveries := []domain.WebhookDelivery{}
 	for rows.Next() {
 		var d domain.WebhookDelivery
-		rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.StatusCode,
-			&d.Response, &d.Success, &d.ErrorMessage, &d.DeliveredAt)
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.QueueID, &d.Attempt, &d.Event, &d.Payload, &d.StatusCode,
+			&d.Response, &d.Success, &d.ErrorMessage, &d.DeliveredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
 		deliveries = append(deliveries, d)
 	}
-	return deliveries, nil
+	return deliveries, rows.Err()
 }
 
 func (r *PostgresRepository) GetAccessLogsByDateRange(ctx context.Context, start, end time.Time) ([]domain.AccessLog, error) {
@@ -1548,40 +2135,215 @@ func (r *PostgresRepository) SaveAccessLog(ctx context.Context, log *domain.Acce
 	return err
 }
 
+func (r *PostgresRepository) SaveAccessKey(ctx context.Context, key *domain.AccessKey) error {
+	query := `INSERT INTO access_keys (access_key_id, secret_key, secret_hash, user_id, status, created_at, last_used_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (access_key_id) DO UPDATE
+		SET secret_key = EXCLUDED.secret_key,
+		    secret_hash = EXCLUDED.secret_hash,
+		    user_id = EXCLUDED.user_id,
+		    status = EXCLUDED.status`
+	_, err := r.db.ExecContext(ctx, query, key.AccessKeyID, key.SecretKey, key.SecretHash, key.UserID,
+		key.Status, key.CreatedAt, key.LastUsedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save access key: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetAccessKeyByID(ctx context.Context, accessKeyID string) (*domain.AccessKey, error) {
+	query := `SELECT access_key_id, secret_key, secret_hash, user_id, status, created_at, last_used_at
+		FROM access_keys WHERE access_key_id = $1`
+
+	return r.scanAccessKey(r.db.QueryRowContext(ctx, query, accessKeyID))
+}
+
+func (r *PostgresRepository) GetAccessKeyBySecretHash(ctx context.Context, secretHash string) (*domain.AccessKey, error) {
+	query := `SELECT access_key_id, secret_key, secret_hash, user_id, status, created_at, last_used_at
+		FROM access_keys WHERE secret_hash = $1`
+
+	return r.scanAccessKey(r.db.QueryRowContext(ctx, query, secretHash))
+}
+
+func (r *PostgresRepository) scanAccessKey(row *sql.Row) (*domain.AccessKey, error) {
+	var key domain.AccessKey
+	err := row.Scan(&key.AccessKeyID, &key.SecretKey, &key.SecretHash, &key.UserID,
+		&key.Status, &key.CreatedAt, &key.LastUsedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get access key: %w", err)
+	}
+
+	return &key, nil
+}
+
+func (r *PostgresRepository) ListAccessKeysByUser(ctx context.Context, userID string) ([]domain.AccessKey, error) {
+	query := `SELECT access_key_id, secret_key, secret_hash, user_id, status, created_at, last_used_at
+		FROM access_keys WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []domain.AccessKey
+	for rows.Next() {
+		var key domain.AccessKey
+		if err := rows.Scan(&key.AccessKeyID, &key.SecretKey, &key.SecretHash, &key.UserID,
+			&key.Status, &key.CreatedAt, &key.LastUsedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan access key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *PostgresRepository) UpdateAccessKeyStatus(ctx context.Context, accessKeyID, status string) error {
+	query := `UPDATE access_keys SET status = $2 WHERE access_key_id = $1`
+	result, err := r.db.ExecContext(ctx, query, accessKeyID, status)
+	if err != nil {
+		return fmt.Errorf("failed to update access key: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) DeleteAccessKey(ctx context.Context, accessKeyID string) error {
+	query := `DELETE FROM access_keys WHERE access_key_id = $1`
+	result, err := r.db.ExecContext(ctx, query, accessKeyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete access key: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) TouchAccessKey(ctx context.Context, accessKeyID string, usedAt time.Time) error {
+	query := `UPDATE access_keys SET last_used_at = $2 WHERE access_key_id = $1`
+	_, err := r.db.ExecContext(ctx, query, accessKeyID, usedAt)
+	return err
+}
+
+// ListUnsealedAccessKeys lists the keys whose stored secret does not start
+// with sealedPrefix
+func (r *PostgresRepository) ListUnsealedAccessKeys(ctx context.Context, sealedPrefix string) ([]domain.AccessKey, error) {
+	query := `SELECT access_key_id, secret_key, secret_hash, user_id, status, created_at, last_used_at
+		FROM access_keys WHERE left(secret_key, length($1)) <> $1`
+
+	rows, err := r.db.QueryContext(ctx, query, sealedPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []domain.AccessKey
+	for rows.Next() {
+		var key domain.AccessKey
+		if err := rows.Scan(&key.AccessKeyID, &key.SecretKey, &key.SecretHash, &key.UserID,
+			&key.Status, &key.CreatedAt, &key.LastUsedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan access key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
 func (r *PostgresRepository) SaveMultipartUpload(ctx context.Context, upload *domain.MultipartUpload) error {
 	partsJSON, _ := json.Marshal(upload.Parts)
-	query := `INSERT INTO multipart_uploads (id, upload_id, bucket_id, key, status, parts, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
-	_, err := r.db.ExecContext(ctx, query, upload.ID, upload.UploadID, upload.BucketID,
-		upload.Key, upload.Status, partsJSON, upload.CreatedAt, upload.UpdatedAt)
+	metadataJSON, err := json.Marshal(upload.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	query := `INSERT INTO multipart_uploads (id, upload_id, storage_upload_id, bucket_id, key, status, parts, created_at, updated_at,
+		encryption, encryption_key, content_type, metadata, checksum_sha256, checksum_crc32c)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12, $13, $14, $15)`
+	_, err = r.db.ExecContext(ctx, query, upload.ID, upload.UploadID, upload.StorageUploadID, upload.BucketID,
+		upload.Key, upload.Status, partsJSON, upload.CreatedAt, upload.UpdatedAt, upload.Encryption, upload.EncryptionKey,
+		upload.ContentType, metadataJSON, upload.ChecksumSHA256, upload.ChecksumCRC32C)
 	return err
 }
 
 func (r *PostgresRepository) GetMultipartUploadByUploadID(ctx context.Context, uploadID string) (*domain.MultipartUpload, error) {
-	query := `SELECT id, upload_id, bucket_id, key, status, parts, created_at, updated_at
+	query := `SELECT id, upload_id, storage_upload_id, bucket_id, key, status, created_at, updated_at,
+		encryption, COALESCE(encryption_key, ''), content_type, metadata, checksum_sha256, checksum_crc32c
 		FROM multipart_uploads WHERE upload_id=$1`
 
 	var upload domain.MultipartUpload
-	var partsJSON []byte
-	err := r.db.QueryRowContext(ctx, query, uploadID).Scan(&upload.ID, &upload.UploadID,
-		&upload.BucketID, &upload.Key, &upload.Status, &partsJSON, &upload.CreatedAt, &upload.UpdatedAt)
+	var metadataJSON []byte
+	err := r.db.QueryRowContext(ctx, query, uploadID).Scan(&upload.ID, &upload.UploadID, &upload.StorageUploadID,
+		&upload.BucketID, &upload.Key, &upload.Status, &upload.CreatedAt, &upload.UpdatedAt,
+		&upload.Encryption, &upload.EncryptionKey, &upload.ContentType, &metadataJSON,
+		&upload.ChecksumSHA256, &upload.ChecksumCRC32C)
 
 	if err != nil {
 		return nil, err
 	}
-	json.Unmarshal(partsJSON, &upload.Parts)
+	if len(metadataJSON) > 0 {
+		json.Unmarshal(metadataJSON, &upload.Metadata)
+	}
+
+	// Parts live in their own table so parallel part uploads don't race
+	upload.Parts, err = r.listMultipartParts(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
 	return &upload, nil
 }
 
+func (r *PostgresRepository) listMultipartParts(ctx context.Context, uploadID string) ([]domain.Part, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT part_number, etag, size, uploaded_at
+		FROM multipart_upload_parts WHERE upload_id = $1 ORDER BY part_number`, uploadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upload parts: %w", err)
+	}
+	defer rows.Close()
+
+	parts := []domain.Part{}
+	for rows.Next() {
+		var part domain.Part
+		if err := rows.Scan(&part.PartNumber, &part.ETag, &part.Size, &part.UploadedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan upload part: %w", err)
+		}
+		parts = append(parts, part)
+	}
+	return parts, rows.Err()
+}
+
+// SaveMultipartPart records a part in one statement, so parts uploaded at
+// the same time do not overwrite each other
+func (r *PostgresRepository) SaveMultipartPart(ctx context.Context, uploadID string, part *domain.Part) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO multipart_upload_parts (upload_id, part_number, etag, size, uploaded_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (upload_id, part_number) DO UPDATE
+		SET etag = EXCLUDED.etag, size = EXCLUDED.size, uploaded_at = EXCLUDED.uploaded_at`,
+		uploadID, part.PartNumber, part.ETag, part.Size, part.UploadedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save upload part: %w", err)
+	}
+	return nil
+}
+
+// UpdateMultipartUpload records the upload's status; its parts are saved
+// with SaveMultipartPart
 func (r *PostgresRepository) UpdateMultipartUpload(ctx context.Context, upload *domain.MultipartUpload) error {
-	partsJSON, _ := json.Marshal(upload.Parts)
-	query := `UPDATE multipart_uploads SET status=$2, parts=$3, updated_at=$4 WHERE upload_id=$1`
-	_, err := r.db.ExecContext(ctx, query, upload.UploadID, upload.Status, partsJSON, upload.UpdatedAt)
+	query := `UPDATE multipart_uploads SET status=$2, updated_at=$3 WHERE upload_id=$1`
+	_, err := r.db.ExecContext(ctx, query, upload.UploadID, upload.Status, upload.UpdatedAt)
 	return err
 }
 
 func (r *PostgresRepository) ListMultipartUploadsByBucket(ctx context.Context, bucketID string) ([]domain.MultipartUpload, error) {
-	query := `SELECT id, upload_id, bucket_id, key, status, parts, created_at, updated_at
+	query := `SELECT id, upload_id, storage_upload_id, bucket_id, key, status, created_at, updated_at
 		FROM multipart_uploads WHERE bucket_id=$1 AND status='initiated' ORDER BY created_at DESC`
 
 	rows, err := r.db.QueryContext(ctx, query, bucketID)
@@ -1593,11 +2355,481 @@ func (r *PostgresRepository) ListMultipartUploadsByBucket(ctx context.Context, b
 	uploads := []domain.MultipartUpload{}
 	for rows.Next() {
 		var upload domain.MultipartUpload
-		var partsJSON []byte
-		rows.Scan(&upload.ID, &upload.UploadID, &upload.BucketID, &upload.Key,
-			&upload.Status, &partsJSON, &upload.CreatedAt, &upload.UpdatedAt)
-		json.Unmarshal(partsJSON, &upload.Parts)
+		rows.Scan(&upload.ID, &upload.UploadID, &upload.StorageUploadID, &upload.BucketID, &upload.Key,
+			&upload.Status, &upload.CreatedAt, &upload.UpdatedAt)
 		uploads = append(uploads, upload)
 	}
 	return uploads, nil
 }
+
+// =============================================================================
+// STORAGE QUOTAS
+// =============================================================================
+
+// SetQuota sets a subject's limits; its usage counters are left untouched
+func (r *PostgresRepository) SetQuota(ctx context.Context, quota *domain.Quota) error {
+	query := `
+		INSERT INTO storage_quotas (scope, subject_id, max_bytes, max_objects, warning_percent, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (scope, subject_id) DO UPDATE
+		SET max_bytes = EXCLUDED.max_bytes,
+		    max_objects = EXCLUDED.max_objects,
+		    warning_percent = EXCLUDED.warning_percent,
+		    updated_at = NOW()
+	`
+
+	_, err := r.db.ExecContext(ctx, query, quota.Scope, quota.SubjectID, quota.MaxBytes, quota.MaxObjects, quota.WarningPercent)
+	if err != nil {
+		return fmt.Errorf("failed to save quota: %w", err)
+	}
+	return nil
+}
+
+// GetQuota returns a subject's limits and usage. A subject that has never
+// stored anything and has no quota returns ErrNotFound.
+func (r *PostgresRepository) GetQuota(ctx context.Context, scope, subjectID string) (*domain.Quota, error) {
+	query := `
+		SELECT scope, subject_id, max_bytes, max_objects, warning_percent,
+		       used_bytes, used_objects, reserved_bytes, reserved_objects, updated_at
+		FROM storage_quotas
+		WHERE scope = $1 AND subject_id = $2
+	`
+
+	var quota domain.Quota
+	err := r.db.QueryRowContext(ctx, query, scope, subjectID).Scan(
+		&quota.Scope, &quota.SubjectID, &quota.MaxBytes, &quota.MaxObjects, &quota.WarningPercent,
+		&quota.UsedBytes, &quota.UsedObjects, &quota.ReservedBytes, &quota.ReservedObjects, &quota.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get quota: %w", err)
+	}
+	return &quota, nil
+}
+
+// ReserveQuota admits a write of bytes and objects against every subject at
+// once. It returns the first subject whose limit the write would exceed, in
+// which case nothing is reserved.
+func (r *PostgresRepository) ReserveQuota(ctx context.Context, subjects []domain.QuotaSubject, bytes, objects int64) (*domain.QuotaSubject, error) {
+	var exceeded *domain.QuotaSubject
+	errExceeded := errors.New("quota exceeded")
+
+	err := r.WithTx(ctx, func(tx *sql.Tx) error {
+		for i := range subjects {
+			subject := subjects[i]
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO storage_quotas (scope, subject_id) VALUES ($1, $2) ON CONFLICT (scope, subject_id) DO NOTHING`,
+				subject.Scope, subject.SubjectID); err != nil {
+				return fmt.Errorf("failed to create quota usage: %w", err)
+			}
+
+			res, err := tx.ExecContext(ctx, `
+				UPDATE storage_quotas
+				SET reserved_bytes = reserved_bytes + $3, reserved_objects = reserved_objects + $4
+				WHERE scope = $1 AND subject_id = $2
+				  AND (max_bytes = 0 OR used_bytes + reserved_bytes + $3 <= max_bytes)
+				  AND (max_objects = 0 OR used_objects + reserved_objects + $4 <= max_objects)
+			`, subject.Scope, subject.SubjectID, bytes, objects)
+			if err != nil {
+				return fmt.Errorf("failed to reserve quota: %w", err)
+			}
+			if rows, _ := res.RowsAffected(); rows == 0 {
+				exceeded = &subject
+				return errExceeded
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errExceeded) {
+		return exceeded, nil
+	}
+	return nil, err
+}
+
+// ReleaseQuota returns a reservation made by ReserveQuota
+func (r *PostgresRepository) ReleaseQuota(ctx context.Context, subjects []domain.QuotaSubject, bytes, objects int64) error {
+	for _, subject := range subjects {
+		_, err := r.db.ExecContext(ctx, `
+			UPDATE storage_quotas
+			SET reserved_bytes = GREATEST(reserved_bytes - $3, 0), reserved_objects = GREATEST(reserved_objects - $4, 0)
+			WHERE scope = $1 AND subject_id = $2
+		`, subject.Scope, subject.SubjectID, bytes, objects)
+		if err != nil {
+			return fmt.Errorf("failed to release quota: %w", err)
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// REPLICATION
+// =============================================================================
+
+// ReplaceReplicationRules swaps the bucket's whole replication configuration
+func (r *PostgresRepository) ReplaceReplicationRules(ctx context.Context, bucketID string, rules []domain.ReplicationRule) error {
+	return r.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM bucket_replication_rules WHERE bucket_id = $1`, bucketID); err != nil {
+			return fmt.Errorf("failed to clear replication rules: %w", err)
+		}
+
+		for _, rule := range rules {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO bucket_replication_rules (bucket_id, rule_id, status, prefix, destination_bucket, replicate_deletes, replicate_metadata, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())`,
+				bucketID, rule.ID, rule.Status, rule.Prefix, rule.DestinationBucket, rule.ReplicateDeletes, rule.ReplicateMetadata,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to save replication rule %s: %w", rule.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetReplicationRules returns the bucket's replication rules in the order
+// they were created
+func (r *PostgresRepository) GetReplicationRules(ctx context.Context, bucketID string) ([]domain.ReplicationRule, error) {
+	query := `
+		SELECT rule_id, status, prefix, destination_bucket, replicate_deletes, replicate_metadata
+		FROM bucket_replication_rules
+		WHERE bucket_id = $1
+		ORDER BY created_at, rule_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, bucketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replication rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []domain.ReplicationRule
+	for rows.Next() {
+		var rule domain.ReplicationRule
+		if err := rows.Scan(&rule.ID, &rule.Status, &rule.Prefix, &rule.DestinationBucket,
+			&rule.ReplicateDeletes, &rule.ReplicateMetadata); err != nil {
+			return nil, fmt.Errorf("failed to scan replication rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// EnqueueReplication makes the task for the key and destination pending
+// with task's operation, creating it if needed. A metadata update does not
+// replace a copy that is still pending, since the copy carries it too.
+func (r *PostgresRepository) EnqueueReplication(ctx context.Context, task *domain.ReplicationTask) error {
+	return r.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO replication_tasks (id, bucket_id, object_key, destination_bucket, rule_id, operation, status, next_attempt_at, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, 'PENDING', NOW(), NOW(), NOW())
+			ON CONFLICT (bucket_id, object_key, destination_bucket) DO UPDATE
+			SET rule_id = EXCLUDED.rule_id,
+			    operation = CASE
+			        WHEN replication_tasks.status = 'PENDING' AND replication_tasks.operation = 'PUT' AND EXCLUDED.operation = 'METADATA'
+			        THEN 'PUT' ELSE EXCLUDED.operation END,
+			    status = 'PENDING',
+			    attempts = 0,
+			    last_error = NULL,
+			    seq = replication_tasks.seq + 1,
+			    next_attempt_at = NOW(),
+			    updated_at = NOW()`,
+			task.ID, task.BucketID, task.Key, task.DestinationBucket, task.RuleID, task.Operation,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to enqueue replication: %w", err)
+		}
+		return refreshReplicationStatus(ctx, tx, task.BucketID, task.Key)
+	})
+}
+
+// ClaimReplicationTasks returns up to limit due tasks and pushes their next
+// attempt lease into the future, so other workers skip them and a crashed
+// worker's tasks are picked up again once the lease runs out
+func (r *PostgresRepository) ClaimReplicationTasks(ctx context.Context, limit int, lease time.Duration) ([]domain.ReplicationTask, error) {
+	var tasks []domain.ReplicationTask
+	err := r.WithTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT id, bucket_id, object_key, destination_bucket, rule_id, operation, status,
+			       attempts, COALESCE(last_error, ''), seq, next_attempt_at, created_at, updated_at
+			FROM replication_tasks
+			WHERE status = 'PENDING' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED`, limit)
+		if err != nil {
+			return fmt.Errorf("failed to claim replication tasks: %w", err)
+		}
+		tasks, err = scanReplicationTasks(rows)
+		if err != nil {
+			return err
+		}
+
+		leaseUntil := time.Now().Add(lease)
+		for i := range tasks {
+			if _, err := tx.ExecContext(ctx, `UPDATE replication_tasks SET next_attempt_at = $1 WHERE id = $2`,
+				leaseUntil, tasks[i].ID); err != nil {
+				return fmt.Errorf("failed to lease replication task: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// FinishReplicationTask records the outcome of an attempt. It is a no-op
+// when a newer event has reset the task since it was claimed.
+func (r *PostgresRepository) FinishReplicationTask(ctx context.Context, task *domain.ReplicationTask) error {
+	return r.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE replication_tasks
+			SET status = $1, attempts = $2, last_error = NULLIF($3, ''), next_attempt_at = $4, updated_at = NOW()
+			WHERE id = $5 AND seq = $6`,
+			task.Status, task.Attempts, task.LastError, task.NextAttemptAt, task.ID, task.Seq,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update replication task: %w", err)
+		}
+		return refreshReplicationStatus(ctx, tx, task.BucketID, task.Key)
+	})
+}
+
+// ListReplicationTasks returns a bucket's most recently updated tasks,
+// optionally only those with status
+func (r *PostgresRepository) ListReplicationTasks(ctx context.Context, bucketID, status string, limit int) ([]domain.ReplicationTask, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, bucket_id, object_key, destination_bucket, rule_id, operation, status,
+		       attempts, COALESCE(last_error, ''), seq, next_attempt_at, created_at, updated_at
+		FROM replication_tasks
+		WHERE bucket_id = $1 AND ($2::text = '' OR status = $2)
+		ORDER BY updated_at DESC
+		LIMIT $3`, bucketID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replication tasks: %w", err)
+	}
+	return scanReplicationTasks(rows)
+}
+
+// RetryFailedReplication makes every failed task of the bucket pending again
+// with a fresh set of attempts
+func (r *PostgresRepository) RetryFailedReplication(ctx context.Context, bucketID string) (int64, error) {
+	var count int64
+	err := r.WithTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			UPDATE replication_tasks
+			SET status = 'PENDING', attempts = 0, seq = seq + 1, next_attempt_at = NOW(), updated_at = NOW()
+			WHERE bucket_id = $1 AND status = 'FAILED'
+			RETURNING object_key`, bucketID)
+		if err != nil {
+			return fmt.Errorf("failed to retry replication: %w", err)
+		}
+		var keys []string
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan replication task: %w", err)
+			}
+			keys = append(keys, key)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err := refreshReplicationStatus(ctx, tx, bucketID, key); err != nil {
+				return err
+			}
+		}
+		count = int64(len(keys))
+		return nil
+	})
+	return count, err
+}
+
+// refreshReplicationStatus sets the file's replication status from its
+// copy tasks: PENDING while any is pending, else FAILED if any failed
+func refreshReplicationStatus(ctx context.Context, tx *sql.Tx, bucketID, key string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE files f
+		SET replication_status = t.status
+		FROM (
+			SELECT CASE
+			           WHEN bool_or(status = 'PENDING') THEN 'PENDING'
+			           WHEN bool_or(status = 'FAILED') THEN 'FAILED'
+			           ELSE 'COMPLETED'
+			       END AS status
+			FROM replication_tasks
+			WHERE bucket_id = $1 AND object_key = $2 AND operation <> 'DELETE'
+			HAVING COUNT(*) > 0
+		) t
+		WHERE f.bucket_id = $1 AND f.key = $2 AND f.replication_status IS DISTINCT FROM 'REPLICA'`,
+		bucketID, key)
+	if err != nil {
+		return fmt.Errorf("failed to update replication status: %w", err)
+	}
+	return nil
+}
+
+func scanReplicationTasks(rows *sql.Rows) ([]domain.ReplicationTask, error) {
+	defer rows.Close()
+
+	var tasks []domain.ReplicationTask
+	for rows.Next() {
+		var task domain.ReplicationTask
+		if err := rows.Scan(&task.ID, &task.BucketID, &task.Key, &task.DestinationBucket, &task.RuleID,
+			&task.Operation, &task.Status, &task.Attempts, &task.LastError, &task.Seq,
+			&task.NextAttemptAt, &task.CreatedAt, &task.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan replication task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// =============================================================================
+// WEBHOOK QUEUE
+// =============================================================================
+
+// webhookQueueLockKey serializes queue claims so per-webhook concurrency
+// limits hold across workers and server instances
+const webhookQueueLockKey = 727274311
+
+// EnqueueWebhook stores an event for delivery to one webhook
+func (r *PostgresRepository) EnqueueWebhook(ctx context.Context, item *domain.WebhookQueueItem) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_queue (id, webhook_id, event, payload, status, attempts, replay_of, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, 0, NULLIF($6, ''), $7, $7, $7)`,
+		item.ID, item.WebhookID, item.Event, item.Payload, domain.WebhookQueuePending, item.ReplayOf, item.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ClaimWebhookQueue leases up to limit due items to the caller until lease
+// has passed. Items whose webhook already has its maximum in flight (its
+// max_concurrency, or defaultConcurrency when that is 0) are left queued.
+func (r *PostgresRepository) ClaimWebhookQueue(ctx context.Context, limit int, lease time.Duration, defaultConcurrency int) ([]domain.WebhookQueueItem, error) {
+	var items []domain.WebhookQueueItem
+	err := r.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, webhookQueueLockKey); err != nil {
+			return fmt.Errorf("failed to lock webhook queue: %w", err)
+		}
+		rows, err := tx.QueryContext(ctx, `
+			WITH in_flight AS (
+				SELECT webhook_id, COUNT(*) AS n
+				FROM webhook_queue
+				WHERE status = 'SENDING' AND next_attempt_at > NOW()
+				GROUP BY webhook_id
+			), ranked AS (
+				SELECT q.id, q.next_attempt_at, q.created_at,
+				       ROW_NUMBER() OVER (PARTITION BY q.webhook_id ORDER BY q.next_attempt_at, q.created_at) AS rn,
+				       COALESCE(NULLIF(w.max_concurrency, 0), $2) - COALESCE(f.n, 0) AS free
+				FROM webhook_queue q
+				JOIN webhooks w ON w.id = q.webhook_id
+				LEFT JOIN in_flight f ON f.webhook_id = q.webhook_id
+				WHERE q.status IN ('PENDING', 'SENDING') AND q.next_attempt_at <= NOW()
+			), claimed AS (
+				SELECT id FROM ranked
+				WHERE rn <= free
+				ORDER BY next_attempt_at, created_at
+				LIMIT $1
+			)
+			UPDATE webhook_queue q
+			SET status = 'SENDING', next_attempt_at = $3, updated_at = NOW()
+			FROM claimed
+			WHERE q.id = claimed.id
+			RETURNING q.id, q.webhook_id, q.event, q.payload, q.status, q.attempts, COALESCE(q.last_error, ''),
+			          COALESCE(q.replay_of, ''), q.next_attempt_at, q.created_at, q.updated_at`,
+			limit, defaultConcurrency, time.Now().Add(lease))
+		if err != nil {
+			return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+		}
+		items, err = scanWebhookQueue(rows)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// FinishWebhookQueueItem records the outcome of an attempt
+func (r *PostgresRepository) FinishWebhookQueueItem(ctx context.Context, item *domain.WebhookQueueItem) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_queue
+		SET status = $1, attempts = $2, last_error = NULLIF($3, ''), next_attempt_at = $4, updated_at = NOW()
+		WHERE id = $5`,
+		item.Status, item.Attempts, item.LastError, item.NextAttemptAt, item.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListDeadWebhookDeliveries returns the last attempt of each dead-lettered
+// event of the webhook that has not been replayed yet, oldest first. An
+// event that went dead without being attempted, such as one queued for a
+// webhook that was then deactivated, is listed with no delivery id, attempt
+// 0 and the queue item's last error.
+func (r *PostgresRepository) ListDeadWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]domain.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT COALESCE(d.id, ''), q.webhook_id, q.id, COALESCE(d.attempt, 0), q.event, q.payload,
+		       COALESCE(d.status_code, 0), COALESCE(d.response, ''), COALESCE(d.success, false),
+		       COALESCE(d.error_message, q.last_error, ''), COALESCE(d.delivered_at, q.updated_at)
+		FROM webhook_queue q
+		LEFT JOIN LATERAL (
+			SELECT id, attempt, status_code, response, success, error_message, delivered_at
+			FROM webhook_deliveries
+			WHERE queue_id = q.id
+			ORDER BY delivered_at DESC
+			LIMIT 1
+		) d ON true
+		WHERE q.webhook_id = $1 AND q.status = 'DEAD'
+		  AND NOT EXISTS (SELECT 1 FROM webhook_queue r WHERE r.replay_of = q.id)
+		ORDER BY q.created_at, q.id
+		LIMIT $2`, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead webhook deliveries: %w", err)
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// ListWebhookQueueBetween returns the events queued for the webhook in
+// [from, to), oldest first, whether or not a delivery was attempted yet.
+// Earlier replays are left out so each event is listed once.
+func (r *PostgresRepository) ListWebhookQueueBetween(ctx context.Context, webhookID string, from, to time.Time, limit int) ([]domain.WebhookQueueItem, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, webhook_id, event, payload, status, attempts, COALESCE(last_error, ''),
+		       COALESCE(replay_of, ''), next_attempt_at, created_at, updated_at
+		FROM webhook_queue
+		WHERE webhook_id = $1 AND replay_of IS NULL AND created_at >= $2 AND created_at < $3
+		ORDER BY created_at, id
+		LIMIT $4`, webhookID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued webhook events: %w", err)
+	}
+	return scanWebhookQueue(rows)
+}
+
+func scanWebhookQueue(rows *sql.Rows) ([]domain.WebhookQueueItem, error) {
+	defer rows.Close()
+
+	var items []domain.WebhookQueueItem
+	for rows.Next() {
+		var item domain.WebhookQueueItem
+		if err := rows.Scan(&item.ID, &item.WebhookID, &item.Event, &item.Payload, &item.Status,
+			&item.Attempts, &item.LastError, &item.ReplayOf, &item.NextAttemptAt, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"s3/internal/domain"
+	"s3/internal/infrastructure/database"
+
+	"github.com/google/uuid"
+)
+
+// testRepository connects to the database in TEST_DATABASE_URL, migrated to
+// the latest schema; tests that need it are skipped when it is unset
+func testRepository(t *testing.T) *PostgresRepository {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	var name string
+	if err := db.QueryRow(`SELECT current_database()`).Scan(&name); err != nil {
+		t.Fatalf("connect to database: %v", err)
+	}
+	if err := database.RunMigrations(db, name); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+	return NewPostgresRepository(db)
+}
+
+func TestListDeadWebhookDeliveries(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	webhook := &domain.Webhook{
+		ID:        uuid.New().String(),
+		BucketID:  uuid.New().String(),
+		Name:      "dead-letters",
+		URL:       "http://127.0.0.1:1/hook",
+		Events:    []string{"object.created"},
+		Secret:    "secret",
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := repo.SaveWebhook(ctx, webhook); err != nil {
+		t.Fatalf("SaveWebhook: %v", err)
+	}
+	t.Cleanup(func() {
+		repo.db.Exec(`DELETE FROM webhook_deliveries WHERE webhook_id = $1`, webhook.ID)
+		repo.db.Exec(`DELETE FROM webhooks WHERE id = $1`, webhook.ID)
+	})
+
+	deadItem := func(payload, lastError string, createdAt time.Time) *domain.WebhookQueueItem {
+		item := &domain.WebhookQueueItem{
+			ID:        uuid.New().String(),
+			WebhookID: webhook.ID,
+			Event:     "object.created",
+			Payload:   payload,
+			CreatedAt: createdAt,
+		}
+		if err := repo.EnqueueWebhook(ctx, item); err != nil {
+			t.Fatalf("EnqueueWebhook: %v", err)
+		}
+		item.Status, item.LastError, item.NextAttemptAt = domain.WebhookQueueDead, lastError, createdAt
+		if err := repo.FinishWebhookQueueItem(ctx, item); err != nil {
+			t.Fatalf("FinishWebhookQueueItem: %v", err)
+		}
+		return item
+	}
+
+	attempted := deadItem(`{"n":1}`, "status 500", now.Add(-2*time.Minute))
+	for attempt := 1; attempt <= 2; attempt++ {
+		if err := repo.SaveWebhookDelivery(ctx, &domain.WebhookDelivery{
+			ID:           uuid.New().String(),
+			WebhookID:    webhook.ID,
+			QueueID:      attempted.ID,
+			Attempt:      attempt,
+			Event:        attempted.Event,
+			Payload:      attempted.Payload,
+			StatusCode:   500,
+			ErrorMessage: "status 500",
+			DeliveredAt:  now.Add(time.Duration(attempt-3) * time.Minute),
+		}); err != nil {
+			t.Fatalf("SaveWebhookDelivery: %v", err)
+		}
+	}
+	neverAttempted := deadItem(`{"n":2}`, "webhook is inactive", now.Add(-time.Minute))
+
+	deliveries, err := repo.ListDeadWebhookDeliveries(ctx, webhook.ID, 10)
+	if err != nil {
+		t.Fatalf("ListDeadWebhookDeliveries: %v", err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("got %d dead deliveries, want 2", len(deliveries))
+	}
+
+	if got := deliveries[0]; got.QueueID != attempted.ID || got.Attempt != 2 || got.ID == "" {
+		t.Errorf("attempted event: got queue %s attempt %d id %q, want queue %s attempt 2 with an id",
+			got.QueueID, got.Attempt, got.ID, attempted.ID)
+	}
+
+	got := deliveries[1]
+	if got.QueueID != neverAttempted.ID || got.ID != "" || got.Attempt != 0 {
+		t.Errorf("never attempted event: got queue %s attempt %d id %q, want queue %s attempt 0 with no id",
+			got.QueueID, got.Attempt, got.ID, neverAttempted.ID)
+	}
+	if got.Event != neverAttempted.Event || got.Payload != neverAttempted.Payload || got.ErrorMessage != "webhook is inactive" {
+		t.Errorf("never attempted event: got %s %s %q, want the queue item's event, payload and last error",
+			got.Event, got.Payload, got.ErrorMessage)
+	}
+}
+
+func TestSaveMultipartPartConcurrently(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	upload := &domain.MultipartUpload{
+		ID:              uuid.New().String(),
+		UploadID:        uuid.New().String(),
+		StorageUploadID: uuid.New().String(),
+		BucketID:        uuid.New().String(),
+		Key:             "parallel/object",
+		Status:          "initiated",
+		Parts:           []domain.Part{},
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := repo.SaveMultipartUpload(ctx, upload); err != nil {
+		t.Fatalf("SaveMultipartUpload: %v", err)
+	}
+	t.Cleanup(func() {
+		repo.db.Exec(`DELETE FROM multipart_uploads WHERE upload_id = $1`, upload.UploadID)
+	})
+
+	const parts = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, parts)
+	for n := 1; n <= parts; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			errs <- repo.SaveMultipartPart(ctx, upload.UploadID, &domain.Part{
+				PartNumber: n, ETag: "first", Size: int64(n), UploadedAt: now,
+			})
+		}(n)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("SaveMultipartPart: %v", err)
+		}
+	}
+
+	// Re-uploading a part replaces it
+	if err := repo.SaveMultipartPart(ctx, upload.UploadID, &domain.Part{
+		PartNumber: 3, ETag: "second", Size: 30, UploadedAt: now,
+	}); err != nil {
+		t.Fatalf("SaveMultipartPart: %v", err)
+	}
+
+	got, err := repo.GetMultipartUploadByUploadID(ctx, upload.UploadID)
+	if err != nil {
+		t.Fatalf("GetMultipartUploadByUploadID: %v", err)
+	}
+	if len(got.Parts) != parts {
+		t.Fatalf("got %d parts, want %d", len(got.Parts), parts)
+	}
+	for i, part := range got.Parts {
+		want := domain.Part{PartNumber: i + 1, ETag: "first", Size: int64(i + 1)}
+		if i+1 == 3 {
+			want.ETag, want.Size = "second", 30
+		}
+		if part.PartNumber != want.PartNumber || part.ETag != want.ETag || part.Size != want.Size {
+			t.Errorf("part %d: got %d %s %d, want %d %s %d", i,
+				part.PartNumber, part.ETag, part.Size, want.PartNumber, want.ETag, want.Size)
+		}
+	}
+}
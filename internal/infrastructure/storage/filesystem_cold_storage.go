@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FilesystemColdStorage is a cold tier on the local filesystem. Objects are
+// stored as <root>/<bucket>/<hh>/<sha256 of key>, so every key gets its own
+// file: keys are not cleaned as paths ("a/./b" and "a/b" differ) and "a" and
+// "a/b" can both exist.
+type FilesystemColdStorage struct {
+	root string
+}
+
+func NewFilesystemColdStorage(root string) (*FilesystemColdStorage, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cold storage path: %w", err)
+	}
+	if err := os.MkdirAll(abs, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create cold storage directory: %w", err)
+	}
+	return &FilesystemColdStorage{root: abs}, nil
+}
+
+// Name implements domain.ColdStoragePort
+func (f *FilesystemColdStorage) Name() string {
+	return "filesystem:" + f.root
+}
+
+// PutObject implements domain.ColdStoragePort. The object is written to a
+// temporary file first so a failed transfer never leaves a partial object.
+func (f *FilesystemColdStorage) PutObject(ctx context.Context, bucket, key string, body io.Reader, size int64) error {
+	path, err := f.path(bucket, key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create cold storage directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cold object: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write cold object: %w", err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("failed to write cold object: wrote %d of %d bytes", written, size)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store cold object: %w", err)
+	}
+	return nil
+}
+
+// GetObject implements domain.ColdStoragePort. The caller must close the reader.
+func (f *FilesystemColdStorage) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	path, err := f.path(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Objects archived before keys were hashed
+		if legacy, ok := f.legacyFile(bucket, key); ok {
+			file, err = os.Open(legacy)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cold object: %w", err)
+	}
+	return file, nil
+}
+
+// DeleteObject implements domain.ColdStoragePort. Deleting a missing object
+// is not an error.
+func (f *FilesystemColdStorage) DeleteObject(ctx context.Context, bucket, key string) error {
+	path, err := f.path(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete cold object: %w", err)
+	}
+	if legacy, ok := f.legacyFile(bucket, key); ok {
+		if err := os.Remove(legacy); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete cold object: %w", err)
+		}
+	}
+	return nil
+}
+
+// path maps bucket and key to the object's file within the root
+func (f *FilesystemColdStorage) path(bucket, key string) (string, error) {
+	if bucket == "" || bucket == "." || bucket == ".." || strings.ContainsAny(bucket, `/\`) {
+		return "", fmt.Errorf("invalid cold storage bucket %q", bucket)
+	}
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(f.root, bucket, name[:2], name), nil
+}
+
+// legacyFile finds an object archived before keys were hashed, when it was
+// stored as <root>/<bucket>/<key>. Keys such as "../x" that would escape the
+// root, and paths that are now hash directories, are never matched.
+func (f *FilesystemColdStorage) legacyFile(bucket, key string) (string, bool) {
+	dir := filepath.Join(f.root, bucket)
+	path := filepath.Join(dir, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+		return "", false
+	}
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	return path, true
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFilesystemColdStorageKeys(t *testing.T) {
+	cold, err := NewFilesystemColdStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFilesystemColdStorage: %v", err)
+	}
+	ctx := context.Background()
+
+	// Each of these is a distinct object that a path-based layout would
+	// either merge or be unable to store alongside the others
+	keys := []string{"a", "a/b", "a/./b", "a//b", "a/b/", "../escape", "a/../a/b", `a\b`}
+	for _, key := range keys {
+		if err := cold.PutObject(ctx, "bucket", key, strings.NewReader("content of "+key), -1); err != nil {
+			t.Fatalf("PutObject(%q): %v", key, err)
+		}
+	}
+	for _, key := range keys {
+		if got := readColdObject(t, cold, "bucket", key); got != "content of "+key {
+			t.Errorf("GetObject(%q) = %q, want %q", key, got, "content of "+key)
+		}
+	}
+
+	if err := cold.DeleteObject(ctx, "bucket", "a/b"); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+	if _, err := cold.GetObject(ctx, "bucket", "a/b"); err == nil {
+		t.Errorf("GetObject after DeleteObject succeeded")
+	}
+	if got := readColdObject(t, cold, "bucket", "a/./b"); got != "content of a/./b" {
+		t.Errorf("DeleteObject removed another key: GetObject(%q) = %q", "a/./b", got)
+	}
+
+	for _, bucket := range []string{"", ".", "..", "a/b"} {
+		if err := cold.PutObject(ctx, bucket, "key", strings.NewReader("x"), 1); err == nil {
+			t.Errorf("PutObject accepted bucket %q", bucket)
+		}
+	}
+}
+
+func TestFilesystemColdStorageLegacyLayout(t *testing.T) {
+	root := t.TempDir()
+	cold, err := NewFilesystemColdStorage(root)
+	if err != nil {
+		t.Fatalf("NewFilesystemColdStorage: %v", err)
+	}
+	ctx := context.Background()
+
+	legacy := filepath.Join(root, "bucket", "logs", "old.txt")
+	if err := os.MkdirAll(filepath.Dir(legacy), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte("archived earlier"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readColdObject(t, cold, "bucket", "logs/old.txt"); got != "archived earlier" {
+		t.Errorf("GetObject(legacy) = %q, want %q", got, "archived earlier")
+	}
+	if err := cold.DeleteObject(ctx, "bucket", "logs/old.txt"); err != nil {
+		t.Fatalf("DeleteObject(legacy): %v", err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("legacy object still exists after DeleteObject: %v", err)
+	}
+
+	// A legacy directory is not an object
+	if err := cold.DeleteObject(ctx, "bucket", "logs"); err != nil {
+		t.Errorf("DeleteObject(directory): %v", err)
+	}
+}
+
+func readColdObject(t *testing.T, cold *FilesystemColdStorage, bucket, key string) string {
+	t.Helper()
+	body, err := cold.GetObject(context.Background(), bucket, key)
+	if err != nil {
+		t.Fatalf("GetObject(%q): %v", key, err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("read %q: %v", key, err)
+	}
+	return string(data)
+}
@@ -7,14 +7,21 @@ import (
 	"io"
 	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
+	"strings"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
 	"github.com/minio/minio-go/v7/pkg/lifecycle"
 )
 
+// unknownSizePartSize is the part size used when streaming bodies of unknown length
+const unknownSizePartSize = 16 << 20
+
 type MinIOAdapter struct {
 	client *minio.Client
+	core   *minio.Core // low-level API for native multipart uploads
 }
 
 type BucketAlreadyExists struct {
@@ -45,6 +52,84 @@ func (m *MinIOAdapter) DeleteObject(ctx context.Context, bucket string, key stri
 	return nil
 }
 
+// GetObjectVersionStream implements domain.StoragePort. The caller must close the reader.
+func (m *MinIOAdapter) GetObjectVersionStream(ctx context.Context, bucket, key, versionID string, enc *domain.ObjectEncryption) (io.ReadCloser, *domain.ObjectInfo, error) {
+	sse, err := serverSide(enc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	object, err := m.client.GetObject(ctx, bucket, key, minio.GetObjectOptions{VersionID: versionID, ServerSideEncryption: sse})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get object version: %w", err)
+	}
+
+	stat, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, nil, fmt.Errorf("failed to stat object version: %w", err)
+	}
+
+	return object, &domain.ObjectInfo{
+		Key:          stat.Key,
+		Size:         stat.Size,
+		ContentType:  stat.ContentType,
+		ETag:         stat.ETag,
+		LastModified: stat.LastModified,
+		VersionID:    stat.VersionID,
+	}, nil
+}
+
+// DeleteObjectVersion implements domain.StoragePort. It goes through the
+// multi-object delete API because that is the call that reports the delete
+// marker created for an unversioned delete.
+func (m *MinIOAdapter) DeleteObjectVersion(ctx context.Context, bucket, key, versionID string) (*domain.ObjectDeletion, error) {
+	objects := make(chan minio.ObjectInfo, 1)
+	objects <- minio.ObjectInfo{Key: key, VersionID: versionID}
+	close(objects)
+
+	deletion := &domain.ObjectDeletion{VersionID: versionID}
+	for result := range m.client.RemoveObjectsWithResult(ctx, bucket, objects, minio.RemoveObjectsOptions{}) {
+		if result.Err != nil {
+			return nil, fmt.Errorf("failed to delete object version: %w", result.Err)
+		}
+		if result.DeleteMarker {
+			deletion.DeleteMarker = true
+			deletion.VersionID = result.DeleteMarkerVersionID
+		}
+	}
+
+	return deletion, nil
+}
+
+// RestoreObjectVersion implements domain.StoragePort. An encrypted version
+// is copied with, and stays encrypted under, its own key.
+func (m *MinIOAdapter) RestoreObjectVersion(ctx context.Context, bucket, key, versionID string, enc *domain.ObjectEncryption) (*domain.ObjectInfo, error) {
+	sse, err := serverSide(enc)
+	if err != nil {
+		return nil, err
+	}
+
+	src := minio.CopySrcOptions{Bucket: bucket, Object: key, VersionID: versionID}
+	dst := minio.CopyDestOptions{Bucket: bucket, Object: key, Encryption: sse}
+	if sse != nil {
+		src.Encryption = encrypt.SSECopy(sse)
+	}
+
+	info, err := m.client.CopyObject(ctx, dst, src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore object version: %w", err)
+	}
+
+	return &domain.ObjectInfo{
+		Key:          info.Key,
+		Size:         info.Size,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+		VersionID:    info.VersionID,
+	}, nil
+}
+
 // CreateBucket implements domain.StoragePort.
 
 func (m *MinIOAdapter) CreateBucket(ctx context.Context, name string) (string, error) {
@@ -176,51 +261,226 @@ func NewMinIOAdapter(endpoint, accessKey, secretKey string, useSSL bool) (*MinIO
 		return nil, fmt.Errorf("failed to create MinIO client: %w", err)
 	}
 
-	return &MinIOAdapter{client: client}, nil
+	return &MinIOAdapter{client: client, core: &minio.Core{Client: client}}, nil
 }
 
 // SaveObject implements domain.StoragePort
 func (m *MinIOAdapter) SaveObject(ctx context.Context, bucket, key string, data []byte, metadata map[string]string) error {
-	reader := bytes.NewReader(data)
+	_, err := m.SaveObjectStream(ctx, bucket, key, bytes.NewReader(data), int64(len(data)), "application/octet-stream", metadata, nil)
+	return err
+}
+
+// GetObject implements domain.StoragePort
+func (m *MinIOAdapter) GetObject(ctx context.Context, bucket, key string) ([]byte, error) {
+	object, _, err := m.GetObjectStream(ctx, bucket, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+
+	return data, nil
+}
+
+// SaveObjectStream implements domain.StoragePort
+func (m *MinIOAdapter) SaveObjectStream(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string, metadata map[string]string, enc *domain.ObjectEncryption) (*domain.ObjectInfo, error) {
+	sse, err := serverSide(enc)
+	if err != nil {
+		return nil, err
+	}
 
 	// Ensure bucket exists
 	exists, err := m.client.BucketExists(ctx, bucket)
 	if err != nil {
-		return fmt.Errorf("failed to check bucket existence: %w", err)
+		return nil, fmt.Errorf("failed to check bucket existence: %w", err)
 	}
 	if !exists {
 		err = m.client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{})
 		if err != nil {
-			return fmt.Errorf("failed to create bucket: %w", err)
+			return nil, fmt.Errorf("failed to create bucket: %w", err)
+		}
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	opts := minio.PutObjectOptions{
+		UserMetadata:         metadata,
+		ContentType:          contentType,
+		ServerSideEncryption: sse,
+	}
+	// With an unknown size minio-go sizes parts for a 5 TiB object (~512 MiB
+	// buffered per part); pin it so memory stays bounded.
+	if size < 0 {
+		opts.PartSize = unknownSizePartSize
+	}
+
+	info, err := m.client.PutObject(ctx, bucket, key, body, size, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to put object: %w", err)
+	}
+
+	return &domain.ObjectInfo{
+		Key:          info.Key,
+		Size:         info.Size,
+		ContentType:  contentType,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+		VersionID:    info.VersionID,
+	}, nil
+}
+
+// GetObjectStream implements domain.StoragePort. The caller must close the reader.
+func (m *MinIOAdapter) GetObjectStream(ctx context.Context, bucket, key string, enc *domain.ObjectEncryption) (io.ReadCloser, *domain.ObjectInfo, error) {
+	sse, err := serverSide(enc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	object, err := m.client.GetObject(ctx, bucket, key, minio.GetObjectOptions{ServerSideEncryption: sse})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	stat, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+
+	return object, &domain.ObjectInfo{
+		Key:          stat.Key,
+		Size:         stat.Size,
+		ContentType:  stat.ContentType,
+		ETag:         stat.ETag,
+		LastModified: stat.LastModified,
+		VersionID:    stat.VersionID,
+	}, nil
+}
+
+func (m *MinIOAdapter) GetObjectRange(ctx context.Context, bucket, key string, offset, length int64, enc *domain.ObjectEncryption) (io.ReadCloser, error) {
+	if offset < 0 || length == 0 || length < -1 {
+		return nil, fmt.Errorf("invalid range: offset %d, length %d", offset, length)
+	}
+
+	sse, err := serverSide(enc)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := minio.GetObjectOptions{ServerSideEncryption: sse}
+	switch {
+	case length > 0:
+		if err := opts.SetRange(offset, offset+length-1); err != nil {
+			return nil, fmt.Errorf("invalid range: %w", err)
+		}
+	case offset > 0:
+		// An end of 0 asks MinIO for everything from offset onwards
+		if err := opts.SetRange(offset, 0); err != nil {
+			return nil, fmt.Errorf("invalid range: %w", err)
 		}
 	}
 
-	// Upload object
-	_, err = m.client.PutObject(ctx, bucket, key, reader, int64(len(data)), minio.PutObjectOptions{
-		UserMetadata: metadata,
-		ContentType:  "application/octet-stream",
+	object, err := m.client.GetObject(ctx, bucket, key, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object range: %w", err)
+	}
+
+	return object, nil
+}
+
+func (m *MinIOAdapter) StatObject(ctx context.Context, bucket, key string, enc *domain.ObjectEncryption) (*domain.ObjectInfo, error) {
+	sse, err := serverSide(enc)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := m.client.StatObject(ctx, bucket, key, minio.StatObjectOptions{ServerSideEncryption: sse})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+
+	return &domain.ObjectInfo{
+		Key:          stat.Key,
+		Size:         stat.Size,
+		ContentType:  stat.ContentType,
+		ETag:         stat.ETag,
+		LastModified: stat.LastModified,
+		VersionID:    stat.VersionID,
+	}, nil
+}
+
+func (m *MinIOAdapter) NewMultipartUpload(ctx context.Context, bucket, key, contentType string, metadata map[string]string, enc *domain.ObjectEncryption) (string, error) {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	sse, err := serverSide(enc)
+	if err != nil {
+		return "", err
+	}
+
+	uploadID, err := m.core.NewMultipartUpload(ctx, bucket, key, minio.PutObjectOptions{
+		ContentType:          contentType,
+		UserMetadata:         metadata,
+		ServerSideEncryption: sse,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to put object: %w", err)
+		return "", fmt.Errorf("failed to initiate multipart upload: %w", err)
 	}
 
-	return nil
+	return uploadID, nil
 }
 
-// GetObject implements domain.StoragePort
-func (m *MinIOAdapter) GetObject(ctx context.Context, bucket, key string) ([]byte, error) {
-	object, err := m.client.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+func (m *MinIOAdapter) PutObjectPart(ctx context.Context, bucket, key, uploadID string, partNumber int, body io.Reader, size int64, enc *domain.ObjectEncryption) (*domain.Part, error) {
+	sse, err := serverSide(enc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get object: %w", err)
+		return nil, err
 	}
-	defer object.Close()
 
-	data, err := io.ReadAll(object)
+	part, err := m.core.PutObjectPart(ctx, bucket, key, uploadID, partNumber, body, size, minio.PutObjectPartOptions{SSE: sse})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read object: %w", err)
+		return nil, fmt.Errorf("failed to upload part %d: %w", partNumber, err)
 	}
 
-	return data, nil
+	return &domain.Part{
+		PartNumber: part.PartNumber,
+		ETag:       strings.Trim(part.ETag, `"`),
+		Size:       part.Size,
+		UploadedAt: time.Now(),
+	}, nil
+}
+
+func (m *MinIOAdapter) CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []domain.Part) (*domain.ObjectInfo, error) {
+	completeParts := make([]minio.CompletePart, len(parts))
+	for i, part := range parts {
+		completeParts[i] = minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag}
+	}
+
+	info, err := m.core.CompleteMultipartUpload(ctx, bucket, key, uploadID, completeParts, minio.PutObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+
+	return &domain.ObjectInfo{
+		Key:          info.Key,
+		Size:         info.Size,
+		ETag:         strings.Trim(info.ETag, `"`),
+		LastModified: info.LastModified,
+		VersionID:    info.VersionID,
+	}, nil
+}
+
+func (m *MinIOAdapter) AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error {
+	if err := m.core.AbortMultipartUpload(ctx, bucket, key, uploadID); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+	return nil
 }
 
 func (m *MinIOAdapter) DeleteBucket(ctx context.Context, name string) error {
@@ -296,19 +556,46 @@ func (m *MinIOAdapter) SetBucketLifecycle(ctx context.Context, name string, inpu
 
 	return nil
 }
-func (m *MinIOAdapter) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error {
+func (m *MinIOAdapter) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, srcEnc, dstEnc *domain.ObjectEncryption) error {
+	srcSSE, err := serverSide(srcEnc)
+	if err != nil {
+		return err
+	}
+	dstSSE, err := serverSide(dstEnc)
+	if err != nil {
+		return err
+	}
+
 	src := minio.CopySrcOptions{
 		Bucket: srcBucket,
 		Object: srcKey,
 	}
+	if srcSSE != nil {
+		src.Encryption = encrypt.SSECopy(srcSSE)
+	}
 
 	dst := minio.CopyDestOptions{
-		Bucket: dstBucket,
-		Object: dstKey,
+		Bucket:     dstBucket,
+		Object:     dstKey,
+		Encryption: dstSSE,
 	}
 
-	_, err := m.client.CopyObject(ctx, dst, src)
+	_, err = m.client.CopyObject(ctx, dst, src)
 	return err
 }
 
+// serverSide maps an object key onto MinIO's SSE options. Both modes are
+// sent as SSE-C, which MinIO only accepts over TLS.
+func serverSide(enc *domain.ObjectEncryption) (encrypt.ServerSide, error) {
+	if enc == nil || enc.Mode == domain.EncryptionNone {
+		return nil, nil
+	}
+
+	sse, err := encrypt.NewSSEC(enc.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return sse, nil
+}
+
 var _ domain.StoragePort = (*MinIOAdapter)(nil)
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// MinIOColdStorage is a cold tier kept in a single bucket of a (usually
+// separate, cheaper) MinIO deployment. Objects are stored as <bucket>/<key>.
+type MinIOColdStorage struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewMinIOColdStorage(ctx context.Context, endpoint, accessKey, secretKey string, useSSL bool, bucket string) (*MinIOColdStorage, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MinIO client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check cold storage bucket: %w", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to create cold storage bucket: %w", err)
+		}
+	}
+
+	return &MinIOColdStorage{client: client, bucket: bucket}, nil
+}
+
+// Name implements domain.ColdStoragePort
+func (m *MinIOColdStorage) Name() string {
+	return "minio:" + m.bucket
+}
+
+// PutObject implements domain.ColdStoragePort
+func (m *MinIOColdStorage) PutObject(ctx context.Context, bucket, key string, body io.Reader, size int64) error {
+	opts := minio.PutObjectOptions{}
+	if size < 0 {
+		opts.PartSize = unknownSizePartSize
+	}
+
+	if _, err := m.client.PutObject(ctx, m.bucket, bucket+"/"+key, body, size, opts); err != nil {
+		return fmt.Errorf("failed to write cold object: %w", err)
+	}
+	return nil
+}
+
+// GetObject implements domain.ColdStoragePort. The caller must close the reader.
+func (m *MinIOColdStorage) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	object, err := m.client.GetObject(ctx, m.bucket, bucket+"/"+key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cold object: %w", err)
+	}
+	// GetObject is lazy; stat so a missing object fails here, not mid-copy
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, fmt.Errorf("failed to open cold object: %w", err)
+	}
+	return object, nil
+}
+
+// DeleteObject implements domain.ColdStoragePort
+func (m *MinIOColdStorage) DeleteObject(ctx context.Context, bucket, key string) error {
+	if err := m.client.RemoveObject(ctx, m.bucket, bucket+"/"+key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete cold object: %w", err)
+	}
+	return nil
+}
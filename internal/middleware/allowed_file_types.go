@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,13 +9,34 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AllowedFileTypesMiddleware() gin.HandlerFunc {
-	var allowedTypes = map[string]bool{
-		"image/png":                true,
-		"image/jpeg":               true,
-		"application/pdf":          true,
-		"application/octet-stream": true,
+var allowedFileTypes = map[string]bool{
+	"image/png":                true,
+	"image/jpeg":               true,
+	"application/pdf":          true,
+	"application/octet-stream": true,
+}
+
+// IsAllowedFileType reports whether a sniffed content type may be uploaded
+func IsAllowedFileType(contentType string) bool {
+	return allowedFileTypes[contentType]
+}
+
+// SniffFileType detects the content type from the first 512 bytes of r without
+// consuming them; the returned reader yields the full stream.
+func SniffFileType(r io.Reader) (io.Reader, string, error) {
+	buffered := bufio.NewReaderSize(r, 512)
+	head, err := buffered.Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, "", err
 	}
+	return buffered, http.DetectContentType(head), nil
+}
+
+// AllowedFileTypesMiddleware rejects multipart uploads whose "file" part is
+// not an allowed type. It reads the form with FormFile, which buffers the
+// whole body, so streaming handlers check SniffFileType and
+// IsAllowedFileType themselves instead.
+func AllowedFileTypesMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		file, _, err := c.Request.FormFile("file")
 		if err != nil {
@@ -33,7 +55,7 @@ func AllowedFileTypesMiddleware() gin.HandlerFunc {
 		file.Seek(0, io.SeekStart)
 		contentType := http.DetectContentType(buffer)
 
-		if !allowedTypes[contentType] {
+		if !IsAllowedFileType(contentType) {
 			c.AbortWithStatusJSON(400, gin.H{"error": "Invalid file type"})
 			return
 		}
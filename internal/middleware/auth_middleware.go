@@ -18,7 +18,7 @@ type StaticAPIKeyValidator struct {
 
 func (v *StaticAPIKeyValidator) ValidateAPIKey(key string) (string, error) {
 
-    userID, ok := v.Keys["my-secret-api-key"]
+    userID, ok := v.Keys[key]
     if !ok {
         return "", fmt.Errorf("invalid api key")
     }
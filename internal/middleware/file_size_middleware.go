@@ -6,16 +6,16 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// MaxFileSizeMiddleware caps the request body without buffering it, so streaming
+// handlers see an *http.MaxBytesError once the limit is crossed.
 func MaxFileSizeMiddleware(maxSize int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
-
-		file, _, err := c.Request.FormFile("file")
-		if err != nil {
-			c.AbortWithStatusJSON(400, gin.H{"error": "File too large or missing"})
+		if c.Request.ContentLength > maxSize {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large"})
 			return
 		}
-		file.Close() // Close immediately, actual handler will reopen
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
 		c.Next()
 	}
 }
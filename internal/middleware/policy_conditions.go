@@ -0,0 +1,278 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Condition keys populated from the request by RequestConditionKeys
+const (
+	ConditionSourceIP        = "aws:SourceIp"
+	ConditionSecureTransport = "aws:SecureTransport"
+	ConditionCurrentTime     = "aws:CurrentTime"
+	ConditionEpochTime       = "aws:EpochTime"
+	ConditionUserAgent       = "aws:UserAgent"
+	ConditionReferer         = "aws:Referer"
+	ConditionUserID          = "aws:userid"
+	ConditionPrefix          = "s3:prefix"
+	ConditionContentLength   = "s3:content-length"
+)
+
+// UnknownContentLength is the s3:content-length of a write whose body has no
+// Content-Length (a chunked upload). Size conditions treat it as too large:
+// an Allow statement that tests the size does not match, a Deny one does.
+const UnknownContentLength = "unknown"
+
+// forwardedTLSKey is set on requests a trusted proxy received over TLS
+const forwardedTLSKey = "forwarded_tls"
+
+// ForwardedProtoMiddleware honours X-Forwarded-Proto only on requests from
+// trustedProxies (IPs or CIDRs), so aws:SecureTransport cannot be claimed by
+// any client that sets the header
+func ForwardedProtoMiddleware(trustedProxies []string) (gin.HandlerFunc, error) {
+	var networks []*net.IPNet
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+		}
+		networks = append(networks, network)
+	}
+
+	return func(c *gin.Context) {
+		if strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https") {
+			if remote := net.ParseIP(c.RemoteIP()); remote != nil {
+				for _, network := range networks {
+					if network.Contains(remote) {
+						c.Set(forwardedTLSKey, true)
+						break
+					}
+				}
+			}
+		}
+		c.Next()
+	}, nil
+}
+
+// RequestConditionKeys collects the condition keys policies can test.
+// aws:SourceIp is the client IP as gin resolves it, so it only reflects
+// X-Forwarded-For when the router trusts the proxy that sent it.
+func RequestConditionKeys(c *gin.Context) map[string]string {
+	now := time.Now().UTC()
+	secure := c.Request.TLS != nil || c.GetBool(forwardedTLSKey)
+
+	keys := map[string]string{
+		ConditionSourceIP:        c.ClientIP(),
+		ConditionSecureTransport: strconv.FormatBool(secure),
+		ConditionCurrentTime:     now.Format(time.RFC3339),
+		ConditionEpochTime:       strconv.FormatInt(now.Unix(), 10),
+	}
+	if ua := c.Request.UserAgent(); ua != "" {
+		keys[ConditionUserAgent] = ua
+	}
+	if referer := c.Request.Referer(); referer != "" {
+		keys[ConditionReferer] = referer
+	}
+	if actor := c.GetString("actor"); actor != "" {
+		keys[ConditionUserID] = actor
+	}
+	if prefix, ok := c.GetQuery("prefix"); ok {
+		keys[ConditionPrefix] = prefix
+	}
+	if c.Request.Method == "PUT" || c.Request.Method == "POST" {
+		keys[ConditionContentLength] = UnknownContentLength
+		if c.Request.ContentLength >= 0 {
+			keys[ConditionContentLength] = strconv.FormatInt(c.Request.ContentLength, 10)
+		}
+	}
+	return keys
+}
+
+// conditionsMatch evaluates a statement's Condition block: every operator
+// and every key within it must hold; a key holds when any of its values
+// matches (for negated operators, when none does). deny is set for Deny
+// statements, which size conditions match when the size is unknown.
+func conditionsMatch(conditions map[string]interface{}, keys map[string]string, deny bool) bool {
+	for op, block := range conditions {
+		entries, ok := block.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, raw := range entries {
+			if !conditionHolds(op, keys, key, conditionValues(raw), deny) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func conditionHolds(op string, keys map[string]string, key string, values []string, deny bool) bool {
+	actual, present := lookupConditionKey(keys, key)
+
+	if op == "Null" {
+		// Null: true means the key must be absent, false that it must be present
+		for _, v := range values {
+			if strings.EqualFold(v, "true") == !present {
+				return true
+			}
+		}
+		return false
+	}
+
+	base, ifExists := strings.CutSuffix(op, "IfExists")
+	if !present {
+		return ifExists
+	}
+
+	if actual == UnknownContentLength && strings.HasPrefix(base, "Numeric") {
+		return deny
+	}
+
+	negated := false
+	switch base {
+	case "StringNotEquals", "StringNotEqualsIgnoreCase", "StringNotLike",
+		"NumericNotEquals", "DateNotEquals", "NotIpAddress":
+		negated = true
+	}
+
+	for _, expected := range values {
+		if compareCondition(base, actual, expected) {
+			return !negated
+		}
+	}
+	return negated
+}
+
+// lookupConditionKey matches condition keys case-insensitively, as IAM does
+func lookupConditionKey(keys map[string]string, key string) (string, bool) {
+	if v, ok := keys[key]; ok {
+		return v, true
+	}
+	for k, v := range keys {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func compareCondition(op, actual, expected string) bool {
+	switch op {
+	case "StringEquals", "StringNotEquals":
+		return actual == expected
+	case "StringEqualsIgnoreCase", "StringNotEqualsIgnoreCase":
+		return strings.EqualFold(actual, expected)
+	case "StringLike", "StringNotLike":
+		return wildcardMatch(expected, actual)
+	case "Bool":
+		a, err1 := strconv.ParseBool(actual)
+		e, err2 := strconv.ParseBool(expected)
+		return err1 == nil && err2 == nil && a == e
+	case "IpAddress", "NotIpAddress":
+		return ipMatches(actual, expected)
+	}
+
+	if strings.HasPrefix(op, "Numeric") {
+		a, err1 := strconv.ParseFloat(actual, 64)
+		e, err2 := strconv.ParseFloat(expected, 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		return compareOrdered(strings.TrimPrefix(op, "Numeric"), a-e)
+	}
+
+	if strings.HasPrefix(op, "Date") {
+		a, err1 := parseConditionTime(actual)
+		e, err2 := parseConditionTime(expected)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		return compareOrdered(strings.TrimPrefix(op, "Date"), float64(a.Sub(e)))
+	}
+
+	return false
+}
+
+// compareOrdered applies a Numeric*/Date* comparison suffix to diff = actual - expected
+func compareOrdered(suffix string, diff float64) bool {
+	switch suffix {
+	case "Equals", "NotEquals":
+		return diff == 0
+	case "LessThan":
+		return diff < 0
+	case "LessThanEquals":
+		return diff <= 0
+	case "GreaterThan":
+		return diff > 0
+	case "GreaterThanEquals":
+		return diff >= 0
+	}
+	return false
+}
+
+func ipMatches(actual, expected string) bool {
+	ip := net.ParseIP(actual)
+	if ip == nil {
+		return false
+	}
+	if !strings.Contains(expected, "/") {
+		return ip.Equal(net.ParseIP(expected))
+	}
+	_, network, err := net.ParseCIDR(expected)
+	return err == nil && network.Contains(ip)
+}
+
+// parseConditionTime accepts RFC 3339 timestamps, plain dates and epoch seconds
+func parseConditionTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// conditionValues flattens a JSON condition value (string, number, bool or
+// a list of them) into strings
+func conditionValues(raw interface{}) []string {
+	switch v := raw.(type) {
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, conditionValues(item)...)
+		}
+		return values
+	case string:
+		return []string{v}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case bool:
+		return []string{strconv.FormatBool(v)}
+	case nil:
+		return nil
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"s3/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+const policyEnforcerKey = "policyEnforcer"
+
+// PolicyStore resolves the bucket (and object key) a request targets
+type PolicyStore interface {
+	// ResolveBucket finds a bucket by id or name; it returns nil, nil when
+	// no such bucket exists.
+	ResolveBucket(ctx context.Context, bucketRef string) (*domain.Bucket, error)
+	// ResolveFileKey returns the object key of a file within a bucket; it
+	// returns "", nil when the bucket holds no such file.
+	ResolveFileKey(ctx context.Context, bucketRef, fileID string) (string, error)
+	// ActorRoles lists the roles ("role:<name>" principals) an actor holds
+	ActorRoles(ctx context.Context, actor string) []string
+}
+
+// PolicyDeniedError is returned by PolicyEnforcer.Check with the reason the
+// request was refused.
+type PolicyDeniedError struct {
+	Reason string
+}
+
+func (e *PolicyDeniedError) Error() string {
+	return "access denied: " + e.Reason
+}
+
+// PolicyEnforcer evaluates bucket policies for the actor set by the auth
+// middlewares. Admins bypass policies; bucket owners are allowed unless a
+// statement explicitly denies them.
+type PolicyEnforcer struct {
+	store   PolicyStore
+	isAdmin func(actor string) bool
+}
+
+func NewPolicyEnforcer(store PolicyStore, isAdmin func(actor string) bool) *PolicyEnforcer {
+	return &PolicyEnforcer{store: store, isAdmin: isAdmin}
+}
+
+// Check decides whether actor may perform action on key in bucketRef;
+// conditionKeys feed the policy's Condition blocks (see
+// RequestConditionKeys). A bucket that does not exist is not denied, so
+// handlers can answer 404.
+func (e *PolicyEnforcer) Check(ctx context.Context, actor, bucketRef, key string, action domain.Action, conditionKeys map[string]string) error {
+	if actor == "" {
+		actor = "public"
+	}
+	if e.isAdmin(actor) {
+		return nil
+	}
+	if bucketRef == "" {
+		return &PolicyDeniedError{Reason: "request does not name a bucket"}
+	}
+
+	bucket, err := e.store.ResolveBucket(ctx, bucketRef)
+	if err != nil {
+		return fmt.Errorf("failed to resolve bucket for policy check: %w", err)
+	}
+	if bucket == nil {
+		return nil
+	}
+
+	if action.IsObjectAction() && key == "" {
+		key = "*"
+	}
+	resource := domain.PolicyResource(bucket.ID, key)
+
+	result := EvaluateRequest(bucket.Policy, PolicyRequest{
+		Principal: actor,
+		Roles:     e.store.ActorRoles(ctx, actor),
+		Action:    action,
+		Resource:  resource,
+		Context:   conditionKeys,
+	})
+	switch {
+	case result.ExplicitDeny:
+		return &PolicyDeniedError{Reason: fmt.Sprintf("%s on %s is explicitly denied by statement %d of the bucket policy",
+			action, resource, result.Statement)}
+	case actor == "user:"+bucket.OwnerID, result.Decision == DecisionAllow:
+		return nil
+	case bucket.Policy == nil:
+		return &PolicyDeniedError{Reason: fmt.Sprintf("%s is not the bucket owner and the bucket has no policy", actor)}
+	default:
+		return &PolicyDeniedError{Reason: fmt.Sprintf("no bucket policy statement allows %s to perform %s on %s",
+			actor, action, resource)}
+	}
+}
+
+// Attach makes the enforcer available to AuthorizePolicy for routes whose
+// targets are only known once the handler has read the request body.
+func (e *PolicyEnforcer) Attach() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(policyEnforcerKey, e)
+		c.Next()
+	}
+}
+
+// Require authorizes the bucket named by the :bucketId route parameter (or
+// the bucket_id query parameter) for every given action. The object key is
+// taken from :fileId when the route has one; a file the bucket does not hold
+// is answered with 404 rather than checked against the whole bucket.
+func (e *PolicyEnforcer) Require(actions ...domain.Action) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(policyEnforcerKey, e)
+
+		bucketRef := c.Param("bucketId")
+		if bucketRef == "" {
+			bucketRef = c.Query("bucket_id")
+		}
+		key := ""
+		if fileID := c.Param("fileId"); fileID != "" {
+			var err error
+			key, err = e.store.ResolveFileKey(c.Request.Context(), bucketRef, fileID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to resolve file for policy check: %v", err)})
+				return
+			}
+			if key == "" {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "file not found"})
+				return
+			}
+		}
+
+		if !AuthorizePolicy(c, bucketRef, key, actions...) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuthorizePolicy checks actions on bucketRef/key for the request's actor
+// using the enforcer attached to the route. When denied it aborts with 403
+// and the reason, and returns false.
+func AuthorizePolicy(c *gin.Context, bucketRef, key string, actions ...domain.Action) bool {
+	for _, action := range actions {
+		if err := checkPolicy(c, bucketRef, key, action); err != nil {
+			if denied, ok := err.(*PolicyDeniedError); ok {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "access denied", "reason": denied.Reason})
+			} else {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// PolicyAllows is AuthorizePolicy without writing a response, for filtering
+// results by what the actor may see.
+func PolicyAllows(c *gin.Context, bucketRef, key string, action domain.Action) bool {
+	return checkPolicy(c, bucketRef, key, action) == nil
+}
+
+func checkPolicy(c *gin.Context, bucketRef, key string, action domain.Action) error {
+	value, ok := c.Get(policyEnforcerKey)
+	if !ok {
+		return fmt.Errorf("no policy enforcer attached to route")
+	}
+	return value.(*PolicyEnforcer).Check(c.Request.Context(), c.GetString("actor"), bucketRef, key, action,
+		RequestConditionKeys(c))
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"s3/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakePolicyStore struct {
+	buckets map[string]*domain.Bucket
+	files   map[string]string // fileID -> key, all in bucket "b1"
+	err     error
+	fileErr error
+	roles   []string
+}
+
+func (s *fakePolicyStore) ResolveBucket(_ context.Context, bucketRef string) (*domain.Bucket, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.buckets[bucketRef], nil
+}
+
+func (s *fakePolicyStore) ResolveFileKey(_ context.Context, bucketRef, fileID string) (string, error) {
+	if s.fileErr != nil {
+		return "", s.fileErr
+	}
+	if bucketRef != "b1" {
+		return "", nil
+	}
+	return s.files[fileID], nil
+}
+
+func (s *fakePolicyStore) ActorRoles(context.Context, string) []string {
+	return s.roles
+}
+
+func TestPolicyEnforcerCheck(t *testing.T) {
+	ownerOnly := &domain.Bucket{ID: "b1", OwnerID: "alice"}
+	withPolicy := &domain.Bucket{ID: "b2", OwnerID: "alice", Policy: &domain.Policy{Statement: []domain.Statement{
+		{Effect: domain.EffectAllow, Principal: []domain.Principal{"public"}, Action: []domain.Action{domain.ActionGetObject}, Resource: []string{"arn:mys3:::b2/*"}},
+		{Effect: domain.EffectDeny, Principal: []domain.Principal{"user:alice"}, Action: []domain.Action{domain.ActionDeleteObject}, Resource: []string{"arn:mys3:::b2/*"}},
+	}}}
+	store := &fakePolicyStore{buckets: map[string]*domain.Bucket{"b1": ownerOnly, "b2": withPolicy}}
+	enforcer := NewPolicyEnforcer(store, func(actor string) bool { return actor == "user:admin" })
+
+	tests := []struct {
+		name       string
+		actor      string
+		bucket     string
+		key        string
+		action     domain.Action
+		wantDenied bool
+	}{
+		{"owner without policy", "user:alice", "b1", "a.txt", domain.ActionPutObject, false},
+		{"other user without policy", "user:bob", "b1", "a.txt", domain.ActionGetObject, true},
+		{"admin", "user:admin", "b1", "a.txt", domain.ActionDeleteObject, false},
+		{"public allowed by policy", "", "b2", "a.txt", domain.ActionGetObject, false},
+		{"public not allowed by policy", "", "b2", "a.txt", domain.ActionPutObject, true},
+		{"owner explicitly denied", "user:alice", "b2", "a.txt", domain.ActionDeleteObject, true},
+		{"no bucket named", "user:alice", "", "", domain.ActionListBucket, true},
+		{"missing bucket is left to the handler", "user:bob", "b9", "", domain.ActionListBucket, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := enforcer.Check(context.Background(), tt.actor, tt.bucket, tt.key, tt.action, nil)
+			var denied *PolicyDeniedError
+			if got := errors.As(err, &denied); got != tt.wantDenied {
+				t.Errorf("Check() = %v, want denied %v", err, tt.wantDenied)
+			}
+		})
+	}
+}
+
+func TestPolicyEnforcerCheckStoreError(t *testing.T) {
+	store := &fakePolicyStore{err: errors.New("connection refused")}
+	enforcer := NewPolicyEnforcer(store, func(string) bool { return false })
+
+	err := enforcer.Check(context.Background(), "user:alice", "b1", "", domain.ActionListBucket, nil)
+	var denied *PolicyDeniedError
+	if err == nil || errors.As(err, &denied) {
+		t.Errorf("Check() = %v, want a lookup error", err)
+	}
+}
+
+func TestPolicyEnforcerRequire(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bucket := &domain.Bucket{ID: "b1", OwnerID: "alice", Policy: &domain.Policy{Statement: []domain.Statement{
+		{Effect: domain.EffectAllow, Principal: []domain.Principal{"user:bob"}, Action: []domain.Action{domain.ActionGetObject}, Resource: []string{"arn:mys3:::b1/public/*"}},
+	}}}
+
+	tests := []struct {
+		name       string
+		actor      string
+		path       string
+		store      *fakePolicyStore
+		wantStatus int
+	}{
+		{"allowed key", "user:bob", "/buckets/b1/files/f1",
+			&fakePolicyStore{buckets: map[string]*domain.Bucket{"b1": bucket}, files: map[string]string{"f1": "public/a.txt"}}, http.StatusOK},
+		{"denied key", "user:bob", "/buckets/b1/files/f2",
+			&fakePolicyStore{buckets: map[string]*domain.Bucket{"b1": bucket}, files: map[string]string{"f2": "private/a.txt"}}, http.StatusForbidden},
+		{"file in another bucket", "user:bob", "/buckets/b2/files/f1",
+			&fakePolicyStore{buckets: map[string]*domain.Bucket{"b1": bucket}, files: map[string]string{"f1": "public/a.txt"}}, http.StatusNotFound},
+		{"unknown file", "user:bob", "/buckets/b1/files/f9",
+			&fakePolicyStore{buckets: map[string]*domain.Bucket{"b1": bucket}}, http.StatusNotFound},
+		{"file lookup error", "user:bob", "/buckets/b1/files/f1",
+			&fakePolicyStore{buckets: map[string]*domain.Bucket{"b1": bucket}, fileErr: errors.New("connection refused")}, http.StatusInternalServerError},
+		{"bucket lookup error", "user:bob", "/buckets/b1",
+			&fakePolicyStore{err: errors.New("connection refused")}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enforcer := NewPolicyEnforcer(tt.store, func(string) bool { return false })
+			router := gin.New()
+			setActor := func(c *gin.Context) { c.Set("actor", tt.actor) }
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			router.GET("/buckets/:bucketId", setActor, enforcer.Require(domain.ActionListBucket), ok)
+			router.GET("/buckets/:bucketId/files/:fileId", setActor, enforcer.Require(domain.ActionGetObject), ok)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequestConditionKeysSecureTransport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	forwardedProto, err := ForwardedProtoMiddleware([]string{"10.0.0.0/8", "192.168.1.5"})
+	if err != nil {
+		t.Fatalf("ForwardedProtoMiddleware: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		proto      string
+		want       string
+	}{
+		{"trusted proxy over https", "10.1.2.3:5000", "https", "true"},
+		{"trusted proxy ip over https", "192.168.1.5:5000", "https", "true"},
+		{"trusted proxy over http", "10.1.2.3:5000", "http", "false"},
+		{"untrusted client claims https", "203.0.113.7:5000", "https", "false"},
+		{"no header", "203.0.113.7:5000", "", "false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(forwardedProto)
+			var got map[string]string
+			router.GET("/", func(c *gin.Context) { got = RequestConditionKeys(c) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got[ConditionSecureTransport] != tt.want {
+				t.Errorf("%s = %s, want %s", ConditionSecureTransport, got[ConditionSecureTransport], tt.want)
+			}
+		})
+	}
+
+	if _, err := ForwardedProtoMiddleware([]string{"not-an-ip"}); err == nil {
+		t.Error("ForwardedProtoMiddleware accepted an invalid proxy")
+	}
+}
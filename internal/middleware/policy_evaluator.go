@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	"path"
 	"s3/internal/domain"
 	"strings"
 )
@@ -13,56 +12,114 @@ const (
 	DecisionAllow
 )
 
+func (d Decision) String() string {
+	if d == DecisionAllow {
+		return "Allow"
+	}
+	return "Deny"
+}
+
+// PolicyRequest is what a policy is evaluated against
+type PolicyRequest struct {
+	// Principal is the actor, e.g. "user:<id>" or "public"
+	Principal string
+	// Roles the principal holds, without the "role:" prefix
+	Roles    []string
+	Action   domain.Action
+	Resource string
+	// Context holds the condition keys of the request, e.g. "aws:SourceIp"
+	Context map[string]string
+}
+
+// PolicyResult explains an evaluation: the decision, whether a Deny
+// statement produced it, and the index of the deciding statement (-1 when no
+// statement matched).
+type PolicyResult struct {
+	Decision     Decision
+	ExplicitDeny bool
+	Statement    int
+}
+
 func EvaluatePolicy(policy *domain.Policy, principal string, action domain.Action, resource string) Decision {
+	return ExplainPolicy(policy, principal, action, resource).Decision
+}
+
+// ExplainPolicy evaluates a request that carries no roles or condition keys
+func ExplainPolicy(policy *domain.Policy, principal string, action domain.Action, resource string) PolicyResult {
+	return EvaluateRequest(policy, PolicyRequest{Principal: principal, Action: action, Resource: resource})
+}
+
+// EvaluateRequest follows IAM evaluation logic: every statement is
+// considered, an explicit Deny anywhere wins over any Allow, and no matching
+// statement is an implicit deny.
+func EvaluateRequest(policy *domain.Policy, req PolicyRequest) PolicyResult {
+	result := PolicyResult{Decision: DecisionDeny, Statement: -1}
 	if policy == nil {
-		return DecisionDeny
+		return result
 	}
-	// iterate statements; Deny wins immediately
-	for _, stmt := range policy.Statement {
-		// principal match?
-		if !principalMatches(stmt.Principal, principal) {
+	for i, stmt := range policy.Statement {
+		if !statementMatches(stmt, req) {
 			continue
 		}
-		// action match?
-		if !actionMatches(stmt.Action, action) {
-			continue
+		if stmt.Effect == domain.EffectDeny {
+			return PolicyResult{Decision: DecisionDeny, ExplicitDeny: true, Statement: i}
 		}
-		// resource match?
-		if !resourceMatches(stmt.Resource, resource) {
-			continue
+		if stmt.Effect == domain.EffectAllow && result.Decision != DecisionAllow {
+			result = PolicyResult{Decision: DecisionAllow, Statement: i}
 		}
-		// evaluation
-		if stmt.Effect == domain.EffectDeny {
-			return DecisionDeny
+	}
+	return result
+}
+
+func statementMatches(stmt domain.Statement, req PolicyRequest) bool {
+	if !principalMatches(stmt.Principal, req.Principal, req.Roles) {
+		return false
+	}
+
+	if len(stmt.NotAction) > 0 {
+		if actionMatches(stmt.NotAction, req.Action) {
+			return false
 		}
-		if stmt.Effect == domain.EffectAllow {
-			// allow, but continue to check for explicit denies above (we checked denies first by order)
-			return DecisionAllow
+	} else if !actionMatches(stmt.Action, req.Action) {
+		return false
+	}
+
+	if len(stmt.NotResource) > 0 {
+		if resourceMatches(stmt.NotResource, req.Resource) {
+			return false
 		}
+	} else if !resourceMatches(stmt.Resource, req.Resource) {
+		return false
 	}
-	return DecisionDeny
+
+	return conditionsMatch(stmt.Condition, req.Context, stmt.Effect == domain.EffectDeny)
 }
 
-func principalMatches(list []domain.Principal, principal string) bool {
+func principalMatches(list []domain.Principal, principal string, roles []string) bool {
 	for _, p := range list {
-		if string(p) == "public" && principal == "public" {
+		// "public" / "*" covers everyone, signed in or not
+		if string(p) == "public" || string(p) == "*" {
 			return true
 		}
-		if strings.HasPrefix(string(p), "user:") && string(p) == principal {
+		if wildcardMatch(string(p), principal) {
 			return true
 		}
-		if strings.HasPrefix(principal, "user:") && string(p) == principal {
-			// exact match
-			return true
+		if role, ok := strings.CutPrefix(string(p), "role:"); ok {
+			for _, r := range roles {
+				if wildcardMatch(role, r) {
+					return true
+				}
+			}
 		}
-		// add role matching here if needed: "role:admin"
 	}
 	return false
 }
 
+// actionMatches compares actions case-insensitively, with wildcards such as
+// "s3:*" or "s3:Get*"
 func actionMatches(actions []domain.Action, action domain.Action) bool {
 	for _, a := range actions {
-		if a == domain.ActionAll || a == action {
+		if wildcardMatch(strings.ToLower(string(a)), strings.ToLower(string(action))) {
 			return true
 		}
 	}
@@ -71,17 +128,36 @@ func actionMatches(actions []domain.Action, action domain.Action) bool {
 
 func resourceMatches(patterns []string, resource string) bool {
 	for _, pat := range patterns {
-		// basic path.Match support - convert ARN-like wildcard to match syntax
-		// replace '*' with '*' and keep it simple for now
-		ok, _ := path.Match(pat, resource)
-		if ok {
-			return true
-		}
-		// also match simple prefix style (e.g., "arn:mys3:::bucketId/*")
-		if strings.HasSuffix(pat, "/*") && strings.HasPrefix(resource, strings.TrimSuffix(pat, "*")) {
+		if wildcardMatch(pat, resource) {
 			return true
 		}
 	}
 	return false
 }
 
+// wildcardMatch matches IAM-style patterns: '*' matches any run of
+// characters (including '/') and '?' matches exactly one.
+func wildcardMatch(pattern, s string) bool {
+	p, i := 0, 0
+	star, mark := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			p++
+			i++
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case star >= 0:
+			p = star + 1
+			mark++
+			i = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
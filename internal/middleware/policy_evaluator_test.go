@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"encoding/json"
+	"testing"
+
+	"s3/internal/domain"
+)
+
+func parsePolicy(t *testing.T, document string) *domain.Policy {
+	t.Helper()
+	var policy domain.Policy
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		t.Fatalf("invalid policy: %v", err)
+	}
+	return &policy
+}
+
+func TestEvaluateRequest(t *testing.T) {
+	policy := `{
+		"Version": "2012-10-17",
+		"Statement": [
+			{"Effect": "Allow", "Principal": ["user:*"], "Action": ["s3:Get*", "s3:ListBucket"], "Resource": ["arn:mys3:::b1", "arn:mys3:::b1/*"]},
+			{"Effect": "Deny", "Principal": ["*"], "Action": ["s3:*"], "Resource": ["arn:mys3:::b1/private/*"]},
+			{"Effect": "Allow", "Principal": ["user:alice"], "Action": ["s3:PutObject"], "Resource": ["arn:mys3:::b1/uploads/????.txt"]},
+			{"Effect": "Allow", "Principal": ["role:auditor"], "NotAction": ["s3:DeleteObject"], "Resource": ["arn:mys3:::b1/*"]},
+			{"Effect": "Deny", "Principal": ["user:bob"], "Action": ["s3:GetObject"], "NotResource": ["arn:mys3:::b1/shared/*"]}
+		]
+	}`
+
+	tests := []struct {
+		name         string
+		req          PolicyRequest
+		want         Decision
+		explicitDeny bool
+		statement    int
+	}{
+		{"wildcard principal and action", PolicyRequest{Principal: "user:carol", Action: domain.ActionGetObject, Resource: "arn:mys3:::b1/a.txt"}, DecisionAllow, false, 0},
+		{"action is case-insensitive", PolicyRequest{Principal: "user:carol", Action: "S3:GETOBJECT", Resource: "arn:mys3:::b1/a.txt"}, DecisionAllow, false, 0},
+		{"bucket resource", PolicyRequest{Principal: "user:carol", Action: domain.ActionListBucket, Resource: "arn:mys3:::b1"}, DecisionAllow, false, 0},
+		{"unmatched action is an implicit deny", PolicyRequest{Principal: "user:carol", Action: domain.ActionPutObject, Resource: "arn:mys3:::b1/a.txt"}, DecisionDeny, false, -1},
+		{"unmatched principal is an implicit deny", PolicyRequest{Principal: "public", Action: domain.ActionGetObject, Resource: "arn:mys3:::b1/a.txt"}, DecisionDeny, false, -1},
+		{"other bucket is an implicit deny", PolicyRequest{Principal: "user:carol", Action: domain.ActionGetObject, Resource: "arn:mys3:::b2/a.txt"}, DecisionDeny, false, -1},
+		{"deny wins over an earlier allow", PolicyRequest{Principal: "user:carol", Action: domain.ActionGetObject, Resource: "arn:mys3:::b1/private/a.txt"}, DecisionDeny, true, 1},
+		{"deny covers nested keys", PolicyRequest{Principal: "user:alice", Action: domain.ActionPutObject, Resource: "arn:mys3:::b1/private/x/y"}, DecisionDeny, true, 1},
+		{"question mark matches one character", PolicyRequest{Principal: "user:alice", Action: domain.ActionPutObject, Resource: "arn:mys3:::b1/uploads/abcd.txt"}, DecisionAllow, false, 2},
+		{"question mark does not match two", PolicyRequest{Principal: "user:alice", Action: domain.ActionPutObject, Resource: "arn:mys3:::b1/uploads/abcde.txt"}, DecisionDeny, false, -1},
+		{"role principal with NotAction", PolicyRequest{Principal: "user:dave", Roles: []string{"auditor"}, Action: domain.ActionPutObject, Resource: "arn:mys3:::b1/a.txt"}, DecisionAllow, false, 3},
+		{"NotAction excludes the action", PolicyRequest{Principal: "user:dave", Roles: []string{"auditor"}, Action: domain.ActionDeleteObject, Resource: "arn:mys3:::b1/a.txt"}, DecisionDeny, false, -1},
+		{"NotResource deny outside the resource", PolicyRequest{Principal: "user:bob", Action: domain.ActionGetObject, Resource: "arn:mys3:::b1/a.txt"}, DecisionDeny, true, 4},
+		{"NotResource deny skips the resource", PolicyRequest{Principal: "user:bob", Action: domain.ActionGetObject, Resource: "arn:mys3:::b1/shared/a.txt"}, DecisionAllow, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EvaluateRequest(parsePolicy(t, policy), tt.req)
+			if got.Decision != tt.want || got.ExplicitDeny != tt.explicitDeny || got.Statement != tt.statement {
+				t.Errorf("EvaluateRequest() = %+v, want {Decision:%v ExplicitDeny:%v Statement:%d}", got, tt.want, tt.explicitDeny, tt.statement)
+			}
+		})
+	}
+}
+
+func TestEvaluateRequestNilPolicy(t *testing.T) {
+	got := EvaluateRequest(nil, PolicyRequest{Principal: "user:alice", Action: domain.ActionGetObject, Resource: "arn:mys3:::b1/a"})
+	if got.Decision != DecisionDeny || got.ExplicitDeny || got.Statement != -1 {
+		t.Errorf("EvaluateRequest(nil) = %+v, want an implicit deny", got)
+	}
+}
+
+func TestEvaluateRequestConditions(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition string
+		context   map[string]string
+		want      Decision
+	}{
+		{"IpAddress in range", `{"IpAddress": {"aws:SourceIp": ["10.0.0.0/8"]}}`, map[string]string{ConditionSourceIP: "10.1.2.3"}, DecisionAllow},
+		{"IpAddress out of range", `{"IpAddress": {"aws:SourceIp": ["10.0.0.0/8"]}}`, map[string]string{ConditionSourceIP: "192.168.1.1"}, DecisionDeny},
+		{"IpAddress single address", `{"IpAddress": {"aws:SourceIp": "192.168.1.1"}}`, map[string]string{ConditionSourceIP: "192.168.1.1"}, DecisionAllow},
+		{"NotIpAddress", `{"NotIpAddress": {"aws:SourceIp": ["10.0.0.0/8"]}}`, map[string]string{ConditionSourceIP: "10.1.2.3"}, DecisionDeny},
+		{"missing key fails", `{"IpAddress": {"aws:SourceIp": ["10.0.0.0/8"]}}`, map[string]string{}, DecisionDeny},
+		{"IfExists passes when missing", `{"StringEqualsIfExists": {"aws:Referer": "https://example.com"}}`, map[string]string{}, DecisionAllow},
+		{"IfExists checks when present", `{"StringEqualsIfExists": {"aws:Referer": "https://example.com"}}`, map[string]string{ConditionReferer: "https://evil.example"}, DecisionDeny},
+		{"Bool", `{"Bool": {"aws:SecureTransport": true}}`, map[string]string{ConditionSecureTransport: "true"}, DecisionAllow},
+		{"Bool mismatch", `{"Bool": {"aws:SecureTransport": "true"}}`, map[string]string{ConditionSecureTransport: "false"}, DecisionDeny},
+		{"StringLike", `{"StringLike": {"s3:prefix": ["home/*"]}}`, map[string]string{ConditionPrefix: "home/alice/"}, DecisionAllow},
+		{"StringNotLike", `{"StringNotLike": {"s3:prefix": ["home/*"]}}`, map[string]string{ConditionPrefix: "home/alice/"}, DecisionDeny},
+		{"StringEqualsIgnoreCase", `{"StringEqualsIgnoreCase": {"aws:UserAgent": "CURL"}}`, map[string]string{ConditionUserAgent: "curl"}, DecisionAllow},
+		{"condition keys are case-insensitive", `{"StringEquals": {"AWS:USERID": "user:alice"}}`, map[string]string{ConditionUserID: "user:alice"}, DecisionAllow},
+		{"any listed value matches", `{"StringEquals": {"aws:userid": ["user:bob", "user:alice"]}}`, map[string]string{ConditionUserID: "user:alice"}, DecisionAllow},
+		{"NumericLessThanEquals", `{"NumericLessThanEquals": {"s3:content-length": 1024}}`, map[string]string{ConditionContentLength: "1024"}, DecisionAllow},
+		{"NumericLessThan", `{"NumericLessThan": {"s3:content-length": 1024}}`, map[string]string{ConditionContentLength: "1024"}, DecisionDeny},
+		{"unknown size fails a size limit", `{"NumericLessThanEquals": {"s3:content-length": 1024}}`, map[string]string{ConditionContentLength: UnknownContentLength}, DecisionDeny},
+		{"unknown size fails IfExists", `{"NumericLessThanEqualsIfExists": {"s3:content-length": 1024}}`, map[string]string{ConditionContentLength: UnknownContentLength}, DecisionDeny},
+		{"DateLessThan", `{"DateLessThan": {"aws:CurrentTime": "2030-01-01T00:00:00Z"}}`, map[string]string{ConditionCurrentTime: "2026-10-17T12:00:00Z"}, DecisionAllow},
+		{"DateGreaterThan", `{"DateGreaterThan": {"aws:CurrentTime": "2030-01-01"}}`, map[string]string{ConditionCurrentTime: "2026-10-17T12:00:00Z"}, DecisionDeny},
+		{"Null true requires absence", `{"Null": {"aws:Referer": "true"}}`, map[string]string{}, DecisionAllow},
+		{"Null false requires presence", `{"Null": {"aws:Referer": "false"}}`, map[string]string{}, DecisionDeny},
+		{"every operator must hold", `{"IpAddress": {"aws:SourceIp": "10.0.0.0/8"}, "Bool": {"aws:SecureTransport": "true"}}`, map[string]string{ConditionSourceIP: "10.0.0.1", ConditionSecureTransport: "false"}, DecisionDeny},
+		{"unknown operator fails", `{"StringSounds": {"aws:userid": "user:alice"}}`, map[string]string{ConditionUserID: "user:alice"}, DecisionDeny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := parsePolicy(t, `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": ["*"],
+				"Action": ["s3:GetObject"], "Resource": ["arn:mys3:::b1/*"], "condition": `+tt.condition+`}]}`)
+			got := EvaluateRequest(policy, PolicyRequest{
+				Principal: "user:alice",
+				Action:    domain.ActionGetObject,
+				Resource:  "arn:mys3:::b1/a.txt",
+				Context:   tt.context,
+			})
+			if got.Decision != tt.want {
+				t.Errorf("EvaluateRequest() = %v, want %v", got.Decision, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateRequestConditionalDeny(t *testing.T) {
+	policy := parsePolicy(t, `{"Version": "2012-10-17", "Statement": [
+		{"Effect": "Allow", "Principal": ["*"], "Action": ["s3:*"], "Resource": ["arn:mys3:::b1/*"]},
+		{"Effect": "Deny", "Principal": ["*"], "Action": ["s3:*"], "Resource": ["arn:mys3:::b1/*"],
+			"condition": {"Bool": {"aws:SecureTransport": "false"}}}
+	]}`)
+
+	tests := []struct {
+		secure string
+		want   Decision
+	}{
+		{"true", DecisionAllow},
+		{"false", DecisionDeny},
+	}
+	for _, tt := range tests {
+		got := EvaluateRequest(policy, PolicyRequest{
+			Principal: "public",
+			Action:    domain.ActionGetObject,
+			Resource:  "arn:mys3:::b1/a.txt",
+			Context:   map[string]string{ConditionSecureTransport: tt.secure},
+		})
+		if got.Decision != tt.want {
+			t.Errorf("EvaluateRequest(SecureTransport=%s) = %v, want %v", tt.secure, got.Decision, tt.want)
+		}
+	}
+}
+
+func TestEvaluateRequestUnknownContentLength(t *testing.T) {
+	policy := parsePolicy(t, `{"Version": "2012-10-17", "Statement": [
+		{"Effect": "Allow", "Principal": ["*"], "Action": ["s3:*"], "Resource": ["arn:mys3:::b1/*"]},
+		{"Effect": "Deny", "Principal": ["*"], "Action": ["s3:PutObject"], "Resource": ["arn:mys3:::b1/*"],
+			"condition": {"NumericGreaterThan": {"s3:content-length": 1048576}}}
+	]}`)
+
+	tests := []struct {
+		length string
+		want   Decision
+	}{
+		{"1024", DecisionAllow},
+		{"2097152", DecisionDeny},
+		{UnknownContentLength, DecisionDeny},
+	}
+	for _, tt := range tests {
+		got := EvaluateRequest(policy, PolicyRequest{
+			Principal: "public",
+			Action:    domain.ActionPutObject,
+			Resource:  "arn:mys3:::b1/a.txt",
+			Context:   map[string]string{ConditionContentLength: tt.length},
+		})
+		if got.Decision != tt.want {
+			t.Errorf("EvaluateRequest(content-length=%s) = %v, want %v", tt.length, got.Decision, tt.want)
+		}
+	}
+}
+
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "", true},
+		{"*", "anything/at/all", true},
+		{"a*c", "abc", true},
+		{"a*c", "a/b/c", true},
+		{"a*c", "abcd", false},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"*.txt", "dir/file.txt", true},
+		{"*.txt", "file.txt.bak", false},
+		{"a**b", "ab", true},
+		{"exact", "exact", true},
+		{"exact", "exactly", false},
+	}
+	for _, tt := range tests {
+		if got := wildcardMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("wildcardMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"s3/internal/domain"
+)
+
+// PolicySimulation is the outcome of PolicyEnforcer.Simulate. Result is what
+// the policy alone decides; Bypass names the rule ("admin" or "owner") that
+// would still let the request through, which only happens without an
+// explicit deny for owners.
+type PolicySimulation struct {
+	Request   PolicyRequest
+	Result    PolicyResult
+	Statement *domain.Statement
+	Bypass    string
+	Effective Decision
+}
+
+// Simulate evaluates req against a bucket's policy without performing the
+// request. When policy is nil the stored bucket policy is used, so a draft
+// can be tested before it is saved. An empty Resource defaults to the bucket
+// ARN and nil Roles are looked up for the principal. It returns
+// domain.ErrNotFound when the bucket does not exist.
+func (e *PolicyEnforcer) Simulate(ctx context.Context, bucketRef string, policy *domain.Policy, req PolicyRequest) (*PolicySimulation, error) {
+	bucket, err := e.store.ResolveBucket(ctx, bucketRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve bucket: %w", err)
+	}
+	if bucket == nil {
+		return nil, fmt.Errorf("%w: bucket %s", domain.ErrNotFound, bucketRef)
+	}
+	if policy == nil {
+		policy = bucket.Policy
+	}
+
+	if req.Principal == "" {
+		req.Principal = "public"
+	}
+	if req.Resource == "" {
+		req.Resource = domain.PolicyResource(bucket.ID, "")
+	}
+	if req.Roles == nil {
+		req.Roles = e.store.ActorRoles(ctx, req.Principal)
+	}
+	if req.Context == nil {
+		req.Context = map[string]string{}
+	}
+
+	sim := &PolicySimulation{Request: req, Result: EvaluateRequest(policy, req)}
+	sim.Effective = sim.Result.Decision
+	if sim.Result.Statement >= 0 {
+		stmt := policy.Statement[sim.Result.Statement]
+		sim.Statement = &stmt
+	}
+
+	// mirror Check: admins always pass, owners unless explicitly denied
+	switch {
+	case e.isAdmin(req.Principal):
+		sim.Bypass, sim.Effective = "admin", DecisionAllow
+	case req.Principal == "user:"+bucket.OwnerID && !sim.Result.ExplicitDeny && sim.Result.Decision == DecisionDeny:
+		sim.Bypass, sim.Effective = "owner", DecisionAllow
+	}
+
+	return sim, nil
+}
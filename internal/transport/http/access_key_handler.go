@@ -0,0 +1,95 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"s3/internal/application"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccessKeyHandler struct {
+	accessKeyService *application.AccessKeyService
+}
+
+func NewAccessKeyHandler(accessKeyService *application.AccessKeyService) *AccessKeyHandler {
+	return &AccessKeyHandler{accessKeyService: accessKeyService}
+}
+
+func (h *AccessKeyHandler) CreateAccessKey(c *gin.Context) {
+	output, err := h.accessKeyService.CreateAccessKey(c.Request.Context(), actorUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, output)
+}
+
+func (h *AccessKeyHandler) ListAccessKeys(c *gin.Context) {
+	output, err := h.accessKeyService.ListAccessKeys(c.Request.Context(), actorUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *AccessKeyHandler) EnableAccessKey(c *gin.Context) {
+	h.setStatus(c, true)
+}
+
+func (h *AccessKeyHandler) DisableAccessKey(c *gin.Context) {
+	h.setStatus(c, false)
+}
+
+func (h *AccessKeyHandler) setStatus(c *gin.Context, active bool) {
+	accessKeyId := c.Param("accessKeyId")
+
+	err := h.accessKeyService.SetAccessKeyStatus(c.Request.Context(), actorUserID(c), accessKeyId, active)
+	if err != nil {
+		c.JSON(accessKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "access key updated successfully"})
+}
+
+func (h *AccessKeyHandler) RotateAccessKey(c *gin.Context) {
+	accessKeyId := c.Param("accessKeyId")
+
+	output, err := h.accessKeyService.RotateAccessKey(c.Request.Context(), actorUserID(c), accessKeyId)
+	if err != nil {
+		c.JSON(accessKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *AccessKeyHandler) DeleteAccessKey(c *gin.Context) {
+	accessKeyId := c.Param("accessKeyId")
+
+	err := h.accessKeyService.DeleteAccessKey(c.Request.Context(), actorUserID(c), accessKeyId)
+	if err != nil {
+		c.JSON(accessKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "access key deleted successfully"})
+}
+
+func accessKeyErrorStatus(err error) int {
+	if errors.Is(err, application.ErrAccessKeyNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// actorUserID returns the user id the auth middleware stored as "user:<id>"
+func actorUserID(c *gin.Context) string {
+	return strings.TrimPrefix(c.GetString("actor"), "user:")
+}
@@ -3,7 +3,9 @@ package http
 import (
 	"net/http"
 	"s3/internal/application"
+	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
+	"s3/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,6 +29,12 @@ func (h *BatchHandler) BatchUpload(c *gin.Context) {
 		return
 	}
 
+	for _, file := range input.Files {
+		if !middleware.AuthorizePolicy(c, input.BucketID, file.Key, domain.ActionPutObject) {
+			return
+		}
+	}
+
 	output, err := h.batchService.BatchUpload(c.Request.Context(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -45,6 +53,12 @@ func (h *BatchHandler) BatchDelete(c *gin.Context) {
 		return
 	}
 
+	for _, key := range input.Keys {
+		if !middleware.AuthorizePolicy(c, input.BucketID, key, domain.ActionDeleteObject) {
+			return
+		}
+	}
+
 	output, err := h.batchService.BatchDelete(c.Request.Context(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -63,6 +77,13 @@ func (h *BatchHandler) BatchCopy(c *gin.Context) {
 		return
 	}
 
+	for _, item := range input.Items {
+		if !middleware.AuthorizePolicy(c, item.SourceBucket, item.SourceKey, domain.ActionGetObject) ||
+			!middleware.AuthorizePolicy(c, item.DestBucket, item.DestKey, domain.ActionPutObject) {
+			return
+		}
+	}
+
 	output, err := h.batchService.BatchCopy(c.Request.Context(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -81,6 +102,13 @@ func (h *BatchHandler) BatchMove(c *gin.Context) {
 		return
 	}
 
+	for _, item := range input.Items {
+		if !middleware.AuthorizePolicy(c, item.SourceBucket, item.SourceKey, domain.ActionGetObject, domain.ActionDeleteObject) ||
+			!middleware.AuthorizePolicy(c, item.DestBucket, item.DestKey, domain.ActionPutObject) {
+			return
+		}
+	}
+
 	output, err := h.batchService.BatchMove(c.Request.Context(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -99,6 +127,12 @@ func (h *BatchHandler) BatchUpdateMetadata(c *gin.Context) {
 		return
 	}
 
+	for _, update := range input.Updates {
+		if !middleware.AuthorizePolicy(c, input.BucketID, update.Key, domain.ActionPutObject) {
+			return
+		}
+	}
+
 	output, err := h.batchService.BatchUpdateMetadata(c.Request.Context(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
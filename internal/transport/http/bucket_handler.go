@@ -1,13 +1,19 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"s3/internal/application"
+	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 // BucketHandler handles bucket-related HTTP endpoints.
@@ -37,6 +43,10 @@ func (h *BucketHandler) CreateBucket(c *gin.Context) {
 		return
 	}
 
+	if input.OwnerId == "" {
+		input.OwnerId = actorUserID(c)
+	}
+
 	output, err := h.bucketService.CreateBucket(c.Request.Context(), input)
 	if err != nil {
 		errorString := fmt.Sprintf("bucket %s already exists", input.Name)
@@ -165,7 +175,9 @@ func (h *BucketHandler) GetBucketPolicy(c *gin.Context) {
 	c.JSON(http.StatusOK, policy)
 }
 
-// // UpdateBucketPolicy handles updating bucket policy
+// // UpdateBucketPolicy handles updating bucket policy. The body is either a
+// // full policy document ({"Version": ..., "Statement": [...]}) or the
+// // single-statement UpdatePolicyInput.
 // // PUT /:bucketId/policy
 func (h *BucketHandler) UpdateBucketPolicy(c *gin.Context) {
     actorI, ok := c.Get("actor") // set by auth middleware
@@ -176,24 +188,117 @@ func (h *BucketHandler) UpdateBucketPolicy(c *gin.Context) {
     actor := actorI.(string)
 
     bucketID := c.Param("bucketId")
-    var input dto.UpdatePolicyInput
-    if err := c.ShouldBindJSON(&input); err != nil {
+    body, err := io.ReadAll(c.Request.Body)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+        return
+    }
 
+    var fields map[string]json.RawMessage
+    if err := json.Unmarshal(body, &fields); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload: " + err.Error()})
         return
     }
 
-    if err := h.bucketService.UpdateBucketPolicy(c.Request.Context(), bucketID, input, actor); err != nil {
-       
-		
-		if strings.Contains(err.Error(), "forbidden") {
-            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    var version *domain.PolicyVersion
+    if _, ok := fields["Statement"]; ok {
+        var policy domain.Policy
+        if err := json.Unmarshal(body, &policy); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid policy document: " + err.Error()})
+            return
+        }
+        version, err = h.bucketService.PutBucketPolicy(c.Request.Context(), bucketID, &policy, actor)
+    } else {
+        var input dto.UpdatePolicyInput
+        if err := binding.JSON.BindBody(body, &input); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload: " + err.Error()})
             return
         }
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        version, err = h.bucketService.UpdateBucketPolicy(c.Request.Context(), bucketID, input, actor)
+    }
+    if err != nil {
+        c.JSON(policyErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
         return
     }
-    c.JSON(http.StatusOK, gin.H{"message": "policy updated"})
+    c.JSON(http.StatusOK, gin.H{"message": "policy updated", "version": version.Version, "updated_by": version.UpdatedBy})
+}
+
+// ListPolicyVersions lists the policy history of a bucket
+// GET /:bucketId/policy/versions
+func (h *BucketHandler) ListPolicyVersions(c *gin.Context) {
+	output, err := h.bucketService.ListPolicyVersions(c.Request.Context(), c.Param("bucketId"))
+	if err != nil {
+		c.JSON(policyErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// GetPolicyVersion returns one version of a bucket policy
+// GET /:bucketId/policy/versions/:version
+func (h *BucketHandler) GetPolicyVersion(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "version must be a positive integer"})
+		return
+	}
+
+	output, err := h.bucketService.GetPolicyVersion(c.Request.Context(), c.Param("bucketId"), version)
+	if err != nil {
+		c.JSON(policyErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// DiffPolicyVersions compares two versions of a bucket policy
+// GET /:bucketId/policy/diff?from=1&to=2
+func (h *BucketHandler) DiffPolicyVersions(c *gin.Context) {
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil || from < 1 || to < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be positive version numbers"})
+		return
+	}
+
+	output, err := h.bucketService.DiffPolicyVersions(c.Request.Context(), c.Param("bucketId"), from, to)
+	if err != nil {
+		c.JSON(policyErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// RollbackBucketPolicy restores an earlier policy version as a new version
+// POST /:bucketId/policy/rollback
+func (h *BucketHandler) RollbackBucketPolicy(c *gin.Context) {
+	var input dto.RollbackPolicyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload: " + err.Error()})
+		return
+	}
+
+	version, err := h.bucketService.RollbackBucketPolicy(c.Request.Context(), c.Param("bucketId"), input.Version, c.GetString("actor"))
+	if err != nil {
+		c.JSON(policyErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, application.PolicyVersionInfo(version))
+}
+
+// policyErrorStatus maps policy errors to a status, falling back to fallback
+func policyErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, application.ErrPolicyForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, application.ErrBucketNotFound), errors.Is(err, application.ErrPolicyVersionNotFound):
+		return http.StatusNotFound
+	}
+	return fallback
 }
 
 
@@ -234,6 +339,36 @@ func (h *BucketHandler) GetBucketVersioning(c *gin.Context) {
 
 
 
+// SetBucketEncryption turns default encryption for new objects on or off.
+// PUT /:bucketId/encryption
+func (h *BucketHandler) SetBucketEncryption(c *gin.Context) {
+	var input dto.BucketEncryptionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	output, err := h.bucketService.SetBucketEncryption(c.Request.Context(), c.Param("bucketId"), *input.Enabled)
+	if err != nil {
+		c.JSON(policyErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// GetBucketEncryption returns the bucket's default encryption.
+// GET /:bucketId/encryption
+func (h *BucketHandler) GetBucketEncryption(c *gin.Context) {
+	output, err := h.bucketService.GetBucketEncryption(c.Request.Context(), c.Param("bucketId"))
+	if err != nil {
+		c.JSON(policyErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
 // // SetBucketLifecycle handles setting lifecycle rules
 // SetBucketLifecycle handles setting lifecycle rules for a bucket.
 // PUT /:bucketId/lifecycle
@@ -247,9 +382,14 @@ func (h *BucketHandler) SetBucketLifecycle(c *gin.Context) {
     }
 
     if err := h.bucketService.SetBucketLifecycle(c.Request.Context(), bucketID, input); err != nil {
-        
-		fmt.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, application.ErrInvalidLifecycleRule):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, application.ErrBucketNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
         return
     }
 
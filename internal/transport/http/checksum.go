@@ -0,0 +1,72 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"s3/internal/application"
+	"s3/internal/infrastructure/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checksumInput reads the upload checksum headers. Both the x-checksum-*
+// names of the REST API and the x-amz-checksum-* names S3 clients send are
+// accepted.
+func checksumInput(header http.Header) dto.ChecksumInput {
+	first := func(names ...string) string {
+		for _, name := range names {
+			if v := header.Get(name); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	return dto.ChecksumInput{
+		ContentMD5: header.Get("Content-MD5"),
+		SHA256:     first("x-checksum-sha256", "x-amz-checksum-sha256"),
+		CRC32C:     first("x-checksum-crc32c", "x-amz-checksum-crc32c"),
+		Algorithm:  first("x-checksum-algorithm", "x-amz-checksum-algorithm", "x-amz-sdk-checksum-algorithm"),
+	}
+}
+
+// setChecksumHeaders returns the recorded checksums of an object, hex
+// encoded on the REST API
+func setChecksumHeaders(c *gin.Context, sha256, crc32c string) {
+	if sha256 != "" {
+		c.Header("x-checksum-sha256", sha256)
+	}
+	if crc32c != "" {
+		c.Header("x-checksum-crc32c", crc32c)
+	}
+}
+
+// setS3ChecksumHeaders returns the recorded checksums of an object, base64
+// encoded as S3 does
+func setS3ChecksumHeaders(c *gin.Context, sha256, crc32c string) {
+	if sum := hexToBase64(sha256); sum != "" {
+		c.Header("x-amz-checksum-sha256", sum)
+	}
+	if sum := hexToBase64(crc32c); sum != "" {
+		c.Header("x-amz-checksum-crc32c", sum)
+	}
+}
+
+func hexToBase64(sum string) string {
+	raw, err := hex.DecodeString(sum)
+	if err != nil || len(raw) == 0 {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// checksumErrorStatus maps checksum errors to a status; ok is false for any
+// other error
+func checksumErrorStatus(err error) (int, bool) {
+	if errors.Is(err, application.ErrInvalidChecksum) || errors.Is(err, application.ErrChecksumMismatch) {
+		return http.StatusBadRequest, true
+	}
+	return 0, false
+}
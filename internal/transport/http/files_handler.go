@@ -1,11 +1,16 @@
 package http
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"s3/internal/application"
+	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
+	"s3/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -28,41 +33,105 @@ func NewFileHandler(
 
 // UploadFile handles file upload
 // POST /buckets/:bucketId/files
+//
+// The multipart body is read part by part and the "file" part is streamed
+// straight to storage, so the object is never held in memory as a whole.
 func (h *HandlerForFiles) UploadFile(c *gin.Context) {
 	bucketID := c.Param("bucketId")
 
-	file, header, err := c.Request.FormFile("file")
+	reader, err := c.Request.MultipartReader()
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart/form-data body is required"})
+		return
+	}
+
+	var part *multipart.Part
+	for {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read multipart body"})
+			return
+		}
+		if p.FormName() == "file" && p.FileName() != "" {
+			part = p
+			break
+		}
+		p.Close()
+	}
+	if part == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
 		return
 	}
-	defer file.Close()
+	defer part.Close()
 
-	data, err := io.ReadAll(file)
+	body, detectedType, err := middleware.SniffFileType(part)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read file"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot read file"})
 		return
 	}
+	if !middleware.IsAllowedFileType(detectedType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file type"})
+		return
+	}
+
+	mimeType := part.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = detectedType
+	}
 
 	output, err := h.uploadService.UploadFile(c.Request.Context(), application.UploadFileInput{
 		BucketID: bucketID,
-		Key:      header.Filename,
-		Data:     data,
-		MimeType: header.Header.Get("Content-Type"),
+		Key:      part.FileName(),
+		Body:     body,
+		Size:     -1,
+		MimeType: mimeType,
 		Metadata: map[string]string{
-			"original_name": header.Filename,
+			"original_name": part.FileName(),
 		},
+		Encryption: sseInput(c.Request.Header),
+		Checksums:  checksumInput(c.Request.Header),
 	})
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large"})
+			return
+		}
+		if status, ok := sseErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if status, ok := checksumErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if status, ok := lockErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if status, ok := quotaErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-fmt.Println(output.FileID)
+
+	setSSEHeaders(c, output.Encryption, output.SSECustomerKeyMD5)
+	setChecksumHeaders(c, output.ChecksumSHA256, output.ChecksumCRC32C)
+	c.Header("ETag", fmt.Sprintf("%q", output.ETag))
 	c.JSON(http.StatusCreated, gin.H{
-		"file_id":    output.FileID,
-		"key":        output.Key,
-		"size":       output.Size,
-		"created_at": output.CreatedAt,
+		"file_id":         output.FileID,
+		"key":             output.Key,
+		"size":            output.Size,
+		"created_at":      output.CreatedAt,
+		"encryption":      output.Encryption,
+		"etag":            output.ETag,
+		"checksum_sha256": output.ChecksumSHA256,
+		"checksum_crc32c": output.ChecksumCRC32C,
 	})
 }
 
@@ -94,12 +163,15 @@ func (h *HandlerForFiles) DeleteFile(c *gin.Context) {
 	fileID := c.Param("fileId")
 
 	err := h.deleteService.DeleteFile(c.Request.Context(), application.DeleteFileInput{
-		FileID:   fileID,
-		BucketID: bucketID,
-	
+		FileID:           fileID,
+		BucketID:         bucketID,
+		BypassGovernance: bypassGovernance(c, bucketID, ""),
 	})
 	if err != nil {
-		fmt.Println()
+		if status, ok := lockErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -129,23 +201,138 @@ func (h *HandlerForFiles) GetFileInfo(c *gin.Context) {
 
 // DownloadFile handles file download
 // GET /:bucketId/files/:fileId/download
+//
+// Supports single and multi-range requests (206) as well as the
+// If-Match/If-None-Match/If-Modified-Since/If-Unmodified-Since/If-Range
+// conditionals.
 func (h *HandlerForFiles) DownloadFile(c *gin.Context) {
 	bucketID := c.Param("bucketId")
 	fileID := c.Param("fileId")
 
+	sse := sseInput(c.Request.Header)
+	metadata, err := h.uploadService.StatFile(c.Request.Context(), bucketID, fileID, sse)
+	if err != nil {
+		status, ok := sseErrorStatus(err)
+		if !ok {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	setSSEHeaders(c, metadata.Encryption, metadata.SSECustomerKeyMD5)
+	setChecksumHeaders(c, metadata.ChecksumSHA256, metadata.ChecksumCRC32C)
 
-	
-	fileData, metadata, err := h.uploadService.DownloadFile(c.Request.Context(), bucketID, fileID)
+	c.Header("ETag", fmt.Sprintf("%q", metadata.ETag))
+	c.Header("Last-Modified", metadata.LastModified.UTC().Format(http.TimeFormat))
+	c.Header("Accept-Ranges", "bytes")
+
+	if status := checkPreconditions(c.Request, metadata.ETag, metadata.LastModified); status != 0 {
+		c.Status(status)
+		return
+	}
+
+	contentType := metadata.MimeType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	var ranges []byteRange
+	if ifRangeAllows(c.Request, metadata.ETag, metadata.LastModified) {
+		ranges, err = parseRange(c.GetHeader("Range"), metadata.Size)
+		if err != nil {
+			c.Header("Content-Range", fmt.Sprintf("bytes */%d", metadata.Size))
+			c.JSON(http.StatusRequestedRangeNotSatisfiable, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if len(ranges) > 1 {
+		h.writeByteRanges(c, bucketID, fileID, contentType, metadata.Size, ranges, sse)
+		return
+	}
+
+	status, offset, length := http.StatusOK, int64(0), int64(-1)
+	extraHeaders := map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=\"%s\"", metadata.Key),
+	}
+	if len(ranges) == 1 {
+		status, offset, length = http.StatusPartialContent, ranges[0].start, ranges[0].length
+		extraHeaders["Content-Range"] = ranges[0].contentRange(metadata.Size)
+	}
+
+	body, err := h.uploadService.DownloadFileRange(c.Request.Context(), bucketID, fileID, offset, length, sse)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(downloadErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", metadata.Key))
-	c.Header("Content-Type", metadata.MimeType)
-	c.Header("Content-Length", fmt.Sprintf("%d", metadata.Size))
-	
-	c.Data(http.StatusOK, metadata.MimeType, fileData)
+	defer body.Close()
+
+	contentLength := metadata.Size
+	if length > 0 {
+		contentLength = length
+	}
+
+	c.DataFromReader(status, contentLength, contentType, body, extraHeaders)
+}
+
+// downloadErrorStatus maps a failed download, copy or move; archived
+// objects need a restore first, like S3's InvalidObjectState
+func downloadErrorStatus(err error) int {
+	if errors.Is(err, application.ErrObjectArchived) {
+		return http.StatusForbidden
+	}
+	if status, ok := sseErrorStatus(err); ok {
+		return status
+	}
+	if status, ok := lockErrorStatus(err); ok {
+		return status
+	}
+	if status, ok := quotaErrorStatus(err); ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// writeByteRanges streams a multipart/byteranges response, opening each
+// range from storage only when it is about to be written.
+func (h *HandlerForFiles) writeByteRanges(c *gin.Context, bucketID, fileID, contentType string, size int64, ranges []byteRange, sse dto.SSEInput) {
+	ctx := c.Request.Context()
+
+	body, err := h.uploadService.DownloadFileRange(ctx, bucketID, fileID, ranges[0].start, ranges[0].length, sse)
+	if err != nil {
+		c.JSON(downloadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	mw := multipart.NewWriter(c.Writer)
+	c.Header("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	c.Status(http.StatusPartialContent)
+
+	for i, r := range ranges {
+		if i > 0 {
+			body, err = h.uploadService.DownloadFileRange(ctx, bucketID, fileID, r.start, r.length, sse)
+			if err != nil {
+				// Headers are already sent; cut the response short
+				c.Error(err)
+				return
+			}
+		}
+
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":  {contentType},
+			"Content-Range": {r.contentRange(size)},
+		})
+		if err == nil {
+			_, err = io.Copy(part, body)
+		}
+		body.Close()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	mw.Close()
 }
 
 
@@ -183,7 +370,13 @@ func (h *HandlerForFiles) CopyFile(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
 		return
 	}
+	input.SourceEncryption = copySourceSSEInput(c.Request.Header)
 	
+	if input.DestinationBucket != "" &&
+		!middleware.AuthorizePolicy(c, input.DestinationBucket, input.NewKey, domain.ActionPutObject) {
+		return
+	}
+
 	output, err := h.uploadService.CopyFile(c.Request.Context(), bucketID, fileID, input)
 	
 	
@@ -192,7 +385,7 @@ func (h *HandlerForFiles) CopyFile(c *gin.Context) {
 	fmt.Println("========================>",err)
 	
 	
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(downloadErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -210,12 +403,18 @@ func (h *HandlerForFiles) MoveFile(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
 		return
 	}
+	input.SourceEncryption = copySourceSSEInput(c.Request.Header)
 	
+	if input.DestinationBucket != "" &&
+		!middleware.AuthorizePolicy(c, input.DestinationBucket, input.NewKey, domain.ActionPutObject) {
+		return
+	}
+
 	output, err := h.uploadService.MoveFile(c.Request.Context(), bucketID, fileID, input)
 	if err != nil {
 		fmt.Println("======")
 		fmt.Println(err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(downloadErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"s3/internal/application"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LifecycleHandler exposes the lifecycle worker: a dry-run report of what
+// the bucket's rules would do now, an on-demand run, and the action history.
+type LifecycleHandler struct {
+	lifecycleService *application.LifecycleService
+}
+
+func NewLifecycleHandler(lifecycleService *application.LifecycleService) *LifecycleHandler {
+	return &LifecycleHandler{lifecycleService: lifecycleService}
+}
+
+// LifecycleReport evaluates the rules without changing anything
+// GET /buckets/:bucketId/lifecycle/report
+func (h *LifecycleHandler) LifecycleReport(c *gin.Context) {
+	report, err := h.lifecycleService.RunBucket(c.Request.Context(), c.Param("bucketId"), true)
+	if err != nil {
+		c.JSON(lifecycleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RunLifecycle applies the rules now instead of waiting for the worker
+// POST /buckets/:bucketId/lifecycle/run
+func (h *LifecycleHandler) RunLifecycle(c *gin.Context) {
+	report, err := h.lifecycleService.RunBucket(c.Request.Context(), c.Param("bucketId"), false)
+	if err != nil {
+		c.JSON(lifecycleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// LifecycleHistory lists the actions the worker took, newest first
+// GET /buckets/:bucketId/lifecycle/history?limit=100
+func (h *LifecycleHandler) LifecycleHistory(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	output, err := h.lifecycleService.History(c.Request.Context(), c.Param("bucketId"), limit)
+	if err != nil {
+		c.JSON(lifecycleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func lifecycleErrorStatus(err error) int {
+	if errors.Is(err, application.ErrBucketNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
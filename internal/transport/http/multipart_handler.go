@@ -1,7 +1,7 @@
 package http
 
 import (
-	"io"
+	"errors"
 	"net/http"
 
 	"s3/internal/application"
@@ -28,10 +28,12 @@ func (h *MultipartHandler) InitiateMultipartUpload(c *gin.Context) {
 		return
 	}
 	input.BucketID = bucketId
+	input.Encryption = sseInput(c.Request.Header)
+	input.Checksums = checksumInput(c.Request.Header)
 
 	output, err := h.multipartService.InitiateMultipartUpload(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(multipartErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -43,9 +45,9 @@ func (h *MultipartHandler) UploadPart(c *gin.Context) {
 	uploadId := c.Param("uploadId")
 	partNumber, _ := strconv.Atoi(c.Param("partNumber"))
 
-	data, err := io.ReadAll(c.Request.Body)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+	// Parts are streamed to the backend, which needs the size up front
+	if c.Request.ContentLength < 0 {
+		c.JSON(http.StatusLengthRequired, gin.H{"error": "Content-Length is required"})
 		return
 	}
 
@@ -53,12 +55,13 @@ func (h *MultipartHandler) UploadPart(c *gin.Context) {
 		BucketID:   bucketId,
 		UploadID:   uploadId,
 		PartNumber: partNumber,
-		Data:       data,
+		Body:       c.Request.Body,
+		Size:       c.Request.ContentLength,
 	}
 
 	output, err := h.multipartService.UploadPart(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(multipartErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -79,7 +82,7 @@ func (h *MultipartHandler) CompleteMultipartUpload(c *gin.Context) {
 
 	output, err := h.multipartService.CompleteMultipartUpload(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(multipartErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -105,7 +108,7 @@ func (h *MultipartHandler) ListParts(c *gin.Context) {
 
 	output, err := h.multipartService.ListParts(c.Request.Context(), bucketId, uploadId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(multipartErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -122,4 +125,26 @@ func (h *MultipartHandler) ListMultipartUploads(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, output)
-}
\ No newline at end of file
+}
+
+func multipartErrorStatus(err error) int {
+	if errors.Is(err, application.ErrInvalidMultipartPart) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, application.ErrUploadNotFound) {
+		return http.StatusNotFound
+	}
+	if status, ok := sseErrorStatus(err); ok {
+		return status
+	}
+	if status, ok := checksumErrorStatus(err); ok {
+		return status
+	}
+	if status, ok := lockErrorStatus(err); ok {
+		return status
+	}
+	if status, ok := quotaErrorStatus(err); ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
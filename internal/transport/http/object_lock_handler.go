@@ -0,0 +1,150 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"s3/internal/application"
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+	"s3/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ObjectLockHandler exposes a bucket's object lock configuration and the
+// retention and legal hold of its objects.
+type ObjectLockHandler struct {
+	objectLockService *application.ObjectLockService
+}
+
+func NewObjectLockHandler(objectLockService *application.ObjectLockService) *ObjectLockHandler {
+	return &ObjectLockHandler{objectLockService: objectLockService}
+}
+
+// SetBucketObjectLock enables object lock and sets the default retention
+// PUT /buckets/:bucketId/object-lock
+func (h *ObjectLockHandler) SetBucketObjectLock(c *gin.Context) {
+	var input dto.ObjectLockConfigurationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "enabled is required"})
+		return
+	}
+
+	output, err := h.objectLockService.SetBucketObjectLock(c.Request.Context(), c.Param("bucketId"), input)
+	if err != nil {
+		c.JSON(objectLockErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// GetBucketObjectLock returns the bucket's object lock configuration
+// GET /buckets/:bucketId/object-lock
+func (h *ObjectLockHandler) GetBucketObjectLock(c *gin.Context) {
+	output, err := h.objectLockService.GetBucketObjectLock(c.Request.Context(), c.Param("bucketId"))
+	if err != nil {
+		c.JSON(objectLockErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// PutObjectRetention sets or removes an object's retention
+// PUT /files/:bucketId/files/:fileId/retention
+func (h *ObjectLockHandler) PutObjectRetention(c *gin.Context) {
+	var input dto.ObjectRetentionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+		return
+	}
+
+	bucketID := c.Param("bucketId")
+	output, err := h.objectLockService.PutObjectRetention(c.Request.Context(), bucketID, c.Param("fileId"), input,
+		bypassGovernance(c, bucketID, ""))
+	if err != nil {
+		c.JSON(objectLockErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// GetObjectRetention returns an object's retention
+// GET /files/:bucketId/files/:fileId/retention
+func (h *ObjectLockHandler) GetObjectRetention(c *gin.Context) {
+	output, err := h.objectLockService.GetObjectRetention(c.Request.Context(), c.Param("bucketId"), c.Param("fileId"))
+	if err != nil {
+		c.JSON(objectLockErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// PutObjectLegalHold places or lifts a legal hold
+// PUT /files/:bucketId/files/:fileId/legal-hold
+func (h *ObjectLockHandler) PutObjectLegalHold(c *gin.Context) {
+	var input dto.ObjectLegalHoldInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "legal_hold is required"})
+		return
+	}
+
+	output, err := h.objectLockService.PutObjectLegalHold(c.Request.Context(), c.Param("bucketId"), c.Param("fileId"), *input.LegalHold)
+	if err != nil {
+		c.JSON(objectLockErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// GetObjectLegalHold returns whether an object is under a legal hold
+// GET /files/:bucketId/files/:fileId/legal-hold
+func (h *ObjectLockHandler) GetObjectLegalHold(c *gin.Context) {
+	output, err := h.objectLockService.GetObjectLegalHold(c.Request.Context(), c.Param("bucketId"), c.Param("fileId"))
+	if err != nil {
+		c.JSON(objectLockErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// bypassGovernance reports whether the request asks to override a
+// governance retention (x-amz-bypass-governance-retention) and the actor is
+// allowed to
+func bypassGovernance(c *gin.Context, bucketRef, key string) bool {
+	if !strings.EqualFold(c.GetHeader("x-amz-bypass-governance-retention"), "true") {
+		return false
+	}
+	return middleware.PolicyAllows(c, bucketRef, key, domain.ActionBypassGovernanceRetention)
+}
+
+func objectLockErrorStatus(err error) int {
+	if status, ok := lockErrorStatus(err); ok {
+		return status
+	}
+	if errors.Is(err, application.ErrBucketNotFound) || errors.Is(err, application.ErrObjectNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// lockErrorStatus maps object lock errors to a status; ok is false for any
+// other error
+func lockErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, application.ErrObjectLocked):
+		return http.StatusForbidden, true
+	case errors.Is(err, application.ErrInvalidObjectLock):
+		return http.StatusBadRequest, true
+	case errors.Is(err, application.ErrObjectLockNotEnabled):
+		return http.StatusConflict, true
+	}
+	return 0, false
+}
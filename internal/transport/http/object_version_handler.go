@@ -0,0 +1,137 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"s3/internal/application"
+	"s3/internal/domain"
+	"s3/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ObjectVersionHandler serves the version history of objects in versioned
+// buckets. Versions are addressed by their version id, which is unique
+// within a bucket; policies are checked against the version's key.
+type ObjectVersionHandler struct {
+	versionService *application.ObjectVersionService
+}
+
+func NewObjectVersionHandler(versionService *application.ObjectVersionService) *ObjectVersionHandler {
+	return &ObjectVersionHandler{versionService: versionService}
+}
+
+// ListObjectVersions lists versions and delete markers, newest first
+// GET /buckets/:bucketId/versions?key=photos/cat.png
+func (h *ObjectVersionHandler) ListObjectVersions(c *gin.Context) {
+	output, err := h.versionService.ListObjectVersions(c.Request.Context(), c.Param("bucketId"), c.Query("key"))
+	if err != nil {
+		c.JSON(objectVersionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// GetObjectVersion returns the metadata of one version
+// GET /buckets/:bucketId/versions/:versionId
+func (h *ObjectVersionHandler) GetObjectVersion(c *gin.Context) {
+	version, ok := h.authorizeVersion(c, domain.ActionGetObjectVersion)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, application.ObjectVersionInfo(version))
+}
+
+// DownloadObjectVersion streams the content of one version
+// GET /buckets/:bucketId/versions/:versionId/download
+func (h *ObjectVersionHandler) DownloadObjectVersion(c *gin.Context) {
+	if _, ok := h.authorizeVersion(c, domain.ActionGetObjectVersion); !ok {
+		return
+	}
+
+	body, version, err := h.versionService.DownloadObjectVersion(c.Request.Context(), c.Param("bucketId"), c.Param("versionId"), sseInput(c.Request.Header))
+	if err != nil {
+		c.JSON(objectVersionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	setSSEHeaders(c, version.Encryption, version.SSECustomerKeyMD5)
+
+	contentType := version.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("ETag", fmt.Sprintf("%q", version.ETag))
+	c.DataFromReader(http.StatusOK, version.Size, contentType, body, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=\"%s\"", version.Key),
+		"x-amz-version-id":    version.VersionID,
+	})
+}
+
+// DeleteObjectVersion permanently deletes one version or delete marker
+// DELETE /buckets/:bucketId/versions/:versionId
+func (h *ObjectVersionHandler) DeleteObjectVersion(c *gin.Context) {
+	if _, ok := h.authorizeVersion(c, domain.ActionDeleteObjectVersion); !ok {
+		return
+	}
+
+	output, err := h.versionService.DeleteObjectVersion(c.Request.Context(), c.Param("bucketId"), c.Param("versionId"))
+	if err != nil {
+		c.JSON(objectVersionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// RestoreObjectVersion makes an older version the latest again
+// POST /buckets/:bucketId/versions/:versionId/restore
+func (h *ObjectVersionHandler) RestoreObjectVersion(c *gin.Context) {
+	if _, ok := h.authorizeVersion(c, domain.ActionGetObjectVersion, domain.ActionPutObject); !ok {
+		return
+	}
+
+	version, err := h.versionService.RestoreObjectVersion(c.Request.Context(), c.Param("bucketId"), c.Param("versionId"), sseInput(c.Request.Header))
+	if err != nil {
+		c.JSON(objectVersionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, application.ObjectVersionInfo(version))
+}
+
+// authorizeVersion looks the version up and checks actions on its key
+func (h *ObjectVersionHandler) authorizeVersion(c *gin.Context, actions ...domain.Action) (*domain.ObjectVersion, bool) {
+	bucketID := c.Param("bucketId")
+	version, err := h.versionService.GetObjectVersion(c.Request.Context(), bucketID, c.Param("versionId"))
+	if err != nil {
+		c.JSON(objectVersionErrorStatus(err), gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	if !middleware.AuthorizePolicy(c, bucketID, version.Key, actions...) {
+		return nil, false
+	}
+	return version, true
+}
+
+func objectVersionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, application.ErrBucketNotFound), errors.Is(err, application.ErrObjectVersionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, application.ErrVersionIsDeleteMarker):
+		return http.StatusBadRequest
+	}
+	if status, ok := lockErrorStatus(err); ok {
+		return status
+	}
+	if status, ok := sseErrorStatus(err); ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
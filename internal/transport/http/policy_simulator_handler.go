@@ -0,0 +1,122 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+	"s3/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PolicySimulatorHandler answers "would this request be allowed, and why"
+// using the same evaluation as the policy enforcer.
+type PolicySimulatorHandler struct {
+	policies *middleware.PolicyEnforcer
+}
+
+func NewPolicySimulatorHandler(policies *middleware.PolicyEnforcer) *PolicySimulatorHandler {
+	return &PolicySimulatorHandler{policies: policies}
+}
+
+// SimulatePolicy evaluates a described request against the bucket policy
+// (or a draft policy from the body) without performing it.
+// POST /buckets/:bucketId/policy/simulate
+func (h *PolicySimulatorHandler) SimulatePolicy(c *gin.Context) {
+	var input dto.SimulatePolicyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload: " + err.Error()})
+		return
+	}
+
+	var draft *domain.Policy
+	source := "bucket"
+	if len(input.Policy) > 0 && string(input.Policy) != "null" {
+		draft = &domain.Policy{}
+		if err := json.Unmarshal(input.Policy, draft); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid policy document: " + err.Error()})
+			return
+		}
+		if err := draft.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid policy: " + err.Error()})
+			return
+		}
+		source = "request"
+	}
+
+	principal := input.Principal
+	if principal == "" {
+		principal = c.GetString("actor")
+	}
+	bucketID := c.Param("bucketId")
+	resource := input.Resource
+	if resource == "" && input.Key != "" {
+		resource = domain.PolicyResource(bucketID, input.Key)
+	}
+
+	sim, err := h.policies.Simulate(c.Request.Context(), bucketID, draft, middleware.PolicyRequest{
+		Principal: principal,
+		Roles:     input.Roles,
+		Action:    domain.Action(input.Action),
+		Resource:  resource,
+		Context:   input.Context,
+	})
+	if errors.Is(err, domain.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, simulationOutput(bucketID, source, sim))
+}
+
+func simulationOutput(bucketID, source string, sim *middleware.PolicySimulation) dto.SimulatePolicyOutput {
+	req := sim.Request
+	output := dto.SimulatePolicyOutput{
+		BucketID:          bucketID,
+		Principal:         req.Principal,
+		Roles:             req.Roles,
+		Action:            string(req.Action),
+		Resource:          req.Resource,
+		Context:           req.Context,
+		PolicySource:      source,
+		Decision:          sim.Result.Decision.String(),
+		ExplicitDeny:      sim.Result.ExplicitDeny,
+		ImplicitDeny:      sim.Statement == nil,
+		Bypass:            sim.Bypass,
+		EffectiveDecision: sim.Effective.String(),
+	}
+	if output.Roles == nil {
+		output.Roles = []string{}
+	}
+
+	if sim.Statement != nil {
+		index := sim.Result.Statement
+		output.MatchedStatement = &index
+		output.Statement, _ = json.Marshal(sim.Statement)
+	}
+
+	switch {
+	case sim.Result.ExplicitDeny:
+		output.Reason = fmt.Sprintf("statement %d explicitly denies %s on %s", sim.Result.Statement, req.Action, req.Resource)
+	case sim.Statement != nil:
+		output.Reason = fmt.Sprintf("statement %d allows %s on %s", sim.Result.Statement, req.Action, req.Resource)
+	default:
+		output.Reason = fmt.Sprintf("implicit deny: no statement matches %s performing %s on %s", req.Principal, req.Action, req.Resource)
+	}
+	switch sim.Bypass {
+	case "admin":
+		output.Reason += "; admins bypass bucket policies"
+	case "owner":
+		output.Reason += "; the bucket owner is allowed unless explicitly denied"
+	}
+
+	return output
+}
@@ -4,7 +4,9 @@ import (
 	"net/http"
 
 	"s3/internal/application"
+	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
+	"s3/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -72,6 +74,12 @@ func (h *PrefixHandler) CopyByPrefix(c *gin.Context) {
 		return
 	}
 	input.BucketID = bucketId
+	input.SourceEncryption = copySourceSSEInput(c.Request.Header)
+
+	if input.DestBucketID != "" &&
+		!middleware.AuthorizePolicy(c, input.DestBucketID, input.DestPrefix+"*", domain.ActionPutObject) {
+		return
+	}
 
 	output, err := h.prefixService.CopyByPrefix(c.Request.Context(), input)
 	if err != nil {
@@ -138,7 +146,7 @@ func (h *PrefixHandler) ArchiveByPrefix(c *gin.Context) {
 
 	output, err := h.prefixService.ArchiveByPrefix(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(prefixErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -164,4 +172,19 @@ func (h *PrefixHandler) SetMetadataByPrefix(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, output)
-}
\ No newline at end of file
+}
+
+// prefixErrorStatus maps the errors a prefix operation can fail with as a
+// whole to a status
+func prefixErrorStatus(err error) int {
+	if status, ok := sseErrorStatus(err); ok {
+		return status
+	}
+	if status, ok := lockErrorStatus(err); ok {
+		return status
+	}
+	if status, ok := quotaErrorStatus(err); ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
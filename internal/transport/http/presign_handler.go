@@ -1,11 +1,15 @@
 package http
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"s3/internal/application"
+	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
+	"s3/internal/middleware"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -88,9 +92,16 @@ func (h *PresignHandler) GenerateDownloadURL(c *gin.Context) {
 // RevokePresignedURL handles revoking a presigned URL
 // DELETE /presign/urls/:urlId
 func (h *PresignHandler) RevokePresignedURL(c *gin.Context) {
-	urlId := c.Param("urlId")
+	presignedURL, err := h.presignService.GetPresignedURL(c.Request.Context(), c.Param("urlId"))
+	if err != nil {
+		c.JSON(presignErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if !middleware.AuthorizePolicy(c, presignedURL.BucketID, presignedURL.Key, presignAction(presignedURL)) {
+		return
+	}
 
-	err := h.presignService.RevokePresignedURL(c.Request.Context(), urlId)
+	err = h.presignService.RevokePresignedURL(c.Request.Context(), presignedURL.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -111,6 +122,11 @@ func (h *PresignHandler) ListPresignedURLs(c *gin.Context) {
 		return
 	}
 
+	// Only admins may list the URLs of every bucket at once
+	if !middleware.AuthorizePolicy(c, bucketId, "", domain.ActionListBucket) {
+		return
+	}
+
 	input := dto.ListPresignedURLsInput{
 		BucketID: bucketId,
 		Limit:    limit,
@@ -136,6 +152,13 @@ func (h *PresignHandler) ValidatePresignedURL(c *gin.Context) {
 		return
 	}
 
+	// An unknown URL is reported as invalid below; a known one tells the
+	// caller its bucket and key, so it needs the same access as using it
+	if presignedURL, err := h.presignService.GetPresignedURL(c.Request.Context(), input.URLID); err == nil &&
+		!middleware.AuthorizePolicy(c, presignedURL.BucketID, presignedURL.Key, presignAction(presignedURL)) {
+		return
+	}
+
 	output, err := h.presignService.ValidatePresignedURL(c.Request.Context(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -165,4 +188,93 @@ func (h *PresignHandler) GenerateMultipartUploadURLs(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, output)
-}
\ No newline at end of file
+}
+// GetPresignedObject serves downloads through a presigned URL (no API key)
+// GET/HEAD /buckets/:bucketId/objects/*key
+func (h *PresignHandler) GetPresignedObject(c *gin.Context) {
+	body, info, err := h.presignService.OpenPresignedObject(c.Request.Context(), presignedRequestInput(c))
+	if err != nil {
+		c.JSON(presignErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	c.Header("ETag", fmt.Sprintf("%q", info.ETag))
+	c.Header("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+
+	if c.Request.Method == http.MethodHead {
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Length", strconv.FormatInt(info.Size, 10))
+		c.Status(http.StatusOK)
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, info.Size, contentType, body, nil)
+}
+
+// PutPresignedObject accepts uploads through a presigned URL (no API key)
+// PUT /buckets/:bucketId/objects/*key
+func (h *PresignHandler) PutPresignedObject(c *gin.Context) {
+	output, err := h.presignService.PutPresignedObject(
+		c.Request.Context(),
+		presignedRequestInput(c),
+		c.Request.Body,
+		c.Request.ContentLength,
+		c.GetHeader("Content-Type"),
+	)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large"})
+			return
+		}
+		c.JSON(presignErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", fmt.Sprintf("%q", output.ETag))
+	c.JSON(http.StatusCreated, output)
+}
+
+// presignedRequestInput collects the presigned URL parts from the request.
+// The bucket segment holds the bucket name; gin requires it to share the
+// :bucketId wildcard name with the /buckets routes.
+func presignedRequestInput(c *gin.Context) dto.PresignedRequestInput {
+	return dto.PresignedRequestInput{
+		BucketName: c.Param("bucketId"),
+		Key:        strings.TrimPrefix(c.Param("key"), "/"),
+		Method:     c.Request.Method,
+		URLID:      c.Query("urlId"),
+		Expires:    c.Query("expires"),
+		SignedFor:  c.Query("method"),
+		Signature:  c.Query("signature"),
+	}
+}
+
+// presignAction is the action a presigned URL grants its holder
+func presignAction(presignedURL *domain.PresignedURL) domain.Action {
+	if presignedURL.Type == "download" {
+		return domain.ActionGetObject
+	}
+	return domain.ActionPutObject
+}
+
+func presignErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, application.ErrPresignInvalid):
+		return http.StatusForbidden
+	case errors.Is(err, application.ErrPresignExpired), errors.Is(err, application.ErrPresignRevoked):
+		return http.StatusGone
+	case errors.Is(err, application.ErrPresignNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, application.ErrObjectLocked), errors.Is(err, application.ErrQuotaExceeded):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
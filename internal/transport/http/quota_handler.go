@@ -0,0 +1,114 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"s3/internal/application"
+	"s3/internal/infrastructure/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QuotaHandler exposes bucket and owner storage quotas and their usage.
+type QuotaHandler struct {
+	quotaService *application.QuotaService
+}
+
+func NewQuotaHandler(quotaService *application.QuotaService) *QuotaHandler {
+	return &QuotaHandler{quotaService: quotaService}
+}
+
+// SetBucketQuota sets the bucket's byte and object limits; 0 means unlimited
+// PUT /buckets/:bucketId/quota
+func (h *QuotaHandler) SetBucketQuota(c *gin.Context) {
+	var input dto.SetQuotaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_bytes and max_objects must be non-negative"})
+		return
+	}
+
+	output, err := h.quotaService.SetBucketQuota(c.Request.Context(), c.Param("bucketId"), input)
+	if err != nil {
+		c.JSON(quotaHandlerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// GetBucketQuota returns the usage and limits of the bucket and of its owner
+// GET /buckets/:bucketId/quota
+func (h *QuotaHandler) GetBucketQuota(c *gin.Context) {
+	output, err := h.quotaService.BucketQuotaStatus(c.Request.Context(), c.Param("bucketId"))
+	if err != nil {
+		c.JSON(quotaHandlerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// SetOwnerQuota sets the limits shared by all of a user's buckets (admin only)
+// PUT /quotas/owners/:ownerId
+func (h *QuotaHandler) SetOwnerQuota(c *gin.Context) {
+	if !application.IsAdmin(c.GetString("actor")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can set owner quotas"})
+		return
+	}
+
+	var input dto.SetQuotaInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_bytes and max_objects must be non-negative"})
+		return
+	}
+
+	output, err := h.quotaService.SetOwnerQuota(c.Request.Context(), c.Param("ownerId"), input)
+	if err != nil {
+		c.JSON(quotaHandlerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// GetOwnerQuota returns a user's quota and usage across their buckets; users
+// may read their own, admins anyone's
+// GET /quotas/owners/:ownerId
+func (h *QuotaHandler) GetOwnerQuota(c *gin.Context) {
+	ownerID := c.Param("ownerId")
+	if ownerID != actorUserID(c) && !application.IsAdmin(c.GetString("actor")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return
+	}
+
+	output, err := h.quotaService.OwnerQuotaStatus(c.Request.Context(), ownerID)
+	if err != nil {
+		c.JSON(quotaHandlerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func quotaHandlerErrorStatus(err error) int {
+	if status, ok := quotaErrorStatus(err); ok {
+		return status
+	}
+	if errors.Is(err, application.ErrBucketNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// quotaErrorStatus maps quota errors to a status; ok is false for any other
+// error
+func quotaErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, application.ErrQuotaExceeded):
+		return http.StatusForbidden, true
+	case errors.Is(err, application.ErrInvalidQuota):
+		return http.StatusBadRequest, true
+	}
+	return 0, false
+}
@@ -0,0 +1,169 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRanges caps the number of ranges served in one multipart/byteranges
+// response; requests asking for more are answered with the full object.
+const maxRanges = 16
+
+var errUnsatisfiableRange = errors.New("requested range not satisfiable")
+
+// byteRange is a resolved span of an object: length bytes starting at start.
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+func (r byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+// parseRange resolves a Range header against an object of the given size.
+// It returns no ranges when the header is absent, malformed or asks for too
+// many ranges (the caller then serves the whole object), and
+// errUnsatisfiableRange when none of the ranges overlap the object.
+func parseRange(header string, size int64) ([]byteRange, error) {
+	if header == "" {
+		return nil, nil
+	}
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return nil, nil
+	}
+
+	var ranges []byteRange
+	specs := strings.Split(spec, ",")
+	if len(specs) > maxRanges {
+		return nil, nil
+	}
+
+	for _, s := range specs {
+		first, last, ok := strings.Cut(strings.TrimSpace(s), "-")
+		if !ok {
+			return nil, nil
+		}
+
+		var r byteRange
+		if first == "" {
+			// Suffix range: the last n bytes
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, nil
+			}
+			if n == 0 || size == 0 {
+				continue
+			}
+			if n > size {
+				n = size
+			}
+			r = byteRange{start: size - n, length: n}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, nil
+			}
+			end := size - 1
+			if last != "" {
+				end, err = strconv.ParseInt(last, 10, 64)
+				if err != nil || end < start {
+					return nil, nil
+				}
+				if end > size-1 {
+					end = size - 1
+				}
+			}
+			if start >= size {
+				continue
+			}
+			r = byteRange{start: start, length: end - start + 1}
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	if len(ranges) == 0 {
+		return nil, errUnsatisfiableRange
+	}
+	return ranges, nil
+}
+
+// checkPreconditions evaluates the conditional request headers in the order
+// given by RFC 7232 section 6. It returns 0 when the request should proceed,
+// or the status (304 or 412) to answer with instead.
+func checkPreconditions(r *http.Request, etag string, lastModified time.Time) int {
+	if header := r.Header.Get("If-Match"); header != "" {
+		if !etagListMatches(header, etag, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if since, ok := parseHTTPTime(r.Header.Get("If-Unmodified-Since")); ok {
+		if lastModified.Truncate(time.Second).After(since) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		if etagListMatches(header, etag, true) {
+			return http.StatusNotModified
+		}
+	} else if since, ok := parseHTTPTime(r.Header.Get("If-Modified-Since")); ok {
+		if !lastModified.Truncate(time.Second).After(since) {
+			return http.StatusNotModified
+		}
+	}
+
+	return 0
+}
+
+// ifRangeAllows reports whether a Range header may be honoured given the
+// request's If-Range validator (if any).
+func ifRangeAllows(r *http.Request, etag string, lastModified time.Time) bool {
+	header := r.Header.Get("If-Range")
+	if header == "" {
+		return true
+	}
+	if strings.HasPrefix(header, `"`) {
+		return etagListMatches(header, etag, false)
+	}
+	since, ok := parseHTTPTime(header)
+	return ok && lastModified.Truncate(time.Second).Equal(since)
+}
+
+// etagListMatches checks a comma separated If-Match/If-None-Match value
+// against etag. Weak comparison ignores the W/ prefix; strong comparison
+// never matches a weak validator.
+func etagListMatches(header, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+		if strings.Trim(candidate, `"`) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func parseHTTPTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
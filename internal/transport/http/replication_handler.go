@@ -0,0 +1,117 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"s3/internal/application"
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+	"s3/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReplicationHandler exposes bucket replication rules, the state of the
+// replication queue and the backfill and retry commands.
+type ReplicationHandler struct {
+	replicationService *application.ReplicationService
+}
+
+func NewReplicationHandler(replicationService *application.ReplicationService) *ReplicationHandler {
+	return &ReplicationHandler{replicationService: replicationService}
+}
+
+// SetReplication replaces the bucket's replication rules. The caller must
+// be allowed to replicate into every destination.
+// PUT /buckets/:bucketId/replication
+func (h *ReplicationHandler) SetReplication(c *gin.Context) {
+	var input dto.SetReplicationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, rule := range input.Rules {
+		if !middleware.PolicyAllows(c, rule.DestinationBucket, rule.Prefix+"*", domain.ActionReplicateObject) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "not allowed to replicate to " + rule.DestinationBucket})
+			return
+		}
+	}
+
+	output, err := h.replicationService.SetReplicationRules(c.Request.Context(), c.Param("bucketId"), input)
+	if err != nil {
+		c.JSON(replicationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// GetReplication returns the bucket's replication rules
+// GET /buckets/:bucketId/replication
+func (h *ReplicationHandler) GetReplication(c *gin.Context) {
+	output, err := h.replicationService.GetReplicationRules(c.Request.Context(), c.Param("bucketId"))
+	if err != nil {
+		c.JSON(replicationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// ReplicationTasks lists the bucket's replication tasks
+// GET /buckets/:bucketId/replication/tasks?status=FAILED&limit=100
+func (h *ReplicationHandler) ReplicationTasks(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", domain.ReplicationPending, domain.ReplicationCompleted, domain.ReplicationFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be PENDING, COMPLETED or FAILED"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	output, err := h.replicationService.Tasks(c.Request.Context(), c.Param("bucketId"), status, limit)
+	if err != nil {
+		c.JSON(replicationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+// BackfillReplication queues the bucket's existing objects for replication
+// POST /buckets/:bucketId/replication/backfill
+func (h *ReplicationHandler) BackfillReplication(c *gin.Context) {
+	output, err := h.replicationService.Backfill(c.Request.Context(), c.Param("bucketId"))
+	if err != nil {
+		c.JSON(replicationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, output)
+}
+
+// RetryReplication makes the bucket's failed tasks pending again
+// POST /buckets/:bucketId/replication/retry
+func (h *ReplicationHandler) RetryReplication(c *gin.Context) {
+	output, err := h.replicationService.RetryFailed(c.Request.Context(), c.Param("bucketId"))
+	if err != nil {
+		c.JSON(replicationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, output)
+}
+
+func replicationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, application.ErrInvalidReplicationRule):
+		return http.StatusBadRequest
+	case errors.Is(err, application.ErrBucketNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
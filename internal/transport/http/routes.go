@@ -1,23 +1,54 @@
 package http
 
 import (
+	"s3/internal/domain"
 	"s3/internal/middleware"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// defaultMaxUploadSize caps a single upload when Handlers.MaxUploadSize is
+// not set
+const defaultMaxUploadSize = 10 << 20
+
 // Handlers struct holds all handler dependencies
 type Handlers struct {
-	File      *HandlerForFiles
-	Bucket    *BucketHandler
-	Health    *HandlerForHealth
-	Presign   *PresignHandler
-	Batch     *BatchHandler
-	Prefix    *PrefixHandler
-	Search    *SearchHandler
-	Webhook   *WebhookHandler
-	Multipart *MultipartHandler
-	Analytics *AnalyticsHandler
+	File        *HandlerForFiles
+	Bucket      *BucketHandler
+	Health      *HandlerForHealth
+	Presign     *PresignHandler
+	Batch       *BatchHandler
+	Prefix      *PrefixHandler
+	Search      *SearchHandler
+	Webhook     *WebhookHandler
+	Multipart   *MultipartHandler
+	Analytics   *AnalyticsHandler
+	AccessKey   *AccessKeyHandler
+	Simulator   *PolicySimulatorHandler
+	Versions    *ObjectVersionHandler
+	Lifecycle   *LifecycleHandler
+	Tiering     *StorageClassHandler
+	Scrub       *ScrubHandler
+	ObjectLock  *ObjectLockHandler
+	Quota       *QuotaHandler
+	Replication *ReplicationHandler
+
+	// APIKeys validates the x-api-key header on protected route groups
+	APIKeys middleware.APIKeyValidator
+	// Policies enforces bucket policies on bucket and object routes
+	Policies *middleware.PolicyEnforcer
+
+	// MaxUploadSize caps a single upload in bytes; 0 uses the default of
+	// 10 MiB
+	MaxUploadSize int64
+}
+
+func (h *Handlers) maxUploadSize() int64 {
+	if h.MaxUploadSize > 0 {
+		return h.MaxUploadSize
+	}
+	return defaultMaxUploadSize
 }
 
 // RegisterRoutes registers all application routes
@@ -26,17 +57,61 @@ func RegisterRoutes(router *gin.Engine, handlers *Handlers) {
 	v1 := router.Group("/api/v1")
 
 	// Register domain-specific routes
-	registerObjectRoutes(v1, handlers.File)
-	registerBucketRoutes(v1, handlers.Bucket)
+	registerObjectRoutes(v1, handlers.File, handlers.APIKeys, handlers.Policies, handlers.maxUploadSize())
+	registerBucketRoutes(v1, handlers.Bucket, handlers.APIKeys, handlers.Policies)
+	registerPolicySimulatorRoutes(v1, handlers.Simulator, handlers.APIKeys, handlers.Policies)
+	registerObjectVersionRoutes(v1, handlers.Versions, handlers.APIKeys, handlers.Policies)
+	registerLifecycleRoutes(v1, handlers.Lifecycle, handlers.APIKeys, handlers.Policies)
+	registerStorageClassRoutes(v1, handlers.Tiering, handlers.APIKeys, handlers.Policies)
+	registerScrubRoutes(v1, handlers.Scrub, handlers.APIKeys, handlers.Policies)
+	registerObjectLockRoutes(v1, handlers.ObjectLock, handlers.APIKeys, handlers.Policies)
+	registerQuotaRoutes(v1, handlers.Quota, handlers.APIKeys, handlers.Policies)
+	registerReplicationRoutes(v1, handlers.Replication, handlers.APIKeys, handlers.Policies)
+	registerAccessKeyRoutes(v1, handlers.AccessKey, handlers.APIKeys)
 	registerHealthRoutes(v1, handlers.Health)
-	registerWebhookRoutes(v1, handlers.Webhook)
-	registerMultipartRoutes(v1, handlers.Multipart)
+	registerWebhookRoutes(v1, handlers.Webhook, handlers.APIKeys, handlers.Policies)
+	registerMultipartRoutes(v1, handlers.Multipart, handlers.APIKeys, handlers.Policies)
 	registerAnalyticsRoutes(v1, handlers.Analytics)
-	registerPresignRoutes(v1, handlers.Presign)
-	registerBatchRoutes(v1, handlers.Batch)
-	registerSearchRoutes(v1, handlers.Search)
-	registerPrefixRoutes(v1, handlers.Prefix)
+	registerPresignRoutes(v1, handlers.Presign, handlers.APIKeys, handlers.Policies)
+	registerPresignedObjectRoutes(v1, handlers.Presign, handlers.maxUploadSize())
+	registerBatchRoutes(v1, handlers.Batch, handlers.APIKeys, handlers.Policies)
+	registerSearchRoutes(v1, handlers.Search, handlers.APIKeys, handlers.Policies)
+	registerPrefixRoutes(v1, handlers.Prefix, handlers.APIKeys, handlers.Policies)
+
+}
 
+// RegisterS3Routes registers the path-style S3 wire protocol on its own
+// router; bucket names take the first path segment so it cannot share the
+// /api/v1 router. Every request must carry a SigV4 signature and is checked
+// against the bucket policy.
+func RegisterS3Routes(router *gin.Engine, handler *S3Handler, keys middleware.AccessKeyLookup, policies *middleware.PolicyEnforcer, maxUploadSize int64) {
+	if maxUploadSize <= 0 {
+		maxUploadSize = defaultMaxUploadSize
+	}
+	router.Use(func(c *gin.Context) {
+		c.Header("x-amz-request-id", uuid.New().String())
+		c.Header("Server", "s3")
+		c.Next()
+	})
+	router.Use(middleware.SigV4AuthMiddleware(keys))
+	router.Use(s3PolicyMiddleware(policies))
+
+	// Service
+	router.GET("/", handler.ListBuckets)
+
+	// Bucket operations
+	router.GET("/:bucket", handler.BucketGet)
+	router.PUT("/:bucket", handler.CreateBucket)
+	router.HEAD("/:bucket", handler.HeadBucket)
+
+	// Object and multipart operations (dispatched on query parameters)
+	router.GET("/:bucket/*key", handler.ObjectGet)
+	router.HEAD("/:bucket/*key", handler.HeadObject)
+	router.PUT("/:bucket/*key",
+		middleware.MaxFileSizeMiddleware(maxUploadSize),
+		handler.ObjectPut)
+	router.POST("/:bucket/*key", handler.ObjectPost)
+	router.DELETE("/:bucket/*key", handler.ObjectDelete)
 }
 
 // registerHealthRoutes registers all health check routes
@@ -50,55 +125,73 @@ func registerHealthRoutes(v1 *gin.RouterGroup, handler *HandlerForHealth) {
 }
 
 // registerFileRoutes registers all file-related routes
-func registerObjectRoutes(v1 *gin.RouterGroup, handler *HandlerForFiles) {
+func registerObjectRoutes(v1 *gin.RouterGroup, handler *HandlerForFiles, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer, maxUploadSize int64) {
 	object := v1.Group("/files")
-		validator := &middleware.StaticAPIKeyValidator{
-		Keys: map[string]string{
-			"my-secret-api-key": "550e8400-e29b-41d4-a716-446655440000",
-		},
-	}
-
 	object.Use(middleware.APIKeyAuthMiddleware(validator))
 
 	{
-		// Upload file to bucket
+		// Upload file to bucket. AllowedFileTypesMiddleware is not used here:
+		// it parses the form with FormFile, which buffers the whole upload
+		// before the handler runs. The handler applies the same allow-list
+		// to the first 512 bytes of the streamed file part instead.
 		object.POST("/upload/:bucketId",
-			middleware.AllowedFileTypesMiddleware(),
-			middleware.MaxFileSizeMiddleware(10<<20),
+			policies.Require(domain.ActionPutObject),
+			middleware.MaxFileSizeMiddleware(maxUploadSize),
 			handler.UploadFile)
 
 		// List files in bucket
-		object.GET("/:bucketId", handler.ListFiles)
+		object.GET("/:bucketId", policies.Require(domain.ActionListBucket), handler.ListFiles)
 
 		// Get file info/metadata
-		object.GET("/:bucketId/files/:fileId", handler.GetFileInfo)
+		object.GET("/:bucketId/files/:fileId", policies.Require(domain.ActionGetObject), handler.GetFileInfo)
 
 		// // Download file
-		object.GET("/:bucketId/files/:fileId/download", handler.DownloadFile)
+		object.GET("/:bucketId/files/:fileId/download", policies.Require(domain.ActionGetObject), handler.DownloadFile)
 
 		// Delete file
-		object.DELETE("/:bucketId/files/:fileId", handler.DeleteFile)
+		object.DELETE("/:bucketId/files/:fileId", policies.Require(domain.ActionDeleteObject), handler.DeleteFile)
 
 		// Update file metadata
-		object.PATCH("/:bucketId/files/:fileId", handler.UpdateFileMetadata)
+		object.PATCH("/:bucketId/files/:fileId", policies.Require(domain.ActionPutObject), handler.UpdateFileMetadata)
 
 		// Copy file
-		object.POST("/:bucketId/files/:fileId/copy", handler.CopyFile)
+		object.POST("/:bucketId/files/:fileId/copy",
+			policies.Require(domain.ActionGetObject),
+			handler.CopyFile)
 
 		// Move file
-		object.POST("/:bucketId/files/:fileId/move", handler.MoveFile)
+		object.POST("/:bucketId/files/:fileId/move",
+			policies.Require(domain.ActionGetObject, domain.ActionDeleteObject),
+			handler.MoveFile)
 	}
 }
 
-// registerBucketRoutes registers all bucket management routes
-func registerBucketRoutes(v1 *gin.RouterGroup, handler *BucketHandler) {
-	buckets := v1.Group("/buckets")
-	validator := &middleware.StaticAPIKeyValidator{
-		Keys: map[string]string{
-			"my-secret-api-key": "550e8400-e29b-41d4-a716-446655440000",
-		},
+// registerAccessKeyRoutes registers credential management for the calling user
+func registerAccessKeyRoutes(v1 *gin.RouterGroup, handler *AccessKeyHandler, validator middleware.APIKeyValidator) {
+	keys := v1.Group("/iam/keys")
+	keys.Use(middleware.APIKeyAuthMiddleware(validator))
+	{
+		// Issue a new access key (the secret is only returned here)
+		keys.POST("", handler.CreateAccessKey)
+
+		// List the caller's access keys
+		keys.GET("", handler.ListAccessKeys)
+
+		// Enable / disable an access key
+		keys.POST("/:accessKeyId/enable", handler.EnableAccessKey)
+		keys.POST("/:accessKeyId/disable", handler.DisableAccessKey)
+
+		// Replace the secret, keeping the access key id
+		keys.POST("/:accessKeyId/rotate", handler.RotateAccessKey)
+
+		// Delete an access key
+		keys.DELETE("/:accessKeyId", handler.DeleteAccessKey)
 	}
+}
 
+// registerBucketRoutes registers all bucket management routes
+func registerBucketRoutes(v1 *gin.RouterGroup, handler *BucketHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
+	buckets := v1.Group("/buckets")
 	buckets.Use(middleware.APIKeyAuthMiddleware(validator))
 	{
 		// Create new bucket
@@ -106,48 +199,200 @@ func registerBucketRoutes(v1 *gin.RouterGroup, handler *BucketHandler) {
 		// List all buckets
 		buckets.GET("", handler.ListBuckets)
 		// // Get bucket info
-		buckets.GET("/:bucketId", handler.GetBucketInfo)
+		buckets.GET("/:bucketId", policies.Require(domain.ActionListBucket), handler.GetBucketInfo)
 		// // Update bucket settings
-		buckets.PATCH("/:bucketId", handler.UpdateBucket)
+		buckets.PATCH("/:bucketId", policies.Require(domain.ActionPutBucket), handler.UpdateBucket)
 		// // Delete bucket
-		buckets.DELETE("/:bucketId", handler.DeleteBucket)
+		buckets.DELETE("/:bucketId", policies.Require(domain.ActionDeleteBucket), handler.DeleteBucket)
 		// // Get bucket statistics
-		buckets.GET("/:bucketId/stats", handler.GetBucketStats)
+		buckets.GET("/:bucketId/stats", policies.Require(domain.ActionListBucket), handler.GetBucketStats)
 		// // Get bucket policy
-		buckets.GET("/:bucketId/policy", handler.GetBucketPolicy)
+		buckets.GET("/:bucketId/policy", policies.Require(domain.ActionGetBucketPolicy), handler.GetBucketPolicy)
 		// // Update bucket policy
-		buckets.PUT("/:bucketId/policy", handler.UpdateBucketPolicy)
+		buckets.PUT("/:bucketId/policy", policies.Require(domain.ActionPutBucketPolicy), handler.UpdateBucketPolicy)
+		// // Policy version history, diff and rollback
+		buckets.GET("/:bucketId/policy/versions", policies.Require(domain.ActionGetBucketPolicy), handler.ListPolicyVersions)
+		buckets.GET("/:bucketId/policy/versions/:version", policies.Require(domain.ActionGetBucketPolicy), handler.GetPolicyVersion)
+		buckets.GET("/:bucketId/policy/diff", policies.Require(domain.ActionGetBucketPolicy), handler.DiffPolicyVersions)
+		buckets.POST("/:bucketId/policy/rollback", policies.Require(domain.ActionPutBucketPolicy), handler.RollbackBucketPolicy)
 		// // Enable/disable bucket versioning
-		buckets.PUT("/:bucketId/versioning", handler.SetBucketVersioning)
+		buckets.PUT("/:bucketId/versioning", policies.Require(domain.ActionPutBucketVersioning), handler.SetBucketVersioning)
 
-		buckets.GET("/:bucketId/versioning", handler.GetBucketVersioning)
+		buckets.GET("/:bucketId/versioning", policies.Require(domain.ActionGetBucketVersioning), handler.GetBucketVersioning)
 		// // Set bucket lifecycle rules
-		buckets.PUT("/:bucketId/lifecycle", handler.SetBucketLifecycle)
-		buckets.GET("/:bucketId/lifecycle", handler.GetBucketLifecycle)
+		buckets.PUT("/:bucketId/lifecycle", policies.Require(domain.ActionPutLifecycleConfiguration), handler.SetBucketLifecycle)
+		buckets.GET("/:bucketId/lifecycle", policies.Require(domain.ActionGetLifecycleConfiguration), handler.GetBucketLifecycle)
+		// // Default server-side encryption for new objects
+		buckets.PUT("/:bucketId/encryption", policies.Require(domain.ActionPutEncryptionConfiguration), handler.SetBucketEncryption)
+		buckets.GET("/:bucketId/encryption", policies.Require(domain.ActionGetEncryptionConfiguration), handler.GetBucketEncryption)
+
+	}
+}
+
+// registerPolicySimulatorRoutes registers the policy simulator; reading the
+// policy is enough to simulate it.
+func registerPolicySimulatorRoutes(v1 *gin.RouterGroup, handler *PolicySimulatorHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
+	buckets := v1.Group("/buckets")
+	buckets.Use(middleware.APIKeyAuthMiddleware(validator))
+	{
+		buckets.POST("/:bucketId/policy/simulate", policies.Require(domain.ActionGetBucketPolicy), handler.SimulatePolicy)
+	}
+}
+
+// registerObjectVersionRoutes registers the object version history routes.
+// Version routes only learn the object key after the lookup, so their
+// handlers authorize against it themselves.
+func registerObjectVersionRoutes(v1 *gin.RouterGroup, handler *ObjectVersionHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
+	buckets := v1.Group("/buckets")
+	buckets.Use(middleware.APIKeyAuthMiddleware(validator))
+	{
+		// List versions of a key (or of every key)
+		buckets.GET("/:bucketId/versions", policies.Require(domain.ActionListBucketVersions), handler.ListObjectVersions)
+
+		// Version metadata and content
+		buckets.GET("/:bucketId/versions/:versionId", policies.Attach(), handler.GetObjectVersion)
+		buckets.GET("/:bucketId/versions/:versionId/download", policies.Attach(), handler.DownloadObjectVersion)
+
+		// Permanently delete a version or delete marker
+		buckets.DELETE("/:bucketId/versions/:versionId", policies.Attach(), handler.DeleteObjectVersion)
+
+		// Restore a version as the latest
+		buckets.POST("/:bucketId/versions/:versionId/restore", policies.Attach(), handler.RestoreObjectVersion)
+	}
+}
+
+// registerScrubRoutes registers the integrity scrub's manual run and the
+// list of flagged objects
+func registerScrubRoutes(v1 *gin.RouterGroup, handler *ScrubHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
+	buckets := v1.Group("/buckets")
+	buckets.Use(middleware.APIKeyAuthMiddleware(validator))
+	{
+		buckets.POST("/:bucketId/scrub", policies.Require(domain.ActionRunIntegrityScrub), handler.RunScrub)
+		buckets.GET("/:bucketId/scrub/flagged", policies.Require(domain.ActionGetIntegrityReport), handler.FlaggedObjects)
+	}
+}
+
+// registerObjectLockRoutes registers the bucket object lock configuration
+// and the per-object retention and legal hold routes
+func registerObjectLockRoutes(v1 *gin.RouterGroup, handler *ObjectLockHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
+	buckets := v1.Group("/buckets")
+	buckets.Use(middleware.APIKeyAuthMiddleware(validator))
+	{
+		buckets.PUT("/:bucketId/object-lock", policies.Require(domain.ActionPutBucketObjectLockConfiguration), handler.SetBucketObjectLock)
+		buckets.GET("/:bucketId/object-lock", policies.Require(domain.ActionGetBucketObjectLockConfiguration), handler.GetBucketObjectLock)
+	}
+
+	object := v1.Group("/files")
+	object.Use(middleware.APIKeyAuthMiddleware(validator))
+	{
+		object.PUT("/:bucketId/files/:fileId/retention", policies.Require(domain.ActionPutObjectRetention), handler.PutObjectRetention)
+		object.GET("/:bucketId/files/:fileId/retention", policies.Require(domain.ActionGetObjectRetention), handler.GetObjectRetention)
+		object.PUT("/:bucketId/files/:fileId/legal-hold", policies.Require(domain.ActionPutObjectLegalHold), handler.PutObjectLegalHold)
+		object.GET("/:bucketId/files/:fileId/legal-hold", policies.Require(domain.ActionGetObjectLegalHold), handler.GetObjectLegalHold)
+	}
+}
+
+// registerQuotaRoutes registers the bucket quota routes and the per-owner
+// quotas, which only admins may set
+func registerQuotaRoutes(v1 *gin.RouterGroup, handler *QuotaHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
+	buckets := v1.Group("/buckets")
+	buckets.Use(middleware.APIKeyAuthMiddleware(validator))
+	{
+		buckets.PUT("/:bucketId/quota", policies.Require(domain.ActionPutBucketQuota), handler.SetBucketQuota)
+		buckets.GET("/:bucketId/quota", policies.Require(domain.ActionGetBucketQuota), handler.GetBucketQuota)
+	}
+
+	owners := v1.Group("/quotas/owners")
+	owners.Use(middleware.APIKeyAuthMiddleware(validator))
+	{
+		owners.PUT("/:ownerId", handler.SetOwnerQuota)
+		owners.GET("/:ownerId", handler.GetOwnerQuota)
+	}
+}
+
+// registerReplicationRoutes registers the replication rules, the task
+// queue and the backfill and retry commands
+func registerReplicationRoutes(v1 *gin.RouterGroup, handler *ReplicationHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
+	buckets := v1.Group("/buckets")
+	buckets.Use(middleware.APIKeyAuthMiddleware(validator))
+	{
+		buckets.PUT("/:bucketId/replication", policies.Require(domain.ActionPutReplicationConfiguration), handler.SetReplication)
+		buckets.GET("/:bucketId/replication", policies.Require(domain.ActionGetReplicationConfiguration), handler.GetReplication)
+		buckets.GET("/:bucketId/replication/tasks", policies.Require(domain.ActionGetReplicationConfiguration), handler.ReplicationTasks)
+
+		// Queue objects stored before the rules existed
+		buckets.POST("/:bucketId/replication/backfill", policies.Require(domain.ActionPutReplicationConfiguration), handler.BackfillReplication)
 
+		// Give failed tasks a fresh set of attempts
+		buckets.POST("/:bucketId/replication/retry", policies.Require(domain.ActionPutReplicationConfiguration), handler.RetryReplication)
+	}
+}
+
+// registerLifecycleRoutes registers the lifecycle worker's report, manual
+// run and history routes
+func registerLifecycleRoutes(v1 *gin.RouterGroup, handler *LifecycleHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
+	buckets := v1.Group("/buckets")
+	buckets.Use(middleware.APIKeyAuthMiddleware(validator))
+	{
+		// Dry run: what the rules would do right now
+		buckets.GET("/:bucketId/lifecycle/report", policies.Require(domain.ActionGetLifecycleConfiguration), handler.LifecycleReport)
+
+		// Apply the rules now
+		buckets.POST("/:bucketId/lifecycle/run", policies.Require(domain.ActionPutLifecycleConfiguration), handler.RunLifecycle)
+
+		// Actions taken by past runs
+		buckets.GET("/:bucketId/lifecycle/history", policies.Require(domain.ActionGetLifecycleConfiguration), handler.LifecycleHistory)
+	}
+}
+
+// registerStorageClassRoutes registers the cold storage restore route
+func registerStorageClassRoutes(v1 *gin.RouterGroup, handler *StorageClassHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
+	object := v1.Group("/files")
+	object.Use(middleware.APIKeyAuthMiddleware(validator))
+	{
+		// Temporary hot copy of a cold object
+		object.POST("/:bucketId/files/:fileId/restore", policies.Require(domain.ActionRestoreObject), handler.RestoreFile)
 	}
 }
 
 // TODO: IMPLEMENT MILTIPART FOR PRESIGNED URLS
-func registerPresignRoutes(v1 *gin.RouterGroup, handler *PresignHandler) {
+func registerPresignRoutes(v1 *gin.RouterGroup, handler *PresignHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
 	presign := v1.Group("/presign")
+	presign.Use(middleware.APIKeyAuthMiddleware(validator))
 	{
 		// Generate presigned URL for upload
-		presign.POST("/:bucketId/upload", handler.GenerateUploadURL)
+		presign.POST("/:bucketId/upload", policies.Require(domain.ActionPutObject), handler.GenerateUploadURL)
 
 		// 		// Generate presigned URL for download
-		presign.POST("/:bucketId/files/:fileId/download", handler.GenerateDownloadURL)
+		presign.POST("/:bucketId/files/:fileId/download", policies.Require(domain.ActionGetObject), handler.GenerateDownloadURL)
 
 		// 		// Revoke presigned URL
-		presign.DELETE("/urls/:urlId", handler.RevokePresignedURL)
+		presign.DELETE("/urls/:urlId", policies.Attach(), handler.RevokePresignedURL)
 
 		// 		// List active presigned URLs
-		presign.GET("/urls", handler.ListPresignedURLs)
+		presign.GET("/urls", policies.Attach(), handler.ListPresignedURLs)
 
 		// 		// Validate presigned URL
-		presign.POST("/validate", handler.ValidatePresignedURL)
+		presign.POST("/validate", policies.Attach(), handler.ValidatePresignedURL)
 		// 		// Generate presigned URL for multipart upload
-		presign.POST("/:bucketId/multipart", handler.GenerateMultipartUploadURLs)
+		presign.POST("/:bucketId/multipart", policies.Require(domain.ActionPutObject), handler.GenerateMultipartUploadURLs)
+	}
+}
+
+// registerPresignedObjectRoutes registers the public routes that serve the
+// URLs generated by PresignService. The signature in the query string is the
+// credential, so no API key middleware is applied.
+func registerPresignedObjectRoutes(v1 *gin.RouterGroup, handler *PresignHandler, maxUploadSize int64) {
+	objects := v1.Group("/buckets/:bucketId/objects")
+	{
+		// Download / inspect an object
+		objects.GET("/*key", handler.GetPresignedObject)
+		objects.HEAD("/*key", handler.GetPresignedObject)
+
+		// Upload an object
+		objects.PUT("/*key",
+			middleware.MaxFileSizeMiddleware(maxUploadSize),
+			handler.PutPresignedObject)
 	}
 }
 
@@ -155,8 +400,11 @@ func registerPresignRoutes(v1 *gin.RouterGroup, handler *PresignHandler) {
 
 
 
-func registerBatchRoutes(v1 *gin.RouterGroup, handler *BatchHandler) {
+// Batch requests name their buckets in the body, so the handlers authorize
+// each item themselves.
+func registerBatchRoutes(v1 *gin.RouterGroup, handler *BatchHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
 	batch := v1.Group("/batch")
+	batch.Use(middleware.APIKeyAuthMiddleware(validator), policies.Attach())
 	{
 		// Batch upload files
 		batch.POST("/upload", handler.BatchUpload)
@@ -185,35 +433,39 @@ func registerBatchRoutes(v1 *gin.RouterGroup, handler *BatchHandler) {
 }
 
 // registerPrefixRoutes registers prefix-based operation routes
-func registerPrefixRoutes(v1 *gin.RouterGroup, handler *PrefixHandler) {
+func registerPrefixRoutes(v1 *gin.RouterGroup, handler *PrefixHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
 	prefix := v1.Group("/prefix")
+	prefix.Use(middleware.APIKeyAuthMiddleware(validator))
 	{
 		// List files by prefix
-		prefix.GET("/:bucketId/list", handler.ListByPrefix)
+		prefix.GET("/:bucketId/list", policies.Require(domain.ActionListBucket), handler.ListByPrefix)
 
 		// Delete files by prefix
-		prefix.DELETE("/:bucketId/delete", handler.DeleteByPrefix)
+		prefix.DELETE("/:bucketId/delete", policies.Require(domain.ActionDeleteObject), handler.DeleteByPrefix)
 
 		// Copy files by prefix
-		prefix.POST("/:bucketId/copy", handler.CopyByPrefix)
+		prefix.POST("/:bucketId/copy", policies.Require(domain.ActionGetObject, domain.ActionPutObject), handler.CopyByPrefix)
 
 		// Get total size of files by prefix
-		prefix.GET("/:bucketId/size", handler.GetSizeByPrefix)
+		prefix.GET("/:bucketId/size", policies.Require(domain.ActionListBucket), handler.GetSizeByPrefix)
 
 		// Count files by prefix
-		prefix.GET("/:bucketId/count", handler.CountByPrefix)
+		prefix.GET("/:bucketId/count", policies.Require(domain.ActionListBucket), handler.CountByPrefix)
 
 		// Archive files by prefix (zip/tar)
-		prefix.POST("/:bucketId/archive", handler.ArchiveByPrefix)
+		prefix.POST("/:bucketId/archive", policies.Require(domain.ActionGetObject, domain.ActionPutObject), handler.ArchiveByPrefix)
 
 		// Set metadata for files by prefix
-		prefix.PATCH("/:bucketId/metadata", handler.SetMetadataByPrefix)
+		prefix.PATCH("/:bucketId/metadata", policies.Require(domain.ActionPutObject), handler.SetMetadataByPrefix)
 	}
 }
 
 // registerSearchRoutes registers search and query routes
-func registerSearchRoutes(v1 *gin.RouterGroup, handler *SearchHandler) {
+// Searches may span buckets; the handlers drop results from buckets the
+// actor cannot list.
+func registerSearchRoutes(v1 *gin.RouterGroup, handler *SearchHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
 	search := v1.Group("/search")
+	search.Use(middleware.APIKeyAuthMiddleware(validator), policies.Attach())
 	{
 		// Search files by name
 		search.GET("/files", handler.SearchFiles)
@@ -270,51 +522,66 @@ func registerAnalyticsRoutes(v1 *gin.RouterGroup, handler *AnalyticsHandler) {
 	}
 }
 
-func registerMultipartRoutes(v1 *gin.RouterGroup, handler *MultipartHandler) {
+func registerMultipartRoutes(v1 *gin.RouterGroup, handler *MultipartHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
 	multipart := v1.Group("/multipart")
+	multipart.Use(middleware.APIKeyAuthMiddleware(validator))
 	{
 		// Initiate multipart upload
-		multipart.POST("/:bucketId/initiate", handler.InitiateMultipartUpload)
+		multipart.POST("/:bucketId/initiate", policies.Require(domain.ActionPutObject), handler.InitiateMultipartUpload)
 
 		// Upload a part
-		multipart.PUT("/:bucketId/:uploadId/parts/:partNumber", handler.UploadPart)
+		multipart.PUT("/:bucketId/:uploadId/parts/:partNumber", policies.Require(domain.ActionPutObject), handler.UploadPart)
 
 		// Complete multipart upload
-		multipart.POST("/:bucketId/:uploadId/complete", handler.CompleteMultipartUpload)
+		multipart.POST("/:bucketId/:uploadId/complete", policies.Require(domain.ActionPutObject), handler.CompleteMultipartUpload)
 
 		// Abort multipart upload
-		multipart.DELETE("/:bucketId/:uploadId", handler.AbortMultipartUpload)
+		multipart.DELETE("/:bucketId/:uploadId", policies.Require(domain.ActionAbortMultipartUpload), handler.AbortMultipartUpload)
 
 		// List parts of multipart upload
-		multipart.GET("/:bucketId/:uploadId/parts", handler.ListParts)
+		multipart.GET("/:bucketId/:uploadId/parts", policies.Require(domain.ActionListMultipartUploadParts), handler.ListParts)
 
 		// List in-progress multipart uploads
-		multipart.GET("/:bucketId/uploads", handler.ListMultipartUploads)
+		multipart.GET("/:bucketId/uploads", policies.Require(domain.ActionListBucketMultipartUploads), handler.ListMultipartUploads)
 	}
 }
 
-func registerWebhookRoutes(v1 *gin.RouterGroup, handler *WebhookHandler) {
+func registerWebhookRoutes(v1 *gin.RouterGroup, handler *WebhookHandler, validator middleware.APIKeyValidator, policies *middleware.PolicyEnforcer) {
 	webhooks := v1.Group("/webhooks")
+	webhooks.Use(middleware.APIKeyAuthMiddleware(validator), policies.Attach())
 	{
-		// Create webhook
+		read := handler.Authorize(domain.ActionGetBucketNotification)
+		write := handler.Authorize(domain.ActionPutBucketNotification)
+
+		// Create webhook (the bucket is checked once the body is read)
 		webhooks.POST("", handler.CreateWebhook)
 
 		// List webhooks for a bucket
-		webhooks.GET("/bucket/:bucketId", handler.ListWebhooks)
+		webhooks.GET("/bucket/:bucketId", policies.Require(domain.ActionGetBucketNotification), handler.ListWebhooks)
 
 		// Get webhook details
-		webhooks.GET("/:webhookId", handler.GetWebhook)
+		webhooks.GET("/:webhookId", read, handler.GetWebhook)
 
 		// Update webhook
-		webhooks.PATCH("/:webhookId", handler.UpdateWebhook)
+		webhooks.PATCH("/:webhookId", write, handler.UpdateWebhook)
 
 		// Delete webhook
-		webhooks.DELETE("/:webhookId", handler.DeleteWebhook)
+		webhooks.DELETE("/:webhookId", write, handler.DeleteWebhook)
+
+		// Rotate webhook secret
+		webhooks.POST("/:webhookId/rotate-secret", write, handler.RotateWebhookSecret)
 
 		// Test webhook
-		webhooks.POST("/:webhookId/test", handler.TestWebhook)
+		webhooks.POST("/:webhookId/test", write, handler.TestWebhook)
 
 		// Get webhook delivery logs
-		webhooks.GET("/:webhookId/deliveries", handler.GetWebhookDeliveries)
+		webhooks.GET("/:webhookId/deliveries", read, handler.GetWebhookDeliveries)
+
+		// Send a logged delivery again
+		webhooks.POST("/:webhookId/deliveries/:deliveryId/redeliver", write, handler.RedeliverWebhook)
+
+		// Replay dead-lettered events, or every event in a time window
+		webhooks.POST("/:webhookId/replay/failed", write, handler.ReplayFailedWebhooks)
+		webhooks.POST("/:webhookId/replay", write, handler.ReplayWebhooks)
 	}
 }
@@ -0,0 +1,590 @@
+package http
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"s3/internal/application"
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// S3Handler serves the path-style S3 wire protocol (/:bucket/*key) on top of
+// the same application services as the JSON API, so files written through
+// either API end up in the Postgres metadata.
+type S3Handler struct {
+	bucketService    *application.BucketService
+	uploadService    *application.UploadService
+	deleteService    *application.DeleteService
+	prefixService    *application.PrefixService
+	multipartService *application.MultipartService
+}
+
+func NewS3Handler(
+	bucketService *application.BucketService,
+	uploadService *application.UploadService,
+	deleteService *application.DeleteService,
+	prefixService *application.PrefixService,
+	multipartService *application.MultipartService,
+) *S3Handler {
+	return &S3Handler{
+		bucketService:    bucketService,
+		uploadService:    uploadService,
+		deleteService:    deleteService,
+		prefixService:    prefixService,
+		multipartService: multipartService,
+	}
+}
+
+// ListBuckets handles GET /
+func (h *S3Handler) ListBuckets(c *gin.Context) {
+	buckets, err := h.bucketService.ListBuckets(c.Request.Context())
+	if err != nil {
+		writeS3ServiceError(c, err)
+		return
+	}
+
+	result := s3ListAllMyBucketsResult{Xmlns: s3Namespace}
+	for _, bucket := range buckets {
+		result.Buckets = append(result.Buckets, s3Bucket{
+			Name:         bucket.Name,
+			CreationDate: s3Time(bucket.CreatedAt),
+		})
+	}
+
+	writeS3XML(c, http.StatusOK, result)
+}
+
+// BucketGet dispatches GET /:bucket to ListObjectsV2, ListMultipartUploads or
+// GetBucketLocation depending on the query string.
+func (h *S3Handler) BucketGet(c *gin.Context) {
+	bucketName := c.Param("bucket")
+
+	if _, ok := c.GetQuery("location"); ok {
+		if _, err := h.bucketService.GetBucketByName(c.Request.Context(), bucketName); err != nil {
+			writeS3ServiceError(c, err)
+			return
+		}
+		writeS3XML(c, http.StatusOK, s3LocationConstraint{Xmlns: s3Namespace})
+		return
+	}
+
+	if _, ok := c.GetQuery("uploads"); ok {
+		h.listMultipartUploads(c, bucketName)
+		return
+	}
+
+	h.listObjectsV2(c, bucketName)
+}
+
+// CreateBucket handles PUT /:bucket
+func (h *S3Handler) CreateBucket(c *gin.Context) {
+	bucketName := c.Param("bucket")
+
+	if _, err := h.bucketService.GetBucketByName(c.Request.Context(), bucketName); err == nil {
+		writeS3Error(c, http.StatusConflict, "BucketAlreadyOwnedByYou", "bucket already exists: "+bucketName)
+		return
+	}
+
+	if _, err := h.bucketService.CreateBucket(c.Request.Context(), dto.CreateBucketInput{
+		Name:    bucketName,
+		OwnerId: actorUserID(c),
+	}); err != nil {
+		writeS3ServiceError(c, err)
+		return
+	}
+
+	c.Header("Location", "/"+bucketName)
+	c.Status(http.StatusOK)
+}
+
+// HeadBucket handles HEAD /:bucket
+func (h *S3Handler) HeadBucket(c *gin.Context) {
+	if _, err := h.bucketService.GetBucketByName(c.Request.Context(), c.Param("bucket")); err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// ObjectPut dispatches PUT /:bucket/*key to UploadPart, CopyObject or PutObject
+func (h *S3Handler) ObjectPut(c *gin.Context) {
+	bucketName, key := c.Param("bucket"), s3Key(c)
+	if key == "" {
+		h.CreateBucket(c)
+		return
+	}
+
+	if uploadID := c.Query("uploadId"); uploadID != "" {
+		h.uploadPart(c, bucketName, key, uploadID)
+		return
+	}
+
+	if source := c.GetHeader("x-amz-copy-source"); source != "" {
+		h.copyObject(c, bucketName, key, source)
+		return
+	}
+
+	if c.Request.ContentLength < 0 {
+		writeS3Error(c, http.StatusLengthRequired, "MissingContentLength", "Content-Length is required")
+		return
+	}
+
+	output, err := h.uploadService.UploadFile(c.Request.Context(), application.UploadFileInput{
+		BucketID:   bucketName,
+		Key:        key,
+		Body:       c.Request.Body,
+		Size:       c.Request.ContentLength,
+		MimeType:   c.GetHeader("Content-Type"),
+		Metadata:   s3UserMetadata(c.Request.Header),
+		Encryption: sseInput(c.Request.Header),
+		Checksums:  checksumInput(c.Request.Header),
+	})
+	if err != nil {
+		writeS3ServiceError(c, err)
+		return
+	}
+
+	setSSEHeaders(c, output.Encryption, output.SSECustomerKeyMD5)
+	setS3ChecksumHeaders(c, output.ChecksumSHA256, output.ChecksumCRC32C)
+	c.Header("ETag", fmt.Sprintf("%q", output.ETag))
+	c.Status(http.StatusOK)
+}
+
+// ObjectGet dispatches GET /:bucket/*key to ListParts or GetObject
+func (h *S3Handler) ObjectGet(c *gin.Context) {
+	bucketName, key := c.Param("bucket"), s3Key(c)
+	if key == "" {
+		h.BucketGet(c)
+		return
+	}
+
+	if uploadID := c.Query("uploadId"); uploadID != "" {
+		h.listParts(c, bucketName, uploadID)
+		return
+	}
+
+	metadata, ok := h.statObject(c, bucketName, key)
+	if !ok {
+		return
+	}
+
+	// S3 serves a single range; multi-range requests get the whole object
+	var r *byteRange
+	if ifRangeAllows(c.Request, metadata.ETag, metadata.LastModified) {
+		ranges, err := parseRange(c.GetHeader("Range"), metadata.Size)
+		if err != nil {
+			c.Header("Content-Range", fmt.Sprintf("bytes */%d", metadata.Size))
+			writeS3Error(c, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", err.Error())
+			return
+		}
+		if len(ranges) == 1 {
+			r = &ranges[0]
+		}
+	}
+
+	status, offset, length := http.StatusOK, int64(0), int64(-1)
+	contentLength := metadata.Size
+	extraHeaders := map[string]string{}
+	if r != nil {
+		status, offset, length = http.StatusPartialContent, r.start, r.length
+		contentLength = r.length
+		extraHeaders["Content-Range"] = r.contentRange(metadata.Size)
+	}
+
+	body, err := h.uploadService.GetObjectRange(c.Request.Context(), bucketName, key, offset, length, sseInput(c.Request.Header))
+	if err != nil {
+		writeS3ServiceError(c, err)
+		return
+	}
+	defer body.Close()
+
+	c.DataFromReader(status, contentLength, s3ContentType(metadata), body, extraHeaders)
+}
+
+// HeadObject handles HEAD /:bucket/*key
+func (h *S3Handler) HeadObject(c *gin.Context) {
+	bucketName, key := c.Param("bucket"), s3Key(c)
+	if key == "" {
+		h.HeadBucket(c)
+		return
+	}
+
+	metadata, ok := h.statObject(c, bucketName, key)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", s3ContentType(metadata))
+	c.Header("Content-Length", strconv.FormatInt(metadata.Size, 10))
+	c.Status(http.StatusOK)
+}
+
+// ObjectDelete dispatches DELETE /:bucket/*key to AbortMultipartUpload or
+// DeleteObject
+func (h *S3Handler) ObjectDelete(c *gin.Context) {
+	bucketName, key := c.Param("bucket"), s3Key(c)
+	if key == "" {
+		writeS3Error(c, http.StatusNotImplemented, "NotImplemented", "DeleteBucket is not supported")
+		return
+	}
+
+	if uploadID := c.Query("uploadId"); uploadID != "" {
+		bucket, err := h.bucketService.GetBucketByName(c.Request.Context(), bucketName)
+		if err != nil {
+			writeS3ServiceError(c, err)
+			return
+		}
+		if err := h.multipartService.AbortMultipartUpload(c.Request.Context(), bucket.BucketID, uploadID); err != nil {
+			writeS3ServiceError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	// Deleting a missing key succeeds, as in S3
+	err := h.deleteService.DeleteObject(c.Request.Context(), bucketName, key, bypassGovernance(c, bucketName, key))
+	if err != nil && !errors.Is(err, application.ErrObjectNotFound) {
+		writeS3ServiceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ObjectPost dispatches POST /:bucket/*key to CreateMultipartUpload or
+// CompleteMultipartUpload
+func (h *S3Handler) ObjectPost(c *gin.Context) {
+	bucketName, key := c.Param("bucket"), s3Key(c)
+
+	bucket, err := h.bucketService.GetBucketByName(c.Request.Context(), bucketName)
+	if err != nil {
+		writeS3ServiceError(c, err)
+		return
+	}
+
+	if _, ok := c.GetQuery("uploads"); ok {
+		output, err := h.multipartService.InitiateMultipartUpload(c.Request.Context(), dto.InitiateMultipartUploadInput{
+			BucketID:    bucket.BucketID,
+			Key:         key,
+			ContentType: c.GetHeader("Content-Type"),
+			Metadata:    s3UserMetadata(c.Request.Header),
+			Encryption:  sseInput(c.Request.Header),
+			Checksums:   checksumInput(c.Request.Header),
+		})
+		if err != nil {
+			writeS3ServiceError(c, err)
+			return
+		}
+
+		setSSEHeaders(c, output.Encryption, "")
+		writeS3XML(c, http.StatusOK, s3InitiateMultipartUploadResult{
+			Xmlns:    s3Namespace,
+			Bucket:   bucketName,
+			Key:      key,
+			UploadID: output.UploadID,
+		})
+		return
+	}
+
+	if uploadID := c.Query("uploadId"); uploadID != "" {
+		var request s3CompleteMultipartUpload
+		if err := xml.NewDecoder(c.Request.Body).Decode(&request); err != nil {
+			writeS3Error(c, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+
+		input := dto.CompleteMultipartUploadInput{BucketID: bucket.BucketID, UploadID: uploadID}
+		for _, part := range request.Parts {
+			input.Parts = append(input.Parts, dto.Part{PartNumber: part.PartNumber, ETag: part.ETag})
+		}
+
+		output, err := h.multipartService.CompleteMultipartUpload(c.Request.Context(), input)
+		if err != nil {
+			writeS3ServiceError(c, err)
+			return
+		}
+
+		writeS3XML(c, http.StatusOK, s3CompleteMultipartUploadResult{
+			Xmlns:    s3Namespace,
+			Location: output.Location,
+			Bucket:   bucketName,
+			Key:      output.Key,
+			ETag:     fmt.Sprintf("%q", output.ETag),
+		})
+		return
+	}
+
+	writeS3Error(c, http.StatusNotImplemented, "NotImplemented", "unsupported POST operation")
+}
+
+func (h *S3Handler) listObjectsV2(c *gin.Context, bucketName string) {
+	maxKeys := 1000
+	if value := c.Query("max-keys"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			writeS3Error(c, http.StatusBadRequest, "InvalidArgument", "invalid max-keys")
+			return
+		}
+		maxKeys = n
+	}
+
+	input := dto.ListObjectsInput{
+		BucketName:        bucketName,
+		Prefix:            c.Query("prefix"),
+		Delimiter:         c.Query("delimiter"),
+		StartAfter:        c.Query("start-after"),
+		ContinuationToken: c.Query("continuation-token"),
+		MaxKeys:           maxKeys,
+	}
+	// ListObjects (v1) pages with marker; treat it as start-after
+	if input.StartAfter == "" {
+		input.StartAfter = c.Query("marker")
+	}
+
+	result := s3ListBucketResult{
+		Xmlns:             s3Namespace,
+		Name:              bucketName,
+		Prefix:            input.Prefix,
+		Delimiter:         input.Delimiter,
+		StartAfter:        input.StartAfter,
+		MaxKeys:           maxKeys,
+		ContinuationToken: input.ContinuationToken,
+	}
+
+	if maxKeys > 0 {
+		output, err := h.prefixService.ListObjectsV2(c.Request.Context(), input)
+		if err != nil {
+			writeS3ServiceError(c, err)
+			return
+		}
+
+		for _, object := range output.Objects {
+			result.Contents = append(result.Contents, s3Object{
+				Key:          object.Key,
+				LastModified: s3Time(object.CreatedAt),
+				Size:         object.Size,
+				StorageClass: object.StorageClass,
+			})
+		}
+		for _, prefix := range output.CommonPrefixes {
+			result.CommonPrefixes = append(result.CommonPrefixes, s3CommonPrefix{Prefix: prefix})
+		}
+		result.KeyCount = len(result.Contents) + len(result.CommonPrefixes)
+		result.IsTruncated = output.IsTruncated
+		result.NextContinuationToken = output.NextContinuationToken
+	}
+
+	writeS3XML(c, http.StatusOK, result)
+}
+
+func (h *S3Handler) listMultipartUploads(c *gin.Context, bucketName string) {
+	bucket, err := h.bucketService.GetBucketByName(c.Request.Context(), bucketName)
+	if err != nil {
+		writeS3ServiceError(c, err)
+		return
+	}
+
+	output, err := h.multipartService.ListMultipartUploads(c.Request.Context(), bucket.BucketID)
+	if err != nil {
+		writeS3ServiceError(c, err)
+		return
+	}
+
+	result := s3ListMultipartUploadsResult{Xmlns: s3Namespace, Bucket: bucketName}
+	for _, upload := range output.Uploads {
+		result.Uploads = append(result.Uploads, s3Upload{
+			Key:       upload.Key,
+			UploadID:  upload.UploadID,
+			Initiated: s3Time(upload.CreatedAt),
+		})
+	}
+
+	writeS3XML(c, http.StatusOK, result)
+}
+
+func (h *S3Handler) listParts(c *gin.Context, bucketName, uploadID string) {
+	bucket, err := h.bucketService.GetBucketByName(c.Request.Context(), bucketName)
+	if err != nil {
+		writeS3ServiceError(c, err)
+		return
+	}
+
+	output, err := h.multipartService.ListParts(c.Request.Context(), bucket.BucketID, uploadID)
+	if err != nil {
+		writeS3ServiceError(c, err)
+		return
+	}
+
+	result := s3ListPartsResult{
+		Xmlns:    s3Namespace,
+		Bucket:   bucketName,
+		Key:      output.Key,
+		UploadID: output.UploadID,
+	}
+	for _, part := range output.Parts {
+		result.Parts = append(result.Parts, s3Part{
+			PartNumber:   part.PartNumber,
+			LastModified: s3Time(part.UploadedAt),
+			ETag:         fmt.Sprintf("%q", part.ETag),
+			Size:         part.Size,
+		})
+	}
+
+	writeS3XML(c, http.StatusOK, result)
+}
+
+func (h *S3Handler) uploadPart(c *gin.Context, bucketName, key, uploadID string) {
+	partNumber, err := strconv.Atoi(c.Query("partNumber"))
+	if err != nil {
+		writeS3Error(c, http.StatusBadRequest, "InvalidArgument", "invalid partNumber")
+		return
+	}
+	if c.Request.ContentLength < 0 {
+		writeS3Error(c, http.StatusLengthRequired, "MissingContentLength", "Content-Length is required")
+		return
+	}
+
+	bucket, err := h.bucketService.GetBucketByName(c.Request.Context(), bucketName)
+	if err != nil {
+		writeS3ServiceError(c, err)
+		return
+	}
+
+	output, err := h.multipartService.UploadPart(c.Request.Context(), dto.UploadPartInput{
+		BucketID:   bucket.BucketID,
+		UploadID:   uploadID,
+		PartNumber: partNumber,
+		Body:       c.Request.Body,
+		Size:       c.Request.ContentLength,
+	})
+	if err != nil {
+		writeS3ServiceError(c, err)
+		return
+	}
+
+	c.Header("ETag", fmt.Sprintf("%q", output.ETag))
+	c.Status(http.StatusOK)
+}
+
+func (h *S3Handler) copyObject(c *gin.Context, bucketName, key, source string) {
+	srcBucket, srcKey, ok := parseCopySource(source)
+	if !ok {
+		writeS3Error(c, http.StatusBadRequest, "InvalidArgument", "invalid x-amz-copy-source")
+		return
+	}
+
+	output, err := h.uploadService.CopyObject(c.Request.Context(), srcBucket, srcKey, bucketName, key, copySourceSSEInput(c.Request.Header))
+	if err != nil {
+		writeS3ServiceError(c, err)
+		return
+	}
+
+	writeS3XML(c, http.StatusOK, s3CopyObjectResult{
+		Xmlns:        s3Namespace,
+		ETag:         fmt.Sprintf("%q", output.ETag),
+		LastModified: s3Time(output.LastModified),
+	})
+}
+
+// statObject looks the object up, writes the common object headers and
+// evaluates conditional headers. It returns false when the response has
+// already been written.
+func (h *S3Handler) statObject(c *gin.Context, bucketName, key string) (*dto.FileInfoOutput, bool) {
+	metadata, err := h.uploadService.StatObject(c.Request.Context(), bucketName, key, sseInput(c.Request.Header))
+	if err != nil {
+		if c.Request.Method == http.MethodHead {
+			c.Status(s3ErrorStatus(err))
+			return nil, false
+		}
+		writeS3ServiceError(c, err)
+		return nil, false
+	}
+
+	c.Header("ETag", fmt.Sprintf("%q", metadata.ETag))
+	c.Header("Last-Modified", metadata.LastModified.UTC().Format(http.TimeFormat))
+	c.Header("Accept-Ranges", "bytes")
+	setSSEHeaders(c, metadata.Encryption, metadata.SSECustomerKeyMD5)
+	setS3ChecksumHeaders(c, metadata.ChecksumSHA256, metadata.ChecksumCRC32C)
+	for k, v := range metadata.Metadata {
+		c.Header("x-amz-meta-"+k, v)
+	}
+	if metadata.StorageClass != "" && metadata.StorageClass != domain.StorageClassStandard {
+		c.Header("x-amz-storage-class", metadata.StorageClass)
+	}
+	if metadata.RestoreExpiresAt != nil && metadata.RestoreExpiresAt.After(time.Now()) {
+		c.Header("x-amz-restore", fmt.Sprintf(`ongoing-request="false", expiry-date="%s"`,
+			metadata.RestoreExpiresAt.UTC().Format(http.TimeFormat)))
+	}
+	if metadata.ReplicationStatus != "" {
+		c.Header("x-amz-replication-status", metadata.ReplicationStatus)
+	}
+
+	if status := checkPreconditions(c.Request, metadata.ETag, metadata.LastModified); status != 0 {
+		c.Status(status)
+		return nil, false
+	}
+
+	return metadata, true
+}
+
+func s3ErrorStatus(err error) int {
+	if errors.Is(err, application.ErrBucketNotFound) || errors.Is(err, application.ErrObjectNotFound) {
+		return http.StatusNotFound
+	}
+	if status, ok := sseErrorStatus(err); ok {
+		return status
+	}
+	if status, ok := lockErrorStatus(err); ok {
+		return status
+	}
+	if status, ok := quotaErrorStatus(err); ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// s3Key returns the object key of a /:bucket/*key route without its leading slash
+func s3Key(c *gin.Context) string {
+	return strings.TrimPrefix(c.Param("key"), "/")
+}
+
+// s3UserMetadata collects x-amz-meta-* headers into an object metadata map
+func s3UserMetadata(header http.Header) map[string]string {
+	metadata := map[string]string{}
+	for name, values := range header {
+		if k, ok := strings.CutPrefix(strings.ToLower(name), "x-amz-meta-"); ok && len(values) > 0 {
+			metadata[k] = values[0]
+		}
+	}
+	return metadata
+}
+
+// parseCopySource splits an x-amz-copy-source header ("/bucket/key" or
+// "bucket/key", URL-encoded) into bucket and key.
+func parseCopySource(source string) (string, string, bool) {
+	source, _, _ = strings.Cut(source, "?versionId=")
+	decoded, err := url.PathUnescape(source)
+	if err != nil {
+		return "", "", false
+	}
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(decoded, "/"), "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", false
+	}
+	return bucket, key, true
+}
+
+func s3ContentType(metadata *dto.FileInfoOutput) string {
+	if metadata.MimeType == "" {
+		return "application/octet-stream"
+	}
+	return metadata.MimeType
+}
@@ -0,0 +1,199 @@
+package http
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"s3/internal/application"
+	"s3/internal/middleware"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// s3TimeFormat is the timestamp layout used inside S3 XML documents
+const s3TimeFormat = "2006-01-02T15:04:05.000Z"
+
+type s3Owner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+type s3Bucket struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+type s3ListAllMyBucketsResult struct {
+	XMLName xml.Name   `xml:"ListAllMyBucketsResult"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Owner   s3Owner    `xml:"Owner"`
+	Buckets []s3Bucket `xml:"Buckets>Bucket"`
+}
+
+type s3Object struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag,omitempty"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type s3CommonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type s3ListBucketResult struct {
+	XMLName               xml.Name         `xml:"ListBucketResult"`
+	Xmlns                 string           `xml:"xmlns,attr"`
+	Name                  string           `xml:"Name"`
+	Prefix                string           `xml:"Prefix"`
+	Delimiter             string           `xml:"Delimiter,omitempty"`
+	StartAfter            string           `xml:"StartAfter,omitempty"`
+	MaxKeys               int              `xml:"MaxKeys"`
+	KeyCount              int              `xml:"KeyCount"`
+	IsTruncated           bool             `xml:"IsTruncated"`
+	ContinuationToken     string           `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string           `xml:"NextContinuationToken,omitempty"`
+	Contents              []s3Object       `xml:"Contents"`
+	CommonPrefixes        []s3CommonPrefix `xml:"CommonPrefixes"`
+}
+
+type s3LocationConstraint struct {
+	XMLName xml.Name `xml:"LocationConstraint"`
+	Xmlns   string   `xml:"xmlns,attr"`
+}
+
+type s3CopyObjectResult struct {
+	XMLName      xml.Name `xml:"CopyObjectResult"`
+	Xmlns        string   `xml:"xmlns,attr"`
+	ETag         string   `xml:"ETag"`
+	LastModified string   `xml:"LastModified"`
+}
+
+type s3InitiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name `xml:"CompleteMultipartUpload"`
+	Parts   []struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	} `xml:"Part"`
+}
+
+type s3CompleteMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
+type s3Part struct {
+	PartNumber   int    `xml:"PartNumber"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+}
+
+type s3ListPartsResult struct {
+	XMLName  xml.Name `xml:"ListPartsResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+	Parts    []s3Part `xml:"Part"`
+}
+
+type s3Upload struct {
+	Key       string `xml:"Key"`
+	UploadID  string `xml:"UploadId"`
+	Initiated string `xml:"Initiated"`
+}
+
+type s3ListMultipartUploadsResult struct {
+	XMLName xml.Name   `xml:"ListMultipartUploadsResult"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Bucket  string     `xml:"Bucket"`
+	Uploads []s3Upload `xml:"Upload"`
+}
+
+type s3ErrorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	Resource  string   `xml:"Resource"`
+	RequestID string   `xml:"RequestId"`
+}
+
+// writeS3XML renders v as an XML document with the standard XML header
+func writeS3XML(c *gin.Context, status int, v interface{}) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+		return
+	}
+	c.Data(status, "application/xml", append([]byte(xml.Header), body...))
+}
+
+func writeS3Error(c *gin.Context, status int, code, message string) {
+	body, _ := xml.Marshal(s3ErrorResponse{
+		Code:      code,
+		Message:   message,
+		Resource:  c.Request.URL.Path,
+		RequestID: c.Writer.Header().Get("x-amz-request-id"),
+	})
+	c.Data(status, "application/xml", append([]byte(xml.Header), body...))
+}
+
+// writeS3ServiceError maps application errors onto S3 error codes
+func writeS3ServiceError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, application.ErrBucketNotFound):
+		writeS3Error(c, http.StatusNotFound, "NoSuchBucket", err.Error())
+	case errors.Is(err, application.ErrObjectNotFound):
+		writeS3Error(c, http.StatusNotFound, "NoSuchKey", err.Error())
+	case errors.Is(err, application.ErrObjectArchived):
+		writeS3Error(c, http.StatusForbidden, "InvalidObjectState", err.Error())
+	case errors.Is(err, application.ErrInvalidEncryptionRequest):
+		writeS3Error(c, http.StatusBadRequest, "InvalidArgument", err.Error())
+	case errors.Is(err, application.ErrSSECustomerKeyRequired):
+		writeS3Error(c, http.StatusBadRequest, "InvalidRequest", err.Error())
+	case errors.Is(err, application.ErrSSECustomerKeyMismatch):
+		writeS3Error(c, http.StatusForbidden, "AccessDenied", err.Error())
+	case errors.Is(err, application.ErrObjectLocked):
+		writeS3Error(c, http.StatusForbidden, "AccessDenied", err.Error())
+	case errors.Is(err, application.ErrQuotaExceeded):
+		writeS3Error(c, http.StatusForbidden, "QuotaExceeded", err.Error())
+	case errors.Is(err, application.ErrInvalidChecksum):
+		writeS3Error(c, http.StatusBadRequest, "InvalidDigest", err.Error())
+	case errors.Is(err, application.ErrChecksumMismatch):
+		writeS3Error(c, http.StatusBadRequest, "BadDigest", err.Error())
+	case errors.Is(err, application.ErrUploadNotFound):
+		writeS3Error(c, http.StatusNotFound, "NoSuchUpload", err.Error())
+	case errors.Is(err, application.ErrInvalidMultipartPart):
+		writeS3Error(c, http.StatusBadRequest, "InvalidPart", err.Error())
+	case errors.As(err, &maxBytesErr):
+		writeS3Error(c, http.StatusRequestEntityTooLarge, "EntityTooLarge", err.Error())
+	case errors.Is(err, middleware.ErrContentSHA256Mismatch):
+		writeS3Error(c, http.StatusBadRequest, "XAmzContentSHA256Mismatch", err.Error())
+	case errors.Is(err, middleware.ErrChunkSignatureMismatch):
+		writeS3Error(c, http.StatusForbidden, "SignatureDoesNotMatch", err.Error())
+	default:
+		writeS3Error(c, http.StatusInternalServerError, "InternalError", err.Error())
+	}
+}
+
+func s3Time(t time.Time) string {
+	return t.UTC().Format(s3TimeFormat)
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"s3/internal/application"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScrubHandler exposes the integrity scrub: an on-demand run over a bucket
+// and the objects the last runs flagged.
+type ScrubHandler struct {
+	scrubService *application.ScrubService
+}
+
+func NewScrubHandler(scrubService *application.ScrubService) *ScrubHandler {
+	return &ScrubHandler{scrubService: scrubService}
+}
+
+// RunScrub re-reads the bucket's objects and verifies their checksums now
+// POST /buckets/:bucketId/scrub
+func (h *ScrubHandler) RunScrub(c *gin.Context) {
+	report, err := h.scrubService.ScrubBucket(c.Request.Context(), c.Param("bucketId"))
+	if err != nil {
+		c.JSON(scrubErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// FlaggedObjects lists objects whose data no longer matches their checksums
+// GET /buckets/:bucketId/scrub/flagged
+func (h *ScrubHandler) FlaggedObjects(c *gin.Context) {
+	output, err := h.scrubService.FlaggedObjects(c.Request.Context(), c.Param("bucketId"))
+	if err != nil {
+		c.JSON(scrubErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func scrubErrorStatus(err error) int {
+	if errors.Is(err, application.ErrBucketNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
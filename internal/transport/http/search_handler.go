@@ -3,7 +3,9 @@ package http
 import (
 	"net/http"
 	"s3/internal/application"
+	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
+	"s3/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,12 +29,17 @@ func (h *SearchHandler) SearchFiles(c *gin.Context) {
 		return
 	}
 
+	if input.BucketID != "" && !middleware.AuthorizePolicy(c, input.BucketID, "", domain.ActionListBucket) {
+		return
+	}
+
 	output, err := h.searchService.SearchFiles(c.Request.Context(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	visibleResults(c, output)
 	c.JSON(http.StatusOK, output)
 }
 
@@ -45,12 +52,17 @@ func (h *SearchHandler) SearchByMetadata(c *gin.Context) {
 		return
 	}
 
+	if input.BucketID != "" && !middleware.AuthorizePolicy(c, input.BucketID, "", domain.ActionListBucket) {
+		return
+	}
+
 	output, err := h.searchService.SearchByMetadata(c.Request.Context(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	visibleResults(c, output)
 	c.JSON(http.StatusOK, output)
 }
 
@@ -63,12 +75,17 @@ func (h *SearchHandler) SearchByTags(c *gin.Context) {
 		return
 	}
 
+	if input.BucketID != "" && !middleware.AuthorizePolicy(c, input.BucketID, "", domain.ActionListBucket) {
+		return
+	}
+
 	output, err := h.searchService.SearchByTags(c.Request.Context(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	visibleResults(c, output)
 	c.JSON(http.StatusOK, output)
 }
 
@@ -81,12 +98,17 @@ func (h *SearchHandler) SearchByContent(c *gin.Context) {
 		return
 	}
 
+	if input.BucketID != "" && !middleware.AuthorizePolicy(c, input.BucketID, "", domain.ActionListBucket) {
+		return
+	}
+
 	output, err := h.searchService.SearchByContent(c.Request.Context(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	visibleResults(c, output)
 	c.JSON(http.StatusOK, output)
 }
 
@@ -99,12 +121,17 @@ func (h *SearchHandler) AdvancedSearch(c *gin.Context) {
 		return
 	}
 
+	if input.BucketID != "" && !middleware.AuthorizePolicy(c, input.BucketID, "", domain.ActionListBucket) {
+		return
+	}
+
 	output, err := h.searchService.AdvancedSearch(c.Request.Context(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	visibleResults(c, output)
 	c.JSON(http.StatusOK, output)
 }
 
@@ -117,6 +144,11 @@ func (h *SearchHandler) GetSearchSuggestions(c *gin.Context) {
 		return
 	}
 
+	// Suggestions carry no bucket, so only admins may ask across buckets
+	if !middleware.AuthorizePolicy(c, input.BucketID, "", domain.ActionListBucket) {
+		return
+	}
+
 	output, err := h.searchService.GetSearchSuggestions(c.Request.Context(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -160,4 +192,22 @@ func (h *SearchHandler) SaveSearch(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, output)
-}
\ No newline at end of file
+}
+
+// visibleResults drops results from buckets the actor is not allowed to list
+func visibleResults(c *gin.Context, output *dto.SearchResultOutput) {
+	allowed := make(map[string]bool)
+	kept := output.Results[:0]
+	for _, result := range output.Results {
+		ok, seen := allowed[result.BucketID]
+		if !seen {
+			ok = middleware.PolicyAllows(c, result.BucketID, "", domain.ActionListBucket)
+			allowed[result.BucketID] = ok
+		}
+		if ok {
+			kept = append(kept, result)
+		}
+	}
+	output.Results = kept
+	output.Total = len(kept)
+}
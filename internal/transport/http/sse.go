@@ -0,0 +1,56 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"s3/internal/application"
+	"s3/internal/domain"
+	"s3/internal/infrastructure/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseInput reads the S3 server-side encryption request headers
+func sseInput(header http.Header) dto.SSEInput {
+	return dto.SSEInput{
+		ServerSideEncryption: header.Get("x-amz-server-side-encryption"),
+		CustomerAlgorithm:    header.Get("x-amz-server-side-encryption-customer-algorithm"),
+		CustomerKey:          header.Get("x-amz-server-side-encryption-customer-key"),
+		CustomerKeyMD5:       header.Get("x-amz-server-side-encryption-customer-key-MD5"),
+	}
+}
+
+// copySourceSSEInput reads the SSE-C headers that unlock the source of a
+// copy (x-amz-copy-source-server-side-encryption-customer-*)
+func copySourceSSEInput(header http.Header) dto.SSEInput {
+	return dto.SSEInput{
+		CustomerAlgorithm: header.Get("x-amz-copy-source-server-side-encryption-customer-algorithm"),
+		CustomerKey:       header.Get("x-amz-copy-source-server-side-encryption-customer-key"),
+		CustomerKeyMD5:    header.Get("x-amz-copy-source-server-side-encryption-customer-key-MD5"),
+	}
+}
+
+// setSSEHeaders reports how an object is encrypted, as S3 does on PUT, GET
+// and HEAD responses
+func setSSEHeaders(c *gin.Context, encryption, customerKeyMD5 string) {
+	switch encryption {
+	case domain.EncryptionSSES3:
+		c.Header("x-amz-server-side-encryption", domain.EncryptionSSES3)
+	case domain.EncryptionSSEC:
+		c.Header("x-amz-server-side-encryption-customer-algorithm", domain.EncryptionSSES3)
+		c.Header("x-amz-server-side-encryption-customer-key-MD5", customerKeyMD5)
+	}
+}
+
+// sseErrorStatus maps encryption errors to a status; ok is false for any
+// other error
+func sseErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, application.ErrInvalidEncryptionRequest), errors.Is(err, application.ErrSSECustomerKeyRequired):
+		return http.StatusBadRequest, true
+	case errors.Is(err, application.ErrSSECustomerKeyMismatch):
+		return http.StatusForbidden, true
+	}
+	return 0, false
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"s3/internal/application"
+	"s3/internal/infrastructure/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StorageClassHandler serves restore requests for objects in cold storage
+// classes. Transitions into cold classes are driven by lifecycle rules.
+type StorageClassHandler struct {
+	tieringService *application.TieringService
+}
+
+func NewStorageClassHandler(tieringService *application.TieringService) *StorageClassHandler {
+	return &StorageClassHandler{tieringService: tieringService}
+}
+
+// RestoreFile makes a temporary hot copy of a cold object available for the
+// requested number of days
+// POST /files/:bucketId/files/:fileId/restore {"days": 7}
+func (h *StorageClassHandler) RestoreFile(c *gin.Context) {
+	var input dto.RestoreObjectInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	output, err := h.tieringService.RestoreObject(c.Request.Context(), c.Param("bucketId"), c.Param("fileId"), input.Days)
+	if err != nil {
+		c.JSON(storageClassErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusAccepted
+	if output.AlreadyRestored {
+		status = http.StatusOK
+	}
+	c.JSON(status, output)
+}
+
+func storageClassErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, application.ErrBucketNotFound), errors.Is(err, application.ErrObjectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, application.ErrInvalidRestoreDays):
+		return http.StatusBadRequest
+	case errors.Is(err, application.ErrObjectNotArchived):
+		return http.StatusConflict
+	case errors.Is(err, application.ErrColdStorageDisabled):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
@@ -1,10 +1,14 @@
 package http
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"s3/internal/application"
+	"s3/internal/domain"
 	"s3/internal/infrastructure/dto"
+	"s3/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,16 +27,35 @@ func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
 		return
 	}
+	if !middleware.AuthorizePolicy(c, input.BucketID, "", domain.ActionPutBucketNotification) {
+		return
+	}
 
 	output, err := h.webhookService.CreateWebhook(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, output)
 }
 
+// Authorize checks actions against the policy of the bucket the :webhookId
+// route's webhook belongs to; an unknown webhook is answered with 404.
+func (h *WebhookHandler) Authorize(actions ...domain.Action) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bucketID, err := h.webhookService.WebhookBucket(c.Request.Context(), c.Param("webhookId"))
+		if err != nil {
+			c.AbortWithStatusJSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		if !middleware.AuthorizePolicy(c, bucketID, "", actions...) {
+			return
+		}
+		c.Next()
+	}
+}
+
 func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
 	bucketId := c.Param("bucketId")
 
@@ -68,13 +91,34 @@ func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
 
 	err := h.webhookService.UpdateWebhook(c.Request.Context(), webhookId, input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "webhook updated successfully"})
 }
 
+// RotateWebhookSecret replaces the webhook's secret, keeping the old one
+// valid for a grace period. Only the bucket owner gets the new secret.
+// POST /webhooks/:webhookId/rotate-secret
+func (h *WebhookHandler) RotateWebhookSecret(c *gin.Context) {
+	var input dto.RotateWebhookSecretInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON payload"})
+			return
+		}
+	}
+
+	output, err := h.webhookService.RotateWebhookSecret(c.Request.Context(), c.Param("webhookId"), c.GetString("actor"), input)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
 func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
 	webhookId := c.Param("webhookId")
 
@@ -99,14 +143,73 @@ func (h *WebhookHandler) TestWebhook(c *gin.Context) {
 	c.JSON(http.StatusOK, output)
 }
 
+// GetWebhookDeliveries lists the webhook's delivery log, newest first
+// GET /webhooks/:webhookId/deliveries?event=object.*&status=failed&limit=50&offset=0
 func (h *WebhookHandler) GetWebhookDeliveries(c *gin.Context) {
 	webhookId := c.Param("webhookId")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	output, err := h.webhookService.GetWebhookDeliveries(c.Request.Context(), webhookId)
+	output, err := h.webhookService.GetWebhookDeliveries(c.Request.Context(), webhookId, c.Query("event"), c.Query("status"), limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, output)
-}
\ No newline at end of file
+}
+
+// RedeliverWebhook queues the payload of one logged delivery again
+// POST /webhooks/:webhookId/deliveries/:deliveryId/redeliver
+func (h *WebhookHandler) RedeliverWebhook(c *gin.Context) {
+	output, err := h.webhookService.RedeliverWebhook(c.Request.Context(), c.Param("webhookId"), c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, output)
+}
+
+// ReplayFailedWebhooks queues the webhook's dead-lettered events again
+// POST /webhooks/:webhookId/replay/failed
+func (h *WebhookHandler) ReplayFailedWebhooks(c *gin.Context) {
+	output, err := h.webhookService.ReplayFailedWebhooks(c.Request.Context(), c.Param("webhookId"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, output)
+}
+
+// ReplayWebhooks queues every event sent to the webhook in a time window again
+// POST /webhooks/:webhookId/replay
+func (h *WebhookHandler) ReplayWebhooks(c *gin.Context) {
+	var input dto.WebhookReplayInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to are required RFC 3339 times"})
+		return
+	}
+
+	output, err := h.webhookService.ReplayWebhooks(c.Request.Context(), c.Param("webhookId"), input)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, output)
+}
+
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, application.ErrInvalidWebhook):
+		return http.StatusBadRequest
+	case errors.Is(err, application.ErrWebhookSecretForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, application.ErrWebhookNotFound), errors.Is(err, application.ErrWebhookDeliveryNotFound),
+		errors.Is(err, application.ErrBucketNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
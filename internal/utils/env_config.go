@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,12 @@ type Config struct {
 	
 	// S3/MinIO
 	S3 S3Config
+
+	// Cold tier for archived storage classes
+	ColdStorage ColdStorageConfig
+
+	// Event bus for downstream consumers
+	Events EventsConfig
 	
 	// Server
 	Server ServerConfig
@@ -38,8 +45,83 @@ type S3Config struct {
 	UseSSL    bool
 }
 
+// ColdStorageConfig selects the cold tier backend: "filesystem" (objects
+// under Path), "minio" (objects in Bucket on Endpoint, which defaults to the
+// hot MinIO) or "" to disable transitions to cold storage classes.
+type ColdStorageConfig struct {
+	Backend   string
+	Path      string
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	Bucket    string
+}
+
+// EventsConfig selects where object and bucket events are published:
+// "nats" (a JetStream stream on NATSURL), "memory" (kept in process) or
+// "none".
+type EventsConfig struct {
+	Backend       string
+	NATSURL       string
+	Stream        string
+	SubjectPrefix string
+}
+
 type ServerConfig struct {
 	Port string
+
+	// S3APIPort enables the S3-compatible front end when set
+	S3APIPort string
+
+	// Bootstrap credentials seeded into access_keys at startup so the S3
+	// front end is usable before any keys have been issued.
+	BootstrapAccessKeyID     string
+	BootstrapSecretAccessKey string
+	BootstrapUserID          string
+
+	// MaxUploadSize caps a single upload in bytes
+	MaxUploadSize int64
+
+	// PresignSecretKey signs presigned URLs
+	PresignSecretKey string
+
+	// AccessKeyEncryptionKey seals access key secrets at rest
+	AccessKeyEncryptionKey string
+
+	// LifecycleInterval is how often lifecycle rules are applied; 0 disables
+	// the background worker
+	LifecycleInterval time.Duration
+
+	// EncryptionMasterKey seals the per-object data keys of server-side
+	// encrypted objects. Both SSE modes reach MinIO as SSE-C, which MinIO
+	// only accepts over TLS, so encryption is refused unless MINIO_USE_SSL
+	// is set.
+	EncryptionMasterKey string
+
+	// ScrubInterval is how often every object is re-read and checked
+	// against its checksums; 0 disables the background scrub
+	ScrubInterval time.Duration
+
+	// ReplicationInterval is how often the replication worker looks for due
+	// retries; new writes wake it straight away. 0 disables the worker.
+	ReplicationInterval time.Duration
+
+	// Webhook delivery: WebhookWorkers deliveries are sent at once, at most
+	// WebhookEndpointConcurrency to one webhook unless it sets its own
+	// limit, and each is tried WebhookMaxAttempts times before it is
+	// dead-lettered. WebhookInterval is how often due retries are looked
+	// for; 0 disables delivery on this server.
+	WebhookWorkers             int
+	WebhookMaxAttempts         int
+	WebhookEndpointConcurrency int
+	WebhookInterval            time.Duration
+
+	// TrustedProxies are the IPs or CIDRs of reverse proxies whose
+	// X-Forwarded-For and X-Forwarded-Proto headers are believed. Policy
+	// conditions on aws:SourceIp and aws:SecureTransport rely on them, so
+	// by default no proxy is trusted.
+	TrustedProxies []string
 }
 
 func Load() (*Config, error) {
@@ -62,14 +144,59 @@ func Load() (*Config, error) {
 			SecretKey: getEnv("MINIO_SECRET_KEY", "minioadmin123"),
 			UseSSL:    getEnvBool("MINIO_USE_SSL", false),
 		},
+		ColdStorage: ColdStorageConfig{
+			Backend:   getEnv("COLD_STORAGE_BACKEND", "filesystem"),
+			Path:      getEnv("COLD_STORAGE_PATH", "./data/cold"),
+			Endpoint:  getEnv("COLD_STORAGE_ENDPOINT", getEnv("MINIO_ENDPOINT", "localhost:9000")),
+			AccessKey: getEnv("COLD_STORAGE_ACCESS_KEY", getEnv("MINIO_ACCESS_KEY", "minioadmin")),
+			SecretKey: getEnv("COLD_STORAGE_SECRET_KEY", getEnv("MINIO_SECRET_KEY", "minioadmin123")),
+			UseSSL:    getEnvBool("COLD_STORAGE_USE_SSL", false),
+			Bucket:    getEnv("COLD_STORAGE_BUCKET", "cold-tier"),
+		},
+		Events: EventsConfig{
+			Backend:       getEnv("EVENT_BUS_BACKEND", "nats"),
+			NATSURL:       getEnv("NATS_URL", "nats://localhost:4222"),
+			Stream:        getEnv("NATS_EVENT_STREAM", "S3_EVENTS"),
+			SubjectPrefix: getEnv("NATS_EVENT_SUBJECT_PREFIX", "s3"),
+		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
+			Port:      getEnv("SERVER_PORT", "8080"),
+			S3APIPort: getEnv("S3_API_PORT", ""),
+
+			MaxUploadSize: int64(getEnvInt("MAX_UPLOAD_SIZE", 10<<20)),
+
+			PresignSecretKey: getEnv("PRESIGN_SECRET_KEY", ""),
+
+			BootstrapAccessKeyID:     getEnv("BOOTSTRAP_ACCESS_KEY_ID", ""),
+			BootstrapSecretAccessKey: getEnv("BOOTSTRAP_SECRET_ACCESS_KEY", ""),
+			BootstrapUserID:          getEnv("BOOTSTRAP_USER_ID", "550e8400-e29b-41d4-a716-446655440000"),
+			AccessKeyEncryptionKey:   getEnv("ACCESS_KEY_ENCRYPTION_KEY", ""),
+			LifecycleInterval:        getEnvDuration("LIFECYCLE_INTERVAL", time.Hour),
+			EncryptionMasterKey:      getEnv("SSE_MASTER_KEY", ""),
+			ScrubInterval:            getEnvDuration("SCRUB_INTERVAL", 7*24*time.Hour),
+			ReplicationInterval:      getEnvDuration("REPLICATION_INTERVAL", 30*time.Second),
+
+			WebhookWorkers:             getEnvInt("WEBHOOK_WORKERS", 4),
+			WebhookMaxAttempts:         getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			WebhookEndpointConcurrency: getEnvInt("WEBHOOK_ENDPOINT_CONCURRENCY", 2),
+			WebhookInterval:            getEnvDuration("WEBHOOK_INTERVAL", 5*time.Second),
+
+			TrustedProxies: getEnvList("TRUSTED_PROXIES"),
 		},
 	}
 	
 	if cfg.DB.Password == "" {
 		return nil, fmt.Errorf("POSTGRES_PASSWORD is required")
 	}
+	if cfg.Server.PresignSecretKey == "" {
+		return nil, fmt.Errorf("PRESIGN_SECRET_KEY is required")
+	}
+	if cfg.Server.AccessKeyEncryptionKey == "" {
+		return nil, fmt.Errorf("ACCESS_KEY_ENCRYPTION_KEY is required")
+	}
+	if cfg.Server.EncryptionMasterKey == "" {
+		return nil, fmt.Errorf("SSE_MASTER_KEY is required")
+	}
 	
 	return cfg, nil
 }
@@ -106,4 +233,15 @@ func getEnvBool(key string, defaultVal bool) bool {
 		}
 	}
 	return defaultVal
-}
\ No newline at end of file
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -1,19 +1,23 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
 	"s3/internal/application"
+	"s3/internal/domain"
 
 	// "s3/internal/infrastructure/database"
 	// "s3/internal/infrastructure/repository"
 	"s3/internal/infrastructure/database"
+	"s3/internal/infrastructure/event"
 	"s3/internal/infrastructure/repository"
 	"s3/internal/infrastructure/storage"
 
 	"s3/internal/infrastructure/system"
+	"s3/internal/middleware"
 	"s3/internal/transport/http"
 	"s3/internal/utils"
 	"strconv"
@@ -40,6 +44,16 @@ func main() {
 		log.Fatalf("Failed to create MinIO adapter: %v", err)
 	}
 
+	coldStorage, err := newColdStorage(cfg.ColdStorage)
+	if err != nil {
+		log.Fatalf("Failed to create cold storage tier: %v", err)
+	}
+
+	eventPublisher, err := newEventPublisher(cfg.Events)
+	if err != nil {
+		log.Fatalf("Failed to create event publisher: %v", err)
+	}
+
 	serverPort := getEnv("SERVER_PORT", "8080")
 
 	dbConfig := database.Config{
@@ -83,17 +97,86 @@ func main() {
 
 	// 2. Initialize Application Layer (Services)
 	log.Println("Initializing services...")
-	uploadService := application.NewUploadService(minioAdapter, postgresRepo)
-	bucketService := application.NewBucketService(postgresRepo, minioAdapter)
-	deleteService := application.NewDeleteService(minioAdapter, postgresRepo)
+	encryptionService, err := application.NewEncryptionService(postgresRepo, cfg.Server.EncryptionMasterKey, cfg.S3.UseSSL)
+	if err != nil {
+		log.Fatalf("Failed to create encryption service: %v", err)
+	}
+	if !encryptionService.Available() {
+		log.Println("WARNING: MINIO_USE_SSL is off; server-side encryption requests will be refused")
+	}
+	webhookService := application.NewWebhookService(postgresRepo, application.WebhookQueueConfig{
+		Workers:             cfg.Server.WebhookWorkers,
+		MaxAttempts:         cfg.Server.WebhookMaxAttempts,
+		EndpointConcurrency: cfg.Server.WebhookEndpointConcurrency,
+	})
+	eventBus := application.NewEventBus(eventPublisher, webhookService)
+	quotaService := application.NewQuotaService(postgresRepo, webhookService)
+	tieringService := application.NewTieringService(minioAdapter, coldStorage, postgresRepo)
+	replicationService := application.NewReplicationService(postgresRepo, minioAdapter, encryptionService, quotaService, tieringService, eventBus)
+	uploadService := application.NewUploadService(minioAdapter, postgresRepo, encryptionService, quotaService, replicationService, eventBus)
+	bucketService := application.NewBucketService(postgresRepo, minioAdapter, eventBus)
+	deleteService := application.NewDeleteService(minioAdapter, postgresRepo, tieringService, replicationService, eventBus)
 	healthService := application.NewHealthService(postgresRepo, minioAdapter, sys)
-	presignedService := application.NewPresignService(postgresRepo, minioAdapter, "sys")
-	batchService := application.NewBatchService(postgresRepo, minioAdapter)
-	prefixService := application.NewPrefixService(postgresRepo, minioAdapter)
+	presignedService := application.NewPresignService(postgresRepo, minioAdapter, encryptionService, quotaService, replicationService, eventBus, cfg.Server.PresignSecretKey)
+	batchService := application.NewBatchService(postgresRepo, minioAdapter, quotaService, replicationService, eventBus)
+	prefixService := application.NewPrefixService(postgresRepo, minioAdapter, encryptionService, quotaService, replicationService, eventBus)
 	SearchService := application.NewSearchService(postgresRepo)
-	webhookService := application.NewWebhookService(postgresRepo)
 	analyticsService := application.NewAnalyticsService(postgresRepo)
-	multipartService := application.NewMultipartService(postgresRepo,minioAdapter)
+	multipartService := application.NewMultipartService(postgresRepo, minioAdapter, encryptionService, quotaService, replicationService, eventBus)
+	objectVersionService := application.NewObjectVersionService(minioAdapter, postgresRepo, encryptionService, replicationService, eventBus)
+	lifecycleService := application.NewLifecycleService(postgresRepo, minioAdapter, tieringService, replicationService, eventBus)
+	scrubService := application.NewScrubService(postgresRepo, minioAdapter, encryptionService)
+	objectLockService := application.NewObjectLockService(postgresRepo)
+	accessKeyService, err := application.NewAccessKeyService(postgresRepo, cfg.Server.AccessKeyEncryptionKey)
+	if err != nil {
+		log.Fatalf("Failed to create access key service: %v", err)
+	}
+	policyService := application.NewPolicyService(postgresRepo)
+	policyEnforcer := middleware.NewPolicyEnforcer(policyService, application.IsAdmin)
+
+	// `s3 replication-backfill <bucket>` queues a bucket's existing objects
+	// for replication; the running server's worker copies them
+	if len(os.Args) > 1 && os.Args[1] == "replication-backfill" {
+		if len(os.Args) != 3 {
+			log.Fatalf("usage: %s replication-backfill <bucket>", os.Args[0])
+		}
+		output, err := replicationService.Backfill(context.Background(), os.Args[2])
+		if err != nil {
+			log.Fatalf("Replication backfill failed: %v", err)
+		}
+		log.Printf("Replication backfill of %s: %d objects scanned, %d tasks queued", output.BucketID, output.Scanned, output.Queued)
+		return
+	}
+
+	// `s3 events-tail <topic>` logs the events published on topic, e.g.
+	// "object.*" or ">" for all of them
+	if len(os.Args) > 1 && os.Args[1] == "events-tail" {
+		if len(os.Args) != 3 || eventPublisher == nil {
+			log.Fatalf("usage: %s events-tail <topic> (requires EVENT_BUS_BACKEND=nats)", os.Args[0])
+		}
+		if err := eventPublisher.Consume(context.Background(), os.Args[2]); err != nil {
+			log.Fatalf("Failed to consume events: %v", err)
+		}
+		return
+	}
+
+	sealed, err := accessKeyService.SealLegacySecrets(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to seal access key secrets: %v", err)
+	}
+	if sealed > 0 {
+		log.Printf("Sealed %d plaintext access key secrets", sealed)
+	}
+
+	if cfg.Server.BootstrapAccessKeyID != "" {
+		if err := accessKeyService.EnsureAccessKey(context.Background(),
+			cfg.Server.BootstrapAccessKeyID,
+			cfg.Server.BootstrapSecretAccessKey,
+			cfg.Server.BootstrapUserID,
+		); err != nil {
+			log.Fatalf("Failed to seed bootstrap access key: %v", err)
+		}
+	}
 
 	// 3. Initialize Transport Layer (HTTP)
 	log.Println("Initializing HTTP handlers...")
@@ -108,13 +191,80 @@ func main() {
 		Webhook:   http.NewWebhookHandler(webhookService),     // TODO: implement later
 		Analytics: http.NewAnalyticsHandler(analyticsService), // TODO: implement later
 		Multipart: http.NewMultipartHandler(multipartService), // TODO: implement later
+		AccessKey: http.NewAccessKeyHandler(accessKeyService),
+		Simulator: http.NewPolicySimulatorHandler(policyEnforcer),
+		Versions:  http.NewObjectVersionHandler(objectVersionService),
+		Lifecycle: http.NewLifecycleHandler(lifecycleService),
+		Scrub:     http.NewScrubHandler(scrubService),
+		ObjectLock: http.NewObjectLockHandler(objectLockService),
+		Quota:     http.NewQuotaHandler(quotaService),
+		Replication: http.NewReplicationHandler(replicationService),
+		Tiering:   http.NewStorageClassHandler(tieringService),
+		APIKeys:   accessKeyService,
+		Policies:  policyEnforcer,
+		MaxUploadSize: cfg.Server.MaxUploadSize,
+	}
+
+	// Background lifecycle worker
+	if cfg.Server.LifecycleInterval > 0 {
+		log.Printf("Lifecycle worker running every %s", cfg.Server.LifecycleInterval)
+		go lifecycleService.Run(context.Background(), cfg.Server.LifecycleInterval)
+	}
+
+	// Background integrity scrub
+	if cfg.Server.ScrubInterval > 0 {
+		log.Printf("Integrity scrub running every %s", cfg.Server.ScrubInterval)
+		go scrubService.Run(context.Background(), cfg.Server.ScrubInterval)
+	}
+
+	// Event bus: broker publishing and webhook dispatch
+	go eventBus.Run(context.Background())
 
+	// Webhook delivery workers
+	if cfg.Server.WebhookInterval > 0 {
+		log.Printf("Webhook delivery running, retries checked every %s", cfg.Server.WebhookInterval)
+		go webhookService.Run(context.Background(), cfg.Server.WebhookInterval)
+	}
+
+	// Background replication worker
+	if cfg.Server.ReplicationInterval > 0 {
+		log.Printf("Replication worker running, retries checked every %s", cfg.Server.ReplicationInterval)
+		go replicationService.Run(context.Background(), cfg.Server.ReplicationInterval)
 	}
 
 	// 4. Setup Router
-	router := gin.Default()
+	forwardedProto, err := middleware.ForwardedProtoMiddleware(cfg.Server.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	router, err := newRouter(cfg.Server.TrustedProxies, forwardedProto)
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	http.RegisterRoutes(router, handlers)
 
+	// S3-compatible front end (aws-cli, rclone, boto3) on its own port
+	if cfg.Server.S3APIPort != "" {
+		s3Router, err := newRouter(cfg.Server.TrustedProxies, forwardedProto)
+		if err != nil {
+			log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+		}
+		http.RegisterS3Routes(s3Router, http.NewS3Handler(
+			bucketService,
+			uploadService,
+			deleteService,
+			prefixService,
+			multipartService,
+		), accessKeyService, policyEnforcer, cfg.Server.MaxUploadSize)
+
+		go func() {
+			log.Printf("S3 API starting on port %s...", cfg.Server.S3APIPort)
+			if err := s3Router.Run(":" + cfg.Server.S3APIPort); err != nil {
+				log.Fatalf("Failed to start S3 API: %v", err)
+			}
+		}()
+	}
+
 	// 5. Start Server
 	log.Printf("🚀 Server starting on port %s...", serverPort)
 	log.Printf("📝 API endpoints:")
@@ -128,6 +278,17 @@ func main() {
 	}
 }
 
+// newRouter creates a gin engine that only believes the forwarding headers
+// of trustedProxies; with none, the client IP is the connection's peer
+func newRouter(trustedProxies []string, forwardedProto gin.HandlerFunc) (*gin.Engine, error) {
+	router := gin.Default()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+	router.Use(forwardedProto)
+	return router, nil
+}
+
 func getEnvInt(key string, defaultVal int) int {
 	if val, exists := os.LookupEnv(key); exists {
 		if intVal, err := strconv.Atoi(val); err == nil {
@@ -151,6 +312,51 @@ func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
 	return defaultVal
 }
 
+// newColdStorage builds the cold tier selected by COLD_STORAGE_BACKEND; nil
+// disables transitions to cold storage classes.
+func newColdStorage(cfg utils.ColdStorageConfig) (domain.ColdStoragePort, error) {
+	switch cfg.Backend {
+	case "", "none":
+		log.Println("Cold storage tier disabled")
+		return nil, nil
+	case "filesystem":
+		cold, err := storage.NewFilesystemColdStorage(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Cold storage tier: %s", cold.Name())
+		return cold, nil
+	case "minio":
+		cold, err := storage.NewMinIOColdStorage(context.Background(), cfg.Endpoint, cfg.AccessKey, cfg.SecretKey, cfg.UseSSL, cfg.Bucket)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Cold storage tier: %s", cold.Name())
+		return cold, nil
+	}
+	return nil, fmt.Errorf("unknown cold storage backend %q", cfg.Backend)
+}
+
+// newEventPublisher builds the event publisher selected by
+// EVENT_BUS_BACKEND; nil disables event publishing.
+func newEventPublisher(cfg utils.EventsConfig) (domain.EventPublisher, error) {
+	switch cfg.Backend {
+	case "", "none":
+		log.Println("Event bus disabled")
+		return nil, nil
+	case "memory":
+		return event.NewMemoryEventPublisher(), nil
+	case "nats":
+		publisher, err := event.NewNATSEventPublisher(cfg.NATSURL, cfg.Stream, cfg.SubjectPrefix)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Event bus: NATS %s, stream %s, subjects %s.>", cfg.NATSURL, cfg.Stream, cfg.SubjectPrefix)
+		return publisher, nil
+	}
+	return nil, fmt.Errorf("unknown event bus backend %q", cfg.Backend)
+}
+
 func monitorDBStats(db *sql.DB) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
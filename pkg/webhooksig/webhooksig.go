@@ -0,0 +1,160 @@
+// Package webhooksig signs and verifies webhook deliveries.
+//
+// Every delivery carries an X-Webhook-Signature header of the form
+//
+//	t=1700000000,v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd
+//
+// where t is the Unix time the delivery was sent and each v1 is the hex
+// HMAC-SHA256 of "<t>.<delivery id>.<body>" with one of the webhook's
+// secrets, the delivery id being the X-Webhook-Delivery header (which never
+// contains a "."). While a
+// secret is being rotated there is one v1 for the new secret and one for
+// the old, so receivers can switch secrets at any point in the window.
+//
+// Receivers should reject deliveries whose timestamp is too old, which stops
+// a captured request from being replayed later, and should drop deliveries
+// whose X-Webhook-Delivery id they have already handled. Retries of an event
+// keep the same delivery id, and since the id is signed it cannot be changed
+// to get a captured request past that check.
+//
+// The package has no dependencies beyond the standard library, so consumer
+// services can vendor it as it is.
+package webhooksig
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the timestamp and signatures
+	SignatureHeader = "X-Webhook-Signature"
+	// DeliveryHeader carries an id that is the same for every attempt to
+	// deliver one event to one webhook
+	DeliveryHeader = "X-Webhook-Delivery"
+
+	// DefaultTolerance is how old a delivery may be before Verify rejects it
+	DefaultTolerance = 5 * time.Minute
+
+	// MaxBodySize is the largest body VerifyRequest accepts
+	MaxBodySize = 10 << 20
+)
+
+var (
+	ErrNoSignature       = errors.New("webhooksig: no signature")
+	ErrInvalidDelivery   = errors.New("webhooksig: missing or invalid delivery id")
+	ErrBodyTooLarge      = errors.New("webhooksig: body larger than MaxBodySize")
+	ErrInvalidHeader     = errors.New("webhooksig: invalid signature header")
+	ErrTooOld            = errors.New("webhooksig: timestamp outside the tolerance")
+	ErrSignatureMismatch = errors.New("webhooksig: no signature matches")
+)
+
+// Sign returns the hex v1 signature of payload sent at timestamp as delivery
+// deliveryID
+func Sign(secret string, timestamp time.Time, deliveryID string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write([]byte(deliveryID))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Header returns the signature header for payload sent at timestamp as
+// delivery deliveryID, with a v1 signature for each secret
+func Header(timestamp time.Time, deliveryID string, payload []byte, secrets ...string) string {
+	parts := []string{"t=" + strconv.FormatInt(timestamp.Unix(), 10)}
+	for _, secret := range secrets {
+		parts = append(parts, "v1="+Sign(secret, timestamp, deliveryID, payload))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Verify checks that header signs payload and deliveryID with one of secrets
+// and was made within tolerance of now; a tolerance of 0 means
+// DefaultTolerance. A delivery id with a "." is refused, since it could take
+// bytes from the start of the body without changing the signature.
+func Verify(payload []byte, deliveryID, header string, tolerance time.Duration, secrets ...string) error {
+	if header == "" {
+		return ErrNoSignature
+	}
+	if deliveryID == "" || strings.Contains(deliveryID, ".") {
+		return ErrInvalidDelivery
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+
+	timestamp, signatures, err := parseHeader(header)
+	if err != nil {
+		return err
+	}
+	if age := time.Since(timestamp); age > tolerance || age < -tolerance {
+		return ErrTooOld
+	}
+
+	for _, secret := range secrets {
+		expected := []byte(Sign(secret, timestamp, deliveryID, payload))
+		for _, signature := range signatures {
+			if hmac.Equal(expected, []byte(signature)) {
+				return nil
+			}
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+// VerifyRequest reads and verifies the body of a webhook request and returns
+// it. The body is put back on r so later handlers can still read it. A body
+// larger than MaxBodySize is refused with ErrBodyTooLarge.
+func VerifyRequest(r *http.Request, tolerance time.Duration, secrets ...string) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxBodySize+1))
+	r.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("webhooksig: failed to read body: %w", err)
+	}
+	if len(body) > MaxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := Verify(body, r.Header.Get(DeliveryHeader), r.Header.Get(SignatureHeader), tolerance, secrets...); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func parseHeader(header string) (time.Time, []string, error) {
+	var timestamp time.Time
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return time.Time{}, nil, ErrInvalidHeader
+		}
+		switch key {
+		case "t":
+			unix, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return time.Time{}, nil, ErrInvalidHeader
+			}
+			timestamp = time.Unix(unix, 0)
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp.IsZero() || len(signatures) == 0 {
+		return time.Time{}, nil, ErrInvalidHeader
+	}
+	return timestamp, signatures, nil
+}
@@ -0,0 +1,185 @@
+package webhooksig
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const deliveryID = "3f2b6a0e-5c1d-4e8f-9a7b-2d4c6e8f0a1b"
+
+var payload = []byte(`{"version":"1","id":"e1","event":"object.created"}`)
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		header     string
+		deliveryID string
+		payload    []byte
+		tolerance  time.Duration
+		secrets    []string
+		want       error
+	}{
+		{"valid", Header(now, deliveryID, payload, "s1"), deliveryID, payload, 0, []string{"s1"}, nil},
+		{"wrong secret", Header(now, deliveryID, payload, "s1"), deliveryID, payload, 0, []string{"s2"}, ErrSignatureMismatch},
+		{"tampered body", Header(now, deliveryID, payload, "s1"), deliveryID, append([]byte{' '}, payload...), 0, []string{"s1"}, ErrSignatureMismatch},
+		{"tampered delivery id", Header(now, deliveryID, payload, "s1"), "another-delivery", payload, 0, []string{"s1"}, ErrSignatureMismatch},
+		{"no delivery id", Header(now, deliveryID, payload, "s1"), "", payload, 0, []string{"s1"}, ErrInvalidDelivery},
+		// "t.d1.x.{...}" signs as id "d1" with body "x.{...}" and as id "d1.x"
+		// with body "{...}", so ids with a "." are refused
+		{"delivery id taking bytes from the body", Header(now, "d1", []byte("x."+string(payload)), "s1"), "d1.x", payload, 0, []string{"s1"}, ErrInvalidDelivery},
+		{"no header", "", deliveryID, payload, 0, []string{"s1"}, ErrNoSignature},
+		{"no secrets", Header(now, deliveryID, payload, "s1"), deliveryID, payload, 0, nil, ErrSignatureMismatch},
+
+		{"within the default tolerance", Header(now.Add(-4*time.Minute), deliveryID, payload, "s1"), deliveryID, payload, 0, []string{"s1"}, nil},
+		{"older than the default tolerance", Header(now.Add(-6*time.Minute), deliveryID, payload, "s1"), deliveryID, payload, 0, []string{"s1"}, ErrTooOld},
+		{"too far in the future", Header(now.Add(6*time.Minute), deliveryID, payload, "s1"), deliveryID, payload, 0, []string{"s1"}, ErrTooOld},
+		{"within a custom tolerance", Header(now.Add(-time.Hour), deliveryID, payload, "s1"), deliveryID, payload, 2 * time.Hour, []string{"s1"}, nil},
+		{"outside a custom tolerance", Header(now.Add(-time.Minute), deliveryID, payload, "s1"), deliveryID, payload, 30 * time.Second, []string{"s1"}, ErrTooOld},
+
+		{"second of several v1 entries", "t=" + unix(now) + ",v1=" + strings.Repeat("0", 64) + ",v1=" + Sign("s1", now, deliveryID, payload),
+			deliveryID, payload, 0, []string{"s1"}, nil},
+		{"none of several v1 entries", "t=" + unix(now) + ",v1=" + strings.Repeat("0", 64) + ",v1=" + strings.Repeat("f", 64),
+			deliveryID, payload, 0, []string{"s1"}, ErrSignatureMismatch},
+		{"unknown schemes are ignored", "t=" + unix(now) + ",v0=abc,v1=" + Sign("s1", now, deliveryID, payload),
+			deliveryID, payload, 0, []string{"s1"}, nil},
+		{"spaces around entries", "t=" + unix(now) + ", v1=" + Sign("s1", now, deliveryID, payload),
+			deliveryID, payload, 0, []string{"s1"}, nil},
+
+		{"no timestamp", "v1=" + Sign("s1", now, deliveryID, payload), deliveryID, payload, 0, []string{"s1"}, ErrInvalidHeader},
+		{"no v1", "t=" + unix(now), deliveryID, payload, 0, []string{"s1"}, ErrInvalidHeader},
+		{"bad timestamp", "t=yesterday,v1=" + Sign("s1", now, deliveryID, payload), deliveryID, payload, 0, []string{"s1"}, ErrInvalidHeader},
+		{"entry without =", "t=" + unix(now) + ",garbage", deliveryID, payload, 0, []string{"s1"}, ErrInvalidHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.payload, tt.deliveryID, tt.header, tt.tolerance, tt.secrets...)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRotation(t *testing.T) {
+	now := time.Now()
+	// While a secret is rotated deliveries are signed with the new and the
+	// old secret, so a receiver on either one accepts them
+	header := Header(now, deliveryID, payload, "new", "old")
+	if got := strings.Count(header, "v1="); got != 2 {
+		t.Fatalf("Header() has %d v1 entries, want 2: %s", got, header)
+	}
+
+	tests := []struct {
+		name    string
+		secrets []string
+		want    error
+	}{
+		{"receiver on the old secret", []string{"old"}, nil},
+		{"receiver on the new secret", []string{"new"}, nil},
+		{"receiver accepting both", []string{"new", "old"}, nil},
+		{"receiver on an unrelated secret", []string{"other"}, ErrSignatureMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(payload, deliveryID, header, 0, tt.secrets...); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Once the grace period ends only the new secret signs
+	after := Header(now, deliveryID, payload, "new")
+	if err := Verify(payload, deliveryID, after, 0, "old"); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("Verify() with the retired secret = %v, want %v", err, ErrSignatureMismatch)
+	}
+}
+
+func TestSign(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	// echo -n '1700000000.d1.{}' | openssl dgst -sha256 -hmac secret
+	const want = "404d1c66d69ce2b375f254d13c34493e9ca7439e4d5150e3c843f70d7b5c4a33"
+	got := Sign("secret", at, "d1", []byte("{}"))
+	if got != want {
+		t.Fatalf("Sign() = %q, want %q", got, want)
+	}
+	if got == Sign("secret", at, "d2", []byte("{}")) {
+		t.Error("Sign() does not depend on the delivery id")
+	}
+	if got == Sign("secret", at.Add(time.Second), "d1", []byte("{}")) {
+		t.Error("Sign() does not depend on the timestamp")
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	now := time.Now()
+	newRequest := func(body []byte, delivery, signature string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(body))
+		if delivery != "" {
+			r.Header.Set(DeliveryHeader, delivery)
+		}
+		if signature != "" {
+			r.Header.Set(SignatureHeader, signature)
+		}
+		return r
+	}
+
+	t.Run("valid request keeps its body", func(t *testing.T) {
+		r := newRequest(payload, deliveryID, Header(now, deliveryID, payload, "s1"))
+		body, err := VerifyRequest(r, 0, "s1")
+		if err != nil {
+			t.Fatalf("VerifyRequest() = %v", err)
+		}
+		if !bytes.Equal(body, payload) {
+			t.Errorf("VerifyRequest() body = %q, want %q", body, payload)
+		}
+		again, _ := io.ReadAll(r.Body)
+		if !bytes.Equal(again, payload) {
+			t.Errorf("r.Body after VerifyRequest = %q, want %q", again, payload)
+		}
+	})
+
+	t.Run("delivery header changed", func(t *testing.T) {
+		r := newRequest(payload, "replayed-as-new", Header(now, deliveryID, payload, "s1"))
+		if _, err := VerifyRequest(r, 0, "s1"); !errors.Is(err, ErrSignatureMismatch) {
+			t.Errorf("VerifyRequest() = %v, want %v", err, ErrSignatureMismatch)
+		}
+	})
+
+	t.Run("delivery header missing", func(t *testing.T) {
+		r := newRequest(payload, "", Header(now, deliveryID, payload, "s1"))
+		if _, err := VerifyRequest(r, 0, "s1"); !errors.Is(err, ErrInvalidDelivery) {
+			t.Errorf("VerifyRequest() = %v, want %v", err, ErrInvalidDelivery)
+		}
+	})
+
+	t.Run("body at the limit", func(t *testing.T) {
+		body := bytes.Repeat([]byte("a"), MaxBodySize)
+		r := newRequest(body, deliveryID, Header(now, deliveryID, body, "s1"))
+		if _, err := VerifyRequest(r, 0, "s1"); err != nil {
+			t.Errorf("VerifyRequest() = %v, want nil", err)
+		}
+	})
+
+	t.Run("body over the limit", func(t *testing.T) {
+		body := bytes.Repeat([]byte("a"), MaxBodySize+1)
+		// signed over the whole body, so a truncated read would not match
+		// either; the error must say why
+		r := newRequest(body, deliveryID, Header(now, deliveryID, body, "s1"))
+		if _, err := VerifyRequest(r, 0, "s1"); !errors.Is(err, ErrBodyTooLarge) {
+			t.Errorf("VerifyRequest() = %v, want %v", err, ErrBodyTooLarge)
+		}
+	})
+}
+
+func unix(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}